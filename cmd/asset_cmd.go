@@ -90,10 +90,11 @@ var AssetCommand = cli.Command{
 		{
 			Action:    getBalance,
 			Name:      "balance",
-			Usage:     "Show balance of ont and ong of specified account",
+			Usage:     "Show balance of ont and ong, or of an issued asset, of specified account",
 			ArgsUsage: "<address|label|index>",
 			Flags: []cli.Flag{
 				utils.RPCPortFlag,
+				utils.BalanceAssetFlag,
 				utils.ExecutorFileFlag,
 			},
 		},
@@ -159,18 +160,13 @@ func transfer(ctx *cli.Context) error {
 		return err
 	}
 
-	var amount uint64
 	amountStr := ctx.String(utils.TransactionAmountFlag.Name)
-	switch strings.ToLower(asset) {
-	case "ont":
-		amount = utils.ParseOnt(amountStr)
-		amountStr = utils.FormatOnt(amount)
-	case "ong":
-		amount = utils.ParseOng(amountStr)
-		amountStr = utils.FormatOng(amount)
-	default:
-		return fmt.Errorf("unsupport asset:%s", asset)
+	precision, err := utils.GetAssetPrecision(asset)
+	if err != nil {
+		return err
 	}
+	amount := utils.ParseAssetAmount(amountStr, precision)
+	amountStr = utils.FormatAssetAmount(amount, precision)
 
 	err = utils.CheckAssetAmount(asset, amount)
 	if err != nil {
@@ -234,6 +230,24 @@ func getBalance(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if asset := ctx.String(utils.GetFlagName(utils.BalanceAssetFlag)); asset != "" {
+		precision, err := utils.GetAssetPrecision(asset)
+		if err != nil {
+			return err
+		}
+		assetBalance, err := utils.GetAssetBalance(accAddr, asset)
+		if err != nil {
+			return err
+		}
+		balance, err := strconv.ParseUint(assetBalance.Balance, 10, 64)
+		if err != nil {
+			return err
+		}
+		PrintInfoMsg("BalanceOf:%s", accAddr)
+		PrintInfoMsg("  Asset:%s", assetBalance.Asset)
+		PrintInfoMsg("  Balance:%s", utils.FormatAssetAmount(balance, precision))
+		return nil
+	}
 	balance, err := utils.GetBalance(accAddr)
 	if err != nil {
 		return err
@@ -274,17 +288,15 @@ func getAllowance(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	switch strings.ToLower(asset) {
-	case "ont":
-	case "ong":
-		balance, err := strconv.ParseUint(balanceStr, 10, 64)
-		if err != nil {
-			return err
-		}
-		balanceStr = utils.FormatOng(balance)
-	default:
-		return fmt.Errorf("unsupport asset:%s", asset)
+	precision, err := utils.GetAssetPrecision(asset)
+	if err != nil {
+		return err
 	}
+	balance, err := strconv.ParseUint(balanceStr, 10, 64)
+	if err != nil {
+		return err
+	}
+	balanceStr = utils.FormatAssetAmount(balance, precision)
 	PrintInfoMsg("Allowance:%s", asset)
 	PrintInfoMsg("  From:%s", fromAddr)
 	PrintInfoMsg("  To:%s", toAddr)
@@ -314,17 +326,12 @@ func approve(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	var amount uint64
-	switch strings.ToLower(asset) {
-	case "ont":
-		amount = utils.ParseOnt(amountStr)
-		amountStr = utils.FormatOnt(amount)
-	case "ong":
-		amount = utils.ParseOng(amountStr)
-		amountStr = utils.FormatOng(amount)
-	default:
-		return fmt.Errorf("unsupport asset:%s", asset)
+	precision, err := utils.GetAssetPrecision(asset)
+	if err != nil {
+		return err
 	}
+	amount := utils.ParseAssetAmount(amountStr, precision)
+	amountStr = utils.FormatAssetAmount(amount, precision)
 
 	err = utils.CheckAssetAmount(asset, amount)
 	if err != nil {
@@ -403,17 +410,12 @@ func transferFrom(ctx *cli.Context) error {
 		return err
 	}
 
-	var amount uint64
-	switch strings.ToLower(asset) {
-	case "ont":
-		amount = utils.ParseOnt(amountStr)
-		amountStr = utils.FormatOnt(amount)
-	case "ong":
-		amount = utils.ParseOng(amountStr)
-		amountStr = utils.FormatOng(amount)
-	default:
-		return fmt.Errorf("unsupport asset:%s", asset)
+	precision, err := utils.GetAssetPrecision(asset)
+	if err != nil {
+		return err
 	}
+	amount := utils.ParseAssetAmount(amountStr, precision)
+	amountStr = utils.FormatAssetAmount(amount, precision)
 
 	err = utils.CheckAssetAmount(asset, amount)
 	if err != nil {
@@ -36,6 +36,7 @@ func SetDNAConfig(ctx *cli.Context) (*config.DNAConfig, error) {
 	}
 	setCommonConfig(ctx, cfg.Common)
 	setConsensusConfig(ctx, cfg.Consensus)
+	setTxPoolConfig(ctx, cfg.TxPool)
 	setP2PNodeConfig(ctx, cfg.P2PNode)
 	setRpcConfig(ctx, cfg.Rpc)
 	setRestfulConfig(ctx, cfg.Restful)
@@ -107,6 +108,20 @@ func setGenesis(ctx *cli.Context, cfg *config.DNAConfig) error {
 		if cfg.Genesis.DBFT.GenBlockTime <= 0 {
 			cfg.Genesis.DBFT.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
 		}
+	case config.CONSENSUS_TYPE_SBFT:
+		if cfg.Genesis.SBFT == nil || len(cfg.Genesis.SBFT.Bookkeepers) < config.SBFT_MIN_NODE_NUM {
+			return fmt.Errorf("SBFT consensus at least need %d bookkeepers in config", config.SBFT_MIN_NODE_NUM)
+		}
+		if cfg.Genesis.SBFT.GenBlockTime <= 0 {
+			cfg.Genesis.SBFT.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
+		}
+	case config.CONSENSUS_TYPE_POA:
+		if cfg.Genesis.POA == nil || len(cfg.Genesis.POA.Bookkeepers) < config.POA_MIN_NODE_NUM {
+			return fmt.Errorf("POA consensus at least need %d bookkeepers in config", config.POA_MIN_NODE_NUM)
+		}
+		if cfg.Genesis.POA.GenBlockTime <= 0 {
+			cfg.Genesis.POA.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
+		}
 	case config.CONSENSUS_TYPE_VBFT:
 		err = governance.CheckVBFTConfig(cfg.Genesis.VBFT)
 		if err != nil {
@@ -125,6 +140,9 @@ func setGenesis(ctx *cli.Context, cfg *config.DNAConfig) error {
 func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
 	cfg.LogLevel = ctx.Uint(utils.GetFlagName(utils.LogLevelFlag))
 	cfg.EnableEventLog = !ctx.Bool(utils.GetFlagName(utils.DisableEventLogFlag))
+	cfg.EnableArchive = ctx.Bool(utils.GetFlagName(utils.EnableArchiveFlag))
+	cfg.UndoBlocks = ctx.Uint(utils.GetFlagName(utils.UndoBlocksFlag))
+	cfg.StoreBackend = ctx.String(utils.GetFlagName(utils.StoreBackendFlag))
 	cfg.GasLimit = ctx.Uint64(utils.GetFlagName(utils.GasLimitFlag))
 	cfg.GasPrice = ctx.Uint64(utils.GetFlagName(utils.GasPriceFlag))
 	cfg.DataDir = ctx.String(utils.GetFlagName(utils.DataDirFlag))
@@ -133,6 +151,14 @@ func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
 func setConsensusConfig(ctx *cli.Context, cfg *config.ConsensusConfig) {
 	cfg.EnableConsensus = ctx.Bool(utils.GetFlagName(utils.EnableConsensusFlag))
 	cfg.MaxTxInBlock = ctx.Uint(utils.GetFlagName(utils.MaxTxInBlockFlag))
+	cfg.PolicyFile = ctx.String(utils.GetFlagName(utils.ConsensusPolicyFlag))
+}
+
+func setTxPoolConfig(ctx *cli.Context, cfg *config.TxPoolConfig) {
+	cfg.MaxTxInPool = ctx.Uint(utils.GetFlagName(utils.MaxTxInPoolFlag))
+	cfg.MaxTxPerPayer = ctx.Uint(utils.GetFlagName(utils.MaxTxPerPayerFlag))
+	cfg.MaxTxLifetime = ctx.Uint(utils.GetFlagName(utils.MaxTxLifetimeFlag))
+	cfg.DisableReplaceByNonce = ctx.Bool(utils.GetFlagName(utils.DisableReplaceByNonceFlag))
 }
 
 func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
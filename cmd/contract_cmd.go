@@ -25,6 +25,7 @@ import (
 	"github.com/dnaproject2/DNA/cmd/utils"
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/payload"
 	httpcom "github.com/dnaproject2/DNA/http/base/common"
 	"github.com/urfave/cli"
 	"io/ioutil"
@@ -37,7 +38,7 @@ var (
 		Action:      cli.ShowSubcommandHelp,
 		Usage:       "Deploy or invoke smart contract",
 		ArgsUsage:   " ",
-		Description: `Smart contract operations support the deployment of NeoVM smart contract, and the pre-execution and execution of NeoVM smart contract.`,
+		Description: `Smart contract operations support the deployment of NeoVM and WasmVM smart contract, and the pre-execution and execution of NeoVM smart contract.`,
 		Subcommands: []cli.Command{
 			{
 				Action:    deployContract,
@@ -49,12 +50,14 @@ var (
 					utils.TransactionGasPriceFlag,
 					utils.TransactionGasLimitFlag,
 					utils.ContractStorageFlag,
+					utils.ContractVmTypeFlag,
 					utils.ContractCodeFileFlag,
 					utils.ContractNameFlag,
 					utils.ContractVersionFlag,
 					utils.ContractAuthorFlag,
 					utils.ContractEmailFlag,
 					utils.ContractDescFlag,
+					utils.ContractAbiFileFlag,
 					utils.ContractPrepareDeployFlag,
 					utils.ExecutorFileFlag,
 					utils.AccountAddressFlag,
@@ -123,6 +126,10 @@ func deployContract(ctx *cli.Context) error {
 	}
 
 	store := ctx.Bool(utils.GetFlagName(utils.ContractStorageFlag))
+	vmType := payload.VmType(ctx.Uint(utils.GetFlagName(utils.ContractVmTypeFlag)))
+	if vmType != payload.NEOVM_TYPE && vmType != payload.WASMVM_TYPE {
+		return fmt.Errorf("unsupported vm type:%d", vmType)
+	}
 	codeFile := ctx.String(utils.GetFlagName(utils.ContractCodeFileFlag))
 	if "" == codeFile {
 		return fmt.Errorf("please specific code file")
@@ -137,6 +144,20 @@ func deployContract(ctx *cli.Context) error {
 	author := ctx.String(utils.GetFlagName(utils.ContractAuthorFlag))
 	email := ctx.String(utils.GetFlagName(utils.ContractEmailFlag))
 	desc := ctx.String(utils.GetFlagName(utils.ContractDescFlag))
+	abi := ""
+	if abiFile := ctx.String(utils.GetFlagName(utils.ContractAbiFileFlag)); abiFile != "" {
+		abiData, err := ioutil.ReadFile(abiFile)
+		if err != nil {
+			return fmt.Errorf("read abi:%s error:%s", abiFile, err)
+		}
+		abi = strings.TrimSpace(string(abiData))
+		if len(abi) > payload.MAX_ABI_LEN {
+			return fmt.Errorf("abi:%s is too long", abiFile)
+		}
+		if !json.Valid([]byte(abi)) {
+			return fmt.Errorf("abi:%s is not valid json", abiFile)
+		}
+	}
 	code := strings.TrimSpace(string(codeStr))
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
 	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
@@ -151,7 +172,7 @@ func deployContract(ctx *cli.Context) error {
 	cversion := fmt.Sprintf("%s", version)
 
 	if ctx.IsSet(utils.GetFlagName(utils.ContractPrepareDeployFlag)) {
-		preResult, err := utils.PrepareDeployContract(store, code, name, cversion, author, email, desc)
+		preResult, err := utils.PrepareDeployContract(store, vmType, code, name, cversion, author, email, desc, abi)
 		if err != nil {
 			return fmt.Errorf("PrepareDeployContract error:%s", err)
 		}
@@ -168,7 +189,7 @@ func deployContract(ctx *cli.Context) error {
 		return fmt.Errorf("get signer account error:%s", err)
 	}
 
-	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, code, name, cversion, author, email, desc)
+	txHash, err := utils.DeployContract(gasPrice, gasLimit, signer, store, vmType, code, name, cversion, author, email, desc, abi)
 	if err != nil {
 		return fmt.Errorf("DeployContract error:%s", err)
 	}
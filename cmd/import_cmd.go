@@ -47,6 +47,9 @@ var ImportCommand = cli.Command{
 		utils.ConfigFlag,
 		utils.NetworkIdFlag,
 		utils.DisableEventLogFlag,
+		utils.EnableArchiveFlag,
+		utils.UndoBlocksFlag,
+		utils.StoreBackendFlag,
 	},
 	Description: "Note that import cmd doesn't support testmode",
 }
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dnaproject2/DNA/cmd/utils"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/core/genesis"
+	"github.com/dnaproject2/DNA/core/ledger"
+	"github.com/urfave/cli"
+)
+
+var RollbackCommand = cli.Command{
+	Name:      "rollback",
+	Usage:     "Rollback the blocks in DB to a height",
+	ArgsUsage: "",
+	Action:    rollbackBlocks,
+	Flags: []cli.Flag{
+		utils.RollbackHeightFlag,
+		utils.DataDirFlag,
+		utils.ConfigFlag,
+		utils.NetworkIdFlag,
+	},
+	Description: "Note that the node should be stopped before rollback, and blocks saved without undo data cannot be rolled back",
+}
+
+func rollbackBlocks(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	if !ctx.IsSet(utils.GetFlagName(utils.RollbackHeightFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.RollbackHeightFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	height := uint32(ctx.Uint(utils.GetFlagName(utils.RollbackHeightFlag)))
+
+	cfg, err := SetDNAConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetDNAConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	currBlockHeight := ledger.DefLedger.GetCurrentBlockHeight()
+	if height >= currBlockHeight {
+		PrintWarnMsg("CurrentBlockHeight:%d less than or equal to rollback height:%d, No blocks to rollback.", currBlockHeight, height)
+		return nil
+	}
+	PrintInfoMsg("Start rollback blocks from height %d to %d.", currBlockHeight, height)
+	err = ledger.DefLedger.RollbackToHeight(height)
+	if err != nil {
+		return fmt.Errorf("RollbackToHeight error:%s", err)
+	}
+	PrintInfoMsg("Rollback completed, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dnaproject2/DNA/cmd/utils"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/core/genesis"
+	"github.com/dnaproject2/DNA/core/ledger"
+	"github.com/urfave/cli"
+)
+
+var SnapshotCommand = cli.Command{
+	Name:        "snapshot",
+	Action:      cli.ShowSubcommandHelp,
+	Usage:       "Export, import state snapshot or prune states",
+	ArgsUsage:   " ",
+	Description: "A state snapshot contains all the states at the current block height. A new node can import a snapshot and start syncing blocks from the next height. Note that the node should be stopped before export or import.",
+	Subcommands: []cli.Command{
+		{
+			Action:    exportSnapshot,
+			Name:      "export",
+			Usage:     "Export state snapshot at current block height to a file",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+		},
+		{
+			Action:    importSnapshot,
+			Name:      "import",
+			Usage:     "Import state snapshot from a file into an empty DB",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.SnapshotFileFlag,
+				utils.SnapshotStateRootFlag,
+				utils.SnapshotStateHashFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "The state merkle root of the snapshot height should be queried from trusted peers by getstatemerkleroot rpc. The state merkle root can not prove the state items of the snapshot, which are verified against the state hash printed by the export of a trusted node at the same height. The snapshot will be rejected if either mismatched.",
+		},
+		{
+			Action:    pruneStates,
+			Name:      "prune",
+			Usage:     "Prune the archived states and undo data only needed by the heights below a height",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				utils.PruneHeightFlag,
+				utils.DataDirFlag,
+				utils.ConfigFlag,
+				utils.NetworkIdFlag,
+			},
+			Description: "The states at the prune height and above can still be queried, but the blocks can no longer be rolled back below it.",
+		},
+	},
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	cfg, err := SetDNAConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetDNAConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	f, err := os.OpenFile(snapshotFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0664)
+	if err != nil {
+		return fmt.Errorf("open snapshot file:%s error:%s", snapshotFile, err)
+	}
+	defer f.Close()
+
+	height := ledger.DefLedger.GetCurrentBlockHeight()
+	PrintInfoMsg("Start export snapshot at height %d.", height)
+	stateHash, err := ledger.DefLedger.ExportSnapshot(f)
+	if err != nil {
+		return fmt.Errorf("ExportSnapshot error:%s", err)
+	}
+	stateRoot, err := ledger.DefLedger.GetStateMerkleRoot(height)
+	if err != nil {
+		return fmt.Errorf("GetStateMerkleRoot error:%s", err)
+	}
+	PrintInfoMsg("Export snapshot complete, height:%d, state merkle root:%s, state hash:%s.", height,
+		stateRoot.ToHexString(), stateHash.ToHexString())
+	return nil
+}
+
+func importSnapshot(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	if !ctx.IsSet(utils.GetFlagName(utils.SnapshotStateRootFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotStateRootFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	stateRoot, err := common.Uint256FromHexString(ctx.String(utils.GetFlagName(utils.SnapshotStateRootFlag)))
+	if err != nil {
+		return fmt.Errorf("invalid state root:%s", err)
+	}
+	if !ctx.IsSet(utils.GetFlagName(utils.SnapshotStateHashFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.SnapshotStateHashFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	stateHash, err := common.Uint256FromHexString(ctx.String(utils.GetFlagName(utils.SnapshotStateHashFlag)))
+	if err != nil {
+		return fmt.Errorf("invalid state hash:%s", err)
+	}
+
+	cfg, err := SetDNAConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetDNAConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	snapshotFile := ctx.String(utils.GetFlagName(utils.SnapshotFileFlag))
+	f, err := os.OpenFile(snapshotFile, os.O_RDONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open snapshot file:%s error:%s", snapshotFile, err)
+	}
+	defer f.Close()
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+
+	PrintInfoMsg("Start import snapshot from %s.", snapshotFile)
+	err = ledger.DefLedger.ImportSnapshot(f, genesisBlock, stateRoot, stateHash)
+	if err != nil {
+		return fmt.Errorf("ImportSnapshot error:%s", err)
+	}
+	PrintInfoMsg("Import snapshot complete, current block height:%d.", ledger.DefLedger.GetCurrentBlockHeight())
+	return nil
+}
+
+func pruneStates(ctx *cli.Context) error {
+	log.InitLog(log.InfoLog)
+
+	if !ctx.IsSet(utils.GetFlagName(utils.PruneHeightFlag)) {
+		PrintErrorMsg("Missing %s argument.", utils.PruneHeightFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	height := uint32(ctx.Uint(utils.GetFlagName(utils.PruneHeightFlag)))
+
+	cfg, err := SetDNAConfig(ctx)
+	if err != nil {
+		PrintErrorMsg("SetDNAConfig error:%s", err)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+
+	stateHashHeight := config.GetStateHashCheckHeight(cfg.P2PNode.NetworkId)
+	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
+	if err != nil {
+		return fmt.Errorf("NewLedger error:%s", err)
+	}
+	defer ledger.DefLedger.Close()
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookKeepers, config.DefConfig.Genesis)
+	if err != nil {
+		return fmt.Errorf("BuildGenesisBlock error %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return fmt.Errorf("init ledger error:%s", err)
+	}
+
+	PrintInfoMsg("Start prune states below height %d.", height)
+	err = ledger.DefLedger.PruneStates(height)
+	if err != nil {
+		return fmt.Errorf("PruneStates error:%s", err)
+	}
+	PrintInfoMsg("Prune states complete.")
+	return nil
+}
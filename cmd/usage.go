@@ -101,6 +101,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ConfigFlag,
 			utils.LogLevelFlag,
 			utils.DisableEventLogFlag,
+			utils.EnableArchiveFlag,
+			utils.UndoBlocksFlag,
+			utils.StoreBackendFlag,
 			utils.DataDirFlag,
 		},
 	},
@@ -133,6 +136,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableConsensusFlag,
 			utils.MaxTxInBlockFlag,
+			utils.ConsensusPolicyFlag,
 		},
 	},
 	{
@@ -140,9 +144,14 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.GasPriceFlag,
 			utils.GasLimitFlag,
+			utils.MaxTxInPoolFlag,
+			utils.MaxTxPerPayerFlag,
+			utils.MaxTxLifetimeFlag,
+			utils.DisableReplaceByNonceFlag,
 			utils.TxpoolPreExecDisableFlag,
 			utils.DisableSyncVerifyTxFlag,
 			utils.DisableBroadcastNetTxFlag,
+			utils.DisableTxPoolJournalFlag,
 		},
 	},
 	{
@@ -196,6 +205,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ContractAddrFlag,
 			utils.ContractAuthorFlag,
 			utils.ContractCodeFileFlag,
+			utils.ContractAbiFileFlag,
 			utils.ContractDescFlag,
 			utils.ContractEmailFlag,
 			utils.ContractNameFlag,
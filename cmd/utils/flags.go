@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
 	"github.com/urfave/cli"
 )
@@ -30,6 +31,7 @@ const (
 	DEFAULT_EXPORT_FILE   = "./OntBlocks.dat"
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
+	DEFAULT_SNAPSHOT_FILE = "./DNASnapshot.dat"
 	DEFAULT_WALLET_PATH   = "./executor_data"
 )
 
@@ -48,6 +50,19 @@ var (
 		Name:  "disable-event-log",
 		Usage: "Discard event log output by smart contract execution",
 	}
+	EnableArchiveFlag = cli.BoolFlag{
+		Name:  "archive",
+		Usage: "Archive the state history of every block to support state queries and simulation at height",
+	}
+	UndoBlocksFlag = cli.UintFlag{
+		Name:  "undo-blocks",
+		Usage: "Keep the undo data of the latest `<number>` blocks for rollback and transaction tracing, 0 keeps all",
+		Value: config.DEFAULT_UNDO_BLOCKS,
+	}
+	StoreBackendFlag = cli.StringFlag{
+		Name:  "store-backend",
+		Usage: "Key-value `<backend>` of ledger store, e.g. leveldb, memory. Default is the backend which created the data dir, or leveldb",
+	}
 	ExecutorFileFlag = cli.StringFlag{
 		Name:  "executor,w",
 		Value: config.DEFAULT_WALLET_FILE_NAME,
@@ -63,6 +78,27 @@ var (
 		Usage: "Stop import block `<height>` of the import.",
 		Value: DEFAULT_EXPORT_HEIGHT,
 	}
+	RollbackHeightFlag = cli.UintFlag{
+		Name:  "height",
+		Usage: "Rollback the chain to block `<height>`",
+	}
+	SnapshotFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "Path of state snapshot `<file>`",
+		Value: DEFAULT_SNAPSHOT_FILE,
+	}
+	SnapshotStateRootFlag = cli.StringFlag{
+		Name:  "state-root",
+		Usage: "State merkle root `<hex>` of the snapshot height, queried from trusted peers by getstatemerkleroot",
+	}
+	SnapshotStateHashFlag = cli.StringFlag{
+		Name:  "state-hash",
+		Usage: "State hash `<hex>` of the snapshot, printed by the export of a trusted node",
+	}
+	PruneHeightFlag = cli.UintFlag{
+		Name:  "height",
+		Usage: "Prune the states only needed by the heights below block `<height>`",
+	}
 	DataDirFlag = cli.StringFlag{
 		Name:  "data-dir",
 		Usage: "Block data storage `<path>`",
@@ -79,6 +115,10 @@ var (
 		Usage: "Max transaction `<number>` in block",
 		Value: config.DEFAULT_MAX_TX_IN_BLOCK,
 	}
+	ConsensusPolicyFlag = cli.StringFlag{
+		Name:  "consensus-policy",
+		Usage: "Transaction admission policy `<file>` of consensus, reloaded when modified",
+	}
 	GasLimitFlag = cli.Uint64Flag{
 		Name:  "gaslimit",
 		Usage: "Min gas limit `<value>` of transaction to be accepted by tx pool.",
@@ -89,6 +129,25 @@ var (
 		Usage: "Min gas price `<value>` of transaction to be accepted by tx pool.",
 		Value: config.DEFAULT_GAS_PRICE,
 	}
+	MaxTxInPoolFlag = cli.UintFlag{
+		Name:  "max-tx-in-pool",
+		Usage: "Max transaction `<number>` in tx pool, the lowest gas price ones are evicted when it is full. 0 means unlimited",
+		Value: config.DEFAULT_MAX_TX_IN_POOL,
+	}
+	MaxTxPerPayerFlag = cli.UintFlag{
+		Name:  "max-tx-per-payer",
+		Usage: "Max transaction `<number>` of a payer in tx pool. 0 means unlimited",
+		Value: config.DEFAULT_MAX_TX_PER_PAYER,
+	}
+	MaxTxLifetimeFlag = cli.UintFlag{
+		Name:  "max-tx-lifetime",
+		Usage: "Max `<seconds>` a transaction can stay in tx pool before it is evicted. 0 means unlimited",
+		Value: config.DEFAULT_MAX_TX_LIFETIME,
+	}
+	DisableReplaceByNonceFlag = cli.BoolFlag{
+		Name:  "disable-replace-by-nonce",
+		Usage: "Keep the transactions of the same payer and nonce in tx pool, instead of replacing one by another with a higher gas price. Disable it when clients use timestamps as nonces",
+	}
 
 	//Test Mode setting
 	EnableTestModeFlag = cli.BoolFlag{
@@ -278,6 +337,11 @@ var (
 		Name:  "needstore",
 		Usage: "Is need use storage in contract",
 	}
+	ContractVmTypeFlag = cli.UintFlag{
+		Name:  "vmtype",
+		Usage: "Specifies contract vm type, 1 for NeoVM, 3 for WasmVM",
+		Value: uint(payload.NEOVM_TYPE),
+	}
 	ContractCodeFileFlag = cli.StringFlag{
 		Name:  "code",
 		Usage: "File path of contract code `<path>`",
@@ -305,6 +369,11 @@ var (
 		Usage: "Set `<text>` as the description of the contract",
 		Value: "",
 	}
+	ContractAbiFileFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "File path of contract json ABI `<path>` embedded in the deploy payload",
+		Value: "",
+	}
 	ContractParamsFlag = cli.StringFlag{
 		Name:  "params",
 		Usage: "Contract parameters list to invoke. separate params with comma ','",
@@ -335,7 +404,7 @@ var (
 	//Transfer setting
 	TransactionAssetFlag = cli.StringFlag{
 		Name:  "asset",
-		Usage: "Asset of ONT or ONG",
+		Usage: "Asset of ONT, ONG or the address of an asset issued by the asset factory",
 		Value: ASSET_ONT,
 	}
 	TransactionFromFlag = cli.StringFlag{
@@ -380,9 +449,13 @@ var (
 	}
 	ApproveAssetFlag = cli.StringFlag{
 		Name:  "asset",
-		Usage: "Asset of ONT of ONG to approve",
+		Usage: "Asset of ONT, ONG or the address of an issued asset to approve",
 		Value: "ont",
 	}
+	BalanceAssetFlag = cli.StringFlag{
+		Name:  "asset",
+		Usage: "Address of an asset issued by the asset factory. Show ONT and ONG balance if not specified",
+	}
 	ApproveAmountFlag = cli.StringFlag{
 		Name:  "amount",
 		Usage: "Amount of approve. Float number",
@@ -484,6 +557,10 @@ var (
 		Name:  "disable-broadcast-net-tx",
 		Usage: "Disable broadcast tx from network in tx pool",
 	}
+	DisableTxPoolJournalFlag = cli.BoolFlag{
+		Name:  "disable-tx-pool-journal",
+		Usage: "Disable journaling tx pool to reload transactions after restart",
+	}
 
 	NonOptionFlag = cli.StringFlag{
 		Name:  "option",
@@ -52,6 +52,7 @@ const (
 	VERSION_TRANSACTION    = byte(0)
 	VERSION_CONTRACT_ONT   = byte(0)
 	VERSION_CONTRACT_ONG   = byte(0)
+	VERSION_CONTRACT_ASSET = byte(0)
 	CONTRACT_TRANSFER      = "transfer"
 	CONTRACT_TRANSFER_FROM = "transferFrom"
 	CONTRACT_APPROVE       = "approve"
@@ -89,12 +90,17 @@ func GetAccountBalance(address, asset string) (uint64, error) {
 	}
 	var balance uint64
 	switch strings.ToLower(asset) {
-	case "ont":
+	case ASSET_ONT:
 		balance, err = strconv.ParseUint(balances.Ont, 10, 64)
-	case "ong":
+	case ASSET_ONG:
 		balance, err = strconv.ParseUint(balances.Ong, 10, 64)
 	default:
-		return 0, fmt.Errorf("unsupport asset:%s", asset)
+		var assetBalance *httpcom.AssetBalanceRsp
+		assetBalance, err = GetAssetBalance(address, asset)
+		if err != nil {
+			return 0, err
+		}
+		balance, err = strconv.ParseUint(assetBalance.Balance, 10, 64)
 	}
 	if err != nil {
 		return 0, err
@@ -102,6 +108,69 @@ func GetAccountBalance(address, asset string) (uint64, error) {
 	return balance, nil
 }
 
+//Return balance of address in an asset issued by the asset factory
+func GetAssetBalance(address, asset string) (*httpcom.AssetBalanceRsp, error) {
+	result, ontErr := sendRpcRequest("getbalance", []interface{}{address, asset})
+	if ontErr != nil {
+		switch ontErr.ErrorCode {
+		case ERROR_INVALID_PARAMS:
+			return nil, fmt.Errorf("invalid address:%s or asset:%s", address, asset)
+		}
+		return nil, ontErr.Error
+	}
+	balance := &httpcom.AssetBalanceRsp{}
+	err := json.Unmarshal(result, balance)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error:%s", err)
+	}
+	return balance, nil
+}
+
+//GetAssetContract return the contract version and address of ont, ong or an asset issued by the asset factory
+func GetAssetContract(asset string) (byte, common.Address, error) {
+	switch strings.ToLower(asset) {
+	case ASSET_ONT:
+		return VERSION_CONTRACT_ONT, utils.OntContractAddress, nil
+	case ASSET_ONG:
+		return VERSION_CONTRACT_ONG, utils.OngContractAddress, nil
+	}
+	contractAddr, err := httpcom.GetAddress(asset)
+	if err != nil {
+		return 0, common.ADDRESS_EMPTY, fmt.Errorf("unsupport asset:%s", asset)
+	}
+	return VERSION_CONTRACT_ASSET, contractAddr, nil
+}
+
+//GetAssetPrecision return the decimals of ont, ong or an asset issued by the asset factory
+func GetAssetPrecision(asset string) (byte, error) {
+	switch strings.ToLower(asset) {
+	case ASSET_ONT:
+		return PRECISION_ONT, nil
+	case ASSET_ONG:
+		return PRECISION_ONG, nil
+	}
+	version, contractAddr, err := GetAssetContract(asset)
+	if err != nil {
+		return 0, err
+	}
+	preResult, err := PrepareInvokeNativeContract(contractAddr, version, "decimals", []interface{}{[]byte{}})
+	if err != nil {
+		return 0, fmt.Errorf("get decimals of asset:%s error:%s", asset, err)
+	}
+	if preResult.State == 0 {
+		return 0, fmt.Errorf("get decimals of asset:%s failed", asset)
+	}
+	result, ok := preResult.Result.(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimals of asset:%s", asset)
+	}
+	decimals, err := ParseNeoVMContractReturnTypeInteger(result)
+	if err != nil {
+		return 0, err
+	}
+	return byte(decimals), nil
+}
+
 func GetAllowance(asset, from, to string) (string, error) {
 	result, ontErr := sendRpcRequest("getallowance", []interface{}{asset, from, to})
 	if ontErr != nil {
@@ -191,17 +260,9 @@ func ApproveTx(gasPrice, gasLimit uint64, asset string, from, to string, amount
 		To:    toAddr,
 		Value: amount,
 	}
-	var version byte
-	var contractAddr common.Address
-	switch strings.ToLower(asset) {
-	case ASSET_ONT:
-		version = VERSION_CONTRACT_ONT
-		contractAddr = utils.OntContractAddress
-	case ASSET_ONG:
-		version = VERSION_CONTRACT_ONG
-		contractAddr = utils.OngContractAddress
-	default:
-		return nil, fmt.Errorf("Unsupport asset:%s", asset)
+	version, contractAddr, err := GetAssetContract(asset)
+	if err != nil {
+		return nil, err
 	}
 	invokeCode, err := cutils.BuildNativeInvokeCode(contractAddr, version, CONTRACT_APPROVE, []interface{}{state})
 	if err != nil {
@@ -226,17 +287,9 @@ func TransferTx(gasPrice, gasLimit uint64, asset, from, to string, amount uint64
 		To:    toAddr,
 		Value: amount,
 	})
-	var version byte
-	var contractAddr common.Address
-	switch strings.ToLower(asset) {
-	case ASSET_ONT:
-		version = VERSION_CONTRACT_ONT
-		contractAddr = utils.OntContractAddress
-	case ASSET_ONG:
-		version = VERSION_CONTRACT_ONG
-		contractAddr = utils.OngContractAddress
-	default:
-		return nil, fmt.Errorf("unsupport asset:%s", asset)
+	version, contractAddr, err := GetAssetContract(asset)
+	if err != nil {
+		return nil, err
 	}
 	invokeCode, err := cutils.BuildNativeInvokeCode(contractAddr, version, CONTRACT_TRANSFER, []interface{}{sts})
 	if err != nil {
@@ -265,17 +318,9 @@ func TransferFromTx(gasPrice, gasLimit uint64, asset, sender, from, to string, a
 		To:     toAddr,
 		Value:  amount,
 	}
-	var version byte
-	var contractAddr common.Address
-	switch strings.ToLower(asset) {
-	case ASSET_ONT:
-		version = VERSION_CONTRACT_ONT
-		contractAddr = utils.OntContractAddress
-	case ASSET_ONG:
-		version = VERSION_CONTRACT_ONG
-		contractAddr = utils.OngContractAddress
-	default:
-		return nil, fmt.Errorf("unsupport asset:%s", asset)
+	version, contractAddr, err := GetAssetContract(asset)
+	if err != nil {
+		return nil, err
 	}
 	invokeCode, err := cutils.BuildNativeInvokeCode(contractAddr, version, CONTRACT_TRANSFER_FROM, []interface{}{transferFrom})
 	if err != nil {
@@ -597,18 +642,20 @@ func DeployContract(
 	gasLimit uint64,
 	signer *account.Account,
 	needStorage bool,
+	vmType payload.VmType,
 	code,
 	cname,
 	cversion,
 	cauthor,
 	cemail,
-	cdesc string) (string, error) {
+	cdesc,
+	cabi string) (string, error) {
 
 	c, err := hex.DecodeString(code)
 	if err != nil {
 		return "", fmt.Errorf("hex.DecodeString error:%s", err)
 	}
-	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, c, needStorage, cname, cversion, cauthor, cemail, cdesc)
+	mutable := NewDeployCodeTransaction(gasPrice, gasLimit, c, needStorage, vmType, cname, cversion, cauthor, cemail, cdesc, cabi)
 
 	err = SignTransaction(signer, mutable)
 	if err != nil {
@@ -627,17 +674,19 @@ func DeployContract(
 
 func PrepareDeployContract(
 	needStorage bool,
+	vmType payload.VmType,
 	code,
 	cname,
 	cversion,
 	cauthor,
 	cemail,
-	cdesc string) (*cstates.PreExecResult, error) {
+	cdesc,
+	cabi string) (*cstates.PreExecResult, error) {
 	c, err := hex.DecodeString(code)
 	if err != nil {
 		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
 	}
-	mutable := NewDeployCodeTransaction(0, 0, c, needStorage, cname, cversion, cauthor, cemail, cdesc)
+	mutable := NewDeployCodeTransaction(0, 0, c, needStorage, vmType, cname, cversion, cauthor, cemail, cdesc, cabi)
 	tx, _ := mutable.IntoImmutable()
 	var buffer bytes.Buffer
 	err = tx.Serialize(&buffer)
@@ -686,6 +735,7 @@ func InvokeWasmVMContract(
 	if err != nil {
 		return "", err
 	}
+	tx.TxType = types.InvokeWasm
 	return InvokeSmartContract(siger, tx)
 }
 
@@ -784,17 +834,19 @@ func PrepareInvokeNativeContract(
 }
 
 //NewDeployCodeTransaction return a smart contract deploy transaction instance
-func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorage bool,
-	cname, cversion, cauthor, cemail, cdesc string) *types.MutableTransaction {
+func NewDeployCodeTransaction(gasPrice, gasLimit uint64, code []byte, needStorage bool, vmType payload.VmType,
+	cname, cversion, cauthor, cemail, cdesc, cabi string) *types.MutableTransaction {
 
 	deployPayload := &payload.DeployCode{
 		Code:        code,
 		NeedStorage: needStorage,
+		VmType:      vmType,
 		Name:        cname,
 		Version:     cversion,
 		Author:      cauthor,
 		Email:       cemail,
 		Description: cdesc,
+		Abi:         cabi,
 	}
 	tx := &types.MutableTransaction{
 		Version:  VERSION_TRANSACTION,
@@ -32,7 +32,7 @@ import (
 const JSON_RPC_VERSION = "2.0"
 
 const (
-	ERROR_INVALID_PARAMS = rpcerr.INVALID_PARAMS
+	ERROR_INVALID_PARAMS = rpcerr.RPC_INVALID_PARAMS
 	ERROR_DNA_COMMON     = 10000
 	ERROR_DNA_SUCCESS    = 0
 )
@@ -63,10 +63,16 @@ type JsonRpcRequest struct {
 	Params  []interface{} `json:"params"`
 }
 
+//JsonRpcError object in rpc response
+type JsonRpcError struct {
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
 //JsonRpcResponse object response for JsonRpcRequest
 type JsonRpcResponse struct {
-	Error  int64           `json:"error"`
-	Desc   string          `json:"desc"`
+	Error  *JsonRpcError   `json:"error"`
 	Result json.RawMessage `json:"result"`
 }
 
@@ -98,8 +104,8 @@ func sendRpcRequest(method string, params []interface{}) ([]byte, *DNAError) {
 	if err != nil {
 		return nil, NewDNAError(fmt.Errorf("json.Unmarshal JsonRpcResponse:%s error:%s", body, err))
 	}
-	if rpcRsp.Error != 0 {
-		return nil, NewDNAError(fmt.Errorf("\n %s ", string(body)), rpcRsp.Error)
+	if rpcRsp.Error != nil {
+		return nil, NewDNAError(fmt.Errorf("\n %s ", string(body)), rpcRsp.Error.Code)
 	}
 	return rpcRsp.Result, nil
 }
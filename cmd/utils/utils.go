@@ -92,7 +92,10 @@ func CheckAssetAmount(asset string, amount uint64) error {
 			return fmt.Errorf("amount:%d larger than ONG total supply:%d", amount, constants.ONG_TOTAL_SUPPLY)
 		}
 	default:
-		return fmt.Errorf("unknown asset:%s", asset)
+		// the supply of issued assets is checked by the asset contract
+		if _, _, err := GetAssetContract(asset); err != nil {
+			return fmt.Errorf("unknown asset:%s", asset)
+		}
 	}
 	return nil
 }
@@ -44,10 +44,14 @@ const (
 	DBFT_MIN_NODE_NUM        = 4 //min node number of dbft consensus
 	SOLO_MIN_NODE_NUM        = 1 //min node number of solo consensus
 	VBFT_MIN_NODE_NUM        = 4 //min node number of vbft consensus
+	SBFT_MIN_NODE_NUM        = 4 //min node number of sbft consensus
+	POA_MIN_NODE_NUM         = 1 //min node number of poa consensus
 
 	CONSENSUS_TYPE_DBFT = "dbft"
 	CONSENSUS_TYPE_SOLO = "solo"
 	CONSENSUS_TYPE_VBFT = "vbft"
+	CONSENSUS_TYPE_SBFT = "sbft"
+	CONSENSUS_TYPE_POA  = "poa"
 
 	DEFAULT_LOG_LEVEL                       = log.InfoLog
 	DEFAULT_MAX_LOG_SIZE                    = 100 //MByte
@@ -70,6 +74,10 @@ const (
 	DEFUALT_CLI_RPC_ADDRESS                 = "127.0.0.1"
 	DEFAULT_GAS_LIMIT                       = 20000
 	DEFAULT_GAS_PRICE                       = 500
+	DEFAULT_MAX_TX_IN_POOL                  = 100000
+	DEFAULT_MAX_TX_PER_PAYER                = 1024
+	DEFAULT_MAX_TX_LIFETIME                 = 3 * 60 * 60
+	DEFAULT_UNDO_BLOCKS                     = 1000
 
 	DEFAULT_DATA_DIR      = "./Chain"
 	DEFAULT_RESERVED_FILE = "./peers.rsv"
@@ -114,6 +122,17 @@ func GetStateHashCheckHeight(id uint32) uint32 {
 	return STATE_HASH_CHECK_HEIGHT[id]
 }
 
+//the block height since which transactions of version 1, which can expire, are accepted
+var TX_VALID_UNTIL_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET:    constants.TX_VALID_UNTIL_HEIGHT_MAINNET, //Network main
+	NETWORK_ID_POLARIS_NET: constants.TX_VALID_UNTIL_HEIGHT_POLARIS, //Network polaris
+	NETWORK_ID_SOLO_NET:    0,                                       //Network solo
+}
+
+func GetTxValidUntilHeight(id uint32) uint32 {
+	return TX_VALID_UNTIL_HEIGHT[id]
+}
+
 func GetNetworkName(id uint32) string {
 	name, ok := NETWORK_NAME[id]
 	if ok {
@@ -185,6 +204,8 @@ var PolarisConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
+	POA:  &POAConfig{},
 }
 
 var MainNetConfig = &GenesisConfig{
@@ -248,6 +269,8 @@ var MainNetConfig = &GenesisConfig{
 	},
 	DBFT: &DBFTConfig{},
 	SOLO: &SOLOConfig{},
+	SBFT: &SBFTConfig{},
+	POA:  &POAConfig{},
 }
 
 var DefConfig = NewDNAConfig()
@@ -258,6 +281,8 @@ type GenesisConfig struct {
 	VBFT          *VBFTConfig
 	DBFT          *DBFTConfig
 	SOLO          *SOLOConfig
+	SBFT          *SBFTConfig
+	POA           *POAConfig
 }
 
 func NewGenesisConfig() *GenesisConfig {
@@ -267,6 +292,8 @@ func NewGenesisConfig() *GenesisConfig {
 		VBFT:          &VBFTConfig{},
 		DBFT:          &DBFTConfig{},
 		SOLO:          &SOLOConfig{},
+		SBFT:          &SBFTConfig{},
+		POA:           &POAConfig{},
 	}
 }
 
@@ -477,19 +504,43 @@ type SOLOConfig struct {
 	Bookkeepers  []string
 }
 
+type SBFTConfig struct {
+	GenBlockTime uint
+	Bookkeepers  []string
+}
+
+// POAConfig configures the round-robin proof-of-authority consensus. Bookkeepers
+// take turns in their sorted order, each turn lasting GenBlockTime seconds.
+type POAConfig struct {
+	GenBlockTime uint
+	Bookkeepers  []string
+}
+
 type CommonConfig struct {
 	LogLevel       uint
 	NodeType       string
 	EnableEventLog bool
+	EnableArchive  bool
+	UndoBlocks     uint // The undo data of the latest blocks kept for rollback and tracing, 0 means all
+	StoreBackend   string
 	SystemFee      map[string]int64
 	GasLimit       uint64
 	GasPrice       uint64
 	DataDir        string
 }
 
+type TxPoolConfig struct {
+	MaxTxInPool   uint // Lowest gas price transactions are evicted when the pool is full, 0 means unlimited
+	MaxTxPerPayer uint // The max number of transactions of a payer in the pool, 0 means unlimited
+	MaxTxLifetime uint // The seconds a transaction can stay in the pool before it is evicted, 0 means unlimited
+
+	DisableReplaceByNonce bool // Transactions of the same payer and nonce are unrelated, instead of replacing the one with a lower gas price
+}
+
 type ConsensusConfig struct {
 	EnableConsensus bool
 	MaxTxInBlock    uint
+	PolicyFile      string
 }
 
 type P2PRsvConfig struct {
@@ -540,6 +591,7 @@ type DNAConfig struct {
 	Genesis   *GenesisConfig
 	Common    *CommonConfig
 	Consensus *ConsensusConfig
+	TxPool    *TxPoolConfig
 	P2PNode   *P2PNodeConfig
 	Rpc       *RpcConfig
 	Restful   *RestfulConfig
@@ -554,12 +606,18 @@ func NewDNAConfig() *DNAConfig {
 			EnableEventLog: DEFAULT_ENABLE_EVENT_LOG,
 			SystemFee:      make(map[string]int64),
 			GasLimit:       DEFAULT_GAS_LIMIT,
+			UndoBlocks:     DEFAULT_UNDO_BLOCKS,
 			DataDir:        DEFAULT_DATA_DIR,
 		},
 		Consensus: &ConsensusConfig{
 			EnableConsensus: true,
 			MaxTxInBlock:    DEFAULT_MAX_TX_IN_BLOCK,
 		},
+		TxPool: &TxPoolConfig{
+			MaxTxInPool:   DEFAULT_MAX_TX_IN_POOL,
+			MaxTxPerPayer: DEFAULT_MAX_TX_PER_PAYER,
+			MaxTxLifetime: DEFAULT_MAX_TX_LIFETIME,
+		},
 		P2PNode: &P2PNodeConfig{
 			ReservedCfg:               &P2PRsvConfig{},
 			ReservedPeersOnly:         false,
@@ -604,6 +662,10 @@ func (this *DNAConfig) GetBookkeepers() ([]keypair.PublicKey, error) {
 		bookKeepers = this.Genesis.DBFT.Bookkeepers
 	case CONSENSUS_TYPE_SOLO:
 		bookKeepers = this.Genesis.SOLO.Bookkeepers
+	case CONSENSUS_TYPE_SBFT:
+		bookKeepers = this.Genesis.SBFT.Bookkeepers
+	case CONSENSUS_TYPE_POA:
+		bookKeepers = this.Genesis.POA.Bookkeepers
 	default:
 		return nil, fmt.Errorf("Does not support %s consensus", this.Genesis.ConsensusType)
 	}
@@ -651,6 +713,10 @@ func (this *DNAConfig) getDefNetworkIDFromGenesisConfig(genCfg *GenesisConfig) (
 		configData, err = json.Marshal(genCfg.VBFT)
 	case CONSENSUS_TYPE_DBFT:
 		configData, err = json.Marshal(genCfg.DBFT)
+	case CONSENSUS_TYPE_SBFT:
+		configData, err = json.Marshal(genCfg.SBFT)
+	case CONSENSUS_TYPE_POA:
+		configData, err = json.Marshal(genCfg.POA)
 	case CONSENSUS_TYPE_SOLO:
 		return NETWORK_ID_SOLO_NET, nil
 	default:
@@ -82,3 +82,7 @@ const (
 // ledger state hash check height
 const STATE_HASH_HEIGHT_MAINNET = 3000000
 const STATE_HASH_HEIGHT_POLARIS = 850000
+
+// transaction version 1 enable height, 0xFFFFFFFF means not scheduled
+const TX_VALID_UNTIL_HEIGHT_MAINNET = 0xFFFFFFFF
+const TX_VALID_UNTIL_HEIGHT_POLARIS = 0xFFFFFFFF
@@ -22,6 +22,8 @@ import (
 	"github.com/dnaproject2/DNA/account"
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/consensus/dbft"
+	"github.com/dnaproject2/DNA/consensus/poa"
+	"github.com/dnaproject2/DNA/consensus/sbft"
 	"github.com/dnaproject2/DNA/consensus/solo"
 	"github.com/dnaproject2/DNA/consensus/vbft"
 	"github.com/ontio/ontology-eventbus/actor"
@@ -37,6 +39,8 @@ const (
 	CONSENSUS_DBFT = "dbft"
 	CONSENSUS_SOLO = "solo"
 	CONSENSUS_VBFT = "vbft"
+	CONSENSUS_SBFT = "sbft"
+	CONSENSUS_POA  = "poa"
 )
 
 func NewConsensusService(consensusType string, account *account.Account, txpool *actor.PID, ledger *actor.PID, p2p *actor.PID) (ConsensusService, error) {
@@ -52,6 +56,10 @@ func NewConsensusService(consensusType string, account *account.Account, txpool
 		consensus, err = solo.NewSoloService(account, txpool)
 	case CONSENSUS_VBFT:
 		consensus, err = vbft.NewVbftServer(account, txpool, p2p)
+	case CONSENSUS_SBFT:
+		consensus, err = sbft.NewSbftService(account, txpool, p2p)
+	case CONSENSUS_POA:
+		consensus, err = poa.NewPoaService(account, txpool, p2p)
 	}
 	log.Infof("ConsensusType:%s", consensusType)
 	return consensus, err
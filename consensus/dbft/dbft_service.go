@@ -29,6 +29,7 @@ import (
 	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
 	actorTypes "github.com/dnaproject2/DNA/consensus/actor"
+	"github.com/dnaproject2/DNA/consensus/policy"
 	"github.com/dnaproject2/DNA/core/genesis"
 	"github.com/dnaproject2/DNA/core/ledger"
 	"github.com/dnaproject2/DNA/core/signature"
@@ -147,6 +148,7 @@ func (this *DbftService) Halt() error {
 func (self *DbftService) handleBlockPersistCompleted(block *types.Block) {
 	log.Infof("persist block: %x", block.Hash())
 	self.p2p.Broadcast(block.Hash())
+	self.RefreshPolicy()
 
 	self.InitializeConsensus(0)
 }
@@ -185,9 +187,7 @@ func (ds *DbftService) CheckExpectedView(viewNumber byte) {
 }
 
 func (ds *DbftService) CheckPolicy(transaction *types.Transaction) error {
-	//TODO: CheckPolicy
-
-	return nil
+	return policy.DefaultPolicy.CheckTransaction(transaction)
 }
 
 func (ds *DbftService) CheckSignatures() error {
@@ -435,6 +435,15 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 	ds.context.Signatures = make([][]byte, len(ds.context.Bookkeepers))
 	ds.context.Signatures[payload.BookkeeperIndex] = message.Signature
 
+	for _, tx := range ds.context.Transactions {
+		if err := policy.DefaultPolicy.CheckBlockTransaction(tx); err != nil {
+			log.Error("PrepareRequestReceived transaction policy check failed, will not sent Prepare Response", err)
+			ds.context = backupContext
+			ds.RequestChangeView()
+			return
+		}
+	}
+
 	if len(ds.context.Transactions) > 0 {
 		height := ds.context.Height - 1
 		start, end := ds.incrValidator.BlockRange()
@@ -586,6 +595,9 @@ func (ds *DbftService) BlockSignaturesReceived(payload *p2pmsg.ConsensusPayload,
 }
 
 func (ds *DbftService) RefreshPolicy() {
+	if err := policy.DefaultPolicy.Refresh(); err != nil {
+		log.Errorf("RefreshPolicy failed: %s", err)
+	}
 }
 
 func (ds *DbftService) RequestChangeView() {
@@ -680,6 +692,10 @@ func (ds *DbftService) Timeout() {
 
 			transactions := make([]*types.Transaction, 0, len(txs))
 			for _, txEntry := range txs {
+				if err := ds.CheckPolicy(txEntry.Tx); err != nil {
+					log.Debugf("[Timeout] skip transaction: %s", err)
+					continue
+				}
 				// TODO optimize to use height in txentry
 				if err := ds.incrValidator.Verify(txEntry.Tx, validHeight); err == nil {
 					transactions = append(transactions, txEntry.Tx)
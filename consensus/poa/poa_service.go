@@ -0,0 +1,292 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package poa
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/common/log"
+	actorTypes "github.com/dnaproject2/DNA/consensus/actor"
+	"github.com/dnaproject2/DNA/consensus/poa/turn"
+	"github.com/dnaproject2/DNA/consensus/policy"
+	"github.com/dnaproject2/DNA/core/ledger"
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/events"
+	"github.com/dnaproject2/DNA/events/message"
+	"github.com/dnaproject2/DNA/validator/increment"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+)
+
+/*
+*Round-robin proof-of-authority consensus for small permissioned networks.
+*The configured bookkeepers take turns producing single signed blocks; when the
+*bookkeeper in turn misses it, the next one takes over one block time later.
+ */
+const ContextVersion uint32 = 0
+
+// retry interval when block generation failed
+const retryInterval = time.Second
+
+type blockTimeout struct {
+	Height uint32
+}
+
+type PoaService struct {
+	Account       *account.Account
+	poolActor     *actorTypes.TxPoolActor
+	p2p           *actorTypes.P2PActor
+	incrValidator *increment.IncrementValidator
+	bookkeepers   []keypair.PublicKey
+	index         int
+	genBlockTime  uint32
+	timer         *time.Timer
+	started       bool
+	pid           *actor.PID
+	sub           *events.ActorSubscriber
+}
+
+func NewPoaService(bkAccount *account.Account, txpool *actor.PID, p2p *actor.PID) (*PoaService, error) {
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeepers error:%s", err)
+	}
+	index := keypair.FindKey(bookkeepers, bkAccount.PublicKey)
+	if index < 0 {
+		return nil, fmt.Errorf("account %s is not a poa bookkeeper", bkAccount.Address.ToBase58())
+	}
+	service := &PoaService{
+		Account:       bkAccount,
+		poolActor:     &actorTypes.TxPoolActor{Pool: txpool},
+		p2p:           &actorTypes.P2PActor{P2P: p2p},
+		incrValidator: increment.NewIncrementValidator(20),
+		bookkeepers:   bookkeepers,
+		index:         index,
+		genBlockTime:  uint32(config.DefConfig.Genesis.POA.GenBlockTime),
+	}
+
+	props := actor.FromProducer(func() actor.Actor {
+		return service
+	})
+
+	pid, err := actor.SpawnNamed(props, "consensus_poa")
+	service.pid = pid
+	service.sub = events.NewActorSubscriber(pid)
+
+	return service, err
+}
+
+func (self *PoaService) Receive(context actor.Context) {
+	switch msg := context.Message().(type) {
+	case *actor.Restarting:
+		log.Info("poa actor restarting")
+	case *actor.Stopping:
+		log.Info("poa actor stopping")
+	case *actor.Stopped:
+		log.Info("poa actor stopped")
+	case *actor.Started:
+		log.Info("poa actor started")
+	case *actor.Restart:
+		log.Info("poa actor restart")
+	case *actorTypes.StartConsensus:
+		if self.started {
+			log.Info("consensus have started")
+			return
+		}
+		self.started = true
+		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+		self.schedule(0)
+	case *actorTypes.StopConsensus:
+		if self.started {
+			self.started = false
+			if self.timer != nil {
+				self.timer.Stop()
+			}
+			self.incrValidator.Clean()
+			self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+		}
+	case *message.SaveBlockCompleteMsg:
+		log.Infof("poa actor receives block complete event. block height=%d txnum=%d", msg.Block.Header.Height, len(msg.Block.Transactions))
+		self.handleBlockPersistCompleted(msg.Block)
+	case *blockTimeout:
+		if !self.started || msg.Height != ledger.DefLedger.GetCurrentBlockHeight()+1 {
+			return
+		}
+		if err := self.genBlock(); err != nil {
+			log.Errorf("poa genBlock error %s", err)
+			self.schedule(retryInterval)
+		}
+	default:
+		log.Info("poa actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+	}
+}
+
+func (self *PoaService) GetPID() *actor.PID {
+	return self.pid
+}
+
+func (self *PoaService) Start() error {
+	self.pid.Tell(&actorTypes.StartConsensus{})
+	return nil
+}
+
+func (self *PoaService) Halt() error {
+	self.pid.Tell(&actorTypes.StopConsensus{})
+	return nil
+}
+
+func (self *PoaService) handleBlockPersistCompleted(block *types.Block) {
+	self.incrValidator.AddBlock(block)
+	if len(block.Header.Bookkeepers) == 1 && keypair.ComparePublicKey(block.Header.Bookkeepers[0], self.Account.PublicKey) {
+		self.p2p.Broadcast(block.Hash())
+	}
+	if err := policy.DefaultPolicy.Refresh(); err != nil {
+		log.Errorf("refresh consensus policy failed: %s", err)
+	}
+	if self.started {
+		self.schedule(0)
+	}
+}
+
+// schedule starts the timer of our next turn, which is one block time after the
+// earliest time we are allowed to produce the next block
+func (self *PoaService) schedule(minDelay time.Duration) {
+	if self.timer != nil {
+		self.timer.Stop()
+	}
+	height := ledger.DefLedger.GetCurrentBlockHeight()
+	prevHeader, err := ledger.DefLedger.GetHeaderByHeight(height)
+	if err != nil {
+		log.Errorf("poa GetHeaderByHeight %d error %s", height, err)
+		return
+	}
+	earliest := turn.EarliestTime(prevHeader.Timestamp, self.index, height+1, len(self.bookkeepers), self.genBlockTime)
+	delay := time.Unix(int64(earliest+self.genBlockTime), 0).Sub(time.Now())
+	if delay < minDelay {
+		delay = minDelay
+	}
+	if offset := turn.Offset(self.index, height+1, len(self.bookkeepers)); offset > 0 {
+		log.Debugf("poa block %d: %d turns behind the proposer, wait %s", height+1, offset, delay)
+	}
+	pid := self.pid
+	self.timer = time.AfterFunc(delay, func() {
+		pid.Tell(&blockTimeout{Height: height + 1})
+	})
+}
+
+func (self *PoaService) genBlock() error {
+	block, err := self.makeBlock()
+	if err != nil {
+		return fmt.Errorf("makeBlock error %s", err)
+	}
+
+	result, err := ledger.DefLedger.ExecuteBlock(block)
+	if err != nil {
+		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+	}
+	err = ledger.DefLedger.SubmitBlock(block, result)
+	if err != nil {
+		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+	}
+	return nil
+}
+
+func (self *PoaService) makeBlock() (*types.Block, error) {
+	nextBookkeeper, err := types.AddressFromBookkeepers(self.bookkeepers)
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeeperAddress error:%s", err)
+	}
+	prevHash := ledger.DefLedger.GetCurrentBlockHash()
+	height := ledger.DefLedger.GetCurrentBlockHeight()
+	prevHeader, err := ledger.DefLedger.GetHeaderByHash(prevHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderByHash error:%s", err)
+	}
+
+	validHeight := height
+
+	start, end := self.incrValidator.BlockRange()
+
+	if height+1 == end {
+		validHeight = start
+	} else {
+		self.incrValidator.Clean()
+		log.Infof("increment validator block height %v != ledger block height %v", int(end)-1, height)
+	}
+
+	txs := self.poolActor.GetTxnPool(true, validHeight)
+
+	transactions := make([]*types.Transaction, 0, len(txs))
+	for _, txEntry := range txs {
+		if err := policy.DefaultPolicy.CheckTransaction(txEntry.Tx); err != nil {
+			continue
+		}
+		if err := self.incrValidator.Verify(txEntry.Tx, validHeight); err == nil {
+			transactions = append(transactions, txEntry.Tx)
+		}
+	}
+
+	txHash := []common.Uint256{}
+	for _, t := range transactions {
+		txHash = append(txHash, t.Hash())
+	}
+	txRoot := common.ComputeMerkleRoot(txHash)
+
+	timestamp := uint32(time.Now().Unix())
+	earliest := turn.EarliestTime(prevHeader.Timestamp, self.index, height+1, len(self.bookkeepers), self.genBlockTime)
+	if timestamp < earliest {
+		timestamp = earliest
+	}
+	if timestamp <= prevHeader.Timestamp {
+		timestamp = prevHeader.Timestamp + 1
+	}
+
+	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(height+1, []common.Uint256{txRoot})
+	header := &types.Header{
+		Version:          ContextVersion,
+		PrevBlockHash:    prevHash,
+		TransactionsRoot: txRoot,
+		BlockRoot:        blockRoot,
+		Timestamp:        timestamp,
+		Height:           height + 1,
+		ConsensusData:    common.GetNonce(),
+		NextBookkeeper:   nextBookkeeper,
+	}
+	block := &types.Block{
+		Header:       header,
+		Transactions: transactions,
+	}
+
+	blockHash := block.Hash()
+
+	sig, err := signature.Sign(self.Account, blockHash[:])
+	if err != nil {
+		return nil, fmt.Errorf("[Signature],Sign error:%s.", err)
+	}
+
+	block.Header.Bookkeepers = []keypair.PublicKey{self.Account.PublicKey}
+	block.Header.SigData = [][]byte{sig}
+	return block, nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package turn holds the round-robin rules of the poa consensus, shared by the
+// consensus service which produces blocks and the ledger which verifies them.
+package turn
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+// MaxTimeDrift is how many seconds a block timestamp may be ahead of the local clock
+const MaxTimeDrift = 5
+
+// Proposer returns the index of the bookkeeper in turn for block height
+func Proposer(height uint32, n int) int {
+	return int(height % uint32(n))
+}
+
+// Offset returns how many turns bookkeeper index is behind the in-turn
+// bookkeeper of block height; 0 means index is in turn.
+func Offset(index int, height uint32, n int) int {
+	return (index - Proposer(height, n) + n) % n
+}
+
+// EarliestTime returns the earliest block timestamp bookkeeper index may use at
+// block height: the in-turn bookkeeper may produce at any time, every missed turn
+// before index delays its fallback block by genBlockTime seconds.
+func EarliestTime(prevTimestamp uint32, index int, height uint32, n int, genBlockTime uint32) uint32 {
+	return prevTimestamp + uint32(Offset(index, height, n))*genBlockTime
+}
+
+// VerifyHeader checks header is signed by one of bookkeepers no earlier than its turn allows,
+// and that its timestamp is not more than MaxTimeDrift seconds ahead of now
+func VerifyHeader(header, prevHeader *types.Header, bookkeepers []keypair.PublicKey, genBlockTime, now uint32) error {
+	if len(bookkeepers) == 0 {
+		return fmt.Errorf("empty bookkeepers")
+	}
+	if len(header.Bookkeepers) != 1 || len(header.SigData) != 1 {
+		return fmt.Errorf("header should have exactly one bookkeeper signature, got %d bookkeepers %d signatures",
+			len(header.Bookkeepers), len(header.SigData))
+	}
+	address, err := types.AddressFromBookkeepers(bookkeepers)
+	if err != nil {
+		return err
+	}
+	if prevHeader.NextBookkeeper != address || header.NextBookkeeper != address {
+		return fmt.Errorf("bookkeeper address error")
+	}
+	index := keypair.FindKey(bookkeepers, header.Bookkeepers[0])
+	if index < 0 {
+		return fmt.Errorf("block signer is not a bookkeeper")
+	}
+	earliest := EarliestTime(prevHeader.Timestamp, index, header.Height, len(bookkeepers), genBlockTime)
+	if header.Timestamp < earliest {
+		return fmt.Errorf("bookkeeper %d out of turn at height %d, timestamp %d earlier than %d",
+			index, header.Height, header.Timestamp, earliest)
+	}
+	if header.Timestamp > now+MaxTimeDrift {
+		return fmt.Errorf("block timestamp %d too far in the future, local time %d", header.Timestamp, now)
+	}
+	hash := header.Hash()
+	if err := signature.Verify(header.Bookkeepers[0], hash[:], header.SigData[0]); err != nil {
+		return fmt.Errorf("verify block signature error:%s", err)
+	}
+	return nil
+}
+
+// Prefer reports whether header a wins over the competing header b of the same height.
+// The block of the bookkeeper closer to its turn wins, blocks of the same bookkeeper
+// are ordered by hash, so every node picks the same block whatever the arrival order.
+// Both headers must have passed VerifyHeader.
+func Prefer(a, b *types.Header, bookkeepers []keypair.PublicKey) bool {
+	n := len(bookkeepers)
+	offsetA := Offset(keypair.FindKey(bookkeepers, a.Bookkeepers[0]), a.Height, n)
+	offsetB := Offset(keypair.FindKey(bookkeepers, b.Bookkeepers[0]), b.Height, n)
+	if offsetA != offsetB {
+		return offsetA < offsetB
+	}
+	hashA, hashB := a.Hash(), b.Hash()
+	return bytes.Compare(hashA[:], hashB[:]) < 0
+}
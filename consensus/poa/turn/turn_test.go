@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package turn
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	genBlockTime = 6
+	now          = 1010
+)
+
+func newBookkeepers(n int) ([]*account.Account, []keypair.PublicKey) {
+	accs := make([]*account.Account, n)
+	pubs := make([]keypair.PublicKey, n)
+	for i := range accs {
+		accs[i] = account.NewAccount("")
+		pubs[i] = accs[i].PublicKey
+	}
+	keypair.SortPublicKeys(pubs)
+	sorted := make([]*account.Account, n)
+	for _, acc := range accs {
+		sorted[keypair.FindKey(pubs, acc.PublicKey)] = acc
+	}
+	return sorted, pubs
+}
+
+func signedHeader(t *testing.T, acc *account.Account, prev *types.Header, timestamp uint32) *types.Header {
+	header := &types.Header{
+		Height:         prev.Height + 1,
+		Timestamp:      timestamp,
+		NextBookkeeper: prev.NextBookkeeper,
+	}
+	hash := header.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	assert.Nil(t, err)
+	header.Bookkeepers = []keypair.PublicKey{acc.PublicKey}
+	header.SigData = [][]byte{sig}
+	return header
+}
+
+func TestOffset(t *testing.T) {
+	assert.Equal(t, 0, Proposer(3, 3))
+	assert.Equal(t, 2, Proposer(5, 3))
+	assert.Equal(t, 0, Offset(2, 5, 3))
+	assert.Equal(t, 1, Offset(0, 5, 3))
+	assert.Equal(t, 2, Offset(1, 5, 3))
+	assert.Equal(t, uint32(100+2*genBlockTime), EarliestTime(100, 1, 5, 3, genBlockTime))
+	assert.Equal(t, 0, Offset(0, 7, 1))
+}
+
+func TestVerifyHeader(t *testing.T) {
+	accs, bookkeepers := newBookkeepers(3)
+	address, err := types.AddressFromBookkeepers(bookkeepers)
+	assert.Nil(t, err)
+	prev := &types.Header{Height: 4, Timestamp: 1000, NextBookkeeper: address}
+
+	// bookkeeper 2 is in turn at height 5 and may produce at once
+	header := signedHeader(t, accs[2], prev, 1001)
+	assert.Nil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// bookkeeper 0 falls back after one missed turn
+	header = signedHeader(t, accs[0], prev, 1001)
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+	header = signedHeader(t, accs[0], prev, 1000+genBlockTime)
+	assert.Nil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// bookkeeper 1 falls back after two missed turns
+	header = signedHeader(t, accs[1], prev, 1000+genBlockTime)
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+	header = signedHeader(t, accs[1], prev, 1000+2*genBlockTime)
+	assert.Nil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// signature of another header
+	signed := signedHeader(t, accs[2], prev, 1001)
+	header = &types.Header{
+		Height:         signed.Height,
+		Timestamp:      signed.Timestamp + 1,
+		NextBookkeeper: signed.NextBookkeeper,
+		Bookkeepers:    signed.Bookkeepers,
+		SigData:        signed.SigData,
+	}
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// signer outside the bookkeepers
+	header = signedHeader(t, account.NewAccount(""), prev, 1100)
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// more than one signature
+	header = signedHeader(t, accs[2], prev, 1001)
+	header.Bookkeepers = append(header.Bookkeepers, accs[0].PublicKey)
+	header.SigData = append(header.SigData, header.SigData[0])
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// timestamp ahead of local time
+	header = signedHeader(t, accs[2], prev, now+MaxTimeDrift)
+	assert.Nil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+	header = signedHeader(t, accs[0], prev, now+MaxTimeDrift+1)
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers, genBlockTime, now))
+
+	// bookkeeper set changed
+	header = signedHeader(t, accs[2], prev, 1001)
+	assert.NotNil(t, VerifyHeader(header, prev, bookkeepers[:2], genBlockTime, now))
+}
+
+func TestPrefer(t *testing.T) {
+	accs, bookkeepers := newBookkeepers(3)
+	address, err := types.AddressFromBookkeepers(bookkeepers)
+	assert.Nil(t, err)
+	prev := &types.Header{Height: 4, Timestamp: 1000, NextBookkeeper: address}
+
+	inTurn := signedHeader(t, accs[2], prev, 1001+2*genBlockTime)
+	fallback := signedHeader(t, accs[0], prev, 1000+genBlockTime)
+	assert.True(t, Prefer(inTurn, fallback, bookkeepers))
+	assert.False(t, Prefer(fallback, inTurn, bookkeepers))
+
+	other := signedHeader(t, accs[2], prev, 1002)
+	assert.NotEqual(t, Prefer(inTurn, other, bookkeepers), Prefer(other, inTurn, bookkeepers))
+	assert.False(t, Prefer(inTurn, inTurn, bookkeepers))
+}
@@ -19,25 +19,19 @@
 package consensus
 
 import (
-	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/consensus/policy"
 )
 
-type Policy struct {
-	PolicyLevel PolicyLevel
-	List        []common.Address
-}
+// Policy is implemented in package consensus/policy so that the consensus
+// engines can enforce it without importing this package.
+type Policy = policy.Policy
 
 func NewPolicy() *Policy {
-	return &Policy{}
-}
-
-func (p *Policy) Refresh() {
-	//TODO: Refresh
+	return policy.NewPolicy()
 }
 
-var DefaultPolicy *Policy
+var DefaultPolicy = policy.DefaultPolicy
 
-func InitPolicy() {
-	DefaultPolicy := NewPolicy()
-	DefaultPolicy.Refresh()
+func InitPolicy(file string) error {
+	return policy.InitPolicy(file)
 }
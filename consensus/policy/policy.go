@@ -0,0 +1,301 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package policy implements the transaction admission policy enforced by
+// consensus nodes. A policy is loaded from the node's local policy file and
+// from the global params native contract. The on-chain setting, when present,
+// takes priority over the local one when the node selects the transactions of
+// its own proposal, while the blocks proposed by other nodes are validated
+// against the on-chain setting only, so that all nodes agree on them.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/core/ledger"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+// global param names which carry the on-chain policy
+const (
+	PARAM_POLICY_LEVEL = "consensusPolicyLevel"
+	PARAM_POLICY_LIST  = "consensusPolicyList"
+)
+
+// PolicyFile is the json layout of the local policy file
+type PolicyFile struct {
+	Level string   `json:"level"`
+	List  []string `json:"list"`
+}
+
+type rule struct {
+	level PolicyLevel
+	list  map[common.Address]struct{}
+}
+
+func newRule(level PolicyLevel, list []common.Address) *rule {
+	r := &rule{level: level, list: make(map[common.Address]struct{}, len(list))}
+	for _, addr := range list {
+		r.list[addr] = struct{}{}
+	}
+	return r
+}
+
+func (r *rule) check(addr common.Address) error {
+	_, listed := r.list[addr]
+	switch r.level {
+	case AllowAll:
+		return nil
+	case DenyAll:
+		return fmt.Errorf("all transactions are denied by policy")
+	case AllowList:
+		if !listed {
+			return fmt.Errorf("address %s is not in policy allow list", addr.ToBase58())
+		}
+	case DenyList:
+		if listed {
+			return fmt.Errorf("address %s is in policy deny list", addr.ToBase58())
+		}
+	default:
+		return fmt.Errorf("unknown policy level %d", r.level)
+	}
+	return nil
+}
+
+// Policy is the transaction admission policy of consensus
+type Policy struct {
+	lock    sync.RWMutex
+	local   *rule
+	chain   *rule
+	file    string
+	modTime time.Time
+}
+
+func NewPolicy() *Policy {
+	return &Policy{local: newRule(AllowAll, nil)}
+}
+
+// SetFile sets the local policy file, which is reloaded by Refresh whenever it is modified
+func (p *Policy) SetFile(file string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.file = file
+	p.modTime = time.Time{}
+}
+
+// Update replaces the local policy
+func (p *Policy) Update(level PolicyLevel, list []common.Address) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.local = newRule(level, list)
+}
+
+func (p *Policy) effective() *rule {
+	if p.chain != nil {
+		return p.chain
+	}
+	return p.local
+}
+
+// Level returns the policy level currently in effect
+func (p *Policy) Level() PolicyLevel {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.effective().level
+}
+
+// List returns the address list currently in effect
+func (p *Policy) List() []common.Address {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	r := p.effective()
+	list := make([]common.Address, 0, len(r.list))
+	for addr := range r.list {
+		list = append(list, addr)
+	}
+	return list
+}
+
+// CheckAddress returns an error if addr is not admitted by the policy
+func (p *Policy) CheckAddress(addr common.Address) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.effective().check(addr)
+}
+
+// CheckChainAddress returns an error if addr is not admitted by the on-chain policy
+func (p *Policy) CheckChainAddress(addr common.Address) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.chain == nil {
+		return nil
+	}
+	return p.chain.check(addr)
+}
+
+// CheckTransaction returns an error if the payer of tx is not admitted by the
+// policy in effect, it is used to select the transactions of our own proposal
+func (p *Policy) CheckTransaction(tx *types.Transaction) error {
+	return checkPayer(tx, p.CheckAddress)
+}
+
+// CheckBlockTransaction returns an error if the payer of tx is not admitted by
+// the on-chain policy, it is used to validate the blocks proposed by other nodes
+func (p *Policy) CheckBlockTransaction(tx *types.Transaction) error {
+	return checkPayer(tx, p.CheckChainAddress)
+}
+
+func checkPayer(tx *types.Transaction, check func(common.Address) error) error {
+	if err := check(tx.Payer); err != nil {
+		hash := tx.Hash()
+		return fmt.Errorf("transaction %s rejected: %s", hash.ToHexString(), err)
+	}
+	return nil
+}
+
+// Refresh reloads the local policy file if it has been modified and the
+// on-chain policy from the global params contract. The on-chain policy is
+// always reloaded, even if the local file fails, so that all nodes keep
+// following the same on-chain setting.
+func (p *Policy) Refresh() error {
+	fileErr := p.refreshFile()
+	chainErr := p.refreshChain()
+	switch {
+	case fileErr != nil && chainErr != nil:
+		return fmt.Errorf("%s; %s", fileErr, chainErr)
+	case fileErr != nil:
+		return fileErr
+	default:
+		return chainErr
+	}
+}
+
+func (p *Policy) refreshFile() error {
+	p.lock.RLock()
+	file, modTime := p.file, p.modTime
+	p.lock.RUnlock()
+	if file == "" {
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("stat policy file %s error:%s", file, err)
+	}
+	if info.ModTime().Equal(modTime) {
+		return nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read policy file %s error:%s", file, err)
+	}
+	pf := &PolicyFile{}
+	if err := json.Unmarshal(data, pf); err != nil {
+		return fmt.Errorf("unmarshal policy file %s error:%s", file, err)
+	}
+	r, err := parseRule(pf.Level, pf.List)
+	if err != nil {
+		return fmt.Errorf("policy file %s: %s", file, err)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.local = r
+	p.modTime = info.ModTime()
+	log.Infof("consensus policy loaded from %s: %s, %d addresses", file, r.level, len(r.list))
+	return nil
+}
+
+func (p *Policy) refreshChain() error {
+	if ledger.DefLedger == nil {
+		return nil
+	}
+	key := append([]byte(global_params.PARAM), byte(global_params.CURRENT_VALUE))
+	value, err := ledger.DefLedger.GetStorageItem(utils.ParamContractAddress, key)
+	if err != nil && err != scom.ErrNotFound {
+		return fmt.Errorf("get global params error:%s", err)
+	}
+	params := global_params.Params{}
+	if len(value) > 0 {
+		if err := params.Deserialize(bytes.NewBuffer(value)); err != nil {
+			return fmt.Errorf("deserialize global params error:%s", err)
+		}
+	}
+	r, err := ruleFromParams(params)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.chain = r
+	return nil
+}
+
+// ruleFromParams returns the on-chain rule, or nil if the policy level param is not set
+func ruleFromParams(params global_params.Params) (*rule, error) {
+	index, level := params.GetParam(PARAM_POLICY_LEVEL)
+	if index < 0 {
+		return nil, nil
+	}
+	var list []string
+	if index, param := params.GetParam(PARAM_POLICY_LIST); index >= 0 && param.Value != "" {
+		list = strings.Split(param.Value, ",")
+	}
+	r, err := parseRule(level.Value, list)
+	if err != nil {
+		return nil, fmt.Errorf("global param %s: %s", PARAM_POLICY_LEVEL, err)
+	}
+	return r, nil
+}
+
+func parseRule(level string, list []string) (*rule, error) {
+	l, err := ParsePolicyLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]common.Address, 0, len(list))
+	for _, s := range list {
+		addr, err := common.AddressFromBase58(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy address %s: %s", s, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return newRule(l, addrs), nil
+}
+
+// DefaultPolicy is the policy shared by all consensus services of the node
+var DefaultPolicy = NewPolicy()
+
+// InitPolicy loads DefaultPolicy from the local policy file, if any, and the ledger
+func InitPolicy(file string) error {
+	DefaultPolicy.SetFile(file)
+	return DefaultPolicy.Refresh()
+}
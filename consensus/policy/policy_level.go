@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PolicyLevel decides how the address list of a Policy is interpreted
+type PolicyLevel byte
+
+const (
+	AllowAll  PolicyLevel = 0x00
+	DenyAll   PolicyLevel = 0x01
+	AllowList PolicyLevel = 0x02
+	DenyList  PolicyLevel = 0x03
+)
+
+var levelNames = map[PolicyLevel]string{
+	AllowAll:  "allowall",
+	DenyAll:   "denyall",
+	AllowList: "allowlist",
+	DenyList:  "denylist",
+}
+
+func (level PolicyLevel) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", byte(level))
+}
+
+// ParsePolicyLevel accepts either the level name (case insensitive) or its numeric value
+func ParsePolicyLevel(s string) (PolicyLevel, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return AllowAll, nil
+	}
+	for level, name := range levelNames {
+		if name == s {
+			return level, nil
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || n > uint64(DenyList) {
+		return AllowAll, fmt.Errorf("invalid policy level: %s", s)
+	}
+	return PolicyLevel(n), nil
+}
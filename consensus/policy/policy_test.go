@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	addr1 = common.Address{1}
+	addr2 = common.Address{2}
+)
+
+func TestParsePolicyLevel(t *testing.T) {
+	for _, level := range []PolicyLevel{AllowAll, DenyAll, AllowList, DenyList} {
+		l, err := ParsePolicyLevel(level.String())
+		assert.Nil(t, err)
+		assert.Equal(t, level, l)
+	}
+	l, err := ParsePolicyLevel("2")
+	assert.Nil(t, err)
+	assert.Equal(t, AllowList, l)
+	l, err = ParsePolicyLevel("")
+	assert.Nil(t, err)
+	assert.Equal(t, AllowAll, l)
+	_, err = ParsePolicyLevel("4")
+	assert.NotNil(t, err)
+	_, err = ParsePolicyLevel("allowsome")
+	assert.NotNil(t, err)
+}
+
+func TestPolicyLevels(t *testing.T) {
+	p := NewPolicy()
+	assert.Nil(t, p.CheckAddress(addr1))
+
+	p.Update(DenyAll, nil)
+	assert.NotNil(t, p.CheckAddress(addr1))
+
+	p.Update(AllowList, []common.Address{addr1})
+	assert.Nil(t, p.CheckAddress(addr1))
+	assert.NotNil(t, p.CheckAddress(addr2))
+
+	p.Update(DenyList, []common.Address{addr1})
+	assert.NotNil(t, p.CheckAddress(addr1))
+	assert.Nil(t, p.CheckAddress(addr2))
+	assert.Equal(t, DenyList, p.Level())
+	assert.Equal(t, []common.Address{addr1}, p.List())
+
+	tx := &types.Transaction{Payer: addr1}
+	assert.NotNil(t, p.CheckTransaction(tx))
+	tx.Payer = addr2
+	assert.Nil(t, p.CheckTransaction(tx))
+}
+
+func TestPolicyFileReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "policy.json")
+
+	write := func(content string, modTime time.Time) {
+		assert.Nil(t, ioutil.WriteFile(file, []byte(content), 0600))
+		assert.Nil(t, os.Chtimes(file, modTime, modTime))
+	}
+	now := time.Now()
+	write(`{"level":"denylist","list":["`+addr1.ToBase58()+`"]}`, now)
+
+	p := NewPolicy()
+	p.SetFile(file)
+	assert.Nil(t, p.Refresh())
+	assert.NotNil(t, p.CheckAddress(addr1))
+	assert.Nil(t, p.CheckAddress(addr2))
+
+	write(`{"level":"allowlist","list":["`+addr1.ToBase58()+`"]}`, now.Add(time.Second))
+	assert.Nil(t, p.Refresh())
+	assert.Nil(t, p.CheckAddress(addr1))
+	assert.NotNil(t, p.CheckAddress(addr2))
+
+	// a broken file keeps the previous policy
+	write(`{"level":"allowlist","list":["xyz"]}`, now.Add(2*time.Second))
+	assert.NotNil(t, p.Refresh())
+	assert.Nil(t, p.CheckAddress(addr1))
+	assert.NotNil(t, p.CheckAddress(addr2))
+}
+
+func TestRuleFromParams(t *testing.T) {
+	params := global_params.Params{}
+	r, err := ruleFromParams(params)
+	assert.Nil(t, err)
+	assert.Nil(t, r)
+
+	params.SetParam(global_params.Param{Key: PARAM_POLICY_LEVEL, Value: "denylist"})
+	params.SetParam(global_params.Param{Key: PARAM_POLICY_LIST, Value: addr1.ToBase58() + ", " + addr2.ToBase58()})
+	r, err = ruleFromParams(params)
+	assert.Nil(t, err)
+
+	// the on-chain policy overrides the local one
+	p := NewPolicy()
+	p.Update(AllowList, []common.Address{addr1})
+	p.chain = r
+	assert.NotNil(t, p.CheckAddress(addr1))
+	assert.NotNil(t, p.CheckAddress(addr2))
+	assert.Nil(t, p.CheckAddress(common.Address{3}))
+
+	params.SetParam(global_params.Param{Key: PARAM_POLICY_LEVEL, Value: "bad"})
+	_, err = ruleFromParams(params)
+	assert.NotNil(t, err)
+}
+
+func TestCheckBlockTransaction(t *testing.T) {
+	tx := &types.Transaction{Payer: addr1}
+
+	// the local policy only applies to our own proposal
+	p := NewPolicy()
+	p.Update(DenyList, []common.Address{addr1})
+	assert.NotNil(t, p.CheckTransaction(tx))
+	assert.Nil(t, p.CheckBlockTransaction(tx))
+
+	params := global_params.Params{}
+	params.SetParam(global_params.Param{Key: PARAM_POLICY_LEVEL, Value: "allowlist"})
+	params.SetParam(global_params.Param{Key: PARAM_POLICY_LIST, Value: addr2.ToBase58()})
+	r, err := ruleFromParams(params)
+	assert.Nil(t, err)
+	p.chain = r
+	assert.NotNil(t, p.CheckBlockTransaction(tx))
+	tx.Payer = addr2
+	assert.Nil(t, p.CheckBlockTransaction(tx))
+}
@@ -18,11 +18,15 @@
 
 package consensus
 
-type PolicyLevel byte
+import (
+	"github.com/dnaproject2/DNA/consensus/policy"
+)
+
+type PolicyLevel = policy.PolicyLevel
 
 const (
-	AllowAll  PolicyLevel = 0x00
-	DenyAll   PolicyLevel = 0x01
-	AllowList PolicyLevel = 0x02
-	DenyList  PolicyLevel = 0x03
+	AllowAll  = policy.AllowAll
+	DenyAll   = policy.DenyAll
+	AllowList = policy.AllowList
+	DenyList  = policy.DenyList
 )
@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package sbft
+
+import (
+	"fmt"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	msg "github.com/dnaproject2/DNA/p2pserver/message/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const ContextVersion uint32 = 0
+
+type ConsensusState byte
+
+const (
+	Initial          ConsensusState = 0x00
+	Primary          ConsensusState = 0x01
+	Backup           ConsensusState = 0x02
+	ProposalSent     ConsensusState = 0x04
+	ProposalReceived ConsensusState = 0x08
+	CommitSent       ConsensusState = 0x10
+	BlockGenerated   ConsensusState = 0x20
+)
+
+func (state ConsensusState) HasFlag(flag ConsensusState) bool {
+	return (state & flag) == flag
+}
+
+// ConsensusContext keeps the consensus round state of the block at Height
+type ConsensusContext struct {
+	State           ConsensusState
+	PrevHash        common.Uint256
+	Height          uint32
+	ViewNumber      byte
+	Bookkeepers     []keypair.PublicKey
+	Owner           keypair.PublicKey
+	BookkeeperIndex int
+	PrimaryIndex    uint32
+	Timestamp       uint32
+	Nonce           uint64
+	NextBookkeeper  common.Address
+	Transactions    []*types.Transaction
+	ExpectedView    []byte
+
+	// prepared block hash of every bookkeeper in current view
+	Prepares []*common.Uint256
+	// block signatures of every bookkeeper by block hash, commits are valid across views
+	Commits map[common.Uint256][][]byte
+	// the block this node has sent commit for, only this block can be prepared in later views
+	Locked *types.Block
+
+	block *types.Block
+}
+
+// M return the quorum size of the bookkeepers
+func (ctx *ConsensusContext) M() int {
+	return len(ctx.Bookkeepers) - (len(ctx.Bookkeepers)-1)/3
+}
+
+func (ctx *ConsensusContext) Reset(bkAccount *account.Account, bookkeepers []keypair.PublicKey, height uint32, prevHash common.Uint256) {
+	ctx.Bookkeepers = bookkeepers
+	ctx.State = Initial
+	ctx.PrevHash = prevHash
+	ctx.Height = height + 1
+	ctx.ViewNumber = 0
+	ctx.BookkeeperIndex = -1
+	ctx.Owner = nil
+	ctx.ExpectedView = make([]byte, len(bookkeepers))
+	ctx.Commits = make(map[common.Uint256][][]byte)
+	ctx.Locked = nil
+	ctx.resetRound()
+
+	for i, bookkeeper := range bookkeepers {
+		if keypair.ComparePublicKey(bkAccount.PublicKey, bookkeeper) {
+			ctx.BookkeeperIndex = i
+			ctx.Owner = bookkeeper
+			break
+		}
+	}
+}
+
+func (ctx *ConsensusContext) ChangeView(viewNum byte) {
+	ctx.State = Initial
+	ctx.ViewNumber = viewNum
+	ctx.resetRound()
+}
+
+func (ctx *ConsensusContext) resetRound() {
+	ctx.PrimaryIndex = (ctx.Height + uint32(ctx.ViewNumber)) % uint32(len(ctx.Bookkeepers))
+	ctx.Transactions = nil
+	ctx.Prepares = make([]*common.Uint256, len(ctx.Bookkeepers))
+	ctx.block = nil
+}
+
+// SetProposal set the block fields of current view, the cached block is rebuilt
+func (ctx *ConsensusContext) SetProposal(timestamp uint32, nonce uint64, nextBookkeeper common.Address, txs []*types.Transaction) {
+	ctx.Timestamp = timestamp
+	ctx.Nonce = nonce
+	ctx.NextBookkeeper = nextBookkeeper
+	ctx.Transactions = txs
+	ctx.block = nil
+}
+
+// MakeBlock return the block of current proposal without signatures
+func (ctx *ConsensusContext) MakeBlock(blockRoot func(height uint32, txRoot common.Uint256) common.Uint256) *types.Block {
+	if ctx.block == nil {
+		txHash := make([]common.Uint256, 0, len(ctx.Transactions))
+		for _, t := range ctx.Transactions {
+			txHash = append(txHash, t.Hash())
+		}
+		txRoot := common.ComputeMerkleRoot(txHash)
+		header := &types.Header{
+			Version:          ContextVersion,
+			PrevBlockHash:    ctx.PrevHash,
+			TransactionsRoot: txRoot,
+			BlockRoot:        blockRoot(ctx.Height, txRoot),
+			Timestamp:        ctx.Timestamp,
+			Height:           ctx.Height,
+			ConsensusData:    ctx.Nonce,
+			NextBookkeeper:   ctx.NextBookkeeper,
+		}
+		ctx.block = &types.Block{
+			Header:       header,
+			Transactions: ctx.Transactions,
+		}
+	}
+	return ctx.block
+}
+
+func (ctx *ConsensusContext) PreparesCount(hash common.Uint256) int {
+	count := 0
+	for _, p := range ctx.Prepares {
+		if p != nil && *p == hash {
+			count++
+		}
+	}
+	return count
+}
+
+func (ctx *ConsensusContext) CommitsCount(hash common.Uint256) int {
+	count := 0
+	for _, sig := range ctx.Commits[hash] {
+		if sig != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (ctx *ConsensusContext) AddCommit(hash common.Uint256, index int, sig []byte) {
+	sigs, ok := ctx.Commits[hash]
+	if !ok {
+		sigs = make([][]byte, len(ctx.Bookkeepers))
+		ctx.Commits[hash] = sigs
+	}
+	sigs[index] = sig
+}
+
+func (ctx *ConsensusContext) MakePayload(message ConsensusMessage) *msg.ConsensusPayload {
+	message.ConsensusMessageData().ViewNumber = ctx.ViewNumber
+	sink := common.NewZeroCopySink(nil)
+	message.Serialization(sink)
+	return &msg.ConsensusPayload{
+		Version:         ContextVersion,
+		PrevHash:        ctx.PrevHash,
+		Height:          ctx.Height,
+		BookkeeperIndex: uint16(ctx.BookkeeperIndex),
+		Timestamp:       ctx.Timestamp,
+		Data:            sink.Bytes(),
+		Owner:           ctx.Owner,
+	}
+}
+
+func (ctx *ConsensusContext) MakeProposal(signature []byte) *msg.ConsensusPayload {
+	proposal := &Proposal{
+		Timestamp:      ctx.Timestamp,
+		Nonce:          ctx.Nonce,
+		NextBookkeeper: ctx.NextBookkeeper,
+		Transactions:   ctx.Transactions,
+		Signature:      signature,
+	}
+	proposal.msgData.Type = ProposalMsg
+	return ctx.MakePayload(proposal)
+}
+
+func (ctx *ConsensusContext) MakePrepare(hash common.Uint256) *msg.ConsensusPayload {
+	prepare := &Prepare{BlockHash: hash}
+	prepare.msgData.Type = PrepareMsg
+	return ctx.MakePayload(prepare)
+}
+
+func (ctx *ConsensusContext) MakeCommit(hash common.Uint256, signature []byte) *msg.ConsensusPayload {
+	commit := &Commit{BlockHash: hash, Signature: signature}
+	commit.msgData.Type = CommitMsg
+	return ctx.MakePayload(commit)
+}
+
+func (ctx *ConsensusContext) MakeViewChange() *msg.ConsensusPayload {
+	vc := &ViewChange{NewViewNumber: ctx.ExpectedView[ctx.BookkeeperIndex]}
+	vc.msgData.Type = ViewChangeMsg
+	return ctx.MakePayload(vc)
+}
+
+func (ctx *ConsensusContext) GetStateDetail() string {
+	return fmt.Sprintf("Primary: %t, Backup: %t, ProposalSent: %t, ProposalReceived: %t, CommitSent: %t, BlockGenerated: %t",
+		ctx.State.HasFlag(Primary),
+		ctx.State.HasFlag(Backup),
+		ctx.State.HasFlag(ProposalSent),
+		ctx.State.HasFlag(ProposalReceived),
+		ctx.State.HasFlag(CommitSent),
+		ctx.State.HasFlag(BlockGenerated))
+}
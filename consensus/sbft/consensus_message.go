@@ -0,0 +1,277 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package sbft
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+)
+
+type ConsensusMessageType byte
+
+const (
+	ProposalMsg   ConsensusMessageType = 0x30
+	PrepareMsg    ConsensusMessageType = 0x31
+	CommitMsg     ConsensusMessageType = 0x32
+	ViewChangeMsg ConsensusMessageType = 0x33
+)
+
+type ConsensusMessage interface {
+	Serialization(sink *common.ZeroCopySink)
+	Deserialization(source *common.ZeroCopySource) error
+	Type() ConsensusMessageType
+	ViewNumber() byte
+	ConsensusMessageData() *ConsensusMessageData
+}
+
+type ConsensusMessageData struct {
+	Type       ConsensusMessageType
+	ViewNumber byte
+}
+
+func (cd *ConsensusMessageData) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteByte(byte(cd.Type))
+	sink.WriteByte(cd.ViewNumber)
+}
+
+func (cd *ConsensusMessageData) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	var temp byte
+	temp, eof = source.NextByte()
+	cd.Type = ConsensusMessageType(temp)
+	cd.ViewNumber, eof = source.NextByte()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// Proposal is the pre-prepare message sent by the primary, it carries the whole block to be agreed
+type Proposal struct {
+	msgData        ConsensusMessageData
+	Timestamp      uint32
+	Nonce          uint64
+	NextBookkeeper common.Address
+	Transactions   []*types.Transaction
+	Signature      []byte
+}
+
+func (pr *Proposal) Serialization(sink *common.ZeroCopySink) {
+	pr.msgData.Serialization(sink)
+	sink.WriteUint32(pr.Timestamp)
+	sink.WriteUint64(pr.Nonce)
+	sink.WriteAddress(pr.NextBookkeeper)
+	sink.WriteVarUint(uint64(len(pr.Transactions)))
+	for _, t := range pr.Transactions {
+		t.Serialization(sink)
+	}
+	sink.WriteVarBytes(pr.Signature)
+}
+
+func (pr *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	err := pr.msgData.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	var eof, irregular bool
+	pr.Timestamp, eof = source.NextUint32()
+	pr.Nonce, eof = source.NextUint64()
+	pr.NextBookkeeper, eof = source.NextAddress()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	length, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	for i := uint64(0); i < length; i++ {
+		var t types.Transaction
+		if err := t.Deserialization(source); err != nil {
+			return fmt.Errorf("[Proposal] transactions deserialization failed: %s", err)
+		}
+		pr.Transactions = append(pr.Transactions, &t)
+	}
+	pr.Signature, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (pr *Proposal) Type() ConsensusMessageType {
+	return pr.msgData.Type
+}
+
+func (pr *Proposal) ViewNumber() byte {
+	return pr.msgData.ViewNumber
+}
+
+func (pr *Proposal) ConsensusMessageData() *ConsensusMessageData {
+	return &(pr.msgData)
+}
+
+// Prepare is sent by backups which accepted the proposal of current view
+type Prepare struct {
+	msgData   ConsensusMessageData
+	BlockHash common.Uint256
+}
+
+func (p *Prepare) Serialization(sink *common.ZeroCopySink) {
+	p.msgData.Serialization(sink)
+	sink.WriteHash(p.BlockHash)
+}
+
+func (p *Prepare) Deserialization(source *common.ZeroCopySource) error {
+	err := p.msgData.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	var eof bool
+	p.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (p *Prepare) Type() ConsensusMessageType {
+	return p.msgData.Type
+}
+
+func (p *Prepare) ViewNumber() byte {
+	return p.msgData.ViewNumber
+}
+
+func (p *Prepare) ConsensusMessageData() *ConsensusMessageData {
+	return &(p.msgData)
+}
+
+// Commit is sent once a node collected enough prepares, the signature is the block signature
+type Commit struct {
+	msgData   ConsensusMessageData
+	BlockHash common.Uint256
+	Signature []byte
+}
+
+func (c *Commit) Serialization(sink *common.ZeroCopySink) {
+	c.msgData.Serialization(sink)
+	sink.WriteHash(c.BlockHash)
+	sink.WriteVarBytes(c.Signature)
+}
+
+func (c *Commit) Deserialization(source *common.ZeroCopySource) error {
+	err := c.msgData.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	var eof, irregular bool
+	c.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	c.Signature, _, irregular, eof = source.NextVarBytes()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (c *Commit) Type() ConsensusMessageType {
+	return c.msgData.Type
+}
+
+func (c *Commit) ViewNumber() byte {
+	return c.msgData.ViewNumber
+}
+
+func (c *Commit) ConsensusMessageData() *ConsensusMessageData {
+	return &(c.msgData)
+}
+
+// ViewChange is sent when a node gives up the current view
+type ViewChange struct {
+	msgData       ConsensusMessageData
+	NewViewNumber byte
+}
+
+func (vc *ViewChange) Serialization(sink *common.ZeroCopySink) {
+	vc.msgData.Serialization(sink)
+	sink.WriteByte(vc.NewViewNumber)
+}
+
+func (vc *ViewChange) Deserialization(source *common.ZeroCopySource) error {
+	err := vc.msgData.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	viewNum, eof := source.NextByte()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	vc.NewViewNumber = viewNum
+	return nil
+}
+
+func (vc *ViewChange) Type() ConsensusMessageType {
+	return vc.msgData.Type
+}
+
+func (vc *ViewChange) ViewNumber() byte {
+	return vc.msgData.ViewNumber
+}
+
+func (vc *ViewChange) ConsensusMessageData() *ConsensusMessageData {
+	return &(vc.msgData)
+}
+
+func DeserializeMessage(data []byte) (ConsensusMessage, error) {
+	if len(data) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var msg ConsensusMessage
+	switch ConsensusMessageType(data[0]) {
+	case ProposalMsg:
+		msg = &Proposal{}
+	case PrepareMsg:
+		msg = &Prepare{}
+	case CommitMsg:
+		msg = &Commit{}
+	case ViewChangeMsg:
+		msg = &ViewChange{}
+	default:
+		return nil, errors.New("The message is invalid.")
+	}
+	if err := msg.Deserialization(common.NewZeroCopySource(data)); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package sbft
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/core/store"
+	"github.com/dnaproject2/DNA/core/types"
+	p2pmsg "github.com/dnaproject2/DNA/p2pserver/message/types"
+	txpool "github.com/dnaproject2/DNA/txnpool/common"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+// harness runs several sbft nodes in process with a deterministic message queue and a virtual clock
+type harness struct {
+	nodes  []*testNode
+	queue  []*testEvent
+	now    time.Duration
+	online []bool
+}
+
+type testNode struct {
+	index   int
+	service *SbftService
+	ledger  *memLedger
+	timer   *fakeTimer
+}
+
+type testEvent struct {
+	to      int
+	payload *p2pmsg.ConsensusPayload
+	block   *types.Block
+}
+
+func newHarness(n int) (*harness, error) {
+	accounts := make([]*account.Account, n)
+	bookkeepers := make([]keypair.PublicKey, n)
+	for i := 0; i < n; i++ {
+		accounts[i] = account.NewAccount("")
+		bookkeepers[i] = accounts[i].PublicKey
+	}
+	keypair.SortPublicKeys(bookkeepers)
+	nextBookkeeper, err := types.AddressFromBookkeepers(bookkeepers)
+	if err != nil {
+		return nil, err
+	}
+	genesis := &types.Block{
+		Header: &types.Header{
+			Timestamp:      uint32(time.Now().Add(-time.Hour).Unix()),
+			NextBookkeeper: nextBookkeeper,
+		},
+	}
+
+	h := &harness{online: make([]bool, n)}
+	for i := 0; i < n; i++ {
+		node := &testNode{index: i}
+		node.timer = &fakeTimer{h: h}
+		node.ledger = &memLedger{h: h, node: i, blocks: []*types.Block{genesis}}
+		node.service = newSbftService(accounts[i], bookkeepers, node.ledger, &emptyPool{},
+			&testNetwork{h: h, from: i}, node.timer)
+		node.service.genBlockTime = time.Second
+		h.nodes = append(h.nodes, node)
+		h.online[i] = true
+	}
+	return h, nil
+}
+
+func (h *harness) start() {
+	for i, node := range h.nodes {
+		if h.online[i] {
+			node.service.started = true
+			node.service.InitializeConsensus(0)
+		}
+	}
+}
+
+// run dispatch messages and timeouts until all online nodes reach the height or the steps run out
+func (h *harness) run(height uint32, maxSteps int) error {
+	for step := 0; step < maxSteps; step++ {
+		if h.reached(height) {
+			return nil
+		}
+		if len(h.queue) > 0 {
+			ev := h.queue[0]
+			h.queue = h.queue[1:]
+			if !h.online[ev.to] {
+				continue
+			}
+			service := h.nodes[ev.to].service
+			if ev.block != nil {
+				service.handleBlockPersistCompleted(ev.block)
+			} else {
+				service.NewConsensusPayload(ev.payload)
+			}
+			continue
+		}
+		// no message in flight, advance the virtual clock to the nearest timeout
+		var next *testNode
+		for i, node := range h.nodes {
+			if h.online[i] && node.timer.armed && (next == nil || node.timer.deadline < next.timer.deadline) {
+				next = node
+			}
+		}
+		if next == nil {
+			return fmt.Errorf("no event to run")
+		}
+		h.now = next.timer.deadline
+		next.timer.armed = false
+		next.service.Timeout()
+	}
+	if h.reached(height) {
+		return nil
+	}
+	return fmt.Errorf("height %d not reached in %d steps", height, maxSteps)
+}
+
+func (h *harness) reached(height uint32) bool {
+	for i, node := range h.nodes {
+		if h.online[i] && node.ledger.GetCurrentBlockHeight() < height {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	h        *harness
+	deadline time.Duration
+	armed    bool
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	active := t.armed
+	t.deadline = t.h.now + d
+	t.armed = true
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	active := t.armed
+	t.armed = false
+	return active
+}
+
+type testNetwork struct {
+	h    *harness
+	from int
+}
+
+func (n *testNetwork) Broadcast(msg interface{}) {
+	payload, ok := msg.(*p2pmsg.ConsensusPayload)
+	if !ok {
+		return
+	}
+	for i := range n.h.nodes {
+		if i != n.from {
+			n.h.queue = append(n.h.queue, &testEvent{to: i, payload: payload})
+		}
+	}
+}
+
+type emptyPool struct{}
+
+func (p *emptyPool) GetTxnPool(byCount bool, height uint32) []*txpool.TXEntry {
+	return nil
+}
+
+func (p *emptyPool) VerifyBlock(txs []*types.Transaction, height uint32) error {
+	return nil
+}
+
+// memLedger keeps blocks in memory and checks the bookkeeper signatures like the ledger store
+type memLedger struct {
+	h      *harness
+	node   int
+	blocks []*types.Block
+	// failures is the number of upcoming SubmitBlock calls to reject
+	failures int
+}
+
+func (l *memLedger) GetCurrentBlockHeight() uint32 {
+	return uint32(len(l.blocks) - 1)
+}
+
+func (l *memLedger) GetCurrentBlockHash() common.Uint256 {
+	return l.blocks[len(l.blocks)-1].Hash()
+}
+
+func (l *memLedger) GetHeaderByHash(blockHash common.Uint256) (*types.Header, error) {
+	for _, block := range l.blocks {
+		if block.Hash() == blockHash {
+			return block.Header, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l *memLedger) GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256 {
+	return common.ComputeMerkleRoot(txRoots)
+}
+
+func (l *memLedger) IsContainBlock(blockHash common.Uint256) (bool, error) {
+	header, err := l.GetHeaderByHash(blockHash)
+	return header != nil, err
+}
+
+func (l *memLedger) ExecuteBlock(b *types.Block) (store.ExecuteResult, error) {
+	return store.ExecuteResult{}, nil
+}
+
+func (l *memLedger) SubmitBlock(b *types.Block, exec store.ExecuteResult) error {
+	if l.failures > 0 {
+		l.failures--
+		return fmt.Errorf("submit block %d failed", b.Header.Height)
+	}
+	prev := l.blocks[len(l.blocks)-1]
+	if b.Header.PrevBlockHash != prev.Hash() || b.Header.Height != prev.Header.Height+1 {
+		return fmt.Errorf("block %d is not continuous", b.Header.Height)
+	}
+	address, err := types.AddressFromBookkeepers(b.Header.Bookkeepers)
+	if err != nil {
+		return err
+	}
+	if address != prev.Header.NextBookkeeper {
+		return fmt.Errorf("bookkeeper address error")
+	}
+	m := len(b.Header.Bookkeepers) - (len(b.Header.Bookkeepers)-1)/3
+	hash := b.Hash()
+	if err := signature.VerifyMultiSignature(hash[:], b.Header.Bookkeepers, m, b.Header.SigData); err != nil {
+		return err
+	}
+	l.blocks = append(l.blocks, b)
+	l.h.queue = append(l.h.queue, &testEvent{to: l.node, block: b})
+	return nil
+}
+
+// sortedHashes return the distinct block hashes of online nodes at the height
+func (h *harness) blockHashes(height uint32) []string {
+	set := make(map[string]bool)
+	for i, node := range h.nodes {
+		if h.online[i] && int(height) < len(node.ledger.blocks) {
+			hash := node.ledger.blocks[height].Hash()
+			set[hash.ToHexString()] = true
+		}
+	}
+	hashes := make([]string, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
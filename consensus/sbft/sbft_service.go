@@ -15,16 +15,615 @@
  * You should have received a copy of the GNU Lesser General Public License
  * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
  */
-
 package sbft
 
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/common/log"
+	actorTypes "github.com/dnaproject2/DNA/consensus/actor"
+	"github.com/dnaproject2/DNA/consensus/policy"
+	"github.com/dnaproject2/DNA/core/genesis"
+	"github.com/dnaproject2/DNA/core/ledger"
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/core/store"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/core/vote"
+	"github.com/dnaproject2/DNA/events"
+	"github.com/dnaproject2/DNA/events/message"
+	p2pmsg "github.com/dnaproject2/DNA/p2pserver/message/types"
+	txpool "github.com/dnaproject2/DNA/txnpool/common"
+	"github.com/dnaproject2/DNA/validator/increment"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+)
+
+/*
+*Simplified BFT consensus: in every view the primary proposes a block (pre-prepare),
+*bookkeepers broadcast prepare for the proposal and commit with the block signature once
+*M prepares are collected, the block is saved after M commits. A node which sent commit
+*locks on the block and only prepares the same block in later views.
+ */
+
+// Ledger is the chain access of sbft consensus, implemented by *ledger.Ledger
+type Ledger interface {
+	GetCurrentBlockHeight() uint32
+	GetCurrentBlockHash() common.Uint256
+	GetHeaderByHash(blockHash common.Uint256) (*types.Header, error)
+	GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256
+	IsContainBlock(blockHash common.Uint256) (bool, error)
+	ExecuteBlock(b *types.Block) (store.ExecuteResult, error)
+	SubmitBlock(b *types.Block, exec store.ExecuteResult) error
+}
+
+// TxPool is the transaction source of sbft consensus, implemented by *actor.TxPoolActor
+type TxPool interface {
+	GetTxnPool(byCount bool, height uint32) []*txpool.TXEntry
+	VerifyBlock(txs []*types.Transaction, height uint32) error
+}
+
+// Network broadcast consensus payloads, implemented by *actor.P2PActor
+type Network interface {
+	Broadcast(msg interface{})
+}
+
+// timer schedule the timeout of current round, implemented by *time.Timer
+type timer interface {
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
 type SbftService struct {
+	context           ConsensusContext
+	Account           *account.Account
+	bookkeepers       []keypair.PublicKey
+	genBlockTime      time.Duration
+	timer             timer
+	timerHeight       uint32
+	timerView         byte
+	blockReceivedTime time.Time
+	started           bool
+	ledger            Ledger
+	incrValidator     *increment.IncrementValidator
+	poolActor         TxPool
+	p2p               Network
+
+	pid *actor.PID
+	sub *events.ActorSubscriber
+}
+
+func NewSbftService(bkAccount *account.Account, txpool, p2p *actor.PID) (*SbftService, error) {
+	bookkeepers, err := vote.GetValidators([]*types.Transaction{})
+	if err != nil {
+		return nil, fmt.Errorf("[NewSbftService] get bookkeepers error: %s", err)
+	}
+	if len(bookkeepers) == 0 {
+		return nil, fmt.Errorf("[NewSbftService] empty bookkeepers")
+	}
+	t := time.NewTimer(time.Second * 15)
+	if !t.Stop() {
+		<-t.C
+	}
+	service := newSbftService(bkAccount, bookkeepers, ledger.DefLedger, &actorTypes.TxPoolActor{Pool: txpool},
+		&actorTypes.P2PActor{P2P: p2p}, t)
+
+	go func() {
+		for {
+			select {
+			case <-t.C:
+				log.Debug("******Get a timeout notice")
+				service.pid.Tell(&actorTypes.TimeOut{})
+			}
+		}
+	}()
+
+	props := actor.FromProducer(func() actor.Actor {
+		return service
+	})
+
+	pid, err := actor.SpawnNamed(props, "consensus_sbft")
+	service.pid = pid
+	service.sub = events.NewActorSubscriber(pid)
+	return service, err
+}
+
+func newSbftService(bkAccount *account.Account, bookkeepers []keypair.PublicKey, ledger Ledger, pool TxPool,
+	p2p Network, t timer) *SbftService {
+	return &SbftService{
+		Account:       bkAccount,
+		bookkeepers:   bookkeepers,
+		genBlockTime:  genesis.GenBlockTime,
+		timer:         t,
+		ledger:        ledger,
+		incrValidator: increment.NewIncrementValidator(20),
+		poolActor:     pool,
+		p2p:           p2p,
+	}
+}
+
+func (this *SbftService) Receive(context actor.Context) {
+	if _, ok := context.Message().(*actorTypes.StartConsensus); this.started == false && ok == false {
+		return
+	}
+
+	switch msg := context.Message().(type) {
+	case *actor.Restarting:
+		log.Warn("sbft actor restarting")
+	case *actor.Stopping:
+		log.Warn("sbft actor stopping")
+	case *actor.Stopped:
+		log.Warn("sbft actor stopped")
+	case *actor.Started:
+		log.Warn("sbft actor started")
+	case *actor.Restart:
+		log.Warn("sbft actor restart")
+	case *actorTypes.StartConsensus:
+		this.start()
+	case *actorTypes.StopConsensus:
+		this.incrValidator.Clean()
+		this.halt()
+	case *actorTypes.TimeOut:
+		log.Info("sbft receive timeout")
+		this.Timeout()
+	case *message.SaveBlockCompleteMsg:
+		log.Infof("sbft actor receives block complete event. block height=%d, numtx=%d",
+			msg.Block.Header.Height, len(msg.Block.Transactions))
+		this.handleBlockPersistCompleted(msg.Block)
+	case *p2pmsg.ConsensusPayload:
+		this.NewConsensusPayload(msg)
+	default:
+		log.Info("sbft actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
+	}
+}
+
+func (this *SbftService) GetPID() *actor.PID {
+	return this.pid
 }
 
-func (ss *SbftService) Halt() error {
+func (this *SbftService) Start() error {
+	this.pid.Tell(&actorTypes.StartConsensus{})
 	return nil
 }
 
-func (ds *SbftService) Start() error {
+func (this *SbftService) Halt() error {
+	this.pid.Tell(&actorTypes.StopConsensus{})
+	return nil
+}
+
+func (this *SbftService) start() {
+	this.started = true
+	if config.DefConfig.Genesis.SBFT != nil && config.DefConfig.Genesis.SBFT.GenBlockTime > config.MIN_GEN_BLOCK_TIME {
+		this.genBlockTime = time.Duration(config.DefConfig.Genesis.SBFT.GenBlockTime) * time.Second
+	} else {
+		log.Warn("The Generate block time should be longer than 2 seconds, so set it to be default 6 seconds.")
+	}
+	this.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	this.InitializeConsensus(0)
+}
+
+func (this *SbftService) halt() {
+	log.Info("SBFT Stop")
+	this.timer.Stop()
+	if this.started {
+		this.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+	}
+	this.started = false
+}
+
+func (this *SbftService) handleBlockPersistCompleted(block *types.Block) {
+	log.Infof("persist block: %x", block.Hash())
+	this.incrValidator.AddBlock(block)
+	this.p2p.Broadcast(block.Hash())
+	if err := policy.DefaultPolicy.Refresh(); err != nil {
+		log.Errorf("refresh consensus policy failed: %s", err)
+	}
+
+	this.InitializeConsensus(0)
+}
+
+// InitializeConsensus start the round of next block when viewNum is 0, otherwise switch to the new view
+func (this *SbftService) InitializeConsensus(viewNum byte) {
+	ctx := &this.context
+	if viewNum == 0 {
+		ctx.Reset(this.Account, this.bookkeepers, this.ledger.GetCurrentBlockHeight(), this.ledger.GetCurrentBlockHash())
+	} else {
+		if ctx.State.HasFlag(BlockGenerated) {
+			return
+		}
+		ctx.ChangeView(viewNum)
+	}
+
+	if ctx.BookkeeperIndex < 0 {
+		log.Info("You aren't bookkeeper")
+		return
+	}
+
+	this.timerHeight = ctx.Height
+	this.timerView = viewNum
+	this.timer.Stop()
+	if ctx.BookkeeperIndex == int(ctx.PrimaryIndex) {
+		ctx.State |= Primary
+		span := time.Now().Sub(this.blockReceivedTime)
+		if span > this.genBlockTime {
+			this.timer.Reset(0)
+		} else {
+			this.timer.Reset(this.genBlockTime - span)
+		}
+	} else {
+		ctx.State |= Backup
+		this.timer.Reset(this.genBlockTime << (viewNum + 1))
+	}
+}
+
+func (this *SbftService) Timeout() {
+	ctx := &this.context
+	if this.timerHeight != ctx.Height || this.timerView != ctx.ViewNumber || ctx.State.HasFlag(BlockGenerated) {
+		return
+	}
+
+	log.Info("Timeout: height: ", this.timerHeight, " View: ", this.timerView, " State: ", ctx.GetStateDetail())
+
+	if ctx.State.HasFlag(Primary) && !ctx.State.HasFlag(ProposalSent) {
+		if err := this.sendProposal(); err != nil {
+			log.Errorf("[Timeout] send proposal error: %s", err)
+			this.RequestChangeView()
+			return
+		}
+		this.timer.Stop()
+		this.timer.Reset(this.genBlockTime << (this.timerView + 1))
+	} else {
+		this.RequestChangeView()
+	}
+}
+
+func (this *SbftService) sendProposal() error {
+	ctx := &this.context
+	if ctx.Locked != nil {
+		// propose the locked block again, the commits of earlier view remain valid
+		header := ctx.Locked.Header
+		ctx.SetProposal(header.Timestamp, header.ConsensusData, header.NextBookkeeper, ctx.Locked.Transactions)
+	} else {
+		header, err := this.ledger.GetHeaderByHash(ctx.PrevHash)
+		if err != nil {
+			return fmt.Errorf("get header by prev hash %x error: %s", ctx.PrevHash, err)
+		}
+		if header == nil {
+			return fmt.Errorf("cannot get header by prev hash %x", ctx.PrevHash)
+		}
+		timestamp := uint32(time.Now().Unix())
+		if timestamp <= header.Timestamp {
+			timestamp = header.Timestamp + 1
+		}
+		nextBookkeeper, err := types.AddressFromBookkeepers(this.bookkeepers)
+		if err != nil {
+			return fmt.Errorf("get bookkeeper address error: %s", err)
+		}
+		ctx.SetProposal(timestamp, common.GetNonce(), nextBookkeeper, this.pendingTransactions())
+	}
+
+	block := ctx.MakeBlock(this.blockRoot)
+	hash := block.Hash()
+	sig, err := signature.Sign(this.Account, hash[:])
+	if err != nil {
+		return fmt.Errorf("sign proposal error: %s", err)
+	}
+	log.Infof("Send proposal: height=%d View=%d tx=%d", ctx.Height, ctx.ViewNumber, len(ctx.Transactions))
+	ctx.State |= ProposalSent | ProposalReceived
+	ctx.Prepares[ctx.BookkeeperIndex] = &hash
+	this.blockReceivedTime = time.Now()
+	this.SignAndRelay(ctx.MakeProposal(sig))
+	this.checkPrepares()
 	return nil
 }
+
+func (this *SbftService) pendingTransactions() []*types.Transaction {
+	validHeight := this.validHeight()
+	txs := this.poolActor.GetTxnPool(true, validHeight)
+	transactions := make([]*types.Transaction, 0, len(txs))
+	for _, txEntry := range txs {
+		if err := policy.DefaultPolicy.CheckTransaction(txEntry.Tx); err != nil {
+			log.Debugf("pendingTransactions skip transaction: %s", err)
+			continue
+		}
+		if err := this.incrValidator.Verify(txEntry.Tx, validHeight); err == nil {
+			transactions = append(transactions, txEntry.Tx)
+		}
+	}
+	return transactions
+}
+
+func (this *SbftService) validHeight() uint32 {
+	height := this.context.Height - 1
+	start, end := this.incrValidator.BlockRange()
+	if height+1 == end {
+		return start
+	}
+	this.incrValidator.Clean()
+	log.Infof("incr validator block height %v != ledger block height %v", int(end)-1, height)
+	return height
+}
+
+func (this *SbftService) blockRoot(height uint32, txRoot common.Uint256) common.Uint256 {
+	return this.ledger.GetBlockRootWithNewTxRoots(height, []common.Uint256{txRoot})
+}
+
+func (this *SbftService) NewConsensusPayload(payload *p2pmsg.ConsensusPayload) {
+	ctx := &this.context
+	if ctx.BookkeeperIndex < 0 {
+		return
+	}
+	//if payload from current peer, ignore it
+	if int(payload.BookkeeperIndex) == ctx.BookkeeperIndex {
+		return
+	}
+	//if payload is not same height with current contex, ignore it
+	if payload.Version != ContextVersion || payload.PrevHash != ctx.PrevHash || payload.Height != ctx.Height {
+		log.Debug("unmatched height")
+		return
+	}
+	if ctx.State.HasFlag(BlockGenerated) {
+		log.Debug("has flag 'BlockGenerated'")
+		return
+	}
+	if int(payload.BookkeeperIndex) >= len(ctx.Bookkeepers) {
+		log.Debug("bookkeeper index out of range")
+		return
+	}
+	if payload.Owner == nil || !keypair.ComparePublicKey(payload.Owner, ctx.Bookkeepers[payload.BookkeeperIndex]) {
+		log.Warn("consensus payload owner is not the bookkeeper of index ", payload.BookkeeperIndex)
+		return
+	}
+
+	msg, err := DeserializeMessage(payload.Data)
+	if err != nil {
+		log.Errorf("DeserializeMessage failed: %s", err)
+		return
+	}
+	if err := payload.Verify(); err != nil {
+		log.Warn(err.Error())
+		return
+	}
+
+	switch m := msg.(type) {
+	case *ViewChange:
+		this.ViewChangeReceived(payload, m)
+	case *Commit:
+		// commits are accepted from any view of current height
+		this.CommitReceived(payload, m)
+	case *Proposal:
+		if m.ViewNumber() == ctx.ViewNumber {
+			this.ProposalReceived(payload, m)
+		}
+	case *Prepare:
+		if m.ViewNumber() == ctx.ViewNumber {
+			this.PrepareReceived(payload, m)
+		}
+	default:
+		log.Warn("unknown consensus message type")
+	}
+}
+
+func (this *SbftService) ProposalReceived(payload *p2pmsg.ConsensusPayload, msg *Proposal) {
+	ctx := &this.context
+	log.Infof("Proposal Received: height=%d View=%d index=%d tx=%d", payload.Height, msg.ViewNumber(), payload.BookkeeperIndex, len(msg.Transactions))
+
+	if !ctx.State.HasFlag(Backup) || ctx.State.HasFlag(ProposalReceived) {
+		return
+	}
+	if uint32(payload.BookkeeperIndex) != ctx.PrimaryIndex {
+		return
+	}
+
+	header, err := this.ledger.GetHeaderByHash(ctx.PrevHash)
+	if err != nil || header == nil {
+		log.Errorf("ProposalReceived cannot get header by PrevHash:%x", ctx.PrevHash)
+		return
+	}
+	if msg.Timestamp <= header.Timestamp || msg.Timestamp > uint32(time.Now().Add(time.Minute*10).Unix()) {
+		log.Infof("ProposalReceived: Timestamp incorrect: %d", msg.Timestamp)
+		return
+	}
+
+	ctx.SetProposal(msg.Timestamp, msg.Nonce, msg.NextBookkeeper, msg.Transactions)
+	hash := ctx.MakeBlock(this.blockRoot).Hash()
+	if err := signature.Verify(ctx.Bookkeepers[payload.BookkeeperIndex], hash[:], msg.Signature); err != nil {
+		log.Warn("ProposalReceived VerifySignature failed.", err)
+		this.RequestChangeView()
+		return
+	}
+	if ctx.Locked != nil && ctx.Locked.Hash() != hash {
+		log.Warnf("ProposalReceived: proposal %x conflicts with locked block %x", hash, ctx.Locked.Hash())
+		this.RequestChangeView()
+		return
+	}
+	nextBookkeeper, err := types.AddressFromBookkeepers(this.bookkeepers)
+	if err != nil || nextBookkeeper != msg.NextBookkeeper {
+		log.Error("[ProposalReceived] Unmatched NextBookkeeper")
+		this.RequestChangeView()
+		return
+	}
+	if len(msg.Transactions) > 0 && ctx.Locked == nil {
+		for _, tx := range msg.Transactions {
+			if err := policy.DefaultPolicy.CheckBlockTransaction(tx); err != nil {
+				log.Error("ProposalReceived transaction policy check failed, will not sent prepare", err)
+				this.RequestChangeView()
+				return
+			}
+		}
+		validHeight := this.validHeight()
+		if err := this.poolActor.VerifyBlock(msg.Transactions, validHeight); err != nil {
+			log.Error("ProposalReceived new transaction verification failed, will not sent prepare", err)
+			this.RequestChangeView()
+			return
+		}
+		for _, tx := range msg.Transactions {
+			if err := this.incrValidator.Verify(tx, validHeight); err != nil {
+				log.Error("ProposalReceived new transaction increment verification failed, will not sent prepare", err)
+				this.RequestChangeView()
+				return
+			}
+		}
+	}
+
+	ctx.State |= ProposalReceived
+	// the proposal is the prepare of primary
+	ctx.Prepares[payload.BookkeeperIndex] = &hash
+	ctx.Prepares[ctx.BookkeeperIndex] = &hash
+	this.blockReceivedTime = time.Now()
+	this.SignAndRelay(ctx.MakePrepare(hash))
+	this.checkPrepares()
+}
+
+func (this *SbftService) PrepareReceived(payload *p2pmsg.ConsensusPayload, msg *Prepare) {
+	log.Infof("Prepare Received: height=%d View=%d index=%d", payload.Height, msg.ViewNumber(), payload.BookkeeperIndex)
+	hash := msg.BlockHash
+	this.context.Prepares[payload.BookkeeperIndex] = &hash
+	this.checkPrepares()
+}
+
+func (this *SbftService) checkPrepares() {
+	ctx := &this.context
+	if !ctx.State.HasFlag(ProposalReceived) || ctx.State.HasFlag(CommitSent) {
+		return
+	}
+	block := ctx.MakeBlock(this.blockRoot)
+	hash := block.Hash()
+	if ctx.PreparesCount(hash) < ctx.M() {
+		return
+	}
+	sig, err := signature.Sign(this.Account, hash[:])
+	if err != nil {
+		log.Error("[checkPrepares] signing failed", err)
+		return
+	}
+	log.Infof("send commit: height=%d View=%d", ctx.Height, ctx.ViewNumber)
+	ctx.State |= CommitSent
+	ctx.Locked = block
+	ctx.AddCommit(hash, ctx.BookkeeperIndex, sig)
+	this.SignAndRelay(ctx.MakeCommit(hash, sig))
+	this.checkCommits()
+}
+
+func (this *SbftService) CommitReceived(payload *p2pmsg.ConsensusPayload, msg *Commit) {
+	ctx := &this.context
+	log.Infof("Commit Received: height=%d View=%d index=%d", payload.Height, msg.ViewNumber(), payload.BookkeeperIndex)
+	if sigs, ok := ctx.Commits[msg.BlockHash]; ok && sigs[payload.BookkeeperIndex] != nil {
+		return
+	}
+	if err := signature.Verify(ctx.Bookkeepers[payload.BookkeeperIndex], msg.BlockHash[:], msg.Signature); err != nil {
+		log.Warn("CommitReceived VerifySignature failed.", err)
+		return
+	}
+	ctx.AddCommit(msg.BlockHash, int(payload.BookkeeperIndex), msg.Signature)
+	this.checkCommits()
+}
+
+func (this *SbftService) checkCommits() {
+	ctx := &this.context
+	if ctx.State.HasFlag(BlockGenerated) {
+		return
+	}
+	var block *types.Block
+	if ctx.Locked != nil {
+		block = ctx.Locked
+	} else if ctx.State.HasFlag(ProposalReceived) {
+		block = ctx.MakeBlock(this.blockRoot)
+	} else {
+		return
+	}
+	hash := block.Hash()
+	if ctx.CommitsCount(hash) < ctx.M() {
+		return
+	}
+
+	block.Header.Bookkeepers = ctx.Bookkeepers
+	block.Header.SigData = make([][]byte, 0, ctx.M())
+	for _, sig := range ctx.Commits[hash] {
+		if sig != nil && len(block.Header.SigData) < ctx.M() {
+			block.Header.SigData = append(block.Header.SigData, sig)
+		}
+	}
+
+	isExist, err := this.ledger.IsContainBlock(hash)
+	if err != nil {
+		log.Errorf("IsContainBlock Hash:%x error:%s", hash, err)
+		return
+	}
+	// leave the timer running on failure so a view change can recover
+	if !isExist {
+		result, err := this.ledger.ExecuteBlock(block)
+		if err != nil {
+			log.Errorf("checkCommits ExecuteBlock Height:%d error:%s", block.Header.Height, err)
+			return
+		}
+		if err := this.ledger.SubmitBlock(block, result); err != nil {
+			log.Errorf("checkCommits SubmitBlock Height:%d error:%s", block.Header.Height, err)
+			return
+		}
+	}
+	ctx.State |= BlockGenerated
+	this.timer.Stop()
+}
+
+func (this *SbftService) ViewChangeReceived(payload *p2pmsg.ConsensusPayload, msg *ViewChange) {
+	ctx := &this.context
+	log.Infof("View Change Received: height=%d View=%d index=%d nv=%d", payload.Height, msg.ViewNumber(), payload.BookkeeperIndex, msg.NewViewNumber)
+
+	if msg.NewViewNumber <= ctx.ExpectedView[payload.BookkeeperIndex] {
+		return
+	}
+	ctx.ExpectedView[payload.BookkeeperIndex] = msg.NewViewNumber
+	this.CheckExpectedView(msg.NewViewNumber)
+}
+
+func (this *SbftService) CheckExpectedView(viewNumber byte) {
+	ctx := &this.context
+	if ctx.State.HasFlag(BlockGenerated) || ctx.ViewNumber >= viewNumber {
+		return
+	}
+	//check the count for same view number
+	count := 0
+	for _, expectedViewNumber := range ctx.ExpectedView {
+		if expectedViewNumber >= viewNumber {
+			count++
+		}
+	}
+	if count >= ctx.M() {
+		log.Infof("[CheckExpectedView] change to view %d", viewNumber)
+		this.InitializeConsensus(viewNumber)
+	}
+}
+
+func (this *SbftService) RequestChangeView() {
+	ctx := &this.context
+	if ctx.State.HasFlag(BlockGenerated) || ctx.BookkeeperIndex < 0 {
+		return
+	}
+	if ctx.ViewNumber >= ctx.ExpectedView[ctx.BookkeeperIndex] {
+		ctx.ExpectedView[ctx.BookkeeperIndex] = ctx.ViewNumber + 1
+	} else {
+		ctx.ExpectedView[ctx.BookkeeperIndex] += 1
+	}
+	newView := ctx.ExpectedView[ctx.BookkeeperIndex]
+	log.Infof("Request change view: height=%d View=%d nv=%d state=%s", ctx.Height, ctx.ViewNumber, newView, ctx.GetStateDetail())
+
+	this.timer.Stop()
+	this.timer.Reset(this.genBlockTime << (newView + 1))
+
+	this.SignAndRelay(ctx.MakeViewChange())
+	this.CheckExpectedView(newView)
+}
+
+func (this *SbftService) SignAndRelay(payload *p2pmsg.ConsensusPayload) {
+	buf := new(bytes.Buffer)
+	payload.SerializeUnsigned(buf)
+	payload.Signature, _ = signature.Sign(this.Account, buf.Bytes())
+
+	this.p2p.Broadcast(payload)
+}
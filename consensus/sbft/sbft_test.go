@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package sbft
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsensusMessage(t *testing.T) {
+	msgs := []ConsensusMessage{
+		&Proposal{msgData: ConsensusMessageData{Type: ProposalMsg, ViewNumber: 1}, Timestamp: 10, Nonce: 20,
+			NextBookkeeper: common.AddressFromVmCode([]byte{1}), Signature: []byte{1, 2, 3}},
+		&Prepare{msgData: ConsensusMessageData{Type: PrepareMsg, ViewNumber: 2}, BlockHash: common.Uint256{1}},
+		&Commit{msgData: ConsensusMessageData{Type: CommitMsg}, BlockHash: common.Uint256{2}, Signature: []byte{4, 5}},
+		&ViewChange{msgData: ConsensusMessageData{Type: ViewChangeMsg, ViewNumber: 3}, NewViewNumber: 4},
+	}
+	for _, msg := range msgs {
+		sink := common.NewZeroCopySink(nil)
+		msg.Serialization(sink)
+		msg2, err := DeserializeMessage(sink.Bytes())
+		assert.Nil(t, err)
+		assert.Equal(t, msg, msg2)
+	}
+	_, err := DeserializeMessage([]byte{byte(CommitMsg), 0, 1})
+	assert.NotNil(t, err)
+}
+
+func TestSbftConsensus(t *testing.T) {
+	h, err := newHarness(4)
+	assert.Nil(t, err)
+	h.start()
+	assert.Nil(t, h.run(5, 10000))
+	for height := uint32(1); height <= 5; height++ {
+		assert.Equal(t, 1, len(h.blockHashes(height)))
+	}
+}
+
+func TestSbftViewChange(t *testing.T) {
+	h, err := newHarness(4)
+	assert.Nil(t, err)
+	// the primary of block 1 in view 0 is offline, the block can only be generated after view change
+	h.online[1%4] = false
+	h.start()
+	assert.Nil(t, h.run(3, 10000))
+	for height := uint32(1); height <= 3; height++ {
+		assert.Equal(t, 1, len(h.blockHashes(height)))
+	}
+}
+
+func TestSbftSubmitFailure(t *testing.T) {
+	h, err := newHarness(4)
+	assert.Nil(t, err)
+	// every node fails to submit the first block it commits and has to retry
+	for _, node := range h.nodes {
+		node.ledger.failures = 1
+	}
+	h.start()
+	assert.Nil(t, h.run(3, 10000))
+	for height := uint32(1); height <= 3; height++ {
+		assert.Equal(t, 1, len(h.blockHashes(height)))
+	}
+}
+
+func TestSbftFaultyNodes(t *testing.T) {
+	h, err := newHarness(7)
+	assert.Nil(t, err)
+	h.online[2] = false
+	h.online[5] = false
+	h.start()
+	assert.Nil(t, h.run(4, 20000))
+	for height := uint32(1); height <= 4; height++ {
+		assert.Equal(t, 1, len(h.blockHashes(height)))
+	}
+}
+
+func TestSbftNoQuorum(t *testing.T) {
+	h, err := newHarness(4)
+	assert.Nil(t, err)
+	h.online[0] = false
+	h.online[1] = false
+	h.start()
+	assert.NotNil(t, h.run(1, 2000))
+	for i, node := range h.nodes {
+		if h.online[i] {
+			assert.Equal(t, uint32(0), node.ledger.GetCurrentBlockHeight())
+		}
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/log"
 	actorTypes "github.com/dnaproject2/DNA/consensus/actor"
+	"github.com/dnaproject2/DNA/consensus/policy"
 	"github.com/dnaproject2/DNA/consensus/vbft/config"
 	"github.com/dnaproject2/DNA/core/ledger"
 	"github.com/dnaproject2/DNA/core/payload"
@@ -213,6 +214,9 @@ func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 	}
 	self.completedBlockNum = block.Header.Height
 	self.incrValidator.AddBlock(block)
+	if err := policy.DefaultPolicy.Refresh(); err != nil {
+		log.Errorf("server %d refresh consensus policy failed: %s", self.Index, err)
+	}
 	if self.nonConsensusNode() {
 		self.chainStore.ReloadFromLedger()
 		self.metaLock.Lock()
@@ -1144,6 +1148,14 @@ func (self *Server) processProposalMsg(msg *blockProposalMsg) {
 
 	txs := msg.Block.Block.Transactions
 	if len(txs) > 0 && self.nonSystxs(txs, msgBlkNum) {
+		for _, tx := range txs {
+			if err := policy.DefaultPolicy.CheckBlockTransaction(tx); err != nil {
+				log.Errorf("server %d proposal blk from %d violates policy, blk %d, err: %s",
+					self.Index, msg.Block.getProposer(), msgBlkNum, err)
+				self.msgPool.DropMsg(msg)
+				return
+			}
+		}
 		height := uint32(msgBlkNum) - 1
 		start, end := self.incrValidator.BlockRange()
 
@@ -1832,6 +1844,9 @@ func (self *Server) processTimerEvent(evt *TimerEvent) error {
 			validHeight := self.validHeight(evt.blockNum)
 			newProposal := false
 			for _, e := range self.poolActor.GetTxnPool(true, validHeight) {
+				if err := policy.DefaultPolicy.CheckTransaction(e.Tx); err != nil {
+					continue
+				}
 				if err := self.incrValidator.Verify(e.Tx, validHeight); err == nil {
 					newProposal = true
 					break
@@ -2215,6 +2230,10 @@ func (self *Server) makeProposal(blkNum uint32, forEmpty bool) error {
 
 	if !forEmpty {
 		for _, e := range self.poolActor.GetTxnPool(true, validHeight) {
+			if err := policy.DefaultPolicy.CheckTransaction(e.Tx); err != nil {
+				log.Debugf("server %d skip tx in proposal: %s", self.Index, err)
+				continue
+			}
 			if err := self.incrValidator.Verify(e.Tx, validHeight); err == nil {
 				userTxs = append(userTxs, e.Tx)
 			}
@@ -20,6 +20,8 @@ package ledger
 
 import (
 	"fmt"
+	"io"
+
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/core/payload"
@@ -80,6 +82,22 @@ func (self *Ledger) SubmitBlock(b *types.Block, exec store.ExecuteResult) error
 	return self.ldgStore.SubmitBlock(b, exec)
 }
 
+func (self *Ledger) RollbackToHeight(height uint32) error {
+	return self.ldgStore.RollbackToHeight(height)
+}
+
+func (self *Ledger) ExportSnapshot(w io.Writer) (common.Uint256, error) {
+	return self.ldgStore.ExportSnapshot(w)
+}
+
+func (self *Ledger) ImportSnapshot(r io.Reader, genesisBlock *types.Block, stateRoot, stateHash common.Uint256) error {
+	return self.ldgStore.ImportSnapshot(r, genesisBlock, stateRoot, stateHash)
+}
+
+func (self *Ledger) PruneStates(height uint32) error {
+	return self.ldgStore.PruneStates(height)
+}
+
 func (self *Ledger) GetStateMerkleRoot(height uint32) (result common.Uint256, err error) {
 	return self.ldgStore.GetStateMerkleRoot(height)
 }
@@ -167,10 +185,29 @@ func (self *Ledger) GetStorageItem(codeHash common.Address, key []byte) ([]byte,
 	return storageItem.Value, nil
 }
 
+func (self *Ledger) GetStorageItemAtHeight(codeHash common.Address, key []byte, height uint32) ([]byte, error) {
+	storageKey := &states.StorageKey{
+		ContractAddress: codeHash,
+		Key:             key,
+	}
+	storageItem, err := self.ldgStore.GetStorageItemAtHeight(storageKey, height)
+	if err != nil {
+		return nil, err
+	}
+	if storageItem == nil {
+		return nil, nil
+	}
+	return storageItem.Value, nil
+}
+
 func (self *Ledger) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
 	return self.ldgStore.GetContractState(contractHash)
 }
 
+func (self *Ledger) GetContractUpgradeInfo(contractHash common.Address) (*states.ContractUpgradeInfo, error) {
+	return self.ldgStore.GetContractUpgradeInfo(contractHash)
+}
+
 func (self *Ledger) GetMerkleProof(proofHeight, rootHeight uint32) ([]common.Uint256, error) {
 	return self.ldgStore.GetMerkleProof(proofHeight, rootHeight)
 }
@@ -179,6 +216,14 @@ func (self *Ledger) PreExecuteContract(tx *types.Transaction) (*cstate.PreExecRe
 	return self.ldgStore.PreExecuteContract(tx)
 }
 
+func (self *Ledger) SimulateTransaction(tx *types.Transaction, height uint32) (*cstate.SimulateResult, error) {
+	return self.ldgStore.SimulateTransaction(tx, height)
+}
+
+func (self *Ledger) TraceTransaction(txHash common.Uint256, withStack bool) (*cstate.TraceResult, error) {
+	return self.ldgStore.TraceTransaction(txHash, withStack)
+}
+
 func (self *Ledger) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return self.ldgStore.GetEventNotifyByTx(tx)
 }
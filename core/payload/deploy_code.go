@@ -27,15 +27,33 @@ import (
 	"github.com/dnaproject2/DNA/common/serialization"
 )
 
+// VmType is the virtual machine which the deployed contract run on
+type VmType byte
+
+const (
+	NEOVM_TYPE  VmType = 1
+	WASMVM_TYPE VmType = 3
+)
+
+// ABI_FLAG is set in the vm flags byte when an ABI follows the description
+const ABI_FLAG byte = 0x80
+
+// MAX_ABI_LEN is the max length of the ABI embedded in deploy code
+const MAX_ABI_LEN = 64 * 1024
+
 // DeployCode is an implementation of transaction payload for deploy smartcontract
+// VmType share the serialized byte with NeedStorage, 0 and 1 mean NeoVM for compatibility
+// Abi is the optional json ABI of the contract, it is serialized only when ABI_FLAG is set
 type DeployCode struct {
 	Code        []byte
 	NeedStorage bool
+	VmType      VmType
 	Name        string
 	Version     string
 	Author      string
 	Email       string
 	Description string
+	Abi         string
 
 	address common.Address
 }
@@ -47,6 +65,40 @@ func (dc *DeployCode) Address() common.Address {
 	return dc.address
 }
 
+// IsWasm return whether the deployed contract is a wasm contract
+func (dc *DeployCode) IsWasm() bool {
+	return dc.VmType == WASMVM_TYPE
+}
+
+func (dc *DeployCode) vmFlags() byte {
+	var flags byte
+	if dc.VmType == WASMVM_TYPE {
+		flags = byte(WASMVM_TYPE)
+	} else if dc.NeedStorage {
+		flags = 1
+	}
+	if dc.Abi != "" {
+		flags |= ABI_FLAG
+	}
+	return flags
+}
+
+func (dc *DeployCode) setVmFlags(flags byte) (bool, error) {
+	hasAbi := flags&ABI_FLAG != 0
+	flags &^= ABI_FLAG
+	switch flags {
+	case 0, 1:
+		dc.NeedStorage = flags == 1
+		dc.VmType = NEOVM_TYPE
+	case byte(WASMVM_TYPE):
+		dc.NeedStorage = true
+		dc.VmType = WASMVM_TYPE
+	default:
+		return false, fmt.Errorf("unsupported vm flags %d", flags)
+	}
+	return hasAbi, nil
+}
+
 func (dc *DeployCode) Serialize(w io.Writer) error {
 	var err error
 
@@ -55,7 +107,7 @@ func (dc *DeployCode) Serialize(w io.Writer) error {
 		return fmt.Errorf("DeployCode Code Serialize failed: %s", err)
 	}
 
-	err = serialization.WriteBool(w, dc.NeedStorage)
+	err = serialization.WriteByte(w, dc.vmFlags())
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Serialize failed: %s", err)
 	}
@@ -85,6 +137,13 @@ func (dc *DeployCode) Serialize(w io.Writer) error {
 		return fmt.Errorf("DeployCode Description Serialize failed: %s", err)
 	}
 
+	if dc.Abi != "" {
+		err = serialization.WriteString(w, dc.Abi)
+		if err != nil {
+			return fmt.Errorf("DeployCode Abi Serialize failed: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -95,7 +154,11 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 	}
 	dc.Code = code
 
-	dc.NeedStorage, err = serialization.ReadBool(r)
+	flags, err := serialization.ReadByte(r)
+	if err != nil {
+		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
+	}
+	hasAbi, err := dc.setVmFlags(flags)
 	if err != nil {
 		return fmt.Errorf("DeployCode NeedStorage Deserialize failed: %s", err)
 	}
@@ -125,6 +188,17 @@ func (dc *DeployCode) Deserialize(r io.Reader) error {
 		return fmt.Errorf("DeployCode Description Deserialize failed: %s", err)
 	}
 
+	dc.Abi = ""
+	if hasAbi {
+		dc.Abi, err = serialization.ReadString(r)
+		if err != nil {
+			return fmt.Errorf("DeployCode Abi Deserialize failed: %s", err)
+		}
+		if dc.Abi == "" || len(dc.Abi) > MAX_ABI_LEN {
+			return fmt.Errorf("DeployCode Abi Deserialize failed: invalid abi length %d", len(dc.Abi))
+		}
+	}
+
 	return nil
 }
 
@@ -136,12 +210,15 @@ func (dc *DeployCode) ToArray() []byte {
 
 func (dc *DeployCode) Serialization(sink *common.ZeroCopySink) error {
 	sink.WriteVarBytes(dc.Code)
-	sink.WriteBool(dc.NeedStorage)
+	sink.WriteByte(dc.vmFlags())
 	sink.WriteString(dc.Name)
 	sink.WriteString(dc.Version)
 	sink.WriteString(dc.Author)
 	sink.WriteString(dc.Email)
 	sink.WriteString(dc.Description)
+	if dc.Abi != "" {
+		sink.WriteString(dc.Abi)
+	}
 
 	return nil
 }
@@ -154,8 +231,13 @@ func (dc *DeployCode) Deserialization(source *common.ZeroCopySource) error {
 		return common.ErrIrregularData
 	}
 
-	dc.NeedStorage, irregular, eof = source.NextBool()
-	if irregular {
+	var flags byte
+	flags, eof = source.NextByte()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	hasAbi, err := dc.setVmFlags(flags)
+	if err != nil {
 		return common.ErrIrregularData
 	}
 
@@ -184,6 +266,14 @@ func (dc *DeployCode) Deserialization(source *common.ZeroCopySource) error {
 		return common.ErrIrregularData
 	}
 
+	dc.Abi = ""
+	if hasAbi && !eof {
+		dc.Abi, _, irregular, eof = source.NextString()
+		if irregular || (!eof && (dc.Abi == "" || len(dc.Abi) > MAX_ABI_LEN)) {
+			return common.ErrIrregularData
+		}
+	}
+
 	if eof {
 		return io.ErrUnexpectedEOF
 	}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package payload
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployCode_Serialize(t *testing.T) {
+	for _, dc := range []DeployCode{
+		{Code: []byte{1, 2, 3}, NeedStorage: false, VmType: NEOVM_TYPE, Name: "neo"},
+		{Code: []byte{1, 2, 3}, NeedStorage: true, VmType: NEOVM_TYPE, Name: "neo"},
+		{Code: []byte{0, 'a', 's', 'm'}, NeedStorage: true, VmType: WASMVM_TYPE, Name: "wasm"},
+	} {
+		bf := new(bytes.Buffer)
+		if err := dc.Serialize(bf); err != nil {
+			t.Fatal("deploy code serialize fail!")
+		}
+		dc2 := DeployCode{}
+		if err := dc2.Deserialize(bf); err != nil {
+			t.Fatal("deploy code deserialize fail!")
+		}
+		assert.Equal(t, dc, dc2)
+
+		sink := common.NewZeroCopySink(nil)
+		dc.Serialization(sink)
+		dc3 := DeployCode{}
+		assert.Nil(t, dc3.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+		assert.Equal(t, dc.IsWasm(), dc3.IsWasm())
+		assert.Equal(t, dc.NeedStorage, dc3.NeedStorage)
+	}
+}
+
+func TestDeployCode_InvalidVmType(t *testing.T) {
+	dc := DeployCode{Code: []byte{1}, VmType: WASMVM_TYPE}
+	data := dc.ToArray()
+	// the vm flags byte follows the var bytes code
+	data[2] = 2
+	assert.NotNil(t, new(DeployCode).Deserialize(bytes.NewBuffer(data)))
+	assert.NotNil(t, new(DeployCode).Deserialization(common.NewZeroCopySource(data)))
+}
+
+func TestDeployCode_Abi(t *testing.T) {
+	dc := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true, VmType: NEOVM_TYPE, Name: "neo"}
+	legacy := dc.ToArray()
+	assert.Equal(t, byte(1), legacy[4])
+
+	dc.Abi = `{"functions":[{"name":"transfer"}]}`
+	data := dc.ToArray()
+	assert.Equal(t, byte(1)|ABI_FLAG, data[4])
+	assert.Equal(t, legacy[5:], data[5:len(legacy)])
+
+	dc2 := DeployCode{}
+	assert.Nil(t, dc2.Deserialize(bytes.NewBuffer(data)))
+	assert.Equal(t, dc, dc2)
+
+	dc3 := DeployCode{}
+	assert.Nil(t, dc3.Deserialization(common.NewZeroCopySource(data)))
+	assert.Equal(t, dc.Abi, dc3.Abi)
+	assert.True(t, dc3.NeedStorage)
+
+	// the abi flag without abi is rejected
+	data = append([]byte{}, legacy...)
+	data[4] |= ABI_FLAG
+	assert.NotNil(t, new(DeployCode).Deserialize(bytes.NewBuffer(data)))
+	assert.NotNil(t, new(DeployCode).Deserialization(common.NewZeroCopySource(data)))
+}
+
+func TestDeployCode_Truncated(t *testing.T) {
+	dc := DeployCode{Code: []byte{1, 2, 3}, NeedStorage: true, VmType: NEOVM_TYPE, Name: "neo"}
+	data := dc.ToArray()
+	// cut right before the vm flags byte
+	assert.Equal(t, io.ErrUnexpectedEOF, new(DeployCode).Deserialization(common.NewZeroCopySource(data[:4])))
+	for i := 0; i < len(data); i++ {
+		assert.NotNil(t, new(DeployCode).Deserialization(common.NewZeroCopySource(data[:i])))
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package states
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/errors"
+)
+
+// ContractUpgrade record a contract upgraded from one address to another
+type ContractUpgrade struct {
+	From   common.Address
+	To     common.Address
+	Height uint32
+	TxHash common.Uint256
+}
+
+func (this *ContractUpgrade) Serialize(w io.Writer) error {
+	if err := this.From.Serialize(w); err != nil {
+		return err
+	}
+	if err := this.To.Serialize(w); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.Height); err != nil {
+		return err
+	}
+	return this.TxHash.Serialize(w)
+}
+
+func (this *ContractUpgrade) Deserialize(r io.Reader) error {
+	if err := this.From.Deserialize(r); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade], From Deserialize failed.")
+	}
+	if err := this.To.Deserialize(r); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade], To Deserialize failed.")
+	}
+	height, err := serialization.ReadUint32(r)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade], Height Deserialize failed.")
+	}
+	this.Height = height
+	if err := this.TxHash.Deserialize(r); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade], TxHash Deserialize failed.")
+	}
+	return nil
+}
+
+// ContractUpgradeInfo is the upgrade history of a contract address. History is the chain of upgrades which
+// lead to the address, Successor is the address the contract is upgraded to, empty if it is not upgraded
+type ContractUpgradeInfo struct {
+	StateBase
+	Successor common.Address
+	History   []ContractUpgrade
+}
+
+func (this *ContractUpgradeInfo) Serialize(w io.Writer) error {
+	this.StateBase.Serialize(w)
+	if err := this.Successor.Serialize(w); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, uint32(len(this.History))); err != nil {
+		return err
+	}
+	for i := range this.History {
+		if err := this.History[i].Serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *ContractUpgradeInfo) Deserialize(r io.Reader) error {
+	if err := this.StateBase.Deserialize(r); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgradeInfo], StateBase Deserialize failed.")
+	}
+	if err := this.Successor.Deserialize(r); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgradeInfo], Successor Deserialize failed.")
+	}
+	n, err := serialization.ReadUint32(r)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgradeInfo], History Deserialize failed.")
+	}
+	this.History = nil
+	for i := uint32(0); i < n; i++ {
+		var upgrade ContractUpgrade
+		if err := upgrade.Deserialize(r); err != nil {
+			return err
+		}
+		this.History = append(this.History, upgrade)
+	}
+	return nil
+}
+
+func (this *ContractUpgradeInfo) ToArray() []byte {
+	b := new(bytes.Buffer)
+	this.Serialize(b)
+	return b.Bytes()
+}
+
+// Predecessors return the addresses the contract is upgraded from, the most recent first
+func (this *ContractUpgradeInfo) Predecessors() []common.Address {
+	addrs := make([]common.Address, 0, len(this.History))
+	for i := len(this.History) - 1; i >= 0; i-- {
+		addrs = append(addrs, this.History[i].From)
+	}
+	return addrs
+}
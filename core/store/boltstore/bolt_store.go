@@ -0,0 +1,323 @@
+//go:build boltdb
+// +build boltdb
+
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package boltstore provides a PersistStore backed by BoltDB, enabled with build tag boltdb
+package boltstore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dnaproject2/DNA/core/store/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	bolt "go.etcd.io/bbolt"
+)
+
+//BACKEND_NAME is the registered name of BoltDB store backend
+const BACKEND_NAME = "boltdb"
+
+//DB_FILE is the BoltDB file name in store directory
+const DB_FILE = "bolt.db"
+
+//INITIAL_MMAP_SIZE is the initial mmap size of the BoltDB file
+const INITIAL_MMAP_SIZE = 1 << 30
+
+var bucketName = []byte("dna")
+
+func init() {
+	common.RegisterBackend(&common.StoreBackend{
+		Name: BACKEND_NAME,
+		Open: func(path string) (common.PersistStore, error) {
+			return NewBoltStore(path)
+		},
+	})
+}
+
+//BoltStore keep all the key-value pairs in a single bucket of BoltDB
+type BoltStore struct {
+	db    *bolt.DB
+	batch *leveldb.Batch
+}
+
+//NewBoltStore open the BoltDB in directory path
+func NewBoltStore(path string) (*BoltStore, error) {
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return nil, err
+	}
+	// a large initial mmap keeps writers from blocking on a remap while an
+	// iterator still holds its read transaction
+	db, err := bolt.Open(filepath.Join(path, DB_FILE), 0600, &bolt.Options{
+		Timeout:         time.Second,
+		InitialMmapSize: INITIAL_MMAP_SIZE,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+//Put the key-value pair to store
+func (self *BoltStore) Put(key []byte, value []byte) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Put(key, value)
+	})
+}
+
+//Get the value of a key, return common.ErrNotFound if the key doesn't exist
+func (self *BoltStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := self.db.View(func(tx *bolt.Tx) error {
+		k, v := tx.Bucket(bucketName).Cursor().Seek(key)
+		if k == nil || !bytes.Equal(k, key) {
+			return common.ErrNotFound
+		}
+		// value is only valid in transaction
+		value = append([]byte{}, v...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+//Has return whether the key is exist in store
+func (self *BoltStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == common.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//Delete the key in store
+func (self *BoltStore) Delete(key []byte) error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).Delete(key)
+	})
+}
+
+//NewBatch start commit batch
+func (self *BoltStore) NewBatch() {
+	self.batch = new(leveldb.Batch)
+}
+
+//BatchPut put a key-value pair to batch
+func (self *BoltStore) BatchPut(key []byte, value []byte) {
+	self.batch.Put(key, value)
+}
+
+//BatchDelete delete a key in batch
+func (self *BoltStore) BatchDelete(key []byte) {
+	self.batch.Delete(key)
+}
+
+//BatchCommit apply the batch in one BoltDB transaction
+func (self *BoltStore) BatchCommit() error {
+	err := self.db.Update(func(tx *bolt.Tx) error {
+		replay := &batchReplay{bucket: tx.Bucket(bucketName)}
+		if err := self.batch.Replay(replay); err != nil {
+			return err
+		}
+		return replay.err
+	})
+	if err != nil {
+		return err
+	}
+	self.batch = nil
+	return nil
+}
+
+type batchReplay struct {
+	bucket *bolt.Bucket
+	err    error
+}
+
+func (self *batchReplay) Put(key, value []byte) {
+	if self.err == nil {
+		self.err = self.bucket.Put(key, value)
+	}
+}
+
+func (self *batchReplay) Delete(key []byte) {
+	if self.err == nil {
+		self.err = self.bucket.Delete(key)
+	}
+}
+
+//Close BoltDB
+func (self *BoltStore) Close() error {
+	return self.db.Close()
+}
+
+//NewIterator return an iterator of the key-value pairs with the key prefix. The iterator works on a snapshot
+//of store, later writes are invisible to it. It holds a BoltDB read transaction, release it as soon as possible.
+func (self *BoltStore) NewIterator(prefix []byte) common.StoreIterator {
+	return self.newIterator(prefix, prefixLimit(prefix))
+}
+
+//NewRangeIterator return an iterator of the key-value pairs with the keys in [start, limit)
+func (self *BoltStore) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return self.newIterator(start, limit)
+}
+
+func (self *BoltStore) newIterator(start, limit []byte) common.StoreIterator {
+	tx, err := self.db.Begin(false)
+	if err != nil {
+		return &iterator{err: err, pos: afterLast}
+	}
+	return &iterator{tx: tx, cursor: tx.Bucket(bucketName).Cursor(), start: start, limit: limit, pos: beforeFirst}
+}
+
+//prefixLimit return the least key greater than all the keys with the prefix, nil if there is no such key
+func prefixLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			limit := append([]byte{}, prefix[:i+1]...)
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
+
+const (
+	beforeFirst = iota
+	atItem
+	afterLast
+)
+
+//iterator walk through the keys in [start, limit) with a cursor of a read transaction
+type iterator struct {
+	tx         *bolt.Tx
+	cursor     *bolt.Cursor
+	start      []byte
+	limit      []byte
+	key, value []byte
+	pos        int
+	err        error
+}
+
+func (self *iterator) set(k, v []byte, outside int) bool {
+	if k == nil || bytes.Compare(k, self.start) < 0 || (self.limit != nil && bytes.Compare(k, self.limit) >= 0) {
+		self.key, self.value, self.pos = nil, nil, outside
+		return false
+	}
+	self.key, self.value, self.pos = k, v, atItem
+	return true
+}
+
+//Next move to the next item, return false if no more item
+func (self *iterator) Next() bool {
+	switch self.pos {
+	case beforeFirst:
+		return self.First()
+	case atItem:
+		k, v := self.cursor.Next()
+		return self.set(k, v, afterLast)
+	}
+	return false
+}
+
+//Prev move to the previous item, return false if no more item
+func (self *iterator) Prev() bool {
+	switch self.pos {
+	case afterLast:
+		return self.Last()
+	case atItem:
+		k, v := self.cursor.Prev()
+		return self.set(k, v, beforeFirst)
+	}
+	return false
+}
+
+//First move to the first item, return false if iterator is empty
+func (self *iterator) First() bool {
+	return self.Seek(self.start)
+}
+
+//Last move to the last item, return false if iterator is empty
+func (self *iterator) Last() bool {
+	if self.cursor == nil {
+		return false
+	}
+	var k, v []byte
+	if self.limit == nil {
+		k, v = self.cursor.Last()
+	} else if k, _ = self.cursor.Seek(self.limit); k == nil {
+		k, v = self.cursor.Last()
+	} else {
+		k, v = self.cursor.Prev()
+	}
+	return self.set(k, v, beforeFirst)
+}
+
+//Seek move to the first item whose key is greater than or equal to key
+func (self *iterator) Seek(key []byte) bool {
+	if self.cursor == nil {
+		return false
+	}
+	if bytes.Compare(key, self.start) < 0 {
+		key = self.start
+	}
+	var k, v []byte
+	if len(key) == 0 {
+		k, v = self.cursor.First()
+	} else {
+		k, v = self.cursor.Seek(key)
+	}
+	return self.set(k, v, afterLast)
+}
+
+//Key return the key of current item, nil if iterator is not positioned at an item
+func (self *iterator) Key() []byte {
+	return self.key
+}
+
+//Value return the value of current item, nil if iterator is not positioned at an item
+func (self *iterator) Value() []byte {
+	return self.value
+}
+
+//Release the read transaction of iterator
+func (self *iterator) Release() {
+	if self.tx != nil {
+		self.tx.Rollback()
+	}
+	self.tx, self.cursor = nil, nil
+	self.key, self.value, self.pos = nil, nil, afterLast
+}
+
+//Error return the error of opening iterator
+func (self *iterator) Error() error {
+	return self.err
+}
@@ -0,0 +1,54 @@
+//go:build boltdb
+// +build boltdb
+
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/store/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStore(t *testing.T) {
+	assert.Contains(t, common.Backends(), BACKEND_NAME)
+	storetest.TestPersistStore(t, func(t *testing.T) common.PersistStore {
+		dir, err := ioutil.TempDir("", "boltstore")
+		assert.Nil(t, err)
+		store, err := common.NewPersistStore(BACKEND_NAME, dir)
+		assert.Nil(t, err)
+		return &removeOnClose{store, dir}
+	})
+}
+
+type removeOnClose struct {
+	common.PersistStore
+	dir string
+}
+
+func (self *removeOnClose) Close() error {
+	err := self.PersistStore.Close()
+	os.RemoveAll(self.dir)
+	return err
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_STORE_BACKEND = "leveldb"       //DEFAULT_STORE_BACKEND is used when no backend is configured
+	STORE_BACKEND_FILE    = "store_backend" //Record the store backend of data directory
+)
+
+//StoreBackend is a key-value engine which can serve PersistStore
+type StoreBackend struct {
+	Name     string
+	Volatile bool                                    //Whether data is lost after the store is closed
+	Open     func(path string) (PersistStore, error) //Open the store at path
+}
+
+var (
+	backendLock sync.RWMutex
+	backends    = make(map[string]*StoreBackend)
+)
+
+//RegisterBackend make a store backend available by its name. It panics if the name is registered twice.
+func RegisterBackend(backend *StoreBackend) {
+	backendLock.Lock()
+	defer backendLock.Unlock()
+	if backend == nil || backend.Open == nil {
+		panic("store: register nil backend")
+	}
+	if _, ok := backends[backend.Name]; ok {
+		panic("store: register backend twice " + backend.Name)
+	}
+	backends[backend.Name] = backend
+}
+
+//GetBackend return the registered store backend by name, the default backend is returned when name is empty
+func GetBackend(name string) (*StoreBackend, error) {
+	if name == "" {
+		name = DEFAULT_STORE_BACKEND
+	}
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %s, available: %s", name, strings.Join(backendNames(), ","))
+	}
+	return backend, nil
+}
+
+//Backends return the names of all registered store backends
+func Backends() []string {
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	return backendNames()
+}
+
+func backendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//NewPersistStore open the persist store at path with the named backend
+func NewPersistStore(name, path string) (PersistStore, error) {
+	backend, err := GetBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	return backend.Open(path)
+}
+
+//SelectBackend return the store backend of data directory. The backend of an existing data directory is recorded in
+//it and can not be changed, a configured name which differs from the record is an error. A directory created before the record, which is told by
+//legacy, defaults to leveldb. The record is not saved for volatile backends.
+func SelectBackend(dataDir, name string, legacy bool) (*StoreBackend, error) {
+	recordPath := filepath.Join(dataDir, STORE_BACKEND_FILE)
+	recorded := ""
+	data, err := ioutil.ReadFile(recordPath)
+	if err == nil {
+		recorded = strings.TrimSpace(string(data))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read store backend record error %s", err)
+	} else if legacy {
+		recorded = DEFAULT_STORE_BACKEND
+	}
+
+	if name == "" {
+		name = recorded
+	}
+	if recorded != "" && name != recorded {
+		return nil, fmt.Errorf("data directory %s is created by store backend %s, can not be opened by %s", dataDir, recorded, name)
+	}
+	backend, err := GetBackend(name)
+	if err != nil {
+		return nil, err
+	}
+	if recorded == "" && !backend.Volatile {
+		err = os.MkdirAll(dataDir, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("create data directory error %s", err)
+		}
+		err = ioutil.WriteFile(recordPath, []byte(backend.Name), 0644)
+		if err != nil {
+			return nil, fmt.Errorf("save store backend record error %s", err)
+		}
+	}
+	return backend, nil
+}
@@ -27,6 +27,9 @@ const (
 	DATA_HEADER                            = 0x01 //Block hash => block hash key prefix
 	DATA_TRANSACTION                       = 0x02 //Transction hash = > transaction key prefix
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
+	DATA_STATE_UNDO                        = 0x22 // block height => state values before the block, used by rollback
+	DATA_STATE_HISTORY                     = 0x23 // state key + block height => state value after the block, used by archive mode
+	DATA_STATE_DELETED                     = 0x27 // state key => block height it is last deleted, used to iterate the past states in archive mode
 
 	// Transaction
 	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
@@ -34,6 +37,7 @@ const (
 	ST_STORAGE    DataEntryPrefix = 0x05 //Smart contract storage key prefix
 	ST_VALIDATOR  DataEntryPrefix = 0x07 //no use
 	ST_VOTE       DataEntryPrefix = 0x08 //Vote state key prefix
+	ST_UPGRADE    DataEntryPrefix = 0x25 //Contract address => contract upgrade history
 
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
 
@@ -43,6 +47,8 @@ const (
 	SYS_CURRENT_STATE_ROOT DataEntryPrefix = 0x12 //no use
 	SYS_BLOCK_MERKLE_TREE  DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE  DataEntryPrefix = 0x20 // state merkle tree root key prefix
+	SYS_ARCHIVE_INFO       DataEntryPrefix = 0x24 // first and last block height of archived storage history, and the first block height of all the states
+	SYS_PRUNED_HEIGHT      DataEntryPrefix = 0x26 // block height below which the state history and undo data are pruned
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
 )
@@ -26,32 +26,34 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+var ErrNotArchived = errors.New("state not archived")
 
 //Store iterator for iterate store
 type StoreIterator interface {
-	Next() bool //Next item. If item available return true, otherwise return false
-	//Prev() bool           //previous item. If item available return true, otherwise return false
-	First() bool //First item. If item available return true, otherwise return false
-	//Last() bool           //Last item. If item available return true, otherwise return false
-	//Seek(key []byte) bool //Seek key. If item available return true, otherwise return false
-	Key() []byte   //Return the current item key
-	Value() []byte //Return the current item value
-	Release()      //Close iterator
-	Error() error  // Error returns any accumulated error.
+	Next() bool           //Next item. If item available return true, otherwise return false
+	Prev() bool           //previous item. If item available return true, otherwise return false
+	First() bool          //First item. If item available return true, otherwise return false
+	Last() bool           //Last item. If item available return true, otherwise return false
+	Seek(key []byte) bool //Seek the first item whose key is greater than or equal to key. If item available return true, otherwise return false
+	Key() []byte          //Return the current item key
+	Value() []byte        //Return the current item value
+	Release()             //Close iterator
+	Error() error         // Error returns any accumulated error.
 }
 
 //PersistStore of ledger
 type PersistStore interface {
-	Put(key []byte, value []byte) error      //Put the key-value pair to store
-	Get(key []byte) ([]byte, error)          //Get the value if key in store
-	Has(key []byte) (bool, error)            //Whether the key is exist in store
-	Delete(key []byte) error                 //Delete the key in store
-	NewBatch()                               //Start commit batch
-	BatchPut(key []byte, value []byte)       //Put a key-value pair to batch
-	BatchDelete(key []byte)                  //Delete the key in batch
-	BatchCommit() error                      //Commit batch to store
-	Close() error                            //Close store
-	NewIterator(prefix []byte) StoreIterator //Return the iterator of store
+	Put(key []byte, value []byte) error                 //Put the key-value pair to store
+	Get(key []byte) ([]byte, error)                     //Get the value if key in store
+	Has(key []byte) (bool, error)                       //Whether the key is exist in store
+	Delete(key []byte) error                            //Delete the key in store
+	NewBatch()                                          //Start commit batch
+	BatchPut(key []byte, value []byte)                  //Put a key-value pair to batch
+	BatchDelete(key []byte)                             //Delete the key in batch
+	BatchCommit() error                                 //Commit batch to store
+	Close() error                                       //Close store
+	NewIterator(prefix []byte) StoreIterator            //Return the iterator of store
+	NewRangeIterator(start, limit []byte) StoreIterator //Return the iterator of keys in [start, limit), nil limit means no upper bound
 }
 
 //StateStore save result of smart contract execution, before commit to store
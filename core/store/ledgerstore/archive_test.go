@@ -0,0 +1,173 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/states"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageAtHeight(t *testing.T) {
+	enableArchive := config.DefConfig.Common.EnableArchive
+	defer func() { config.DefConfig.Common.EnableArchive = enableArchive }()
+	config.DefConfig.Common.EnableArchive = false
+
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("a")})
+	_, err := ledger.GetStorageItemAtHeight(testStorageKey("k0"), 1)
+	assert.Equal(t, scom.ErrNotArchived, err)
+
+	// archive from block 2, the values before it are kept at block 1
+	config.DefConfig.Common.EnableArchive = true
+	submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("v1")})
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("b"), "k1": nil})
+	submitTestBlock(t, ledger, map[string][]byte{"k2": []byte("c")})
+	start, last, err := ledger.stateStore.GetArchiveInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), start)
+	assert.Equal(t, uint32(4), last)
+
+	getAt := func(key string, height uint32) ([]byte, error) {
+		item, err := ledger.GetStorageItemAtHeight(testStorageKey(key), height)
+		if err != nil {
+			return nil, err
+		}
+		return item.Value, nil
+	}
+	cases := []struct {
+		key    string
+		height uint32
+		value  string
+		err    error
+	}{
+		{"k0", 0, "", scom.ErrNotArchived},
+		{"k0", 1, "a", nil},
+		{"k1", 1, "", scom.ErrNotFound},
+		{"k0", 2, "a", nil},
+		{"k1", 2, "v1", nil},
+		{"k0", 3, "b", nil},
+		{"k1", 3, "", scom.ErrNotFound}, // deleted
+		{"k2", 3, "", scom.ErrNotFound},
+		{"k2", 4, "c", nil},
+		{"k9", 4, "", scom.ErrNotFound}, // never written
+		{"k0", 5, "", scom.ErrNotArchived},
+	}
+	for _, c := range cases {
+		value, err := getAt(c.key, c.height)
+		assert.Equal(t, c.err, err, "%s at %d", c.key, c.height)
+		if c.err == nil {
+			assert.Equal(t, []byte(c.value), value, "%s at %d", c.key, c.height)
+		}
+	}
+
+	// the history of reverted blocks is dropped along with them
+	assert.Nil(t, ledger.RollbackToHeight(3))
+	_, err = getAt("k2", 4)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	value, err := getAt("k0", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("b"), value)
+	submitTestBlock(t, ledger, map[string][]byte{"k2": []byte("d")})
+	value, err = getAt("k2", 4)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("d"), value)
+}
+
+func TestHistoryStoreIterator(t *testing.T) {
+	enableArchive := config.DefConfig.Common.EnableArchive
+	defer func() { config.DefConfig.Common.EnableArchive = enableArchive }()
+	config.DefConfig.Common.EnableArchive = true
+
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("a"), "k2": []byte("b")})
+	submitTestBlock(t, ledger, map[string][]byte{"k1": nil, "k3": []byte("c")})
+	submitTestBlock(t, ledger, map[string][]byte{"k2": nil, "k1": []byte("d")})
+
+	prefix, err := ledger.stateStore.getStorageKey(testStorageKey(""))
+	assert.Nil(t, err)
+	keysAt := func(height uint32) []string {
+		history, err := ledger.stateStore.newHistoryStore(height)
+		assert.Nil(t, err)
+		iter := history.NewIterator(prefix)
+		defer iter.Release()
+		keys := make([]string, 0)
+		for iter.Next() {
+			item := new(states.StorageItem)
+			assert.Nil(t, item.Deserialize(bytes.NewReader(iter.Value())))
+			keys = append(keys, string(iter.Key()[len(prefix):])+"="+string(item.Value))
+		}
+		assert.Nil(t, iter.Error())
+		return keys
+	}
+	assert.Equal(t, []string{}, keysAt(0))
+	assert.Equal(t, []string{"k1=a", "k2=b"}, keysAt(1))
+	assert.Equal(t, []string{"k2=b", "k3=c"}, keysAt(2))
+	assert.Equal(t, []string{"k1=d", "k3=c"}, keysAt(3))
+
+	// the deleted keys are pruned along with the history
+	assert.Nil(t, ledger.PruneStates(2))
+	assert.Equal(t, []string{"k2=b", "k3=c"}, keysAt(2))
+	for key, kept := range map[string]bool{"k1": false, "k2": true} {
+		storeKey, err := ledger.stateStore.getStorageKey(testStorageKey(key))
+		assert.Nil(t, err)
+		_, err = ledger.stateStore.store.Get(ledger.stateStore.genStateDeletedKey(storeKey))
+		assert.Equal(t, kept, err == nil, key)
+	}
+	_, err = ledger.stateStore.newHistoryStore(1)
+	assert.Equal(t, scom.ErrNotArchived, err)
+}
+
+func TestHistoryStoreOfStorageArchive(t *testing.T) {
+	enableArchive := config.DefConfig.Common.EnableArchive
+	defer func() { config.DefConfig.Common.EnableArchive = enableArchive }()
+	config.DefConfig.Common.EnableArchive = true
+
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, nil)
+
+	// an archive made when only storage is archived can not serve the other states before it is upgraded
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(0)
+	sink.WriteUint32(1)
+	assert.Nil(t, ledger.stateStore.store.Put(ledger.stateStore.genArchiveInfoKey(), sink.Bytes()))
+	_, err := ledger.stateStore.newHistoryStore(0)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	_, err = ledger.stateStore.newHistoryStore(1)
+	assert.Nil(t, err)
+
+	submitTestBlock(t, ledger, nil)
+	submitTestBlock(t, ledger, nil)
+	info, err := ledger.stateStore.getArchiveInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, archiveInfo{start: 0, last: 3, stateStart: 2}, *info)
+	_, err = ledger.stateStore.newHistoryStore(1)
+	assert.Nil(t, err)
+	_, err = ledger.stateStore.newHistoryStore(0)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	_, err = ledger.GetStorageItemAtHeight(testStorageKey("k0"), 0)
+	assert.Equal(t, scom.ErrNotFound, err)
+}
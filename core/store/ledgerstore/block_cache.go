@@ -98,6 +98,16 @@ func (this *BlockCache) GetTransaction(txHash common.Uint256) (*types.Transactio
 	return txValue.Tx, txValue.Height
 }
 
+//RemoveBlock remove block from cache
+func (this *BlockCache) RemoveBlock(blockHash common.Uint256) {
+	this.blockCache.Remove(string(blockHash.ToArray()))
+}
+
+//RemoveTransaction remove transaction from cache
+func (this *BlockCache) RemoveTransaction(txHash common.Uint256) {
+	this.transactionCache.Remove(string(txHash.ToArray()))
+}
+
 //ContainTransaction return whether transaction is in cache
 func (this *BlockCache) ContainTransaction(txHash common.Uint256) bool {
 	return this.transactionCache.Contains(string(txHash.ToArray()))
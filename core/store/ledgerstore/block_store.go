@@ -26,21 +26,20 @@ import (
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/serialization"
 	scom "github.com/dnaproject2/DNA/core/store/common"
-	"github.com/dnaproject2/DNA/core/store/leveldbstore"
 	"github.com/dnaproject2/DNA/core/types"
 	"io"
 )
 
 //Block store save the data of block & transaction
 type BlockStore struct {
-	enableCache bool                       //Is enable lru cache
-	dbDir       string                     //The path of store file
-	cache       *BlockCache                //The cache of block, if have.
-	store       *leveldbstore.LevelDBStore //block store handler
+	enableCache bool              //Is enable lru cache
+	dbDir       string            //The path of store file
+	cache       *BlockCache       //The cache of block, if have.
+	store       scom.PersistStore //block store handler
 }
 
 //NewBlockStore return the block store instance
-func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
+func NewBlockStore(dbDir string, enableCache bool, backend string) (*BlockStore, error) {
 	var cache *BlockCache
 	var err error
 	if enableCache {
@@ -50,7 +49,7 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		}
 	}
 
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	store, err := scom.NewPersistStore(backend, dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -367,6 +366,35 @@ func (this *BlockStore) SaveBlockHash(height uint32, blockHash common.Uint256) {
 	this.store.BatchPut(key, blockHash.ToArray())
 }
 
+//RemoveBlock delete the block of height with its header, transactions and height index in the batch
+func (this *BlockStore) RemoveBlock(height uint32) error {
+	blockHash, err := this.GetBlockHash(height)
+	if err != nil {
+		return fmt.Errorf("GetBlockHash height %d error %s", height, err)
+	}
+	_, txHashes, err := this.loadHeaderWithTx(blockHash)
+	if err != nil {
+		return fmt.Errorf("loadHeaderWithTx height %d error %s", height, err)
+	}
+	for _, txHash := range txHashes {
+		if this.enableCache {
+			this.cache.RemoveTransaction(txHash)
+		}
+		this.store.BatchDelete(this.getTransactionKey(txHash))
+	}
+	if this.enableCache {
+		this.cache.RemoveBlock(blockHash)
+	}
+	this.store.BatchDelete(this.getHeaderKey(blockHash))
+	this.store.BatchDelete(this.getBlockHashKey(height))
+	return nil
+}
+
+//RemoveHeaderIndexList delete the header index list start from startIndex in the batch
+func (this *BlockStore) RemoveHeaderIndexList(startIndex uint32) {
+	this.store.BatchDelete(this.getHeaderIndexListKey(startIndex))
+}
+
 //SaveTransaction persist transaction to store
 func (this *BlockStore) SaveTransaction(tx *types.Transaction, height uint32) error {
 	if this.enableCache {
@@ -27,19 +27,18 @@ import (
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/common/serialization"
 	scom "github.com/dnaproject2/DNA/core/store/common"
-	"github.com/dnaproject2/DNA/core/store/leveldbstore"
 	"github.com/dnaproject2/DNA/smartcontract/event"
 )
 
 //Saving event notifies gen by smart contract execution
 type EventStore struct {
-	dbDir string                     //Store path
-	store *leveldbstore.LevelDBStore //Store handler
+	dbDir string            //Store path
+	store scom.PersistStore //Store handler
 }
 
 //NewEventStore return event store instance
-func NewEventStore(dbDir string) (*EventStore, error) {
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+func NewEventStore(dbDir string, backend string) (*EventStore, error) {
+	store, err := scom.NewPersistStore(backend, dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +87,19 @@ func (this *EventStore) SaveEventNotifyByBlock(height uint32, txHashs []common.U
 	return nil
 }
 
+//RemoveEventNotifyByBlock delete the event notifies of block height and its transactions in the batch
+func (this *EventStore) RemoveEventNotifyByBlock(height uint32, txHashs []common.Uint256) error {
+	key, err := this.getEventNotifyByBlockKey(height)
+	if err != nil {
+		return err
+	}
+	this.store.BatchDelete(key)
+	for _, txHash := range txHashs {
+		this.store.BatchDelete(this.getEventNotifyByTxKey(txHash))
+	}
+	return nil
+}
+
 //GetEventNotifyByTx return event notify by trasanction hash
 func (this *EventStore) GetEventNotifyByTx(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	key := this.getEventNotifyByTxKey(txHash)
@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"errors"
+
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+)
+
+var errReadOnlyStore = errors.New("state history store is read only")
+
+//historyStore is a read only view of the state store after a past block height. The states are read from
+//the state history, and the keys deleted since the height are iterated along with the latest keys.
+type historyStore struct {
+	scom.PersistStore
+	state  *StateStore
+	height uint32
+}
+
+//newHistoryStore return the state view after block height, which should be archived with all the states
+func (self *StateStore) newHistoryStore(height uint32) (*historyStore, error) {
+	info, err := self.getArchiveInfo()
+	if err == scom.ErrNotFound {
+		return nil, scom.ErrNotArchived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = self.checkArchived(height, info.stateStart, info.last); err != nil {
+		return nil, err
+	}
+	return &historyStore{PersistStore: self.store, state: self, height: height}, nil
+}
+
+func (self *historyStore) Get(key []byte) ([]byte, error) {
+	if !isStateKey(key) {
+		return self.PersistStore.Get(key)
+	}
+	value, archived, err := self.state.getStateHistory(key, self.height)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		// never changed since archived
+		return self.PersistStore.Get(key)
+	}
+	if len(value) == 0 {
+		return nil, scom.ErrNotFound
+	}
+	return value, nil
+}
+
+func (self *historyStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (self *historyStore) Put(key []byte, value []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *historyStore) Delete(key []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *historyStore) NewBatch() {}
+
+func (self *historyStore) BatchPut(key []byte, value []byte) {}
+
+func (self *historyStore) BatchDelete(key []byte) {}
+
+func (self *historyStore) BatchCommit() error {
+	return errReadOnlyStore
+}
+
+//NewIterator iterates the latest keys and the keys deleted after the height, with their values at the height.
+//Keys which do not exist at the height are skipped.
+func (self *historyStore) NewIterator(prefix []byte) scom.StoreIterator {
+	deleted := self.PersistStore.NewIterator(self.state.genStateDeletedKey(prefix))
+	return self.newIter(self.PersistStore.NewIterator(prefix), deleted)
+}
+
+func (self *historyStore) NewRangeIterator(start, limit []byte) scom.StoreIterator {
+	deletedLimit := []byte{byte(scom.DATA_STATE_DELETED) + 1}
+	if limit != nil {
+		deletedLimit = self.state.genStateDeletedKey(limit)
+	}
+	deleted := self.PersistStore.NewRangeIterator(self.state.genStateDeletedKey(start), deletedLimit)
+	return self.newIter(self.PersistStore.NewRangeIterator(start, limit), deleted)
+}
+
+func (self *historyStore) newIter(latest, deleted scom.StoreIterator) scom.StoreIterator {
+	iter := overlaydb.NewJoinIter(&deletedIter{StoreIterator: deleted, state: self.state}, latest)
+	return &historyIter{iter: iter, store: self}
+}
+
+//deletedIter iterates the records of deleted keys as the keys themselves
+type deletedIter struct {
+	scom.StoreIterator
+	state *StateStore
+}
+
+func (self *deletedIter) Key() []byte {
+	key := self.StoreIterator.Key()
+	if len(key) == 0 {
+		return nil
+	}
+	return key[1:]
+}
+
+func (self *deletedIter) Seek(key []byte) bool {
+	return self.StoreIterator.Seek(self.state.genStateDeletedKey(key))
+}
+
+type historyIter struct {
+	iter  scom.StoreIterator
+	store *historyStore
+	value []byte
+	err   error
+}
+
+//load the value of current key at the height, return false if the key does not exist then
+func (self *historyIter) load() bool {
+	value, err := self.store.Get(self.iter.Key())
+	if err == scom.ErrNotFound {
+		return false
+	}
+	self.value, self.err = value, err
+	return true
+}
+
+func (self *historyIter) skip(ok bool, step func() bool) bool {
+	for ; ok; ok = step() {
+		if self.load() {
+			return self.err == nil
+		}
+	}
+	return false
+}
+
+func (self *historyIter) Next() bool {
+	return self.skip(self.iter.Next(), self.iter.Next)
+}
+
+func (self *historyIter) Prev() bool {
+	return self.skip(self.iter.Prev(), self.iter.Prev)
+}
+
+func (self *historyIter) First() bool {
+	return self.skip(self.iter.First(), self.iter.Next)
+}
+
+func (self *historyIter) Last() bool {
+	return self.skip(self.iter.Last(), self.iter.Prev)
+}
+
+func (self *historyIter) Seek(key []byte) bool {
+	return self.skip(self.iter.Seek(key), self.iter.Next)
+}
+
+func (self *historyIter) Key() []byte {
+	return self.iter.Key()
+}
+
+func (self *historyIter) Value() []byte {
+	return self.value
+}
+
+func (self *historyIter) Release() {
+	self.iter.Release()
+}
+
+func (self *historyIter) Error() error {
+	if self.err != nil {
+		return self.err
+	}
+	return self.iter.Error()
+}
@@ -35,12 +35,14 @@ import (
 	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/consensus/poa/turn"
 	"github.com/dnaproject2/DNA/consensus/vbft/config"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/signature"
 	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/core/store"
 	scom "github.com/dnaproject2/DNA/core/store/common"
+	_ "github.com/dnaproject2/DNA/core/store/memstore"
 	"github.com/dnaproject2/DNA/core/store/overlaydb"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
@@ -52,6 +54,7 @@ import (
 	"github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
 	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
+	"github.com/dnaproject2/DNA/smartcontract/service/wasmvm"
 	sstate "github.com/dnaproject2/DNA/smartcontract/states"
 	"github.com/dnaproject2/DNA/smartcontract/storage"
 	"github.com/ontio/ontology-crypto/keypair"
@@ -60,6 +63,7 @@ import (
 const (
 	SYSTEM_VERSION          = byte(1)      //Version of ledger store
 	HEADER_INDEX_BATCH_SIZE = uint32(2000) //Bath size of saving header index
+	MAX_TRACE_DEPTH         = uint32(100)  //Max blocks below current block a transaction can be traced
 )
 
 var (
@@ -98,7 +102,12 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 		stateHashCheckHeight: stateHashHeight,
 	}
 
-	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true)
+	backend, err := selectStoreBackend(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	blockStore, err := NewBlockStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock), true, backend.Name)
 	if err != nil {
 		return nil, fmt.Errorf("NewBlockStore error %s", err)
 	}
@@ -106,13 +115,13 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 
 	dbPath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirState)
 	merklePath := fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), MerkleTreeStorePath)
-	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight)
+	stateStore, err := NewStateStore(dbPath, merklePath, stateHashHeight, backend.Name)
 	if err != nil {
 		return nil, fmt.Errorf("NewStateStore error %s", err)
 	}
 	ledgerStore.stateStore = stateStore
 
-	eventState, err := NewEventStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent))
+	eventState, err := NewEventStore(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirEvent), backend.Name)
 	if err != nil {
 		return nil, fmt.Errorf("NewEventStore error %s", err)
 	}
@@ -121,6 +130,13 @@ func NewLedgerStore(dataDir string, stateHashHeight uint32) (*LedgerStoreImp, er
 	return ledgerStore, nil
 }
 
+//selectStoreBackend return the store backend of data directory, data directories created before the backend is
+//recorded default to leveldb.
+func selectStoreBackend(dataDir string) (*scom.StoreBackend, error) {
+	_, err := os.Stat(fmt.Sprintf("%s%s%s", dataDir, string(os.PathSeparator), DBDirBlock))
+	return scom.SelectBackend(dataDir, config.DefConfig.Common.StoreBackend, err == nil)
+}
+
 //InitLedgerStoreWithGenesisBlock init the ledger store with genesis block. It's the first operation after NewLedgerStore.
 func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.Block, defaultBookkeeper []keypair.PublicKey) error {
 	hasInit, err := this.hasAlreadyInitGenesisBlock()
@@ -178,10 +194,24 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 			return fmt.Errorf("init error %s", err)
 		}
 	}
-	//load vbft peerInfo
+	err = this.loadVbftPeerInfo()
+	if err != nil {
+		return err
+	}
+	err = this.checkArchive()
+	if err != nil {
+		return err
+	}
+	// check and fix imcompatible states
+	err = this.stateStore.CheckStorage()
+	return err
+}
+
+//loadVbftPeerInfo load the vbft peers of current block
+func (this *LedgerStoreImp) loadVbftPeerInfo() error {
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
 	if consensusType == "vbft" {
-		header, err := this.GetHeaderByHash(this.currBlockHash)
+		header, err := this.GetHeaderByHash(this.GetCurrentBlockHash())
 		if err != nil {
 			return err
 		}
@@ -215,9 +245,26 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		}
 		this.lock.Unlock()
 	}
-	// check and fix imcompatible states
-	err = this.stateStore.CheckStorage()
-	return err
+	return nil
+}
+
+//checkArchive make sure no block is missed in state history when archive mode is enabled
+func (this *LedgerStoreImp) checkArchive() error {
+	if !config.DefConfig.Common.EnableArchive {
+		return nil
+	}
+	_, last, err := this.stateStore.GetArchiveInfo()
+	if err == scom.ErrNotFound {
+		log.Infof("archive state history from block %d", this.GetCurrentBlockHeight()+1)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("GetArchiveInfo error %s", err)
+	}
+	if last < this.GetCurrentBlockHeight() {
+		return fmt.Errorf("blocks after %d are saved without archive mode, state history is incomplete, please resync the ledger", last)
+	}
+	return nil
 }
 
 func (this *LedgerStoreImp) hasAlreadyInitGenesisBlock() (bool, error) {
@@ -309,7 +356,7 @@ func (this *LedgerStoreImp) recoverStore() error {
 		if err != nil {
 			return fmt.Errorf("save to state store height:%d error:%s", i, err)
 		}
-		err = this.saveBlockToEventStore(block)
+		err = this.saveBlockToEventStore(block, result)
 		if err != nil {
 			return fmt.Errorf("save to event store height:%d error:%s", i, err)
 		}
@@ -470,6 +517,17 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 			return peerInfo, nil
 		}
 		return vbftPeerInfo, nil
+	} else if consensusType == config.CONSENSUS_TYPE_POA {
+		bookkeepers, err := config.DefConfig.GetBookkeepers()
+		if err != nil {
+			return vbftPeerInfo, err
+		}
+		err = turn.VerifyHeader(header, prevHeader, bookkeepers, uint32(config.DefConfig.Genesis.POA.GenBlockTime),
+			uint32(time.Now().Unix()))
+		if err != nil {
+			log.Errorf("verify poa header error:%s, height:%d", err, header.Height)
+			return vbftPeerInfo, err
+		}
 	} else {
 		address, err := types.AddressFromBookkeepers(header.Bookkeepers)
 		if err != nil {
@@ -574,6 +632,10 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, result store.Execute
 func (this *LedgerStoreImp) AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error {
 	currBlockHeight := this.GetCurrentBlockHeight()
 	blockHeight := block.Header.Height
+	if blockHeight <= currBlockHeight && blockHeight > 0 &&
+		strings.ToLower(config.DefConfig.Genesis.ConsensusType) == config.CONSENSUS_TYPE_POA {
+		return this.replaceCompetingBlock(block, stateMerkleRoot)
+	}
 	if blockHeight <= currBlockHeight {
 		return nil
 	}
@@ -595,6 +657,45 @@ func (this *LedgerStoreImp) AddBlock(block *types.Block, stateMerkleRoot common.
 	return nil
 }
 
+//replaceCompetingBlock replaces the saved block of the same height by a competing poa block
+//when the fork choice rule of turn.Prefer picks it, so that nodes converge on the same chain.
+//The ledger is rolled back to the fork point, the parent of both blocks, which must be within
+//the undo data retention window, and the competing block is saved on top of it.
+func (this *LedgerStoreImp) replaceCompetingBlock(block *types.Block, stateMerkleRoot common.Uint256) error {
+	blockHeight := block.Header.Height
+	current, err := this.GetHeaderByHeight(blockHeight)
+	if err != nil {
+		return fmt.Errorf("get header of height %d error %s", blockHeight, err)
+	}
+	if current.Hash() == block.Hash() || current.PrevBlockHash != block.Header.PrevBlockHash {
+		return nil
+	}
+	_, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
+	if err != nil {
+		return fmt.Errorf("verifyHeader error %s", err)
+	}
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return err
+	}
+	if !turn.Prefer(block.Header, current, bookkeepers) {
+		return nil
+	}
+	currentHash, blockHash := current.Hash(), block.Hash()
+	log.Infof("replace block %s by competing block %s at height %d, current block height %d",
+		currentHash.ToHexString(), blockHash.ToHexString(), blockHeight, this.GetCurrentBlockHeight())
+	err = this.RollbackToHeight(blockHeight - 1)
+	if err != nil {
+		return fmt.Errorf("RollbackToHeight error %s", err)
+	}
+	err = this.saveBlock(block, stateMerkleRoot)
+	if err != nil {
+		return fmt.Errorf("saveBlock error %s", err)
+	}
+	this.delHeaderCache(block.Hash())
+	return nil
+}
+
 func (this *LedgerStoreImp) saveBlockToBlockStore(block *types.Block) error {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
@@ -625,12 +726,41 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 			Tx:     &types.Transaction{},
 		}
 
-		err = refreshGlobalParam(config, storage.NewCacheDB(this.stateStore.NewOverlayDB()), this)
+		//proposals reaching the block height take effect before the transactions of the block,
+		//a failed execution changes nothing and is retried in the next block. Their notifies are
+		//saved with the block events under the block hash.
+		cache := storage.NewCacheDB(overlay)
+		notifies, e := executeProposals(config, cache, this)
+		if e != nil {
+			log.Errorf("executeBlock height:%d execute proposals error %s", block.Header.Height, e)
+		} else {
+			cache.Commit()
+			if len(notifies) > 0 {
+				result.Notify = append(result.Notify, &event.ExecuteNotify{
+					TxHash: block.Hash(),
+					State:  event.CONTRACT_STATE_SUCCESS,
+					Notify: notifies,
+				})
+			}
+		}
+
+		err = refreshGlobalParam(config, storage.NewCacheDB(overlay), this)
 		if err != nil {
 			return
 		}
 	}
 
+	for _, tx := range block.Transactions {
+		if err = tx.CheckVersion(block.Header.Height); err != nil {
+			return
+		}
+		if tx.IsExpired(block.Header.Height) {
+			hash := tx.Hash()
+			err = fmt.Errorf("transaction %s expired at height %d", hash.ToHexString(), tx.ValidUntilHeight)
+			return
+		}
+	}
+
 	cache := storage.NewCacheDB(overlay)
 	for _, tx := range block.Transactions {
 		cache.Reset()
@@ -701,6 +831,33 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 		SaveNotify(this.eventStore, notify.TxHash, notify)
 	}
 
+	var historyKeys [][]byte
+	if config.DefConfig.Common.EnableArchive {
+		keys, err := this.stateStore.SaveStateHistory(blockHeight, result.WriteSet)
+		if err != nil {
+			return fmt.Errorf("SaveStateHistory error %s", err)
+		}
+		historyKeys = keys
+	}
+
+	if blockHeight > 0 {
+		undoKeys := this.stateStore.getBlockSysKeys(blockHeight)
+		result.WriteSet.ForEach(func(key, val []byte) {
+			undoKeys = append(undoKeys, key)
+		})
+		undoKeys = append(undoKeys, historyKeys...)
+		err := this.stateStore.SaveUndoData(blockHeight, undoKeys)
+		if err != nil {
+			return fmt.Errorf("SaveUndoData error %s", err)
+		}
+		if keep := config.DefConfig.Common.UndoBlocks; keep > 0 && uint(blockHeight) > keep {
+			err = this.stateStore.DeleteUndoData(blockHeight - uint32(keep) + 1)
+			if err != nil {
+				return fmt.Errorf("DeleteUndoData error %s", err)
+			}
+		}
+	}
+
 	err := this.stateStore.AddStateMerkleTreeRoot(blockHeight, result.Hash)
 	if err != nil {
 		return fmt.Errorf("AddBlockMerkleTreeRoot error %s", err)
@@ -729,13 +886,12 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	return nil
 }
 
-func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) error {
+func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block, result store.ExecuteResult) error {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
-	txs := make([]common.Uint256, 0)
-	for _, tx := range block.Transactions {
-		txHash := tx.Hash()
-		txs = append(txs, txHash)
+	txs := make([]common.Uint256, 0, len(result.Notify))
+	for _, notify := range result.Notify {
+		txs = append(txs, notify.TxHash)
 	}
 	if len(txs) > 0 {
 		err := this.eventStore.SaveEventNotifyByBlock(block.Header.Height, txs)
@@ -793,7 +949,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, result store.Execute
 	if err != nil {
 		return fmt.Errorf("save to state store height:%d error:%s", blockHeight, err)
 	}
-	err = this.saveBlockToEventStore(block)
+	err = this.saveBlockToEventStore(block, result)
 	if err != nil {
 		return fmt.Errorf("save to event store height:%d error:%s", blockHeight, err)
 	}
@@ -858,7 +1014,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 		if err != nil {
 			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
-	case types.Invoke:
+	case types.Invoke, types.InvokeWasm:
 		err := this.stateStore.HandleInvokeTransaction(this, overlay, cache, tx, block, notify)
 		if overlay.Error() != nil {
 			return nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
@@ -871,6 +1027,118 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 	return notify, nil
 }
 
+//RollbackToHeight revert the ledger to block height, dropping all blocks, headers, states and events above it.
+//States are restored with the undo data saved along with every block, which is kept for the latest UndoBlocks blocks.
+func (this *LedgerStoreImp) RollbackToHeight(height uint32) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	currBlockHeight := this.GetCurrentBlockHeight()
+	if height >= currBlockHeight {
+		return fmt.Errorf("rollback height %d should be less than current block height %d", height, currBlockHeight)
+	}
+	blockHash := this.GetBlockHash(height)
+	if blockHash == common.UINT256_EMPTY {
+		return fmt.Errorf("cannot find block hash of height %d", height)
+	}
+	_, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	for h := height + 1; h <= stateHeight; h++ {
+		has, err := this.stateStore.HasUndoData(h)
+		if err != nil {
+			return fmt.Errorf("HasUndoData height:%d error %s", h, err)
+		}
+		if !has {
+			return fmt.Errorf("undo data of block %d not found, cannot rollback to height %d", h, height)
+		}
+	}
+
+	// revert states block by block, so that an interrupted rollback leaves a consistent state store
+	for h := stateHeight; h > height; h-- {
+		this.stateStore.NewBatch()
+		err = this.stateStore.RevertBlock(h)
+		if err != nil {
+			return fmt.Errorf("revert state of block %d error %s", h, err)
+		}
+		err = this.stateStore.CommitTo()
+		if err != nil {
+			return fmt.Errorf("stateStore.CommitTo height:%d error %s", h, err)
+		}
+	}
+	err = this.stateStore.ReloadMerkleTree(height)
+	if err != nil {
+		return fmt.Errorf("ReloadMerkleTree error %s", err)
+	}
+
+	this.eventStore.NewBatch()
+	this.blockStore.NewBatch()
+	for h := currBlockHeight; h > height; h-- {
+		block, err := this.GetBlockByHeight(h)
+		if err != nil {
+			return fmt.Errorf("GetBlockByHeight height:%d error %s", h, err)
+		}
+		//the block hash keys the notifies of the proposals executed in the block
+		txHashes := make([]common.Uint256, 0, len(block.Transactions)+1)
+		txHashes = append(txHashes, block.Hash())
+		for _, tx := range block.Transactions {
+			txHashes = append(txHashes, tx.Hash())
+		}
+		err = this.eventStore.RemoveEventNotifyByBlock(h, txHashes)
+		if err != nil {
+			return fmt.Errorf("RemoveEventNotifyByBlock height:%d error %s", h, err)
+		}
+		err = this.blockStore.RemoveBlock(h)
+		if err != nil {
+			return fmt.Errorf("RemoveBlock height:%d error %s", h, err)
+		}
+	}
+	err = this.eventStore.SaveCurrentBlock(height, blockHash)
+	if err != nil {
+		return fmt.Errorf("eventStore.SaveCurrentBlock error %s", err)
+	}
+	err = this.eventStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+
+	this.lock.Lock()
+	storedIndexCount := this.storedIndexCount
+	for storedIndexCount > height {
+		storedIndexCount -= HEADER_INDEX_BATCH_SIZE
+		this.blockStore.RemoveHeaderIndexList(storedIndexCount)
+	}
+	this.lock.Unlock()
+	err = this.blockStore.SaveCurrentBlock(height, blockHash)
+	if err != nil {
+		return fmt.Errorf("blockStore.SaveCurrentBlock error %s", err)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+
+	this.lock.Lock()
+	for h := range this.headerIndex {
+		if h > height {
+			delete(this.headerIndex, h)
+		}
+	}
+	this.headerCache = make(map[common.Uint256]*types.Header)
+	this.storedIndexCount = storedIndexCount
+	this.currBlockHeight = height
+	this.currBlockHash = blockHash
+	this.lock.Unlock()
+
+	err = this.loadVbftPeerInfo()
+	if err != nil {
+		return fmt.Errorf("loadVbftPeerInfo error %s", err)
+	}
+	log.Infof("ledger rollback from height %d to %d, current block hash %s", currBlockHeight, height, blockHash.ToHexString())
+	return nil
+}
+
 func (this *LedgerStoreImp) saveHeaderIndexList() error {
 	this.lock.RLock()
 	storeCount := this.storedIndexCount
@@ -994,11 +1262,21 @@ func (this *LedgerStoreImp) GetContractState(contractHash common.Address) (*payl
 	return this.stateStore.GetContractState(contractHash)
 }
 
+//GetContractUpgradeInfo return the upgrade history of contract address. Wrap function of StateStore.GetContractUpgradeInfo
+func (this *LedgerStoreImp) GetContractUpgradeInfo(contractHash common.Address) (*states.ContractUpgradeInfo, error) {
+	return this.stateStore.GetContractUpgradeInfo(contractHash)
+}
+
 //GetStorageItem return the storage value of the key in smart contract. Wrap function of StateStore.GetStorageState
 func (this *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.StorageItem, error) {
 	return this.stateStore.GetStorageState(key)
 }
 
+//GetStorageItemAtHeight return the storage value of the key after block height. Only available in archive mode.
+func (this *LedgerStoreImp) GetStorageItemAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	return this.stateStore.GetStorageStateAtHeight(key, height)
+}
+
 //GetEventNotifyByTx return the events notify gen by executing of smart contract.  Wrap function of EventStore.GetEventNotifyByTx
 func (this *LedgerStoreImp) GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error) {
 	return this.eventStore.GetEventNotifyByTx(tx)
@@ -1028,7 +1306,7 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		return stf, err
 	}
 
-	if tx.TxType == types.Invoke {
+	if tx.TxType == types.Invoke || tx.TxType == types.InvokeWasm {
 		invoke := tx.Payload.(*payload.InvokeCode)
 
 		sc := smartcontract.SmartContract{
@@ -1040,7 +1318,7 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		}
 
 		//start the smart contract executive function
-		engine, _ := sc.NewExecuteEngine(invoke.Code)
+		engine, _ := sc.NewTxExecuteEngine(tx.TxType, invoke.Code)
 		result, err := engine.Invoke()
 		if err != nil {
 			return stf, err
@@ -1050,9 +1328,14 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 		if gasCost < mixGas {
 			gasCost = mixGas
 		}
-		cv, err := scommon.ConvertNeoVmTypeHexString(result)
-		if err != nil {
-			return stf, err
+		var cv interface{}
+		if tx.TxType == types.InvokeWasm {
+			cv = common.ToHexString(result.([]byte))
+		} else {
+			cv, err = scommon.ConvertNeoVmTypeHexString(result)
+			if err != nil {
+				return stf, err
+			}
 		}
 		return &sstate.PreExecResult{State: event.CONTRACT_STATE_SUCCESS, Gas: gasCost, Result: cv, Notify: sc.Notifications}, nil
 	} else if tx.TxType == types.Deploy {
@@ -1063,6 +1346,231 @@ func (this *LedgerStoreImp) PreExecuteContract(tx *types.Transaction) (*sstate.P
 	}
 }
 
+//SimulateTransaction execute the transaction on the state after block height without commit to store, and return
+//its call tree, the gas of every opcode and the state changes including the fee charged to the payer. Heights
+//before the current one are only available in archive mode, since all the states are archived.
+func (this *LedgerStoreImp) SimulateTransaction(tx *types.Transaction, height uint32) (*sstate.SimulateResult, error) {
+	current := this.GetCurrentBlockHeight()
+	if height > current {
+		return nil, fmt.Errorf("height %d is higher than current block height %d", height, current)
+	}
+	var backend scom.PersistStore = this.stateStore.store
+	if height < current {
+		history, err := this.stateStore.newHistoryStore(height)
+		if err != nil {
+			return nil, err
+		}
+		backend = history
+	}
+	blockTime := uint32(time.Now().Unix())
+	if height < current {
+		header, err := this.GetHeaderByHeight(height + 1)
+		if err != nil {
+			return nil, err
+		}
+		blockTime = header.Timestamp
+	}
+	config := &smartcontract.Config{
+		Time:      blockTime,
+		Height:    height + 1,
+		Tx:        tx,
+		BlockHash: this.GetBlockHash(height),
+	}
+
+	overlay := overlaydb.NewOverlayDB(backend)
+	cache := storage.NewCacheDB(overlay)
+	preGas, err := this.getPreGas(config, cache)
+	if err != nil {
+		return nil, err
+	}
+	cache.Reset()
+
+	res := &sstate.SimulateResult{Height: height}
+	res.State = event.CONTRACT_STATE_FAIL
+	switch tx.TxType {
+	case types.Invoke, types.InvokeWasm:
+		invoke := tx.Payload.(*payload.InvokeCode)
+		res.CodeGas = calcGasByCodeLen(len(invoke.Code), preGas[neovm.UINT_INVOKE_CODE_LEN_NAME])
+		tracer := smartcontract.NewExecTracer(false)
+		sc := smartcontract.SmartContract{
+			Config:  config,
+			Store:   this,
+			CacheDB: cache,
+			Gas:     math.MaxUint64 - res.CodeGas,
+			PreExec: true,
+			Tracer:  tracer,
+		}
+		engine, _ := sc.NewTxExecuteEngine(tx.TxType, invoke.Code)
+		result, err := engine.Invoke()
+		tracer.End(sc.Gas, err)
+		res.CallTree = tracer.CallTree()
+		res.Steps, res.Truncated = tracer.Steps()
+		res.ExecGas = math.MaxUint64 - res.CodeGas - sc.Gas
+		res.Gas = res.CodeGas + res.ExecGas
+		if res.Gas < neovm.MIN_TRANSACTION_GAS {
+			res.Gas = neovm.MIN_TRANSACTION_GAS
+		}
+		res.Notify = sc.Notifications
+		if err != nil {
+			res.Error = err.Error()
+			return res, nil
+		}
+		if tx.TxType == types.InvokeWasm {
+			res.Result = common.ToHexString(result.([]byte))
+		} else {
+			res.Result, err = scommon.ConvertNeoVmTypeHexString(result)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case types.Deploy:
+		deploy := tx.Payload.(*payload.DeployCode)
+		res.CodeGas = calcGasByCodeLen(len(deploy.Code), preGas[neovm.UINT_DEPLOY_CODE_LEN_NAME])
+		res.ExecGas = preGas[neovm.CONTRACT_CREATE_NAME]
+		res.Gas = res.CodeGas + res.ExecGas
+		if err := wasmvm.VerifyDeployCode(deploy); err != nil {
+			res.Error = err.Error()
+			return res, nil
+		}
+		dep, err := cache.GetContract(deploy.Address())
+		if err != nil {
+			return nil, err
+		}
+		if dep == nil {
+			address := deploy.Address()
+			upgraded, err := cache.IsContractUpgraded(address)
+			if err != nil {
+				return nil, err
+			}
+			if upgraded {
+				res.Error = fmt.Sprintf("contract %s has been upgraded, can not be deployed again", address.ToHexString())
+				return res, nil
+			}
+			cache.PutContract(deploy)
+		}
+	default:
+		return nil, errors.NewErr("transaction type error")
+	}
+	if tx.GasPrice != 0 {
+		// charge the payer as block execution does, the transaction is not required to be signed yet
+		costGas, overflow := common.SafeMul(res.Gas, tx.GasPrice)
+		if overflow {
+			res.Error = fmt.Sprintf("gas cost overflow, gas:%d, gas price:%d", res.Gas, tx.GasPrice)
+			return res, nil
+		}
+		balance, err := getBalanceFromNative(config, cache, this, tx.Payer)
+		if err != nil {
+			return nil, err
+		}
+		if balance < costGas {
+			res.Error = fmt.Sprintf("gas insufficient, balance:%d < costGas:%d", balance, costGas)
+			return res, nil
+		}
+		feeTx := *tx
+		feeTx.SignedAddr = []common.Address{tx.Payer}
+		feeConfig := *config
+		feeConfig.Tx = &feeTx
+		notifies, err := chargeCostGas(tx.Payer, costGas, &feeConfig, cache, this)
+		if err != nil {
+			return nil, err
+		}
+		res.Notify = append(res.Notify, notifies...)
+	}
+	cache.Commit()
+	if err := overlay.Error(); err != nil {
+		return nil, err
+	}
+
+	res.State = event.CONTRACT_STATE_SUCCESS
+	res.WriteSet = make([]*sstate.StateChange, 0)
+	overlay.GetWriteSet().ForEach(func(key, val []byte) {
+		old, e := backend.Get(key)
+		if e != nil && e != scom.ErrNotFound && err == nil {
+			err = e
+		}
+		if bytes.Equal(old, val) {
+			return
+		}
+		res.WriteSet = append(res.WriteSet, &sstate.StateChange{
+			Key:      append([]byte{}, key...),
+			OldValue: old,
+			NewValue: append([]byte{}, val...),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+//TraceTransaction re-execute a committed invoke transaction on the states before its block, after the transactions
+//before it in the block, and return the contracts called and the NeoVM opcodes executed, with the evaluation stacks
+//if withStack is set. The states of the parent block are restored with the undo data of the blocks above it, which
+//are reverted by every request, so only the transactions of the latest MAX_TRACE_DEPTH blocks, and no more than the
+//blocks whose undo data is kept, can be traced. Blocks keep being saved while tracing.
+func (this *LedgerStoreImp) TraceTransaction(txHash common.Uint256, withStack bool) (*sstate.TraceResult, error) {
+	tx, height, err := this.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx.TxType != types.Invoke && tx.TxType != types.InvokeWasm {
+		return nil, fmt.Errorf("transaction type %d cannot be traced", tx.TxType)
+	}
+	if height == 0 {
+		return nil, fmt.Errorf("transaction of genesis block cannot be traced")
+	}
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("cannot find block %d", height)
+	}
+
+	depth := MAX_TRACE_DEPTH
+	if keep := config.DefConfig.Common.UndoBlocks; keep > 0 && keep < uint(depth) {
+		depth = uint32(keep)
+	}
+	if current := this.GetCurrentBlockHeight(); current-height >= depth {
+		return nil, fmt.Errorf("transaction of block %d is too far below current block %d to be traced", height, current)
+	}
+	reverted, err := this.newRevertedStore(height)
+	if err != nil {
+		return nil, err
+	}
+	overlay := overlaydb.NewOverlayDB(reverted)
+	cache := storage.NewCacheDB(overlay)
+	for _, t := range block.Transactions {
+		if t.Hash() == txHash {
+			break
+		}
+		cache.Reset()
+		if _, err := this.handleTransaction(overlay, cache, block, t); err != nil {
+			return nil, err
+		}
+	}
+
+	cache.Reset()
+	tracer := smartcontract.NewExecTracer(withStack)
+	notify := &event.ExecuteNotify{TxHash: txHash, State: event.CONTRACT_STATE_FAIL}
+	err = this.stateStore.handleInvokeTransaction(this, overlay, cache, tx, block, notify, tracer)
+	if overlay.Error() != nil {
+		return nil, overlay.Error()
+	}
+	res := &sstate.TraceResult{
+		TxHash:      txHash,
+		Height:      height,
+		State:       notify.State,
+		GasConsumed: notify.GasConsumed,
+		CallTree:    tracer.CallTree(),
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.Steps, res.Truncated = tracer.Steps()
+	return res, nil
+}
+
 func (this *LedgerStoreImp) getPreGas(config *smartcontract.Config, cache *storage.CacheDB) (map[string]uint64, error) {
 	bf := new(bytes.Buffer)
 	names := []string{neovm.CONTRACT_CREATE_NAME, neovm.UINT_INVOKE_CODE_LEN_NAME, neovm.UINT_DEPLOY_CODE_LEN_NAME}
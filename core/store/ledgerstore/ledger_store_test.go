@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/genesis"
+	"github.com/dnaproject2/DNA/core/store/memstore"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/core/utils"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLedgerStore open a ledger store in a temporary directory, initialized with the genesis block of
+// default config if init is true. The returned function closes the store and removes the directory.
+func newTestLedgerStore(t *testing.T, init bool) (*LedgerStoreImp, *types.Block, func()) {
+	dir, err := ioutil.TempDir("", "ledgerstore")
+	assert.Nil(t, err)
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	bookkeepers, err := config.DefConfig.GetBookkeepers()
+	assert.Nil(t, err)
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	if init {
+		assert.Nil(t, store.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	}
+	return store, genesisBlock, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestExecuteExpiredTransaction(t *testing.T) {
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, nil)
+
+	mutable := utils.NewInvokeTransaction([]byte{byte(vm.PUSH1)})
+	mutable.Version = types.TX_VERSION_VALID_UNTIL
+	mutable.ValidUntilHeight = 1
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	prev := ledger.GetCurrentBlockHash()
+	block := &types.Block{
+		Header:       &types.Header{PrevBlockHash: prev, Height: 2},
+		Transactions: []*types.Transaction{tx},
+	}
+	// the version is not enabled before the fork height
+	_, err = ledger.ExecuteBlock(block)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not enabled")
+
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	_, err = ledger.ExecuteBlock(block)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "expired")
+}
+
+func TestMemoryBackendMerkleHashes(t *testing.T) {
+	backend := config.DefConfig.Common.StoreBackend
+	config.DefConfig.Common.StoreBackend = memstore.BACKEND_NAME
+	defer func() { config.DefConfig.Common.StoreBackend = backend }()
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+
+	for i := 0; i < 4; i++ {
+		submitTestBlock(t, ledger, nil)
+	}
+	proof, err := ledger.GetMerkleProof(1, 4)
+	assert.Nil(t, err)
+	assert.Nil(t, ledger.RollbackToHeight(2))
+	submitTestBlock(t, ledger, nil)
+	_, err = ledger.GetMerkleProof(1, 3)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, proof)
+
+	_, err = os.Stat(ledger.stateStore.merklePath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/dnaproject2/DNA/common"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//revertedStore is a read only view of the states before a past block is applied, which reverts the blocks since
+//then with their undo data. The blocks saved after the view is made are reverted as soon as they are seen, so the
+//view stays the same without holding the saving block lock.
+type revertedStore struct {
+	scom.PersistStore
+	ledger     *LedgerStoreImp
+	reverted   *overlaydb.MemDB //Values of the keys changed since the view height, empty if the key did not exist
+	loaded     uint32           //Block height up to which the undo data is loaded
+	loadedHash common.Uint256
+}
+
+//newRevertedStore return the view of the states before block height is applied
+func (this *LedgerStoreImp) newRevertedStore(height uint32) (*revertedStore, error) {
+	if height == 0 {
+		return nil, fmt.Errorf("cannot revert states to block %d", height)
+	}
+	store := &revertedStore{
+		PersistStore: this.stateStore.store,
+		ledger:       this,
+		reverted:     overlaydb.NewMemDB(0, 0),
+		loaded:       height - 1,
+		loadedHash:   this.GetBlockHash(height - 1),
+	}
+	if err := store.update(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+//update revert the blocks saved after the undo data is loaded. The earliest value of a key is kept since blocks
+//are loaded in ascending order.
+func (self *revertedStore) update() error {
+	hash, current, err := self.ledger.stateStore.GetCurrentBlock()
+	if err != nil {
+		return err
+	}
+	if current < self.loaded || self.ledger.GetBlockHash(self.loaded) != self.loadedHash {
+		return fmt.Errorf("ledger is rolled back below block %d", self.loaded)
+	}
+	for h := self.loaded + 1; h <= current; h++ {
+		err := self.ledger.stateStore.forEachUndoValue(h, func(key []byte, exist bool, val []byte) {
+			if _, unknown := self.reverted.Get(key); !unknown {
+				return
+			}
+			if exist {
+				self.reverted.Put(key, val)
+			} else {
+				self.reverted.Delete(key)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	self.loaded, self.loadedHash = current, hash
+	return nil
+}
+
+//Get read the latest value before loading the blocks saved meanwhile, which then override it if they change the key
+func (self *revertedStore) Get(key []byte) ([]byte, error) {
+	value, err := self.PersistStore.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	if e := self.update(); e != nil {
+		return nil, e
+	}
+	if reverted, unknown := self.reverted.Get(key); !unknown {
+		if len(reverted) == 0 {
+			return nil, scom.ErrNotFound
+		}
+		return reverted, nil
+	}
+	return value, err
+}
+
+func (self *revertedStore) Has(key []byte) (bool, error) {
+	_, err := self.Get(key)
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (self *revertedStore) Put(key []byte, value []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *revertedStore) Delete(key []byte) error {
+	return errReadOnlyStore
+}
+
+func (self *revertedStore) NewBatch() {}
+
+func (self *revertedStore) BatchPut(key []byte, value []byte) {}
+
+func (self *revertedStore) BatchDelete(key []byte) {}
+
+func (self *revertedStore) BatchCommit() error {
+	return errReadOnlyStore
+}
+
+func (self *revertedStore) NewIterator(prefix []byte) scom.StoreIterator {
+	return self.newIter(self.PersistStore.NewIterator(prefix), util.BytesPrefix(prefix))
+}
+
+func (self *revertedStore) NewRangeIterator(start, limit []byte) scom.StoreIterator {
+	return self.newIter(self.PersistStore.NewRangeIterator(start, limit), &util.Range{Start: start, Limit: limit})
+}
+
+//newIter join the reverted values with the latest ones, which are taken before the blocks saved meanwhile are loaded
+func (self *revertedStore) newIter(latest scom.StoreIterator, slice *util.Range) scom.StoreIterator {
+	if err := self.update(); err != nil {
+		latest.Release()
+		return iterator.NewEmptyIterator(err)
+	}
+	return overlaydb.NewJoinIter(self.reverted.NewIterator(slice), latest)
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/core/store"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContract = common.Address{0xaa}
+
+func testStorageKey(key string) *states.StorageKey {
+	return &states.StorageKey{ContractAddress: testContract, Key: []byte(key)}
+}
+
+// submitTestBlock save an empty block on top of the current one, with the storage values of the test
+// contract written by it. A nil value deletes the key. The consensus payload of the genesis block is
+// carried along, so the vbft chain config can be loaded at any height.
+func submitTestBlock(t *testing.T, ledger *LedgerStoreImp, values map[string][]byte) *types.Block {
+	height := ledger.GetCurrentBlockHeight() + 1
+	prev, err := ledger.GetHeaderByHeight(height - 1)
+	assert.Nil(t, err)
+	header := &types.Header{
+		PrevBlockHash:    prev.Hash(),
+		Height:           height,
+		Timestamp:        prev.Timestamp + 1,
+		NextBookkeeper:   prev.NextBookkeeper,
+		ConsensusPayload: prev.ConsensusPayload,
+	}
+	header.BlockRoot = ledger.GetBlockRootWithNewTxRoots(height, []common.Uint256{header.TransactionsRoot})
+	block := &types.Block{Header: header}
+
+	overlay := ledger.stateStore.NewOverlayDB()
+	for k, v := range values {
+		key, err := ledger.stateStore.getStorageKey(testStorageKey(k))
+		assert.Nil(t, err)
+		if v == nil {
+			overlay.Delete(key)
+		} else {
+			overlay.Put(key, states.GenRawStorageItem(v))
+		}
+	}
+	result := store.ExecuteResult{WriteSet: overlay.GetWriteSet(), Hash: overlay.ChangeHash()}
+	assert.Nil(t, ledger.submitBlock(block, result))
+	return block
+}
+
+func getTestStorage(t *testing.T, ledger *LedgerStoreImp, key string) []byte {
+	item, err := ledger.GetStorageItem(testStorageKey(key))
+	if err == scom.ErrNotFound {
+		return nil
+	}
+	assert.Nil(t, err)
+	return item.Value
+}
+
+func TestRollbackToHeight(t *testing.T) {
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+
+	block1 := submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("v1")})
+	root1, err := ledger.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+	submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("v2"), "k2": []byte("v2")})
+	submitTestBlock(t, ledger, map[string][]byte{"k1": nil, "k3": []byte("v3")})
+	assert.Nil(t, getTestStorage(t, ledger, "k1"))
+
+	assert.NotNil(t, ledger.RollbackToHeight(3))
+	assert.Nil(t, ledger.RollbackToHeight(1))
+	assert.Equal(t, uint32(1), ledger.GetCurrentBlockHeight())
+	assert.Equal(t, block1.Hash(), ledger.GetCurrentBlockHash())
+	assert.Equal(t, []byte("v1"), getTestStorage(t, ledger, "k1"))
+	assert.Nil(t, getTestStorage(t, ledger, "k2"))
+	assert.Nil(t, getTestStorage(t, ledger, "k3"))
+	root, err := ledger.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+	assert.Equal(t, root1, root)
+	for h := uint32(2); h <= 3; h++ {
+		has, err := ledger.stateStore.HasUndoData(h)
+		assert.Nil(t, err)
+		assert.False(t, has)
+	}
+
+	// the chain continues from the restored block
+	submitTestBlock(t, ledger, map[string][]byte{"k2": []byte("v4")})
+	assert.Equal(t, uint32(2), ledger.GetCurrentBlockHeight())
+	assert.Equal(t, []byte("v1"), getTestStorage(t, ledger, "k1"))
+	assert.Equal(t, []byte("v4"), getTestStorage(t, ledger, "k2"))
+}
+
+func TestUndoDataRetention(t *testing.T) {
+	undoBlocks := config.DefConfig.Common.UndoBlocks
+	defer func() { config.DefConfig.Common.UndoBlocks = undoBlocks }()
+	config.DefConfig.Common.UndoBlocks = 2
+
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	for i := 0; i < 4; i++ {
+		submitTestBlock(t, ledger, map[string][]byte{"k": {byte(i)}})
+	}
+	for h := uint32(1); h <= 4; h++ {
+		has, err := ledger.stateStore.HasUndoData(h)
+		assert.Nil(t, err)
+		assert.Equal(t, h > 2, has)
+	}
+	assert.NotNil(t, ledger.RollbackToHeight(1))
+	assert.Nil(t, ledger.RollbackToHeight(2))
+	assert.Equal(t, []byte{1}, getTestStorage(t, ledger, "k"))
+
+	// lowering the retention prunes the whole range below the window
+	config.DefConfig.Common.UndoBlocks = 1
+	submitTestBlock(t, ledger, nil)
+	for h := uint32(1); h <= 3; h++ {
+		has, err := ledger.stateStore.HasUndoData(h)
+		assert.Nil(t, err)
+		assert.Equal(t, h == 3, has)
+	}
+}
+
+func TestRevertedStore(t *testing.T) {
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("v1")})
+	submitTestBlock(t, ledger, map[string][]byte{"k1": []byte("v2"), "k2": []byte("v2")})
+	submitTestBlock(t, ledger, map[string][]byte{"k1": nil, "k3": []byte("v3")})
+
+	reverted, err := ledger.newRevertedStore(2)
+	assert.Nil(t, err)
+	prefix, err := ledger.stateStore.getStorageKey(testStorageKey(""))
+	assert.Nil(t, err)
+	check := func() {
+		for key, value := range map[string][]byte{"k1": []byte("v1"), "k2": nil, "k3": nil, "k4": nil} {
+			storeKey, err := ledger.stateStore.getStorageKey(testStorageKey(key))
+			assert.Nil(t, err)
+			val, err := reverted.Get(storeKey)
+			if value == nil {
+				assert.Equal(t, scom.ErrNotFound, err, key)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, states.GenRawStorageItem(value), val, key)
+			}
+		}
+		iter := reverted.NewIterator(prefix)
+		var keys []string
+		for iter.Next() {
+			keys = append(keys, string(iter.Key()[len(prefix):]))
+		}
+		assert.Nil(t, iter.Error())
+		iter.Release()
+		assert.Equal(t, []string{"k1"}, keys)
+	}
+	check()
+
+	// the blocks saved after the view is made are reverted too
+	submitTestBlock(t, ledger, map[string][]byte{"k2": []byte("v4"), "k4": []byte("v4")})
+	check()
+
+	assert.Nil(t, ledger.RollbackToHeight(1))
+	_, err = reverted.Get(prefix)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/core/utils"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+	nutils "github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateTransaction(t *testing.T) {
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+
+	payer := common.Address{0xbb}
+	newTx := func(gasPrice uint64) *types.Transaction {
+		mutable := utils.NewInvokeTransaction([]byte{byte(vm.PUSH1), byte(vm.PUSH2), byte(vm.ADD)})
+		mutable.GasPrice = gasPrice
+		mutable.GasLimit = 20000
+		mutable.Payer = payer
+		tx, err := mutable.IntoImmutable()
+		assert.Nil(t, err)
+		return tx
+	}
+	height := ledger.GetCurrentBlockHeight()
+
+	res, err := ledger.SimulateTransaction(newTx(0), height)
+	assert.Nil(t, err)
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, res.State)
+	assert.False(t, res.Truncated)
+	ops := make([]string, 0, len(res.Steps))
+	for _, step := range res.Steps {
+		ops = append(ops, step.Op)
+	}
+	assert.Equal(t, []string{"PUSH1", "PUSH2", "ADD"}, ops)
+	assert.Equal(t, res.ExecGas, res.Steps[len(res.Steps)-1].GasUsed)
+	assert.Empty(t, res.WriteSet)
+
+	// the payer can not afford the fee
+	res, err = ledger.SimulateTransaction(newTx(1), height)
+	assert.Nil(t, err)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, res.State)
+	assert.NotEmpty(t, res.Error)
+
+	balanceKey, err := ledger.stateStore.getStorageKey(&states.StorageKey{
+		ContractAddress: nutils.OngContractAddress,
+		Key:             payer[:],
+	})
+	assert.Nil(t, err)
+	balance := uint64(1000000)
+	assert.Nil(t, ledger.stateStore.store.Put(balanceKey, nutils.GenUInt64StorageItem(balance).ToArray()))
+	res, err = ledger.SimulateTransaction(newTx(2), height)
+	assert.Nil(t, err)
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, res.State)
+	expected := nutils.GenUInt64StorageItem(balance - res.Gas*2).ToArray()
+	found := false
+	for _, change := range res.WriteSet {
+		if bytes.Equal(change.Key, balanceKey) {
+			found = true
+			assert.Equal(t, expected, change.NewValue)
+		}
+	}
+	assert.True(t, found)
+	assert.NotEmpty(t, res.Notify)
+}
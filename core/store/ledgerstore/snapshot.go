@@ -0,0 +1,514 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/common/serialization"
+	vconfig "github.com/dnaproject2/DNA/consensus/vbft/config"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/merkle"
+)
+
+const (
+	SNAPSHOT_MAGIC   = "DNASNAP"
+	SNAPSHOT_VERSION = byte(1)
+)
+
+//snapshotView is a consistent view of the ledger at a block height, taken under the saving block lock. The
+//state iterators work on a snapshot of the store, so the view can be read after the lock is released.
+type snapshotView struct {
+	height       uint32
+	blockHash    common.Uint256
+	stateRoot    common.Uint256
+	blocks       []*types.Block
+	headerHashes []common.Uint256
+	treeValues   [][]byte
+	iters        []scom.StoreIterator
+}
+
+func (this *snapshotView) release() {
+	for _, iter := range this.iters {
+		iter.Release()
+	}
+}
+
+//openSnapshotView take the view of the ledger at current block height
+func (this *LedgerStoreImp) openSnapshotView() (*snapshotView, error) {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	height, blockHash := this.GetCurrentBlock()
+	_, stateHeight, err := this.stateStore.GetCurrentBlock()
+	if err != nil {
+		return nil, fmt.Errorf("stateStore.GetCurrentBlock error %s", err)
+	}
+	if stateHeight != height {
+		return nil, fmt.Errorf("state height %d is inconsistent with block height %d", stateHeight, height)
+	}
+	view := &snapshotView{height: height, blockHash: blockHash}
+	view.stateRoot, err = this.GetStateMerkleRoot(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetStateMerkleRoot height:%d error %s", height, err)
+	}
+	view.blocks, err = this.snapshotBlocks(height)
+	if err != nil {
+		return nil, err
+	}
+	view.headerHashes = make([]common.Uint256, height+1)
+	for h := range view.headerHashes {
+		view.headerHashes[h] = this.GetBlockHash(uint32(h))
+	}
+	store := this.stateStore.store
+	for _, key := range [][]byte{
+		this.stateStore.genBlockMerkleTreeKey(),
+		this.stateStore.genStateMerkleTreeKey(),
+		this.stateStore.genStateMerkleRootKey(height),
+	} {
+		value, err := store.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			return nil, err
+		}
+		view.treeValues = append(view.treeValues, value)
+	}
+	for _, prefix := range statePrefixes {
+		view.iters = append(view.iters, store.NewIterator([]byte{byte(prefix)}))
+	}
+	return view, nil
+}
+
+//ExportSnapshot write the state snapshot at current block height to w, and return the state hash of the
+//snapshot. The snapshot contains the current block, the header hash list, all the contract states, storage items
+//and bookkeeper state, and the compact forms of the block and state merkle trees. The state hash is the hash of
+//all the state items, which should be passed to ImportSnapshot along with the state merkle root. The saving block
+//lock is only held while the view of the ledger is taken, so blocks keep being saved during the export.
+func (this *LedgerStoreImp) ExportSnapshot(w io.Writer) (common.Uint256, error) {
+	view, err := this.openSnapshotView()
+	if err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	defer view.release()
+	height := view.height
+
+	bw := bufio.NewWriter(w)
+	hasher := sha256.New()
+	out := io.MultiWriter(bw, hasher)
+
+	out.Write([]byte(SNAPSHOT_MAGIC))
+	serialization.WriteByte(out, SNAPSHOT_VERSION)
+	serialization.WriteUint32(out, height)
+	view.blockHash.Serialize(out)
+	view.stateRoot.Serialize(out)
+
+	serialization.WriteUint32(out, uint32(len(view.blocks)))
+	for _, block := range view.blocks {
+		serialization.WriteVarBytes(out, block.ToArray())
+	}
+	for _, hash := range view.headerHashes {
+		hash.Serialize(out)
+	}
+	for _, value := range view.treeValues {
+		serialization.WriteVarBytes(out, value)
+	}
+
+	//the merkle hash file is append only, the hashes of the view are not changed by the blocks saved meanwhile
+	hashNum := merkle.StoredHashNum(height + 1)
+	serialization.WriteUint64(out, uint64(hashNum))
+	for pos := int64(0); pos < hashNum; pos++ {
+		hash, err := this.stateStore.merkleHashStore.GetHash(uint32(pos))
+		if err != nil {
+			return common.UINT256_EMPTY, fmt.Errorf("get merkle hash %d error %s", pos, err)
+		}
+		hash.Serialize(out)
+	}
+
+	stateHasher := sha256.New()
+	count := 0
+	for _, iter := range view.iters {
+		for iter.Next() {
+			serialization.WriteBool(out, true)
+			serialization.WriteVarBytes(out, iter.Key())
+			serialization.WriteVarBytes(out, iter.Value())
+			serialization.WriteVarBytes(stateHasher, iter.Key())
+			serialization.WriteVarBytes(stateHasher, iter.Value())
+			count++
+		}
+		if err := iter.Error(); err != nil {
+			return common.UINT256_EMPTY, fmt.Errorf("iterate states error %s", err)
+		}
+	}
+	serialization.WriteBool(out, false)
+
+	if _, err := bw.Write(hasher.Sum(nil)); err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	if err := bw.Flush(); err != nil {
+		return common.UINT256_EMPTY, err
+	}
+	if this.GetBlockHash(height) != view.blockHash {
+		return common.UINT256_EMPTY, fmt.Errorf("ledger is rolled back below height %d during export", height)
+	}
+	var stateHash common.Uint256
+	stateHasher.Sum(stateHash[:0])
+	log.Infof("export snapshot at height %d, block hash %s, %d states, state hash %s", height,
+		view.blockHash.ToHexString(), count, stateHash.ToHexString())
+	return stateHash, nil
+}
+
+//snapshotBlocks return the blocks should be kept by snapshot: the current block, and the last config block of vbft
+func (this *LedgerStoreImp) snapshotBlocks(height uint32) ([]*types.Block, error) {
+	block, err := this.GetBlockByHeight(height)
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockByHeight height:%d error %s", height, err)
+	}
+	blocks := []*types.Block{block}
+	if strings.ToLower(config.DefConfig.Genesis.ConsensusType) == config.CONSENSUS_TYPE_VBFT {
+		blkInfo, err := vconfig.VbftBlock(block.Header)
+		if err != nil {
+			return nil, err
+		}
+		if blkInfo.NewChainConfig == nil && blkInfo.LastConfigBlockNum != height {
+			cfgBlock, err := this.GetBlockByHeight(blkInfo.LastConfigBlockNum)
+			if err != nil {
+				return nil, fmt.Errorf("GetBlockByHeight height:%d error %s", blkInfo.LastConfigBlockNum, err)
+			}
+			blocks = append(blocks, cfgBlock)
+		}
+	}
+	return blocks, nil
+}
+
+//ImportSnapshot initialize an empty ledger store with the snapshot read from r. The header hash list and
+//merkle trees of the snapshot are checked against the genesis block, the block root of its current block and
+//stateRoot, which should be the state merkle root of the snapshot height reported by trusted peers. The state
+//merkle tree commits to the hashes of block write sets rather than to the state content, so it can not prove the
+//state items. They are checked against stateHash instead, the state hash returned by ExportSnapshot on a trusted
+//node at the same height, and the import is refused without it.
+func (this *LedgerStoreImp) ImportSnapshot(r io.Reader, genesisBlock *types.Block, stateRoot,
+	stateHash common.Uint256) error {
+	if stateHash == common.UINT256_EMPTY {
+		return fmt.Errorf("state hash of snapshot is required to verify the states")
+	}
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	hasInit, err := this.hasAlreadyInitGenesisBlock()
+	if err != nil {
+		return fmt.Errorf("hasAlreadyInit error %s", err)
+	}
+	if hasInit {
+		return fmt.Errorf("ledger has already been initialized, snapshot can only be imported into an empty ledger")
+	}
+
+	br := bufio.NewReader(r)
+	hasher := sha256.New()
+	in := io.TeeReader(br, hasher)
+
+	magic, err := serialization.ReadBytes(in, uint64(len(SNAPSHOT_MAGIC)))
+	if err != nil || string(magic) != SNAPSHOT_MAGIC {
+		return fmt.Errorf("not a snapshot file")
+	}
+	version, err := serialization.ReadByte(in)
+	if err != nil {
+		return err
+	}
+	if version != SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+	height, err := serialization.ReadUint32(in)
+	if err != nil {
+		return err
+	}
+	var blockHash, snapStateRoot common.Uint256
+	if err = blockHash.Deserialize(in); err != nil {
+		return err
+	}
+	if err = snapStateRoot.Deserialize(in); err != nil {
+		return err
+	}
+	if snapStateRoot != stateRoot {
+		return fmt.Errorf("state merkle root of snapshot %s is not the expected %s",
+			snapStateRoot.ToHexString(), stateRoot.ToHexString())
+	}
+
+	blockCount, err := serialization.ReadUint32(in)
+	if err != nil {
+		return err
+	}
+	blocks := make([]*types.Block, 0, blockCount)
+	for i := uint32(0); i < blockCount; i++ {
+		raw, err := serialization.ReadVarBytes(in)
+		if err != nil {
+			return err
+		}
+		block, err := types.BlockFromRawBytes(raw)
+		if err != nil {
+			return fmt.Errorf("block deserialize error %s", err)
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) == 0 || blocks[0].Hash() != blockHash || blocks[0].Header.Height != height {
+		return fmt.Errorf("current block of snapshot is invalid")
+	}
+	headerHashes := make([]common.Uint256, height+1)
+	for h := range headerHashes {
+		if err = headerHashes[h].Deserialize(in); err != nil {
+			return err
+		}
+	}
+	genesisHash := genesisBlock.Hash()
+	if headerHashes[0] != genesisHash {
+		return fmt.Errorf("genesis block of snapshot %s mismatch %s",
+			headerHashes[0].ToHexString(), genesisHash.ToHexString())
+	}
+	for _, block := range blocks {
+		if headerHashes[block.Header.Height] != block.Hash() {
+			return fmt.Errorf("block %d of snapshot mismatch header hash list", block.Header.Height)
+		}
+	}
+
+	var blockTreeValue, stateTreeValue, stateRootValue []byte
+	for _, value := range []*[]byte{&blockTreeValue, &stateTreeValue, &stateRootValue} {
+		if *value, err = serialization.ReadVarBytes(in); err != nil {
+			return err
+		}
+	}
+	if err = verifySnapshotTrees(blocks[0].Header, blockTreeValue, stateTreeValue, stateRootValue,
+		stateRoot, this.stateHashCheckHeight); err != nil {
+		return err
+	}
+
+	hashNum, err := serialization.ReadUint64(in)
+	if err != nil {
+		return err
+	}
+	if int64(hashNum) != merkle.StoredHashNum(height+1) {
+		return fmt.Errorf("merkle hash number %d mismatch block height %d", hashNum, height)
+	}
+	merkleHashes, err := serialization.ReadBytes(in, hashNum*common.UINT256_SIZE)
+	if err != nil {
+		return err
+	}
+
+	if err = this.blockStore.ClearAll(); err != nil {
+		return fmt.Errorf("blockStore.ClearAll error %s", err)
+	}
+	if err = this.stateStore.ClearAll(); err != nil {
+		return fmt.Errorf("stateStore.ClearAll error %s", err)
+	}
+	if err = this.eventStore.ClearAll(); err != nil {
+		return fmt.Errorf("eventStore.ClearAll error %s", err)
+	}
+
+	stateStore := this.stateStore.store
+	stateStore.NewBatch()
+	stateHasher := sha256.New()
+	count := 0
+	for {
+		more, err := serialization.ReadBool(in)
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+		key, err := serialization.ReadVarBytes(in)
+		if err != nil {
+			return err
+		}
+		value, err := serialization.ReadVarBytes(in)
+		if err != nil {
+			return err
+		}
+		if !isStateKey(key) {
+			return fmt.Errorf("unexpected state key %x in snapshot", key)
+		}
+		stateStore.BatchPut(key, value)
+		serialization.WriteVarBytes(stateHasher, key)
+		serialization.WriteVarBytes(stateHasher, value)
+		count++
+	}
+	checksum, err := serialization.ReadBytes(br, sha256.Size)
+	if err != nil {
+		return fmt.Errorf("read checksum error %s", err)
+	}
+	if !bytes.Equal(checksum, hasher.Sum(nil)) {
+		return fmt.Errorf("snapshot checksum mismatch")
+	}
+	if !bytes.Equal(stateHash[:], stateHasher.Sum(nil)) {
+		return fmt.Errorf("states of snapshot mismatch state hash %s", stateHash.ToHexString())
+	}
+
+	stateStore.BatchPut(this.stateStore.genBlockMerkleTreeKey(), blockTreeValue)
+	if len(stateTreeValue) > 0 {
+		stateStore.BatchPut(this.stateStore.genStateMerkleTreeKey(), stateTreeValue)
+		stateStore.BatchPut(this.stateStore.genStateMerkleRootKey(height), stateRootValue)
+	}
+	this.stateStore.SaveCurrentBlock(height, blockHash)
+	if err = this.stateStore.CommitTo(); err != nil {
+		return fmt.Errorf("stateStore.CommitTo error %s", err)
+	}
+	if err = this.stateStore.setMerkleHashes(merkleHashes); err != nil {
+		return err
+	}
+	if err = this.stateStore.ReloadMerkleTree(height); err != nil {
+		return fmt.Errorf("ReloadMerkleTree error %s", err)
+	}
+
+	this.eventStore.NewBatch()
+	this.eventStore.SaveCurrentBlock(height, blockHash)
+	if err = this.eventStore.CommitTo(); err != nil {
+		return fmt.Errorf("eventStore.CommitTo error %s", err)
+	}
+
+	this.blockStore.NewBatch()
+	for _, block := range append(blocks, genesisBlock) {
+		if err = this.blockStore.SaveBlock(block); err != nil {
+			return fmt.Errorf("SaveBlock height:%d error %s", block.Header.Height, err)
+		}
+	}
+	for h, hash := range headerHashes {
+		this.blockStore.SaveBlockHash(uint32(h), hash)
+	}
+	storedIndexCount := uint32(0)
+	for ; storedIndexCount+HEADER_INDEX_BATCH_SIZE <= height; storedIndexCount += HEADER_INDEX_BATCH_SIZE {
+		this.blockStore.SaveHeaderIndexList(storedIndexCount, headerHashes[storedIndexCount:storedIndexCount+HEADER_INDEX_BATCH_SIZE])
+	}
+	this.blockStore.SaveCurrentBlock(height, blockHash)
+	if err = this.blockStore.CommitTo(); err != nil {
+		return fmt.Errorf("blockStore.CommitTo error %s", err)
+	}
+	// version is saved at last, an interrupted import leaves the ledger uninitialized
+	if err = this.initGenesisBlock(); err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+	if err = this.init(); err != nil {
+		return fmt.Errorf("init error %s", err)
+	}
+
+	log.Infof("import snapshot at height %d, block hash %s, %d states", height, blockHash.ToHexString(), count)
+	return nil
+}
+
+//PruneStates drop the states which are only needed by the heights below height: the archived versions of states
+//superseded at height, and the undo data of the blocks up to height. The states at height and above can still be
+//queried, but the ledger can no longer be rolled back below height.
+func (this *LedgerStoreImp) PruneStates(height uint32) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	currBlockHeight := this.GetCurrentBlockHeight()
+	if height > currBlockHeight {
+		return fmt.Errorf("prune height %d is greater than current block height %d", height, currBlockHeight)
+	}
+	pruned, err := this.stateStore.GetPrunedHeight()
+	if err != nil {
+		return fmt.Errorf("GetPrunedHeight error %s", err)
+	}
+	if height <= pruned {
+		return nil
+	}
+	this.stateStore.NewBatch()
+	if err = this.stateStore.PruneStateHistory(height); err != nil {
+		return fmt.Errorf("PruneStateHistory error %s", err)
+	}
+	if err = this.stateStore.DeleteUndoData(height + 1); err != nil {
+		return fmt.Errorf("DeleteUndoData error %s", err)
+	}
+	if err = this.stateStore.CommitTo(); err != nil {
+		return fmt.Errorf("stateStore.CommitTo error %s", err)
+	}
+	log.Infof("prune states below height %d", height)
+	return nil
+}
+
+func verifySnapshotTrees(header *types.Header, blockTreeValue, stateTreeValue, stateRootValue []byte,
+	stateRoot common.Uint256, stateHashCheckHeight uint32) error {
+	treeSize, hashes, err := parseMerkleTree(blockTreeValue)
+	if err != nil {
+		return fmt.Errorf("block merkle tree of snapshot is invalid: %s", err)
+	}
+	if treeSize != header.Height+1 {
+		return fmt.Errorf("block merkle tree size %d mismatch block height %d", treeSize, header.Height)
+	}
+	if header.Height > 0 && merkle.NewTree(treeSize, hashes, nil).Root() != header.BlockRoot {
+		return fmt.Errorf("block merkle tree of snapshot mismatch block root")
+	}
+
+	if header.Height < stateHashCheckHeight {
+		if len(stateTreeValue) > 0 || stateRoot != common.UINT256_EMPTY {
+			return fmt.Errorf("unexpected state merkle tree before state hash check height %d", stateHashCheckHeight)
+		}
+		return nil
+	}
+	treeSize, hashes, err = parseMerkleTree(stateTreeValue)
+	if err != nil {
+		return fmt.Errorf("state merkle tree of snapshot is invalid: %s", err)
+	}
+	if treeSize != header.Height-stateHashCheckHeight+1 {
+		return fmt.Errorf("state merkle tree size %d mismatch block height %d", treeSize, header.Height)
+	}
+	if merkle.NewTree(treeSize, hashes, nil).Root() != stateRoot {
+		return fmt.Errorf("state merkle tree of snapshot mismatch state root")
+	}
+	source := common.NewZeroCopySource(stateRootValue)
+	source.NextHash()
+	root, eof := source.NextHash()
+	if eof || root != stateRoot {
+		return fmt.Errorf("state merkle root record of snapshot mismatch state root")
+	}
+	return nil
+}
+
+func parseMerkleTree(data []byte) (uint32, []common.Uint256, error) {
+	source := common.NewZeroCopySource(data)
+	treeSize, eof := source.NextUint32()
+	if eof || (len(data)-4)%common.UINT256_SIZE != 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	hashes := make([]common.Uint256, 0, (len(data)-4)/common.UINT256_SIZE)
+	for source.Len() > 0 {
+		hash, _ := source.NextHash()
+		hashes = append(hashes, hash)
+	}
+	return treeSize, hashes, nil
+}
+
+func writeMerkleHashFile(path string, hashes []byte) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("open merkle hash file error %s", err)
+	}
+	defer f.Close()
+	if _, err = f.Write(hashes); err != nil {
+		return fmt.Errorf("write merkle hash file error %s", err)
+	}
+	return f.Sync()
+}
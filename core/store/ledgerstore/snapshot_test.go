@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ledgerstore
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
+	"github.com/dnaproject2/DNA/core/states"
+	scom "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	src, genesisBlock, closeSrc := newTestLedgerStore(t, true)
+	defer closeSrc()
+	var buf bytes.Buffer
+	stateHash, err := src.ExportSnapshot(&buf)
+	assert.Nil(t, err)
+	stateRoot, err := src.GetStateMerkleRoot(src.GetCurrentBlockHeight())
+	assert.Nil(t, err)
+	snapshot := buf.Bytes()
+
+	dst, _, closeDst := newTestLedgerStore(t, false)
+	defer closeDst()
+	assert.Nil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, stateHash))
+	assert.Equal(t, src.GetCurrentBlockHash(), dst.GetCurrentBlockHash())
+	key := &states.StorageKey{
+		ContractAddress: utils.OntContractAddress,
+		Key:             []byte(ont.TOTAL_SUPPLY_NAME),
+	}
+	expected, err := src.GetStorageItem(key)
+	assert.Nil(t, err)
+	actual, err := dst.GetStorageItem(key)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+
+	// an initialized ledger can not be imported again
+	assert.NotNil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, stateHash))
+}
+
+func TestImportTamperedSnapshot(t *testing.T) {
+	src, genesisBlock, closeSrc := newTestLedgerStore(t, true)
+	defer closeSrc()
+	var buf bytes.Buffer
+	stateHash, err := src.ExportSnapshot(&buf)
+	assert.Nil(t, err)
+	stateRoot, err := src.GetStateMerkleRoot(src.GetCurrentBlockHeight())
+	assert.Nil(t, err)
+	snapshot := buf.Bytes()
+
+	importSnapshot := func(data []byte, root common.Uint256) error {
+		dst, _, closeDst := newTestLedgerStore(t, false)
+		defer closeDst()
+		return dst.ImportSnapshot(bytes.NewReader(data), genesisBlock, root, stateHash)
+	}
+	// the state items are covered by the checksum of the file
+	tampered := append([]byte{}, snapshot...)
+	tampered[len(tampered)-64] ^= 0xff
+	assert.NotNil(t, importSnapshot(tampered, stateRoot))
+	// truncated file
+	assert.NotNil(t, importSnapshot(snapshot[:len(snapshot)-1], stateRoot))
+	// not the state root expected
+	assert.NotNil(t, importSnapshot(snapshot, common.Uint256{1}))
+	tampered = append([]byte{}, snapshot...)
+	tampered[0] ^= 0xff
+	assert.NotNil(t, importSnapshot(tampered, stateRoot))
+
+	// the states are verified against the trusted state hash, even if the file is consistent
+	dst, _, closeDst := newTestLedgerStore(t, false)
+	defer closeDst()
+	assert.NotNil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, common.Uint256{1}))
+	assert.NotNil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, common.UINT256_EMPTY))
+	assert.Nil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, stateHash))
+}
+
+func TestExportSnapshotWhileSaving(t *testing.T) {
+	src, genesisBlock, closeSrc := newTestLedgerStore(t, true)
+	defer closeSrc()
+	submitTestBlock(t, src, map[string][]byte{"k": []byte("v1")})
+
+	r, w := io.Pipe()
+	var stateHash common.Uint256
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		stateHash, err = src.ExportSnapshot(w)
+		w.CloseWithError(err)
+		done <- err
+	}()
+	// the export is blocked by the reader, blocks can still be saved meanwhile
+	head := make([]byte, 1)
+	_, err := io.ReadFull(r, head)
+	assert.Nil(t, err)
+	submitTestBlock(t, src, map[string][]byte{"k": []byte("v2")})
+	rest, err := ioutil.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Nil(t, <-done)
+
+	// the snapshot is taken at the height when the export started
+	stateRoot, err := src.GetStateMerkleRoot(1)
+	assert.Nil(t, err)
+	dst, _, closeDst := newTestLedgerStore(t, false)
+	defer closeDst()
+	snapshot := append(head, rest...)
+	assert.Nil(t, dst.ImportSnapshot(bytes.NewReader(snapshot), genesisBlock, stateRoot, stateHash))
+	assert.Equal(t, uint32(1), dst.GetCurrentBlockHeight())
+	assert.Equal(t, []byte("v1"), getTestStorage(t, dst, "k"))
+}
+
+func TestPruneStates(t *testing.T) {
+	enableArchive := config.DefConfig.Common.EnableArchive
+	defer func() { config.DefConfig.Common.EnableArchive = enableArchive }()
+	config.DefConfig.Common.EnableArchive = true
+
+	ledger, _, closeLedger := newTestLedgerStore(t, true)
+	defer closeLedger()
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("a"), "k1": []byte("x")})
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("b")})
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("c"), "k1": nil})
+	submitTestBlock(t, ledger, map[string][]byte{"k0": []byte("d")})
+
+	assert.NotNil(t, ledger.PruneStates(5))
+	assert.Nil(t, ledger.PruneStates(2))
+	getAt := func(key string, height uint32) ([]byte, error) {
+		item, err := ledger.GetStorageItemAtHeight(testStorageKey(key), height)
+		if err != nil {
+			return nil, err
+		}
+		return item.Value, nil
+	}
+	_, err := getAt("k0", 1)
+	assert.Equal(t, scom.ErrNotArchived, err)
+	for height, value := range []string{2: "b", 3: "c", 4: "d"} {
+		if value == "" {
+			continue
+		}
+		v, err := getAt("k0", uint32(height))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(value), v)
+	}
+	v, err := getAt("k1", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("x"), v)
+	_, err = getAt("k1", 3)
+	assert.Equal(t, scom.ErrNotFound, err)
+
+	// only the latest version at or below the prune height is kept
+	key, err := ledger.stateStore.getStorageKey(testStorageKey("k0"))
+	assert.Nil(t, err)
+	iter := ledger.stateStore.store.NewIterator(ledger.stateStore.genStateHistoryPrefix(key))
+	count := 0
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	assert.Equal(t, 3, count)
+
+	// the pruned blocks can not be rolled back
+	assert.NotNil(t, ledger.RollbackToHeight(1))
+	assert.Nil(t, ledger.RollbackToHeight(2))
+}
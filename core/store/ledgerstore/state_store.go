@@ -42,6 +42,28 @@ var (
 	BOOKKEEPER = []byte("Bookkeeper") //Bookkeeper store key
 )
 
+//prefixes of ledger states, which are exported to snapshot and archived in state history
+var statePrefixes = []scom.DataEntryPrefix{
+	scom.ST_BOOKKEEPER,
+	scom.ST_CONTRACT,
+	scom.ST_STORAGE,
+	scom.ST_UPGRADE,
+	scom.ST_VALIDATOR,
+	scom.ST_VOTE,
+}
+
+func isStateKey(key []byte) bool {
+	if len(key) == 0 {
+		return false
+	}
+	for _, prefix := range statePrefixes {
+		if key[0] == byte(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 //StateStore saving the data of ledger states. Like balance of account, and the execution result of smart contract
 type StateStore struct {
 	dbDir                string                    //Store file path
@@ -50,13 +72,18 @@ type StateStore struct {
 	merkleTree           *merkle.CompactMerkleTree //Merkle tree of block root
 	deltaMerkleTree      *merkle.CompactMerkleTree //Merkle tree of delta state root
 	merkleHashStore      merkle.HashStore
+	volatile             bool //Keep merkle hashes in memory since the store does not survive restart
 	stateHashCheckHeight uint32
 }
 
 //NewStateStore return state store instance
-func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*StateStore, error) {
+func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32, backend string) (*StateStore, error) {
 	var err error
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	storeBackend, err := scom.GetBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	store, err := storeBackend.Open(dbDir)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +91,7 @@ func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*Stat
 		dbDir:                dbDir,
 		store:                store,
 		merklePath:           merklePath,
+		volatile:             storeBackend.Volatile,
 		stateHashCheckHeight: stateHashCheckHeight,
 	}
 	_, height, err := stateStore.GetCurrentBlock()
@@ -111,7 +139,7 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 	if treeSize > 0 && treeSize != currBlockHeight+1 {
 		return fmt.Errorf("merkle tree size is inconsistent with blockheight: %d", currBlockHeight+1)
 	}
-	self.merkleHashStore, err = merkle.NewFileHashStore(self.merklePath, treeSize)
+	self.merkleHashStore, err = self.openMerkleHashStore(treeSize)
 	if err != nil {
 		log.Warn("merkle store is inconsistent with ChainStore. persistence will be disabled")
 	}
@@ -130,6 +158,60 @@ func (self *StateStore) init(currBlockHeight uint32) error {
 	return nil
 }
 
+//openMerkleHashStore open the hash store of block merkle tree with treeSize leaves. Volatile stores keep the
+//hashes in memory, the hashes of the previous hash store are carried over when the tree is reloaded
+func (self *StateStore) openMerkleHashStore(treeSize uint32) (merkle.HashStore, error) {
+	if !self.volatile {
+		return merkle.NewFileHashStore(self.merklePath, treeSize)
+	}
+	hashes := make([]common.Uint256, merkle.StoredHashNum(treeSize))
+	if len(hashes) > 0 && self.merkleHashStore == nil {
+		return nil, fmt.Errorf("merkle hash store is missing")
+	}
+	for i := range hashes {
+		hash, err := self.merkleHashStore.GetHash(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	return self.newMemMerkleHashStore(hashes)
+}
+
+func (self *StateStore) newMemMerkleHashStore(hashes []common.Uint256) (merkle.HashStore, error) {
+	store := merkle.NewMemHashStore()
+	if err := store.Append(hashes); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+//setMerkleHashes replace the stored hashes of block merkle tree, the tree should be reloaded afterwards
+func (self *StateStore) setMerkleHashes(hashes []byte) error {
+	if self.merkleHashStore != nil {
+		self.merkleHashStore.Close()
+	}
+	if !self.volatile {
+		self.merkleHashStore = nil
+		return writeMerkleHashFile(self.merklePath, hashes)
+	}
+	source := common.NewZeroCopySource(hashes)
+	values := make([]common.Uint256, 0, len(hashes)/common.UINT256_SIZE)
+	for source.Len() > 0 {
+		hash, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		values = append(values, hash)
+	}
+	store, err := self.newMemMerkleHashStore(values)
+	if err != nil {
+		return err
+	}
+	self.merkleHashStore = store
+	return nil
+}
+
 //GetStateMerkleTree return merkle tree size an tree node
 func (self *StateStore) GetStateMerkleTree() (uint32, []common.Uint256, error) {
 	key := self.genStateMerkleTreeKey()
@@ -260,6 +342,23 @@ func (self *StateStore) GetContractState(contractHash common.Address) (*payload.
 	return contractState, nil
 }
 
+//GetContractUpgradeInfo return the upgrade history of contract address, nil if the contract is never upgraded
+func (self *StateStore) GetContractUpgradeInfo(contractHash common.Address) (*states.ContractUpgradeInfo, error) {
+	key := append([]byte{byte(scom.ST_UPGRADE)}, contractHash[:]...)
+	value, err := self.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	info := new(states.ContractUpgradeInfo)
+	if err := info.Deserialize(bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 //GetBookkeeperState return current book keeper states
 func (self *StateStore) GetBookkeeperState() (*states.BookkeeperState, error) {
 	key, err := self.getBookkeeperKey()
@@ -395,6 +494,407 @@ func (self *StateStore) genStateMerkleRootKey(height uint32) []byte {
 	return key
 }
 
+func (self *StateStore) genStateUndoKey(height uint32) []byte {
+	key := make([]byte, 5, 5)
+	key[0] = byte(scom.DATA_STATE_UNDO)
+	//big endian, so the undo data is iterated in height order
+	binary.BigEndian.PutUint32(key[1:], height)
+	return key
+}
+
+//getBlockSysKeys return the keys of system states updated by every block
+func (self *StateStore) getBlockSysKeys(height uint32) [][]byte {
+	keys := [][]byte{self.getCurrentBlockKey(), self.genBlockMerkleTreeKey()}
+	if height >= self.stateHashCheckHeight {
+		keys = append(keys, self.genStateMerkleTreeKey(), self.genStateMerkleRootKey(height))
+	}
+	return keys
+}
+
+//SaveUndoData persist the values of keys before block height is applied, which is used to roll back the block
+func (self *StateStore) SaveUndoData(height uint32, keys [][]byte) error {
+	undoKey := self.genStateUndoKey(height)
+	_, err := self.store.Get(undoKey)
+	if err == nil {
+		// block is re-executed in recovering process, the saved values are the right ones
+		return nil
+	}
+	if err != scom.ErrNotFound {
+		return err
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(uint32(len(keys)))
+	for _, key := range keys {
+		val, err := self.store.Get(key)
+		if err != nil && err != scom.ErrNotFound {
+			return err
+		}
+		sink.WriteVarBytes(key)
+		sink.WriteBool(err == nil)
+		sink.WriteVarBytes(val)
+	}
+	self.store.BatchPut(undoKey, sink.Bytes())
+	return nil
+}
+
+//DeleteUndoData remove the undo data of all the blocks below height in the batch, these blocks cannot be
+//rolled back afterwards
+func (self *StateStore) DeleteUndoData(height uint32) error {
+	iter := self.store.NewIterator([]byte{byte(scom.DATA_STATE_UNDO)})
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 5 || binary.BigEndian.Uint32(key[1:]) >= height {
+			break
+		}
+		self.store.BatchDelete(key)
+	}
+	return iter.Error()
+}
+
+//HasUndoData return whether the undo data of block height is in store
+func (self *StateStore) HasUndoData(height uint32) (bool, error) {
+	_, err := self.store.Get(self.genStateUndoKey(height))
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//RevertBlock restore the values changed by block height in the batch, and delete its undo data
+func (self *StateStore) RevertBlock(height uint32) error {
+	err := self.forEachUndoValue(height, func(key []byte, exist bool, val []byte) {
+		if exist {
+			self.store.BatchPut(key, val)
+		} else {
+			self.store.BatchDelete(key)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	self.store.BatchDelete(self.genStateUndoKey(height))
+	return nil
+}
+
+//forEachUndoValue iterate the keys changed by block height with their values before the block is applied
+func (self *StateStore) forEachUndoValue(height uint32, fn func(key []byte, exist bool, val []byte)) error {
+	data, err := self.store.Get(self.genStateUndoKey(height))
+	if err != nil {
+		return fmt.Errorf("get undo data of block %d error %s", height, err)
+	}
+	source := common.NewZeroCopySource(data)
+	count, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	for i := uint32(0); i < count; i++ {
+		key, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return fmt.Errorf("undo data of block %d is broken", height)
+		}
+		exist, irregular, eof := source.NextBool()
+		if irregular || eof {
+			return fmt.Errorf("undo data of block %d is broken", height)
+		}
+		val, _, irregular, eof := source.NextVarBytes()
+		if irregular || eof {
+			return fmt.Errorf("undo data of block %d is broken", height)
+		}
+		fn(key, exist, val)
+	}
+	return nil
+}
+
+//ReloadMerkleTree reload the merkle trees from store after the current block is changed to height
+func (self *StateStore) ReloadMerkleTree(height uint32) error {
+	if self.merkleHashStore != nil && !self.volatile {
+		self.merkleHashStore.Close()
+	}
+	if height < self.stateHashCheckHeight {
+		self.deltaMerkleTree = nil
+	}
+	return self.init(height)
+}
+
+func (self *StateStore) genArchiveInfoKey() []byte {
+	return []byte{byte(scom.SYS_ARCHIVE_INFO)}
+}
+
+func (self *StateStore) genStateHistoryPrefix(key []byte) []byte {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(scom.DATA_STATE_HISTORY))
+	sink.WriteVarBytes(key)
+	return sink.Bytes()
+}
+
+func (self *StateStore) genStateHistoryKey(key []byte, height uint32) []byte {
+	prefix := self.genStateHistoryPrefix(key)
+	historyKey := make([]byte, len(prefix)+4)
+	copy(historyKey, prefix)
+	// big endian keeps the versions of a key sorted by height
+	binary.BigEndian.PutUint32(historyKey[len(prefix):], height)
+	return historyKey
+}
+
+func (self *StateStore) genStateDeletedKey(key []byte) []byte {
+	return append([]byte{byte(scom.DATA_STATE_DELETED)}, key...)
+}
+
+//archiveInfo is the range of archived blocks. Storage changes are archived since start, while all the state
+//changes and the deleted keys are archived since stateStart, which is later than start if the archive is begun
+//when only storage is archived.
+type archiveInfo struct {
+	start      uint32
+	last       uint32
+	stateStart uint32
+}
+
+func (self *StateStore) getArchiveInfo() (*archiveInfo, error) {
+	data, err := self.store.Get(self.genArchiveInfoKey())
+	if err != nil {
+		return nil, err
+	}
+	source := common.NewZeroCopySource(data)
+	info := &archiveInfo{}
+	var eof bool
+	info.start, eof = source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	info.last, eof = source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if source.Len() == 0 {
+		// only storage is archived so far
+		info.stateStart = info.last + 1
+		return info, nil
+	}
+	info.stateStart, eof = source.NextUint32()
+	if eof {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return info, nil
+}
+
+//GetArchiveInfo return the first and last block height whose storage changes are archived
+func (self *StateStore) GetArchiveInfo() (uint32, uint32, error) {
+	info, err := self.getArchiveInfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	return info.start, info.last, nil
+}
+
+//checkArchived make sure the states after block height can be read from the history archived since start
+func (self *StateStore) checkArchived(height, start, last uint32) error {
+	if height+1 < start || height > last {
+		return scom.ErrNotArchived
+	}
+	pruned, err := self.GetPrunedHeight()
+	if err != nil {
+		return err
+	}
+	if height < pruned {
+		return scom.ErrNotArchived
+	}
+	return nil
+}
+
+//SaveStateHistory archive the state values changed by block height in the batch, and return the keys written.
+//The value of a key before the first archived block is kept at the height before it, so that the states
+//at any height since then can be answered. The deleted keys are recorded to iterate the states at a past height.
+func (self *StateStore) SaveStateHistory(height uint32, writeSet *overlaydb.MemDB) ([][]byte, error) {
+	info, err := self.getArchiveInfo()
+	if err == scom.ErrNotFound {
+		info, err = &archiveInfo{start: height, stateStart: height}, nil
+	} else if err != nil {
+		return nil, err
+	} else if info.last+1 < height {
+		return nil, fmt.Errorf("state history of block %d to %d is not archived", info.last+1, height-1)
+	}
+
+	var keys [][]byte
+	writeSet.ForEach(func(key, val []byte) {
+		if err != nil || !isStateKey(key) {
+			return
+		}
+		start := info.stateStart
+		if key[0] == byte(scom.ST_STORAGE) {
+			start = info.start
+		}
+		if start > 0 {
+			var archived bool
+			archived, err = self.hasStateHistory(key)
+			if err != nil {
+				return
+			}
+			if !archived {
+				prev, e := self.store.Get(key)
+				if e != nil && e != scom.ErrNotFound {
+					err = e
+					return
+				}
+				if e == nil {
+					baseKey := self.genStateHistoryKey(key, start-1)
+					self.store.BatchPut(baseKey, prev)
+					keys = append(keys, baseKey)
+				}
+			}
+		}
+		historyKey := self.genStateHistoryKey(key, height)
+		self.store.BatchPut(historyKey, val)
+		keys = append(keys, historyKey)
+		if len(val) == 0 {
+			deletedKey := self.genStateDeletedKey(key)
+			sink := common.NewZeroCopySink(nil)
+			sink.WriteUint32(height)
+			self.store.BatchPut(deletedKey, sink.Bytes())
+			keys = append(keys, deletedKey)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(info.start)
+	sink.WriteUint32(height)
+	sink.WriteUint32(info.stateStart)
+	self.store.BatchPut(self.genArchiveInfoKey(), sink.Bytes())
+	return append(keys, self.genArchiveInfoKey()), nil
+}
+
+func (self *StateStore) hasStateHistory(key []byte) (bool, error) {
+	iter := self.store.NewIterator(self.genStateHistoryPrefix(key))
+	defer iter.Release()
+	has := iter.Next()
+	return has, iter.Error()
+}
+
+//getStateHistory return the value of key after block height, and whether the key has been archived. Value is
+//nil if the key doesn't exist at that height.
+func (self *StateStore) getStateHistory(key []byte, height uint32) ([]byte, bool, error) {
+	prefix := self.genStateHistoryPrefix(key)
+	iter := self.store.NewIterator(prefix)
+	defer iter.Release()
+	var value []byte
+	archived := false
+	for iter.Next() {
+		historyKey := iter.Key()
+		if len(historyKey) != len(prefix)+4 {
+			continue
+		}
+		archived = true
+		if binary.BigEndian.Uint32(historyKey[len(prefix):]) > height {
+			break
+		}
+		value = append([]byte{}, iter.Value()...)
+	}
+	return value, archived, iter.Error()
+}
+
+func (self *StateStore) genPrunedHeightKey() []byte {
+	return []byte{byte(scom.SYS_PRUNED_HEIGHT)}
+}
+
+//GetPrunedHeight return the block height below which the states are pruned, 0 if never pruned
+func (self *StateStore) GetPrunedHeight() (uint32, error) {
+	data, err := self.store.Get(self.genPrunedHeightKey())
+	if err == scom.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	height, eof := common.NewZeroCopySource(data).NextUint32()
+	if eof {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return height, nil
+}
+
+//PruneStateHistory remove the archived versions of states which are not needed by the heights since height in
+//the batch. For every key, the latest version saved at or below height is kept, the earlier ones are deleted,
+//so are the records of keys deleted at or below height.
+func (self *StateStore) PruneStateHistory(height uint32) error {
+	deleted := self.store.NewIterator([]byte{byte(scom.DATA_STATE_DELETED)})
+	defer deleted.Release()
+	for deleted.Next() {
+		deletedHeight, eof := common.NewZeroCopySource(deleted.Value()).NextUint32()
+		if !eof && deletedHeight <= height {
+			self.store.BatchDelete(append([]byte{}, deleted.Key()...))
+		}
+	}
+	if err := deleted.Error(); err != nil {
+		return err
+	}
+
+	iter := self.store.NewIterator([]byte{byte(scom.DATA_STATE_HISTORY)})
+	defer iter.Release()
+	var prevKey []byte
+	var prevPrefix []byte
+	for iter.Next() {
+		historyKey := iter.Key()
+		if len(historyKey) < 5 {
+			continue
+		}
+		prefix := historyKey[:len(historyKey)-4]
+		if binary.BigEndian.Uint32(historyKey[len(prefix):]) > height {
+			prevKey = nil
+			continue
+		}
+		if prevKey != nil && bytes.Equal(prefix, prevPrefix) {
+			self.store.BatchDelete(prevKey)
+		}
+		prevKey = append([]byte{}, historyKey...)
+		prevPrefix = prevKey[:len(prefix)]
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint32(height)
+	self.store.BatchPut(self.genPrunedHeightKey(), sink.Bytes())
+	return nil
+}
+
+//GetStorageStateAtHeight return the storage item after block height, which should be archived
+func (self *StateStore) GetStorageStateAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error) {
+	start, last, err := self.GetArchiveInfo()
+	if err == scom.ErrNotFound {
+		return nil, scom.ErrNotArchived
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = self.checkArchived(height, start, last); err != nil {
+		return nil, err
+	}
+	storeKey, err := self.getStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, archived, err := self.getStateHistory(storeKey, height)
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		// never changed since archived
+		return self.GetStorageState(key)
+	}
+	if len(data) == 0 {
+		return nil, scom.ErrNotFound
+	}
+	storageState := new(states.StorageItem)
+	err = storageState.Deserialize(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return storageState, nil
+}
+
 //ClearAll clear all data in state store
 func (self *StateStore) ClearAll() error {
 	self.store.NewBatch()
@@ -41,6 +41,7 @@ import (
 	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
 	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
+	"github.com/dnaproject2/DNA/smartcontract/service/wasmvm"
 	"github.com/dnaproject2/DNA/smartcontract/storage"
 )
 
@@ -97,6 +98,9 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 		cache.Commit()
 	}
 
+	if err := wasmvm.VerifyDeployCode(deploy); err != nil {
+		return err
+	}
 	address := deploy.Address()
 	log.Infof("deploy contract address:%s", address.ToHexString())
 	// store contract message
@@ -105,6 +109,13 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 		return err
 	}
 	if dep == nil {
+		upgraded, err := cache.IsContractUpgraded(address)
+		if err != nil {
+			return err
+		}
+		if upgraded {
+			return fmt.Errorf("contract %s has been upgraded, can not be deployed again", address.ToHexString())
+		}
 		cache.PutContract(deploy)
 	}
 	cache.Commit()
@@ -118,6 +129,12 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 //HandleInvokeTransaction deal with smart contract invoke transaction
 func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay *overlaydb.OverlayDB, cache *storage.CacheDB,
 	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify) error {
+	return self.handleInvokeTransaction(store, overlay, cache, tx, block, notify, nil)
+}
+
+//handleInvokeTransaction deal with smart contract invoke transaction, the execution is observed by tracer if it is not nil
+func (self *StateStore) handleInvokeTransaction(store store.LedgerStore, overlay *overlaydb.OverlayDB, cache *storage.CacheDB,
+	tx *types.Transaction, block *types.Block, notify *event.ExecuteNotify, tracer *smartcontract.ExecTracer) error {
 	invoke := tx.Payload.(*payload.InvokeCode)
 	code := invoke.Code
 	sysTransFlag := bytes.Compare(code, ninit.COMMIT_DPOS_BYTES) == 0 || block.Header.Height == 0
@@ -194,11 +211,17 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 		Store:   store,
 		Gas:     availableGasLimit - codeLenGasLimit,
 	}
+	if tracer != nil {
+		sc.Tracer = tracer
+	}
 
 	//start the smart contract executive function
-	engine, _ := sc.NewExecuteEngine(invoke.Code)
+	engine, _ := sc.NewTxExecuteEngine(tx.TxType, invoke.Code)
 
 	_, err = engine.Invoke()
+	if tracer != nil {
+		tracer.End(sc.Gas, err)
+	}
 
 	costGasLimit = availableGasLimit - sc.Gas
 	if costGasLimit < neovm.MIN_TRANSACTION_GAS {
@@ -292,6 +315,21 @@ func chargeCostGas(payer common.Address, gas uint64, config *smartcontract.Confi
 	return sc.Notifications, nil
 }
 
+func executeProposals(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore) ([]*event.NotifyEventInfo, error) {
+	sc := smartcontract.SmartContract{
+		Config:  config,
+		CacheDB: cache,
+		Store:   store,
+		Gas:     math.MaxUint64,
+	}
+
+	service, _ := sc.NewNativeService()
+	if _, err := service.NativeCall(utils.GovernanceContractAddress, "executeProposal", []byte{}); err != nil {
+		return nil, err
+	}
+	return sc.Notifications, nil
+}
+
 func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore) error {
 	bf := new(bytes.Buffer)
 	if err := utils.WriteVarUint(bf, uint64(len(neovm.GAS_TABLE_KEYS))); err != nil {
@@ -29,6 +29,18 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+//BACKEND_NAME is the registered name of leveldb store backend
+const BACKEND_NAME = "leveldb"
+
+func init() {
+	common.RegisterBackend(&common.StoreBackend{
+		Name: BACKEND_NAME,
+		Open: func(path string) (common.PersistStore, error) {
+			return NewLevelDBStore(path)
+		},
+	})
+}
+
 //LevelDB store
 type LevelDBStore struct {
 	db    *leveldb.DB // LevelDB instance
@@ -157,3 +169,8 @@ func (self *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
 
 	return iter
 }
+
+//NewRangeIterator return a iterator of leveldb with the keys in [start, limit)
+func (self *LevelDBStore) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return self.db.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+}
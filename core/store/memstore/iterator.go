@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memstore
+
+import (
+	"bytes"
+	"sort"
+)
+
+//Iterator walk through the key-value pairs captured when it is created
+type Iterator struct {
+	keys   [][]byte
+	values [][]byte
+	index  int
+}
+
+//NewIterator return an iterator of the key-value pairs, keys should be sorted
+func NewIterator(keys, values [][]byte) *Iterator {
+	return &Iterator{keys: keys, values: values, index: -1}
+}
+
+func (self *Iterator) valid() bool {
+	return self.index >= 0 && self.index < len(self.keys)
+}
+
+//Next move to the next item, return false if no more item
+func (self *Iterator) Next() bool {
+	if self.index < len(self.keys) {
+		self.index++
+	}
+	return self.valid()
+}
+
+//Prev move to the previous item, return false if no more item
+func (self *Iterator) Prev() bool {
+	if self.index >= 0 {
+		self.index--
+	}
+	return self.valid()
+}
+
+//First move to the first item, return false if iterator is empty
+func (self *Iterator) First() bool {
+	self.index = 0
+	return self.valid()
+}
+
+//Last move to the last item, return false if iterator is empty
+func (self *Iterator) Last() bool {
+	self.index = len(self.keys) - 1
+	return self.valid()
+}
+
+//Seek move to the first item whose key is greater than or equal to key
+func (self *Iterator) Seek(key []byte) bool {
+	self.index = sort.Search(len(self.keys), func(i int) bool {
+		return bytes.Compare(self.keys[i], key) >= 0
+	})
+	return self.valid()
+}
+
+//Key return the key of current item, nil if iterator is not positioned at an item
+func (self *Iterator) Key() []byte {
+	if !self.valid() {
+		return nil
+	}
+	return self.keys[self.index]
+}
+
+//Value return the value of current item, nil if iterator is not positioned at an item
+func (self *Iterator) Value() []byte {
+	if !self.valid() {
+		return nil
+	}
+	return self.values[self.index]
+}
+
+//Release the iterator
+func (self *Iterator) Release() {
+	self.keys = nil
+	self.values = nil
+	self.index = 0
+}
+
+//Error always return nil since iterating memory never fails
+func (self *Iterator) Error() error {
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package memstore provides an in-memory PersistStore, which is useful for tests
+package memstore
+
+import (
+	"sync"
+
+	"github.com/dnaproject2/DNA/core/store/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//BACKEND_NAME is the registered name of memory store backend
+const BACKEND_NAME = "memory"
+
+func init() {
+	common.RegisterBackend(&common.StoreBackend{
+		Name:     BACKEND_NAME,
+		Volatile: true,
+		Open: func(path string) (common.PersistStore, error) {
+			return NewMemStore(), nil
+		},
+	})
+}
+
+//MemStore keep all the key-value pairs in memory, ordered by key
+type MemStore struct {
+	lock  sync.RWMutex
+	db    *memdb.DB
+	batch *leveldb.Batch
+}
+
+//NewMemStore return an empty MemStore
+func NewMemStore() *MemStore {
+	return &MemStore{
+		db: memdb.New(comparer.DefaultComparer, 0),
+	}
+}
+
+//Put the key-value pair to store
+func (self *MemStore) Put(key []byte, value []byte) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.db.Put(key, value)
+}
+
+//Get the value of a key, return common.ErrNotFound if the key doesn't exist
+func (self *MemStore) Get(key []byte) ([]byte, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	value, err := self.db.Get(key)
+	if err != nil {
+		if err == memdb.ErrNotFound {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return copyBytes(value), nil
+}
+
+//Has return whether the key is exist in store
+func (self *MemStore) Has(key []byte) (bool, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.db.Contains(key), nil
+}
+
+//Delete the key in store
+func (self *MemStore) Delete(key []byte) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.delete(key)
+	return nil
+}
+
+func (self *MemStore) delete(key []byte) {
+	err := self.db.Delete(key)
+	if err != nil && err != memdb.ErrNotFound {
+		panic(err)
+	}
+}
+
+//NewBatch start commit batch
+func (self *MemStore) NewBatch() {
+	self.batch = new(leveldb.Batch)
+}
+
+//BatchPut put a key-value pair to batch
+func (self *MemStore) BatchPut(key []byte, value []byte) {
+	self.batch.Put(key, value)
+}
+
+//BatchDelete delete a key in batch
+func (self *MemStore) BatchDelete(key []byte) {
+	self.batch.Delete(key)
+}
+
+//BatchCommit apply the batch to store atomically
+func (self *MemStore) BatchCommit() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	err := self.batch.Replay(batchReplay{self})
+	if err != nil {
+		return err
+	}
+	self.batch = nil
+	return nil
+}
+
+type batchReplay struct {
+	store *MemStore
+}
+
+func (self batchReplay) Put(key, value []byte) {
+	self.store.db.Put(key, value)
+}
+
+func (self batchReplay) Delete(key []byte) {
+	self.store.delete(key)
+}
+
+//Close release all the data
+func (self *MemStore) Close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.db.Reset()
+	return nil
+}
+
+//NewIterator return an iterator of the key-value pairs with the key prefix. The iterator works on a snapshot
+//of store, later writes are invisible to it.
+func (self *MemStore) NewIterator(prefix []byte) common.StoreIterator {
+	return self.newIterator(util.BytesPrefix(prefix))
+}
+
+//NewRangeIterator return an iterator of the key-value pairs with the keys in [start, limit)
+func (self *MemStore) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	return self.newIterator(&util.Range{Start: start, Limit: limit})
+}
+
+func (self *MemStore) newIterator(slice *util.Range) common.StoreIterator {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	iter := self.db.NewIterator(slice)
+	defer iter.Release()
+	var keys, values [][]byte
+	for iter.Next() {
+		keys = append(keys, copyBytes(iter.Key()))
+		values = append(values, copyBytes(iter.Value()))
+	}
+	return NewIterator(keys, values)
+}
+
+func copyBytes(data []byte) []byte {
+	result := make([]byte, len(data))
+	copy(result, data)
+	return result
+}
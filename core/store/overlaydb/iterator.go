@@ -15,6 +15,7 @@
  * You should have received a copy of the GNU Lesser General Public License
  * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
  */
+
 package overlaydb
 
 import (
@@ -30,81 +31,54 @@ const (
 	FromBoth           = iota
 )
 
+type direction byte
+
+const (
+	dirSOI direction = iota // before the first item
+	dirEOI                  // after the last item
+	dirForward
+	dirBackward
+)
+
+// JoinIter merge the iterator of memdb and the iterator of backend store. The value in memdb overrides the one
+// in backend, and the item with empty value, which is deleted in memdb, is skipped.
 type JoinIter struct {
-	backend     common.StoreIterator
-	memdb       common.StoreIterator
-	key, value  []byte
-	keyOrigin   KeyOrigin
-	nextMemEnd  bool
-	nextBackEnd bool
-	cmp         comparer.BasicComparer
+	backend    common.StoreIterator
+	memdb      common.StoreIterator
+	backValid  bool
+	memValid   bool
+	key, value []byte
+	keyOrigin  KeyOrigin
+	dir        direction
+	cmp        comparer.BasicComparer
 }
 
 func NewJoinIter(memIter, backendIter common.StoreIterator) *JoinIter {
 	return &JoinIter{
 		backend: backendIter,
 		memdb:   memIter,
+		dir:     dirSOI,
 		cmp:     comparer.DefaultComparer,
 	}
 }
 
 func (iter *JoinIter) First() bool {
-	f := iter.first()
-	if f == false {
-		return false
-	}
-	for len(iter.value) == 0 {
-		if iter.next() == false {
-			return false
-		}
-	}
+	iter.backValid = iter.backend.First()
+	iter.memValid = iter.memdb.First()
+	return iter.forward()
+}
 
-	return true
+func (iter *JoinIter) Last() bool {
+	iter.backValid = iter.backend.Last()
+	iter.memValid = iter.memdb.Last()
+	return iter.backward()
 }
 
-func (iter *JoinIter) first() bool {
-	var bkey, bval, mkey, mval []byte
-	back := iter.backend.First()
-	mem := iter.memdb.First()
-	// check error
-	if iter.Error() != nil {
-		return false
-	}
-	if back {
-		bkey = iter.backend.Key()
-		bval = iter.backend.Value()
-		if mem == false {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-			return true
-		}
-		mkey = iter.memdb.Key()
-		mval = iter.memdb.Value()
-		cmp := iter.cmp.Compare(mkey, bkey)
-		if cmp < 1 {
-			iter.key = mkey
-			iter.value = mval
-			if cmp == 0 {
-				iter.keyOrigin = FromBoth
-			} else {
-				iter.keyOrigin = FromMem
-			}
-		} else {
-			iter.key = bkey
-			iter.value = bval
-			iter.keyOrigin = FromBack
-		}
-		return true
-	} else {
-		if mem {
-			iter.key = iter.memdb.Key()
-			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
-			return true
-		}
-		return false
-	}
+// Seek move to the first item whose key is greater than or equal to key
+func (iter *JoinIter) Seek(key []byte) bool {
+	iter.backValid = iter.backend.Seek(key)
+	iter.memValid = iter.memdb.Seek(key)
+	return iter.forward()
 }
 
 func (iter *JoinIter) Key() []byte {
@@ -116,71 +90,125 @@ func (iter *JoinIter) Value() []byte {
 }
 
 func (iter *JoinIter) Next() bool {
-	f := iter.next()
-	if f == false {
+	switch iter.dir {
+	case dirSOI:
+		return iter.First()
+	case dirEOI:
 		return false
+	case dirBackward:
+		// move both iterators after current key
+		key := append([]byte{}, iter.key...)
+		iter.backValid = iter.backend.Seek(key)
+		if iter.backValid && iter.cmp.Compare(iter.backend.Key(), key) == 0 {
+			iter.backValid = iter.backend.Next()
+		}
+		iter.memValid = iter.memdb.Seek(key)
+		if iter.memValid && iter.cmp.Compare(iter.memdb.Key(), key) == 0 {
+			iter.memValid = iter.memdb.Next()
+		}
+	default:
+		iter.step(true)
 	}
+	return iter.forward()
+}
 
-	for len(iter.value) == 0 {
-		if iter.next() == false {
-			return false
+func (iter *JoinIter) Prev() bool {
+	switch iter.dir {
+	case dirSOI:
+		return false
+	case dirEOI:
+		return iter.Last()
+	case dirForward:
+		// move both iterators before current key
+		key := append([]byte{}, iter.key...)
+		if iter.backend.Seek(key) {
+			iter.backValid = iter.backend.Prev()
+		} else {
+			iter.backValid = iter.backend.Last()
 		}
+		if iter.memdb.Seek(key) {
+			iter.memValid = iter.memdb.Prev()
+		} else {
+			iter.memValid = iter.memdb.Last()
+		}
+	default:
+		iter.step(false)
 	}
+	return iter.backward()
+}
 
-	return true
+// step move the iterators positioned at current key
+func (iter *JoinIter) step(forward bool) {
+	if iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth {
+		if forward {
+			iter.memValid = iter.memdb.Next()
+		} else {
+			iter.memValid = iter.memdb.Prev()
+		}
+	}
+	if iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth {
+		if forward {
+			iter.backValid = iter.backend.Next()
+		} else {
+			iter.backValid = iter.backend.Prev()
+		}
+	}
 }
 
-func (iter *JoinIter) next() bool {
-	if (iter.keyOrigin == FromMem || iter.keyOrigin == FromBoth) && iter.nextMemEnd == false {
-		iter.nextMemEnd = !iter.memdb.Next()
+// forward pick the smallest key of the two iterators, skipping the deleted items
+func (iter *JoinIter) forward() bool {
+	iter.dir = dirForward
+	for iter.pick(-1) {
+		if len(iter.value) != 0 {
+			return true
+		}
+		iter.step(true)
 	}
-	if (iter.keyOrigin == FromBack || iter.keyOrigin == FromBoth) && iter.nextBackEnd == false {
-		iter.nextBackEnd = !iter.backend.Next()
+	iter.dir = dirEOI
+	return false
+}
+
+// backward pick the largest key of the two iterators, skipping the deleted items
+func (iter *JoinIter) backward() bool {
+	iter.dir = dirBackward
+	for iter.pick(1) {
+		if len(iter.value) != 0 {
+			return true
+		}
+		iter.step(false)
 	}
+	iter.dir = dirSOI
+	return false
+}
 
+// pick set current item to the memdb one if its key compares to backend key as order, or equals
+func (iter *JoinIter) pick(order int) bool {
 	// check error
-	if iter.Error() != nil {
+	if iter.Error() != nil || (!iter.backValid && !iter.memValid) {
+		iter.key = nil
+		iter.value = nil
 		return false
 	}
-
-	if iter.nextBackEnd {
-		if iter.nextMemEnd {
-			iter.key = nil
-			iter.value = nil
-			return false
-		} else {
+	fromMem := !iter.backValid
+	if iter.backValid && iter.memValid {
+		cmp := iter.cmp.Compare(iter.memdb.Key(), iter.backend.Key())
+		if cmp == 0 {
 			iter.key = iter.memdb.Key()
 			iter.value = iter.memdb.Value()
-			iter.keyOrigin = FromMem
+			iter.keyOrigin = FromBoth
+			return true
 		}
+		fromMem = cmp == order
+	}
+	if fromMem {
+		iter.key = iter.memdb.Key()
+		iter.value = iter.memdb.Value()
+		iter.keyOrigin = FromMem
 	} else {
-		if iter.nextMemEnd {
-			iter.key = iter.backend.Key()
-			iter.value = iter.backend.Value()
-			iter.keyOrigin = FromBack
-		} else {
-			bkey := iter.backend.Key()
-			mkey := iter.memdb.Key()
-			cmp := iter.cmp.Compare(mkey, bkey)
-			switch cmp {
-			case -1:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromMem
-			case 0:
-				iter.key = mkey
-				iter.value = iter.memdb.Value()
-				iter.keyOrigin = FromBoth
-			case 1:
-				iter.key = bkey
-				iter.value = iter.backend.Value()
-				iter.keyOrigin = FromBack
-			default:
-				panic("unreachable")
-			}
-		}
+		iter.key = iter.backend.Key()
+		iter.value = iter.backend.Value()
+		iter.keyOrigin = FromBack
 	}
-
 	return true
 }
 
@@ -116,3 +116,11 @@ func (self *OverlayDB) NewIterator(key []byte) common.StoreIterator {
 
 	return NewJoinIter(memIter, backIter)
 }
+
+// NewRangeIterator return the iterator of keys in [start, limit), nil limit means no upper bound
+func (self *OverlayDB) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	backIter := self.store.NewRangeIterator(start, limit)
+	memIter := self.memdb.NewIterator(&util.Range{Start: start, Limit: limit})
+
+	return NewJoinIter(memIter, backIter)
+}
@@ -19,6 +19,8 @@
 package store
 
 import (
+	"io"
+
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/states"
@@ -44,7 +46,11 @@ type LedgerStore interface {
 	AddBlock(block *types.Block, stateMerkleRoot common.Uint256) error
 	ExecuteBlock(b *types.Block) (ExecuteResult, error)   // called by consensus
 	SubmitBlock(b *types.Block, exec ExecuteResult) error // called by consensus
+	RollbackToHeight(height uint32) error
 	GetStateMerkleRoot(height uint32) (result common.Uint256, err error)
+	ExportSnapshot(w io.Writer) (common.Uint256, error)
+	ImportSnapshot(r io.Reader, genesisBlock *types.Block, stateRoot, stateHash common.Uint256) error
+	PruneStates(height uint32) error
 	GetCurrentBlockHash() common.Uint256
 	GetCurrentBlockHeight() uint32
 	GetCurrentHeaderHeight() uint32
@@ -61,9 +67,13 @@ type LedgerStore interface {
 	GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256
 	GetMerkleProof(m, n uint32) ([]common.Uint256, error)
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
+	GetContractUpgradeInfo(contractHash common.Address) (*states.ContractUpgradeInfo, error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
+	GetStorageItemAtHeight(key *states.StorageKey, height uint32) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
+	SimulateTransaction(tx *types.Transaction, height uint32) (*cstates.SimulateResult, error)
+	TraceTransaction(txHash common.Uint256, withStack bool) (*cstates.TraceResult, error)
 	GetEventNotifyByTx(tx common.Uint256) (*event.ExecuteNotify, error)
 	GetEventNotifyByBlock(height uint32) ([]*event.ExecuteNotify, error)
 }
@@ -0,0 +1,290 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package storetest provides the conformance test suite that every PersistStore backend must pass
+package storetest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+//TestPersistStore run the conformance suite, open should return an empty store for every case
+func TestPersistStore(t *testing.T, open func(t *testing.T) common.PersistStore) {
+	cases := []struct {
+		name string
+		test func(t *testing.T, store common.PersistStore)
+	}{
+		{"PutGet", testPutGet},
+		{"Delete", testDelete},
+		{"Batch", testBatch},
+		{"IteratorPrefix", testIteratorPrefix},
+		{"IteratorFirst", testIteratorFirst},
+		{"IteratorSnapshot", testIteratorSnapshot},
+		{"IteratorSeek", testIteratorSeek},
+		{"IteratorReverse", testIteratorReverse},
+		{"RangeIterator", testRangeIterator},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			c.test(t, store)
+		})
+	}
+}
+
+func testPutGet(t *testing.T, store common.PersistStore) {
+	_, err := store.Get([]byte("key"))
+	assert.Equal(t, common.ErrNotFound, err)
+	has, err := store.Has([]byte("key"))
+	assert.Nil(t, err)
+	assert.False(t, has)
+
+	value := []byte("value")
+	assert.Nil(t, store.Put([]byte("key"), value))
+	value[0] = 'x'
+	got, err := store.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), got)
+	has, err = store.Has([]byte("key"))
+	assert.Nil(t, err)
+	assert.True(t, has)
+
+	got[0] = 'x'
+	got, err = store.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), got)
+
+	assert.Nil(t, store.Put([]byte("key"), []byte("value2")))
+	got, err = store.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value2"), got)
+
+	assert.Nil(t, store.Put([]byte("empty"), []byte{}))
+	got, err = store.Get([]byte("empty"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(got))
+}
+
+func testDelete(t *testing.T, store common.PersistStore) {
+	assert.Nil(t, store.Delete([]byte("missing")))
+	assert.Nil(t, store.Put([]byte("key"), []byte("value")))
+	assert.Nil(t, store.Delete([]byte("key")))
+	_, err := store.Get([]byte("key"))
+	assert.Equal(t, common.ErrNotFound, err)
+	has, err := store.Has([]byte("key"))
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
+
+func testBatch(t *testing.T, store common.PersistStore) {
+	assert.Nil(t, store.Put([]byte("a"), []byte("1")))
+	assert.Nil(t, store.Put([]byte("b"), []byte("2")))
+
+	store.NewBatch()
+	store.BatchPut([]byte("c"), []byte("3"))
+	store.BatchDelete([]byte("a"))
+	store.BatchPut([]byte("b"), []byte("4"))
+	store.BatchPut([]byte("d"), []byte("5"))
+	store.BatchDelete([]byte("d"))
+
+	// nothing is visible before commit
+	_, err := store.Get([]byte("c"))
+	assert.Equal(t, common.ErrNotFound, err)
+	got, err := store.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), got)
+
+	assert.Nil(t, store.BatchCommit())
+	_, err = store.Get([]byte("a"))
+	assert.Equal(t, common.ErrNotFound, err)
+	got, err = store.Get([]byte("b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("4"), got)
+	got, err = store.Get([]byte("c"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("3"), got)
+	_, err = store.Get([]byte("d"))
+	assert.Equal(t, common.ErrNotFound, err)
+
+	// an empty batch is fine
+	store.NewBatch()
+	assert.Nil(t, store.BatchCommit())
+}
+
+func collect(t *testing.T, iter common.StoreIterator) ([][]byte, [][]byte) {
+	var keys, values [][]byte
+	for iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+		values = append(values, append([]byte{}, iter.Value()...))
+	}
+	assert.Nil(t, iter.Key())
+	assert.Nil(t, iter.Value())
+	iter.Release()
+	assert.Nil(t, iter.Error())
+	return keys, values
+}
+
+func testIteratorPrefix(t *testing.T, store common.PersistStore) {
+	data := [][]byte{
+		{0x01, 0x02},
+		{0x01},
+		{0x01, 0x00, 0xff},
+		{0x02},
+		{0x00, 0x01},
+		{0xff, 0xff},
+		{0xff},
+		{0x01, 0xff, 0x00},
+	}
+	for i, key := range data {
+		assert.Nil(t, store.Put(key, []byte{byte(i)}))
+	}
+
+	keys, values := collect(t, store.NewIterator([]byte{0x01}))
+	assert.Equal(t, [][]byte{{0x01}, {0x01, 0x00, 0xff}, {0x01, 0x02}, {0x01, 0xff, 0x00}}, keys)
+	assert.Equal(t, [][]byte{{1}, {2}, {0}, {7}}, values)
+
+	keys, _ = collect(t, store.NewIterator([]byte{0xff}))
+	assert.Equal(t, [][]byte{{0xff}, {0xff, 0xff}}, keys)
+
+	keys, _ = collect(t, store.NewIterator([]byte{0x01, 0x00}))
+	assert.Equal(t, [][]byte{{0x01, 0x00, 0xff}}, keys)
+
+	keys, _ = collect(t, store.NewIterator([]byte{0x03}))
+	assert.Equal(t, 0, len(keys))
+
+	keys, _ = collect(t, store.NewIterator(nil))
+	assert.Equal(t, len(data), len(keys))
+	for i := 1; i < len(keys); i++ {
+		assert.True(t, bytes.Compare(keys[i-1], keys[i]) < 0)
+	}
+}
+
+func testIteratorFirst(t *testing.T, store common.PersistStore) {
+	iter := store.NewIterator([]byte("p"))
+	assert.False(t, iter.First())
+	iter.Release()
+
+	for _, key := range []string{"p1", "p2", "p3", "q1"} {
+		assert.Nil(t, store.Put([]byte(key), []byte(key)))
+	}
+	iter = store.NewIterator([]byte("p"))
+	assert.True(t, iter.First())
+	assert.Equal(t, []byte("p1"), iter.Key())
+	assert.True(t, iter.Next())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("p3"), iter.Key())
+	assert.False(t, iter.Next())
+	assert.True(t, iter.First())
+	assert.Equal(t, []byte("p1"), iter.Key())
+	assert.Equal(t, []byte("p1"), iter.Value())
+	iter.Release()
+	assert.Nil(t, iter.Error())
+}
+
+func testIteratorSnapshot(t *testing.T, store common.PersistStore) {
+	assert.Nil(t, store.Put([]byte("k1"), []byte("v1")))
+	assert.Nil(t, store.Put([]byte("k2"), []byte("v2")))
+	iter := store.NewIterator([]byte("k"))
+
+	assert.Nil(t, store.Put([]byte("k0"), []byte("v0")))
+	assert.Nil(t, store.Put([]byte("k1"), []byte("changed")))
+	assert.Nil(t, store.Delete([]byte("k2")))
+
+	keys, values := collect(t, iter)
+	assert.Equal(t, [][]byte{[]byte("k1"), []byte("k2")}, keys)
+	assert.Equal(t, [][]byte{[]byte("v1"), []byte("v2")}, values)
+}
+
+func testIteratorSeek(t *testing.T, store common.PersistStore) {
+	for _, key := range []string{"k1", "k3", "k5", "m1"} {
+		assert.Nil(t, store.Put([]byte(key), []byte(key)))
+	}
+	iter := store.NewIterator([]byte("k"))
+	assert.True(t, iter.Seek([]byte("k3")))
+	assert.Equal(t, []byte("k3"), iter.Key())
+	assert.True(t, iter.Seek([]byte("k2")))
+	assert.Equal(t, []byte("k3"), iter.Key())
+	assert.Equal(t, []byte("k3"), iter.Value())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("k5"), iter.Key())
+	assert.False(t, iter.Seek([]byte("k6")))
+	assert.True(t, iter.Seek([]byte("a")))
+	assert.Equal(t, []byte("k1"), iter.Key())
+	iter.Release()
+	assert.Nil(t, iter.Error())
+}
+
+func testIteratorReverse(t *testing.T, store common.PersistStore) {
+	iter := store.NewIterator([]byte("p"))
+	assert.False(t, iter.Last())
+	iter.Release()
+
+	for _, key := range []string{"o1", "p1", "p2", "p3", "q1"} {
+		assert.Nil(t, store.Put([]byte(key), []byte(key)))
+	}
+	iter = store.NewIterator([]byte("p"))
+	var keys []string
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		keys = append(keys, string(iter.Key()))
+	}
+	assert.Equal(t, []string{"p3", "p2", "p1"}, keys)
+
+	// change direction in the middle
+	assert.True(t, iter.Seek([]byte("p2")))
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte("p1"), iter.Key())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("p2"), iter.Key())
+	assert.True(t, iter.Next())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte("p2"), iter.Key())
+	iter.Release()
+	assert.Nil(t, iter.Error())
+}
+
+func testRangeIterator(t *testing.T, store common.PersistStore) {
+	for _, key := range []string{"a", "b", "b1", "c", "d"} {
+		assert.Nil(t, store.Put([]byte(key), []byte(key)))
+	}
+	keys, _ := collect(t, store.NewRangeIterator([]byte("b"), []byte("d")))
+	assert.Equal(t, [][]byte{[]byte("b"), []byte("b1"), []byte("c")}, keys)
+
+	keys, _ = collect(t, store.NewRangeIterator([]byte("b1"), nil))
+	assert.Equal(t, [][]byte{[]byte("b1"), []byte("c"), []byte("d")}, keys)
+
+	keys, _ = collect(t, store.NewRangeIterator(nil, []byte("b")))
+	assert.Equal(t, [][]byte{[]byte("a")}, keys)
+
+	keys, _ = collect(t, store.NewRangeIterator([]byte("c"), []byte("c")))
+	assert.Equal(t, 0, len(keys))
+
+	iter := store.NewRangeIterator([]byte("b"), []byte("d"))
+	assert.True(t, iter.Last())
+	assert.Equal(t, []byte("c"), iter.Key())
+	assert.False(t, iter.Seek([]byte("c1")))
+	assert.True(t, iter.Seek([]byte("a")))
+	assert.Equal(t, []byte("b"), iter.Key())
+	assert.False(t, iter.Prev())
+	iter.Release()
+	assert.Nil(t, iter.Error())
+}
@@ -73,6 +73,9 @@ func (self *Block) Deserialization(source *common.ZeroCopySource) error {
 		if err != nil {
 			return err
 		}
+		if err := transaction.CheckVersion(self.Header.Height); err != nil {
+			return err
+		}
 		txhash := transaction.Hash()
 		if mask[txhash] {
 			return errors.New("duplicated transaction in block")
@@ -38,7 +38,10 @@ type MutableTransaction struct {
 	Payload  Payload
 	//Attributes []*TxAttribute
 	attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
-	Sigs       []Sig
+	// ValidUntilHeight is the last block height the transaction can be included in, 0 means no limit.
+	// It is only serialized since version TX_VERSION_VALID_UNTIL
+	ValidUntilHeight uint32
+	Sigs             []Sig
 }
 
 // output has no reference to self
@@ -124,6 +127,11 @@ func (tx *MutableTransaction) serializeUnsigned(sink *common.ZeroCopySink) error
 		return errors.New("wrong transaction payload type")
 	}
 	sink.WriteVarUint(uint64(tx.attributes))
+	if tx.Version >= TX_VERSION_VALID_UNTIL {
+		sink.WriteUint32(tx.ValidUntilHeight)
+	} else if tx.ValidUntilHeight != 0 {
+		return fmt.Errorf("ValidUntilHeight is not supported by transaction version %d", tx.Version)
+	}
 
 	return nil
 }
@@ -156,7 +164,7 @@ func (tx *MutableTransaction) DeserializeUnsigned(r io.Reader) error {
 	}
 
 	switch tx.TxType {
-	case Invoke:
+	case Invoke, InvokeWasm:
 		tx.Payload = new(payload.InvokeCode)
 	case Deploy:
 		tx.Payload = new(payload.DeployCode)
@@ -179,5 +187,12 @@ func (tx *MutableTransaction) DeserializeUnsigned(r io.Reader) error {
 	}
 	tx.attributes = 0
 
+	if tx.Version >= TX_VERSION_VALID_UNTIL {
+		tx.ValidUntilHeight, err = serialization.ReadUint32(r)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
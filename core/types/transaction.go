@@ -27,6 +27,7 @@ import (
 
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/constants"
+	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/serialization"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/program"
@@ -35,6 +36,12 @@ import (
 
 const MAX_TX_SIZE = 1024 * 1024 // The max size of a transaction to prevent DOS attacks
 
+// Transaction versions. Since version 1 a transaction has ValidUntilHeight serialized after the attributes
+const (
+	TX_VERSION_BASE        byte = 0
+	TX_VERSION_VALID_UNTIL byte = 1
+)
+
 type Transaction struct {
 	Version  byte
 	TxType   TransactionType
@@ -45,7 +52,10 @@ type Transaction struct {
 	Payload  Payload
 	//Attributes []*TxAttribute
 	attributes byte //this must be 0 now, Attribute Array length use VarUint encoding, so byte is enough for extension
-	Sigs       []RawSig
+	// ValidUntilHeight is the last block height the transaction can be included in, 0 means no limit.
+	// It is only serialized since version TX_VERSION_VALID_UNTIL
+	ValidUntilHeight uint32
+	Sigs             []RawSig
 
 	Raw []byte // raw transaction data
 
@@ -128,6 +138,8 @@ func (tx *Transaction) IntoMutable() (*MutableTransaction, error) {
 		GasLimit: tx.GasLimit,
 		Payer:    tx.Payer,
 		Payload:  tx.Payload,
+
+		ValidUntilHeight: tx.ValidUntilHeight,
 	}
 
 	for _, raw := range tx.Sigs {
@@ -158,7 +170,7 @@ func (tx *Transaction) deserializationUnsigned(source *common.ZeroCopySource) er
 	copy(tx.Payer[:], buf)
 
 	switch tx.TxType {
-	case Invoke:
+	case Invoke, InvokeWasm:
 		pl := new(payload.InvokeCode)
 		err := pl.Deserialization(source)
 		if err != nil {
@@ -190,9 +202,35 @@ func (tx *Transaction) deserializationUnsigned(source *common.ZeroCopySource) er
 	}
 	tx.attributes = 0
 
+	if tx.Version >= TX_VERSION_VALID_UNTIL {
+		tx.ValidUntilHeight, eof = source.NextUint32()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+	}
+
 	return nil
 }
 
+// CheckVersion returns error if the transaction version is not enabled in the block of height yet. Version
+// TX_VERSION_VALID_UNTIL is enabled since the fork height of the network, blocks below it must be parsed and
+// executed as the nodes not knowing the version do.
+func (tx *Transaction) CheckVersion(height uint32) error {
+	if tx.Version < TX_VERSION_VALID_UNTIL {
+		return nil
+	}
+	forkHeight := config.GetTxValidUntilHeight(config.DefConfig.P2PNode.NetworkId)
+	if height < forkHeight {
+		return fmt.Errorf("transaction version %d is not enabled before block height %d", tx.Version, forkHeight)
+	}
+	return nil
+}
+
+// IsExpired returns whether the transaction can not be included in the block of height any more
+func (tx *Transaction) IsExpired(height uint32) bool {
+	return tx.ValidUntilHeight != 0 && height > tx.ValidUntilHeight
+}
+
 type RawSig struct {
 	Invoke []byte
 	Verify []byte
@@ -379,6 +417,7 @@ const (
 	Bookkeeper TransactionType = 0x02
 	Deploy     TransactionType = 0xd0
 	Invoke     TransactionType = 0xd1
+	InvokeWasm TransactionType = 0xd2
 )
 
 // Payload define the func for loading the payload data
@@ -30,10 +30,16 @@ import (
 	"github.com/dnaproject2/DNA/core/signature"
 	"github.com/dnaproject2/DNA/core/types"
 	ontErrors "github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/smartcontract/service/wasmvm"
 )
 
 // VerifyTransaction verifys received single transaction
 func VerifyTransaction(tx *types.Transaction) ontErrors.ErrCode {
+	if tx.Version > types.TX_VERSION_VALID_UNTIL {
+		log.Infof("transaction verify error: unsupported version %d", tx.Version)
+		return ontErrors.ErrTxVersion
+	}
+
 	if err := checkTransactionSignatures(tx); err != nil {
 		log.Info("transaction verify error:", err)
 		return ontErrors.ErrVerifySignature
@@ -114,7 +120,7 @@ func checkTransactionPayload(tx *types.Transaction) error {
 
 	switch pld := tx.Payload.(type) {
 	case *payload.DeployCode:
-		return nil
+		return wasmvm.VerifyDeployCode(pld)
 	case *payload.InvokeCode:
 		return nil
 	default:
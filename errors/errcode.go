@@ -52,6 +52,10 @@ const (
 	ErrNetVerifyFail        ErrCode = 45019
 	ErrGasPrice             ErrCode = 45020
 	ErrVerifySignature      ErrCode = 45021
+	ErrReplaceUnderpriced   ErrCode = 45022
+	ErrPayerTxLimit         ErrCode = 45023
+	ErrTxExpired            ErrCode = 45024
+	ErrTxVersion            ErrCode = 45025
 )
 
 func (err ErrCode) Error() string {
@@ -100,6 +104,14 @@ func (err ErrCode) Error() string {
 		return "invalid gas price"
 	case ErrVerifySignature:
 		return "transaction verify signature fail"
+	case ErrReplaceUnderpriced:
+		return "replacement transaction underpriced"
+	case ErrPayerTxLimit:
+		return "too many transactions of the payer in tx pool"
+	case ErrTxExpired:
+		return "transaction expired"
+	case ErrTxVersion:
+		return "unsupported transaction version"
 
 	}
 
@@ -29,6 +29,24 @@ const (
 	TOPIC_NODE_DISCONNECT           = "noddis"
 	TOPIC_NODE_CONSENSUS_DISCONNECT = "nodcnsdis"
 	TOPIC_SMART_CODE_EVENT          = "scevt"
+	TOPIC_TXPOOL_CHANGE             = "txpoolchg"
+)
+
+//transaction pool change kinds
+const (
+	TXPOOL_TX_ENTER = "enter"
+	TXPOOL_TX_LEAVE = "leave"
+)
+
+//reasons for a transaction leaving the pool, other than a failed re-verification
+const (
+	TXPOOL_REASON_COMMITTED   = "committed"
+	TXPOOL_REASON_UNDERPRICED = "underpriced"
+	TXPOOL_REASON_REPLACED    = "replaced"
+	TXPOOL_REASON_EVICTED     = "evicted"
+	TXPOOL_REASON_EXPIRED     = "expired"
+	TXPOOL_REASON_TIMEOUT     = "timeout"
+	TXPOOL_REASON_REMOVED     = "removed"
 )
 
 type SaveBlockCompleteMsg struct {
@@ -46,3 +64,10 @@ type SmartCodeEventMsg struct {
 type BlockConsensusComplete struct {
 	Block *types.Block
 }
+
+//TxPoolChangeMsg is published when a transaction enters or leaves the transaction pool
+type TxPoolChangeMsg struct {
+	Tx     *types.Transaction
+	Change string //TXPOOL_TX_ENTER or TXPOOL_TX_LEAVE
+	Reason string //why the transaction left the pool
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/ledger"
 	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/smartcontract/event"
 	cstate "github.com/dnaproject2/DNA/smartcontract/states"
@@ -47,6 +48,11 @@ func GetBlockHashFromStore(height uint32) common.Uint256 {
 	return ledger.DefLedger.GetBlockHash(height)
 }
 
+//GetStateMerkleRoot from ledger
+func GetStateMerkleRoot(height uint32) (common.Uint256, error) {
+	return ledger.DefLedger.GetStateMerkleRoot(height)
+}
+
 //CurrentBlockHash from ledger
 func CurrentBlockHash() common.Uint256 {
 	return ledger.DefLedger.GetCurrentBlockHash()
@@ -72,12 +78,22 @@ func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
+//GetStorageItemAtHeight from ledger
+func GetStorageItemAtHeight(address common.Address, key []byte, height uint32) ([]byte, error) {
+	return ledger.DefLedger.GetStorageItemAtHeight(address, key, height)
+}
+
 //GetContractStateFromStore from ledger
 func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error) {
 	hash = updateNativeSCAddr(hash)
 	return ledger.DefLedger.GetContractState(hash)
 }
 
+//GetContractUpgradeInfo return the upgrade history of contract, nil if the contract is never upgraded
+func GetContractUpgradeInfo(hash common.Address) (*states.ContractUpgradeInfo, error) {
+	return ledger.DefLedger.GetContractUpgradeInfo(hash)
+}
+
 //GetTxnWithHeightByTxHash from ledger
 func GetTxnWithHeightByTxHash(hash common.Uint256) (uint32, *types.Transaction, error) {
 	tx, height, err := ledger.DefLedger.GetTransactionWithHeight(hash)
@@ -89,6 +105,16 @@ func PreExecuteContract(tx *types.Transaction) (*cstate.PreExecResult, error) {
 	return ledger.DefLedger.PreExecuteContract(tx)
 }
 
+//SimulateTransaction on the state after block height
+func SimulateTransaction(tx *types.Transaction, height uint32) (*cstate.SimulateResult, error) {
+	return ledger.DefLedger.SimulateTransaction(tx, height)
+}
+
+//TraceTransaction re-execute a committed transaction on the state of its parent block
+func TraceTransaction(txHash common.Uint256, withStack bool) (*cstate.TraceResult, error) {
+	return ledger.DefLedger.TraceTransaction(txHash, withStack)
+}
+
 //GetEventNotifyByTxHash from ledger
 func GetEventNotifyByTxHash(txHash common.Uint256) (*event.ExecuteNotify, error) {
 	return ledger.DefLedger.GetEventNotifyByTx(txHash)
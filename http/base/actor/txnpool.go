@@ -114,6 +114,25 @@ func GetTxFromPool(hash common.Uint256) (tcomn.TXEntry, error) {
 	return txnEntry, nil
 }
 
+//GetTxStatusFromPool return the verified results and the pool state of a transaction in txpool
+//or dropped from txpool recently
+func GetTxStatusFromPool(hash common.Uint256) (*tcomn.GetTxnStatusRsp, error) {
+	future := txnPid.RequestFuture(&tcomn.GetTxnStatusReq{Hash: hash}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	txStatus, ok := result.(*tcomn.GetTxnStatusRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	if txStatus.TxStatus == nil && txStatus.Pool == nil {
+		return nil, errors.New("fail")
+	}
+	return txStatus, nil
+}
+
 //GetTxnCount from txpool actor
 func GetTxnCount() ([]uint32, error) {
 	future := txnPid.RequestFuture(&tcomn.GetTxnCountReq{}, REQ_TIMEOUT*time.Second)
@@ -128,3 +147,36 @@ func GetTxnCount() ([]uint32, error) {
 	}
 	return txnCnt.Count, nil
 }
+
+//GetTxListFromPool returns the transactions in txpool, including the ones being re-verified
+func GetTxListFromPool() ([]*tcomn.PoolTxEntry, error) {
+	future := txnPid.RequestFuture(&tcomn.GetTxnListReq{}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	rsp, ok := result.(*tcomn.GetTxnListRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return rsp.Txs, nil
+}
+
+//EvictTxFromPool removes a transaction from txpool, and returns the removed transaction
+func EvictTxFromPool(hash common.Uint256) (*types.Transaction, error) {
+	future := txnPid.RequestFuture(&tcomn.EvictTxnReq{Hash: hash}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	rsp, ok := result.(*tcomn.EvictTxnRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	if rsp.Tx == nil {
+		return nil, errors.New("fail")
+	}
+	return rsp.Tx, nil
+}
@@ -37,6 +37,10 @@ func updateNativeSCAddr(hash common.Address) common.Address {
 		hash = common.AddressFromVmCode(utils.AuthContractAddress[:])
 	} else if hash == utils.GovernanceContractAddress {
 		hash = common.AddressFromVmCode(utils.GovernanceContractAddress[:])
+	} else if hash == utils.CredentialContractAddress {
+		hash = common.AddressFromVmCode(utils.CredentialContractAddress[:])
+	} else if hash == utils.AssetFactoryContractAddress {
+		hash = common.AddressFromVmCode(utils.AssetFactoryContractAddress[:])
 	}
 	return hash
 }
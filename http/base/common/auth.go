@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package common
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/auth"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+type AuthRoleInfo struct {
+	Role      string   `json:"role"`
+	FuncNames []string `json:"funcNames"`
+	Quota     uint64   `json:"quota"`
+	Window    uint32   `json:"window"`
+}
+
+type AuthRoleMember struct {
+	OntID      string `json:"ontId"`
+	ExpireTime uint32 `json:"expireTime"`
+	Level      uint8  `json:"level"`
+	Delegator  string `json:"delegator,omitempty"`
+}
+
+type AuthRoleMembers struct {
+	Total   uint64           `json:"total"`
+	Members []AuthRoleMember `json:"members"`
+}
+
+type AuthRecordInfo struct {
+	Height   uint32 `json:"height"`
+	Op       string `json:"op"`
+	Role     string `json:"role,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Operator string `json:"operator,omitempty"`
+}
+
+type AuthHistory struct {
+	Total   uint64           `json:"total"`
+	Records []AuthRecordInfo `json:"records"`
+}
+
+// GetAuthRoles lists the roles of a contract managed by the auth contract.
+func GetAuthRoles(contract common.Address) ([]AuthRoleInfo, error) {
+	data, err := preExecuteNative(utils.AuthContractAddress, "listRoles",
+		[]interface{}{&auth.ListRolesParam{ContractAddr: contract}})
+	if err != nil {
+		return nil, err
+	}
+	rd := bytes.NewReader(data)
+	n, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("read role count error:%s", err)
+	}
+	roles := make([]AuthRoleInfo, 0, n)
+	for i := uint64(0); i < n; i++ {
+		info := new(auth.RoleInfo)
+		if err := info.Deserialize(rd); err != nil {
+			return nil, fmt.Errorf("deserialize role error:%s", err)
+		}
+		roles = append(roles, AuthRoleInfo{
+			Role:      string(info.Role),
+			FuncNames: info.FuncNames,
+			Quota:     info.Quota,
+			Window:    info.Window,
+		})
+	}
+	return roles, nil
+}
+
+// GetAuthRoleMembers lists the DNA IDs currently holding role of a contract among the recorded members
+// from offset.
+func GetAuthRoleMembers(contract common.Address, role string, offset, limit uint64) (*AuthRoleMembers, error) {
+	data, err := preExecuteNative(utils.AuthContractAddress, "listRoleMembers",
+		[]interface{}{&auth.ListRoleMembersParam{ContractAddr: contract, Role: []byte(role), Offset: offset,
+			Limit: limit}})
+	if err != nil {
+		return nil, err
+	}
+	rd := bytes.NewReader(data)
+	total, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("read total member count error:%s", err)
+	}
+	n, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("read member count error:%s", err)
+	}
+	members := &AuthRoleMembers{Total: total, Members: make([]AuthRoleMember, 0, n)}
+	for i := uint64(0); i < n; i++ {
+		m := new(auth.RoleMember)
+		if err := m.Deserialize(rd); err != nil {
+			return nil, fmt.Errorf("deserialize member error:%s", err)
+		}
+		members.Members = append(members.Members, AuthRoleMember{
+			OntID:      string(m.OntID),
+			ExpireTime: m.ExpireTime,
+			Level:      m.Level,
+			Delegator:  string(m.Root),
+		})
+	}
+	return members, nil
+}
+
+// GetAuthHistory returns the auth history of a contract from offset.
+func GetAuthHistory(contract common.Address, offset, limit uint64) (*AuthHistory, error) {
+	data, err := preExecuteNative(utils.AuthContractAddress, "getAuthHistory",
+		[]interface{}{&auth.GetAuthHistoryParam{ContractAddr: contract, Offset: offset, Limit: limit}})
+	if err != nil {
+		return nil, err
+	}
+	rd := bytes.NewReader(data)
+	total, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("read history count error:%s", err)
+	}
+	n, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return nil, fmt.Errorf("read record count error:%s", err)
+	}
+	history := &AuthHistory{Total: total, Records: make([]AuthRecordInfo, 0, n)}
+	for i := uint64(0); i < n; i++ {
+		record := new(auth.AuthRecord)
+		if err := record.Deserialize(rd); err != nil {
+			return nil, fmt.Errorf("deserialize record error:%s", err)
+		}
+		history.Records = append(history.Records, AuthRecordInfo{
+			Height:   record.Height,
+			Op:       record.Op,
+			Role:     string(record.Role),
+			Subject:  string(record.Subject),
+			Operator: string(record.Operator),
+		})
+	}
+	return history, nil
+}
@@ -29,6 +29,8 @@ import (
 	"github.com/dnaproject2/DNA/common/serialization"
 	"github.com/dnaproject2/DNA/core/ledger"
 	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
+	scom "github.com/dnaproject2/DNA/core/store/common"
 	"github.com/dnaproject2/DNA/core/types"
 	cutils "github.com/dnaproject2/DNA/core/utils"
 	ontErrors "github.com/dnaproject2/DNA/errors"
@@ -37,6 +39,7 @@ import (
 	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
 	cstate "github.com/dnaproject2/DNA/smartcontract/states"
+	tcomn "github.com/dnaproject2/DNA/txnpool/common"
 	"github.com/dnaproject2/DNA/vm/neovm"
 	"github.com/ontio/ontology-crypto/keypair"
 	"strings"
@@ -51,6 +54,11 @@ type BalanceOfRsp struct {
 	Ong string `json:"ong"`
 }
 
+type AssetBalanceRsp struct {
+	Asset   string `json:"asset"`
+	Balance string `json:"balance"`
+}
+
 type MerkleProof struct {
 	Type             string
 	TransactionsRoot string
@@ -73,6 +81,62 @@ type ExecuteNotify struct {
 	Notify      []NotifyEventInfo
 }
 
+type CallFrameInfo struct {
+	Contract string
+	VmType   string
+	Method   string `json:",omitempty"`
+	GasUsed  uint64
+	Failed   bool
+	Calls    []*CallFrameInfo `json:",omitempty"`
+}
+
+type StateChangeInfo struct {
+	Key        string
+	Contract   string `json:",omitempty"`
+	StorageKey string `json:",omitempty"`
+	OldValue   string
+	NewValue   string
+	Deleted    bool
+}
+
+type SimulateResult struct {
+	State     byte
+	Height    uint32
+	Gas       uint64
+	CodeGas   uint64
+	ExecGas   uint64
+	Result    interface{}
+	Error     string `json:",omitempty"`
+	Notify    []NotifyEventInfo
+	CallTree  *CallFrameInfo
+	Steps     []ExecStepInfo
+	Truncated bool
+	WriteSet  []StateChangeInfo
+}
+
+type ExecStepInfo struct {
+	Contract string
+	Depth    int
+	Pc       int
+	Op       string
+	SysCall  string `json:",omitempty"`
+	Gas      uint64
+	GasUsed  uint64
+	Stack    []interface{} `json:",omitempty"`
+	Error    string        `json:",omitempty"`
+}
+
+type TraceResult struct {
+	TxHash      string
+	Height      uint32
+	State       byte
+	GasConsumed uint64
+	Error       string `json:",omitempty"`
+	CallTree    *CallFrameInfo
+	Steps       []ExecStepInfo
+	Truncated   bool
+}
+
 type PreExecuteResult struct {
 	State  byte
 	Gas    uint64
@@ -117,6 +181,8 @@ type Transactions struct {
 	Sigs       []Sig
 	Hash       string
 	Height     uint32
+
+	ValidUntilHeight uint32 `json:",omitempty"`
 }
 
 type BlockHead struct {
@@ -168,6 +234,37 @@ type TXNAttrInfo struct {
 
 type TXNEntryInfo struct {
 	State []TXNAttrInfo // the result from each validator
+	Pool  *TXNPoolInfo  `json:",omitempty"`
+}
+
+type TXNPoolInfo struct {
+	Payer        string
+	Nonce        uint32
+	GasPrice     uint64
+	PayerTxCount int
+	Dropped      string `json:",omitempty"`
+	ReplacedBy   string `json:",omitempty"`
+}
+
+func ConvertTxStatus(status *tcomn.GetTxnStatusRsp) TXNEntryInfo {
+	attrs := []TXNAttrInfo{}
+	for _, t := range status.TxStatus {
+		attrs = append(attrs, TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
+	}
+	info := TXNEntryInfo{State: attrs}
+	if pool := status.Pool; pool != nil {
+		info.Pool = &TXNPoolInfo{
+			Payer:        pool.Payer.ToBase58(),
+			Nonce:        pool.Nonce,
+			GasPrice:     pool.GasPrice,
+			PayerTxCount: pool.PayerTxCount,
+			Dropped:      pool.Dropped,
+		}
+		if pool.ReplacedBy != common.UINT256_EMPTY {
+			info.Pool.ReplacedBy = pool.ReplacedBy.ToHexString()
+		}
+	}
+	return info
 }
 
 func GetLogEvent(obj *event.LogEventArgs) (map[string]bool, LogEventArgs) {
@@ -196,14 +293,114 @@ func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts}
 }
 
+func ConvertSimulateResult(obj *cstate.SimulateResult) SimulateResult {
+	evts := []NotifyEventInfo{}
+	for _, v := range obj.Notify {
+		evts = append(evts, NotifyEventInfo{v.ContractAddress.ToHexString(), v.States})
+	}
+	changes := make([]StateChangeInfo, 0, len(obj.WriteSet))
+	for _, v := range obj.WriteSet {
+		changes = append(changes, convertStateChange(v))
+	}
+	return SimulateResult{
+		State:     obj.State,
+		Height:    obj.Height,
+		Gas:       obj.Gas,
+		CodeGas:   obj.CodeGas,
+		ExecGas:   obj.ExecGas,
+		Result:    obj.Result,
+		Error:     obj.Error,
+		Notify:    evts,
+		CallTree:  convertCallFrame(obj.CallTree),
+		Steps:     convertExecSteps(obj.Steps),
+		Truncated: obj.Truncated,
+		WriteSet:  changes,
+	}
+}
+
+func ConvertTraceResult(obj *cstate.TraceResult) TraceResult {
+	return TraceResult{
+		TxHash:      obj.TxHash.ToHexString(),
+		Height:      obj.Height,
+		State:       obj.State,
+		GasConsumed: obj.GasConsumed,
+		Error:       obj.Error,
+		CallTree:    convertCallFrame(obj.CallTree),
+		Steps:       convertExecSteps(obj.Steps),
+		Truncated:   obj.Truncated,
+	}
+}
+
+func convertExecSteps(list []*cstate.ExecStep) []ExecStepInfo {
+	steps := make([]ExecStepInfo, 0, len(list))
+	for _, v := range list {
+		steps = append(steps, ExecStepInfo{
+			Contract: v.Contract.ToHexString(),
+			Depth:    v.Depth,
+			Pc:       v.Pc,
+			Op:       v.Op,
+			SysCall:  v.SysCall,
+			Gas:      v.Gas,
+			GasUsed:  v.GasUsed,
+			Stack:    v.Stack,
+			Error:    v.Error,
+		})
+	}
+	return steps
+}
+
+func convertCallFrame(frame *cstate.CallFrame) *CallFrameInfo {
+	if frame == nil {
+		return nil
+	}
+	info := &CallFrameInfo{
+		Contract: frame.Contract.ToHexString(),
+		VmType:   frame.VmType,
+		Method:   frame.Method,
+		GasUsed:  frame.GasUsed,
+		Failed:   frame.Failed,
+	}
+	for _, call := range frame.Calls {
+		info.Calls = append(info.Calls, convertCallFrame(call))
+	}
+	return info
+}
+
+//convertStateChange decodes the contract address, key and values of storage changes,
+//other states are shown in raw
+func convertStateChange(change *cstate.StateChange) StateChangeInfo {
+	info := StateChangeInfo{
+		Key:      common.ToHexString(change.Key),
+		OldValue: common.ToHexString(change.OldValue),
+		NewValue: common.ToHexString(change.NewValue),
+		Deleted:  len(change.NewValue) == 0,
+	}
+	key := change.Key
+	if len(key) < 1+common.ADDR_LEN || key[0] != byte(scom.ST_STORAGE) {
+		return info
+	}
+	address, _ := common.AddressParseFromBytes(key[1 : 1+common.ADDR_LEN])
+	info.Contract = address.ToHexString()
+	info.StorageKey = common.ToHexString(key[1+common.ADDR_LEN:])
+	if value, err := states.GetValueFromRawStorageItem(change.OldValue); err == nil && len(change.OldValue) > 0 {
+		info.OldValue = common.ToHexString(value)
+	}
+	if value, err := states.GetValueFromRawStorageItem(change.NewValue); err == nil && len(change.NewValue) > 0 {
+		info.NewValue = common.ToHexString(value)
+	}
+	return info
+}
+
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
 	trans := new(Transactions)
+	trans.Version = ptx.Version
 	trans.TxType = ptx.TxType
 	trans.Nonce = ptx.Nonce
 	trans.GasLimit = ptx.GasLimit
 	trans.GasPrice = ptx.GasPrice
 	trans.Payer = ptx.Payer.ToBase58()
 	trans.Payload = TransPayloadToHex(ptx.Payload)
+	trans.ValidUntilHeight = ptx.ValidUntilHeight
 
 	trans.Attributes = make([]TxAttributeInfo, 0)
 	trans.Sigs = []Sig{}
@@ -291,6 +488,62 @@ func GetBalance(address common.Address) (*BalanceOfRsp, error) {
 	}, nil
 }
 
+//GetBalanceAtHeight return the balance of address after block height, read from the archived storage
+func GetBalanceAtHeight(address common.Address, height uint32) (*BalanceOfRsp, error) {
+	ont, err := getBalanceAtHeight(utils.OntContractAddress, address, height)
+	if err != nil {
+		return nil, err
+	}
+	ong, err := getBalanceAtHeight(utils.OngContractAddress, address, height)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceOfRsp{
+		Ont: fmt.Sprintf("%d", ont),
+		Ong: fmt.Sprintf("%d", ong),
+	}, nil
+}
+
+//GetAssetBalance return the balance of address in an asset issued by the asset factory
+func GetAssetBalance(asset, address common.Address) (*AssetBalanceRsp, error) {
+	balance, err := GetContractBalance(0, asset, address)
+	if err != nil {
+		return nil, fmt.Errorf("get asset balance error:%s", err)
+	}
+	return &AssetBalanceRsp{
+		Asset:   asset.ToHexString(),
+		Balance: fmt.Sprintf("%d", balance),
+	}, nil
+}
+
+//GetAssetBalanceAtHeight return the asset balance of address after block height, read from the archived storage
+func GetAssetBalanceAtHeight(asset, address common.Address, height uint32) (*AssetBalanceRsp, error) {
+	balance, err := getBalanceAtHeight(asset, address, height)
+	if err != nil {
+		return nil, err
+	}
+	return &AssetBalanceRsp{
+		Asset:   asset.ToHexString(),
+		Balance: fmt.Sprintf("%d", balance),
+	}, nil
+}
+
+func getBalanceAtHeight(contractAddr, accAddr common.Address, height uint32) (uint64, error) {
+	return getUInt64AtHeight(contractAddr, accAddr[:], height)
+}
+
+//getUInt64AtHeight return the uint64 stored by a native contract under key after block height, 0 if not stored
+func getUInt64AtHeight(contractAddr common.Address, key []byte, height uint32) (uint64, error) {
+	value, err := bactor.GetStorageItemAtHeight(contractAddr, key, height)
+	if err == scom.ErrNotFound || (err == nil && value == nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return serialization.ReadUint64(bytes.NewBuffer(value))
+}
+
 func GetGrantOng(addr common.Address) (string, error) {
 	key := append([]byte(ont.UNBOUND_TIME_OFFSET), addr[:]...)
 	value, err := ledger.DefLedger.GetStorageItem(utils.OntContractAddress, key)
@@ -309,15 +562,26 @@ func GetGrantOng(addr common.Address) (string, error) {
 	return fmt.Sprintf("%v", boundong), nil
 }
 
-func GetAllowance(asset string, from, to common.Address) (string, error) {
-	var contractAddr common.Address
+//getAssetContract return the contract of asset, which is ont, ong or the address of an asset issued by the asset factory
+func getAssetContract(asset string) (common.Address, error) {
 	switch strings.ToLower(asset) {
 	case "ont":
-		contractAddr = utils.OntContractAddress
+		return utils.OntContractAddress, nil
 	case "ong":
-		contractAddr = utils.OngContractAddress
+		return utils.OngContractAddress, nil
 	default:
-		return "", fmt.Errorf("unsupport asset")
+		addr, err := GetAddress(asset)
+		if err != nil {
+			return common.ADDRESS_EMPTY, fmt.Errorf("unsupport asset")
+		}
+		return addr, nil
+	}
+}
+
+func GetAllowance(asset string, from, to common.Address) (string, error) {
+	contractAddr, err := getAssetContract(asset)
+	if err != nil {
+		return "", err
 	}
 	allowance, err := GetContractAllowance(0, contractAddr, from, to)
 	if err != nil {
@@ -326,6 +590,20 @@ func GetAllowance(asset string, from, to common.Address) (string, error) {
 	return fmt.Sprintf("%v", allowance), nil
 }
 
+//GetAllowanceAtHeight return the allowance of asset from one address to another after block height, read from the
+//archived storage
+func GetAllowanceAtHeight(asset string, from, to common.Address, height uint32) (string, error) {
+	contractAddr, err := getAssetContract(asset)
+	if err != nil {
+		return "", err
+	}
+	allowance, err := getUInt64AtHeight(contractAddr, append(from[:], to[:]...), height)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", allowance), nil
+}
+
 func GetContractBalance(cVersion byte, contractAddr, accAddr common.Address) (uint64, error) {
 	mutable, err := NewNativeInvokeTransaction(0, 0, contractAddr, cVersion, "balanceOf", []interface{}{accAddr[:]})
 	if err != nil {
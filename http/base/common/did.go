@@ -0,0 +1,334 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package common
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/serialization"
+	bactor "github.com/dnaproject2/DNA/http/base/actor"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/credential"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/ec"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const (
+	DID_CONTEXT = "https://www.w3.org/ns/did/v1"
+	// attributes with this key prefix are resolved as service endpoints,
+	// the rest of the key is the fragment of the service ID, the attribute
+	// type is the service type and the value is the endpoint
+	DID_SERVICE_PREFIX = "service:"
+)
+
+type DIDDocument struct {
+	Context            []string                `json:"@context"`
+	Id                 string                  `json:"id"`
+	Controller         []string                `json:"controller,omitempty"`
+	ControllerPolicy   *DIDGroupPolicy         `json:"controllerPolicy,omitempty"`
+	VerificationMethod []DIDVerificationMethod `json:"verificationMethod"`
+	Authentication     []string                `json:"authentication"`
+	Service            []DIDService            `json:"service,omitempty"`
+	Attributes         []DIDAttribute          `json:"attributes,omitempty"`
+	Recovery           string                  `json:"recovery,omitempty"`
+	RecoveryPolicy     *DIDGroupPolicy         `json:"recoveryPolicy,omitempty"`
+}
+
+type DIDVerificationMethod struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyHex string `json:"publicKeyHex"`
+}
+
+type DIDService struct {
+	Id              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+type DIDAttribute struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// DIDGroupPolicy is an m-of-n policy, members are DIDs or hex encoded
+// public keys.
+type DIDGroupPolicy struct {
+	Members   []string `json:"members"`
+	Threshold uint64   `json:"threshold"`
+}
+
+type CredentialInfo struct {
+	Id           string `json:"id"`
+	Issuer       string `json:"issuer"`
+	Holder       string `json:"holder"`
+	Status       string `json:"status"`
+	CommitHeight uint32 `json:"commitHeight"`
+	RevokeHeight uint32 `json:"revokeHeight,omitempty"`
+}
+
+// GetDIDDocument resolves a DNA ID to a W3C DID document, nil is returned if
+// the ID is not registered.
+func GetDIDDocument(did string) (*DIDDocument, error) {
+	if !account.VerifyID(did) {
+		return nil, fmt.Errorf("invalid DID %s", did)
+	}
+	ddo, err := preExecuteNative(utils.OntIDContractAddress, "getDDO", []interface{}{[]byte(did)})
+	if err != nil {
+		return nil, err
+	}
+	if len(ddo) == 0 {
+		return nil, nil
+	}
+	return ParseDIDDocument(did, ddo)
+}
+
+// ParseDIDDocument builds the DID document from the DDO returned by the
+// getDDO method of the DNA ID contract.
+func ParseDIDDocument(did string, ddo []byte) (*DIDDocument, error) {
+	doc := &DIDDocument{
+		Context:            []string{DID_CONTEXT},
+		Id:                 did,
+		VerificationMethod: []DIDVerificationMethod{},
+		Authentication:     []string{},
+	}
+	r := bytes.NewBuffer(ddo)
+	keys, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read public keys error: %s", err)
+	}
+	if err := doc.parseKeys(keys); err != nil {
+		return nil, err
+	}
+	attrs, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read attributes error: %s", err)
+	}
+	if err := doc.parseAttributes(attrs); err != nil {
+		return nil, err
+	}
+	recovery, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read recovery error: %s", err)
+	}
+	if len(recovery) > 0 {
+		addr, err := common.AddressParseFromBytes(recovery)
+		if err != nil {
+			return nil, fmt.Errorf("parse recovery error: %s", err)
+		}
+		doc.Recovery = addr.ToBase58()
+	}
+	if r.Len() == 0 {
+		return doc, nil
+	}
+	// controller and recovery group only exist for IDs which have them
+	controller, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read controller error: %s", err)
+	}
+	if doc.ControllerPolicy, err = parseGroupPolicy(controller); err != nil {
+		return nil, fmt.Errorf("parse controller error: %s", err)
+	}
+	if doc.ControllerPolicy != nil {
+		for _, m := range doc.ControllerPolicy.Members {
+			if account.VerifyID(m) {
+				doc.Controller = append(doc.Controller, m)
+			}
+		}
+	}
+	group, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, fmt.Errorf("read recovery group error: %s", err)
+	}
+	if doc.RecoveryPolicy, err = parseGroupPolicy(group); err != nil {
+		return nil, fmt.Errorf("parse recovery group error: %s", err)
+	}
+	return doc, nil
+}
+
+func (this *DIDDocument) parseKeys(data []byte) error {
+	r := bytes.NewBuffer(data)
+	for r.Len() > 0 {
+		index, err := serialization.ReadUint32(r)
+		if err != nil {
+			return fmt.Errorf("read key index error: %s", err)
+		}
+		key, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read public key error: %s", err)
+		}
+		pk, err := keypair.DeserializePublicKey(key)
+		if err != nil {
+			return fmt.Errorf("parse public key %d error: %s", index, err)
+		}
+		id := fmt.Sprintf("%s#keys-%d", this.Id, index)
+		this.VerificationMethod = append(this.VerificationMethod, DIDVerificationMethod{
+			Id:           id,
+			Type:         verificationMethodType(pk),
+			Controller:   this.Id,
+			PublicKeyHex: hex.EncodeToString(key),
+		})
+		this.Authentication = append(this.Authentication, id)
+	}
+	return nil
+}
+
+func (this *DIDDocument) parseAttributes(data []byte) error {
+	r := bytes.NewBuffer(data)
+	for r.Len() > 0 {
+		key, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read attribute key error: %s", err)
+		}
+		valueType, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read attribute type error: %s", err)
+		}
+		value, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return fmt.Errorf("read attribute value error: %s", err)
+		}
+		if strings.HasPrefix(string(key), DID_SERVICE_PREFIX) {
+			this.Service = append(this.Service, DIDService{
+				Id:              this.Id + "#" + strings.TrimPrefix(string(key), DID_SERVICE_PREFIX),
+				Type:            string(valueType),
+				ServiceEndpoint: string(value),
+			})
+			continue
+		}
+		this.Attributes = append(this.Attributes, DIDAttribute{
+			Key:   string(key),
+			Type:  string(valueType),
+			Value: string(value),
+		})
+	}
+	return nil
+}
+
+func verificationMethodType(pk keypair.PublicKey) string {
+	switch keypair.GetKeyType(pk) {
+	case keypair.PK_ECDSA:
+		if key, ok := pk.(*ec.PublicKey); ok && key.Curve == elliptic.P256() {
+			return "EcdsaSecp256r1VerificationKey2019"
+		}
+		return "EcdsaVerificationKey2019"
+	case keypair.PK_SM2:
+		return "SM2VerificationKey2019"
+	case keypair.PK_EDDSA:
+		return "Ed25519VerificationKey2018"
+	}
+	return "UnknownVerificationKey"
+}
+
+func parseGroupPolicy(data []byte) (*DIDGroupPolicy, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	r := bytes.NewBuffer(data)
+	n, err := utils.ReadVarUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	policy := &DIDGroupPolicy{Members: make([]string, 0, n)}
+	for i := uint64(0); i < n; i++ {
+		m, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		if account.VerifyID(string(m)) {
+			policy.Members = append(policy.Members, string(m))
+		} else {
+			policy.Members = append(policy.Members, hex.EncodeToString(m))
+		}
+	}
+	if policy.Threshold, err = utils.ReadVarUint(r); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetCredential queries the credential registry by the credential ID and
+// its issuer, nil is returned if the credential has never been committed.
+func GetCredential(id []byte, issuer string) (*CredentialInfo, error) {
+	if !account.VerifyID(issuer) {
+		return nil, fmt.Errorf("invalid issuer %s", issuer)
+	}
+	data, err := preExecuteNative(utils.CredentialContractAddress, credential.GET_CREDENTIAL,
+		[]interface{}{id, []byte(issuer)})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	cred := new(credential.Credential)
+	if err := cred.Deserialize(bytes.NewBuffer(data)); err != nil {
+		return nil, fmt.Errorf("deserialize credential error:%s", err)
+	}
+	info := &CredentialInfo{
+		Id:           hex.EncodeToString(id),
+		Issuer:       string(cred.Issuer),
+		Holder:       string(cred.Holder),
+		CommitHeight: cred.CommitHeight,
+		RevokeHeight: cred.RevokeHeight,
+	}
+	switch cred.Status {
+	case credential.STATUS_COMMITTED:
+		info.Status = "committed"
+	case credential.STATUS_REVOKED:
+		info.Status = "revoked"
+	default:
+		info.Status = "unknown"
+	}
+	return info, nil
+}
+
+func preExecuteNative(contract common.Address, method string, params []interface{}) ([]byte, error) {
+	mutable, err := NewNativeInvokeTransaction(0, 0, contract, 0, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("NewNativeInvokeTransaction error:%s", err)
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bactor.PreExecuteContract(tx)
+	if err != nil {
+		return nil, fmt.Errorf("PrepareInvokeContract error:%s", err)
+	}
+	if result.State == 0 {
+		return nil, fmt.Errorf("prepare invoke failed")
+	}
+	data, err := hex.DecodeString(result.Result.(string))
+	if err != nil {
+		return nil, fmt.Errorf("hex.DecodeString error:%s", err)
+	}
+	return data, nil
+}
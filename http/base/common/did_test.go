@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDIDDocument(t *testing.T) {
+	did, err := account.GenerateID()
+	assert.Nil(t, err)
+	member, err := account.GenerateID()
+	assert.Nil(t, err)
+	_, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	pk := keypair.SerializePublicKey(pub)
+
+	var keys, attrs, group, ddo bytes.Buffer
+	serialization.WriteUint32(&keys, 2)
+	serialization.WriteVarBytes(&keys, pk)
+	for _, v := range []string{"service:hub", "IdentityHub", "https://hub.example.com", "name", "string", "alice"} {
+		serialization.WriteVarBytes(&attrs, []byte(v))
+	}
+	utils.WriteVarUint(&group, 2)
+	serialization.WriteVarBytes(&group, pk)
+	serialization.WriteVarBytes(&group, []byte(member))
+	utils.WriteVarUint(&group, 1)
+	recovery := common.Address{1}
+
+	serialization.WriteVarBytes(&ddo, keys.Bytes())
+	serialization.WriteVarBytes(&ddo, attrs.Bytes())
+	serialization.WriteVarBytes(&ddo, recovery[:])
+	serialization.WriteVarBytes(&ddo, group.Bytes())
+	serialization.WriteVarBytes(&ddo, []byte{})
+
+	doc, err := ParseDIDDocument(did, ddo.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{DID_CONTEXT}, doc.Context)
+	assert.Equal(t, []DIDVerificationMethod{{
+		Id:           did + "#keys-2",
+		Type:         "EcdsaSecp256r1VerificationKey2019",
+		Controller:   did,
+		PublicKeyHex: hex.EncodeToString(pk),
+	}}, doc.VerificationMethod)
+	assert.Equal(t, []string{did + "#keys-2"}, doc.Authentication)
+	assert.Equal(t, []DIDService{{Id: did + "#hub", Type: "IdentityHub", ServiceEndpoint: "https://hub.example.com"}}, doc.Service)
+	assert.Equal(t, []DIDAttribute{{Key: "name", Type: "string", Value: "alice"}}, doc.Attributes)
+	assert.Equal(t, recovery.ToBase58(), doc.Recovery)
+	assert.Equal(t, []string{member}, doc.Controller)
+	assert.Equal(t, &DIDGroupPolicy{Members: []string{hex.EncodeToString(pk), member}, Threshold: 1}, doc.ControllerPolicy)
+	assert.Nil(t, doc.RecoveryPolicy)
+
+	// IDs without controller and recovery group
+	doc, err = ParseDIDDocument(did, ddo.Bytes()[:ddo.Len()-group.Len()-2])
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Controller)
+	assert.Nil(t, doc.ControllerPolicy)
+}
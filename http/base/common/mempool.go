@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/types"
+	neovms "github.com/dnaproject2/DNA/smartcontract/service/neovm"
+	"github.com/dnaproject2/DNA/smartcontract/states"
+	tcomn "github.com/dnaproject2/DNA/txnpool/common"
+	"github.com/dnaproject2/DNA/vm/neovm"
+	vmutils "github.com/dnaproject2/DNA/vm/neovm/utils"
+)
+
+const (
+	DEFAULT_MEMPOOL_PAGE_SIZE = 100  //the number of transactions listed in a page of mempool by default
+	MAX_MEMPOOL_PAGE_SIZE     = 1000 //the max number of transactions listed in a page of mempool
+)
+
+//MemPoolFilter selects the transactions listed in mempool, nil fields match everything
+type MemPoolFilter struct {
+	Payer    *common.Address
+	Contract *common.Address
+}
+
+type MemPoolTxInfo struct {
+	Hash      string
+	TxType    types.TransactionType
+	Payer     string
+	Nonce     uint32
+	GasPrice  uint64
+	GasLimit  uint64
+	Size      int
+	Age       uint64   //seconds since the transaction entered the pool
+	Contracts []string //hex addresses of the contracts deployed or invoked directly
+	Verifying bool     //whether the transaction is being re-verified
+	State     []TXNAttrInfo
+}
+
+type MemPoolInfo struct {
+	Total int //the number of transactions matching the filter
+	Txs   []*MemPoolTxInfo
+}
+
+//ListMemPool filters the transactions of txpool and returns the page from offset
+func ListMemPool(entries []*tcomn.PoolTxEntry, filter *MemPoolFilter, offset, limit int, now time.Time) *MemPoolInfo {
+	info := &MemPoolInfo{Txs: make([]*MemPoolTxInfo, 0)}
+	for _, entry := range entries {
+		tx := entry.Tx
+		if filter.Payer != nil && tx.Payer != *filter.Payer {
+			continue
+		}
+		contracts := TxContracts(tx)
+		if filter.Contract != nil && !containsAddress(contracts, *filter.Contract) {
+			continue
+		}
+		info.Total++
+		if info.Total <= offset || len(info.Txs) >= limit {
+			continue
+		}
+
+		hash := tx.Hash()
+		txInfo := &MemPoolTxInfo{
+			Hash:      hash.ToHexString(),
+			TxType:    tx.TxType,
+			Payer:     tx.Payer.ToBase58(),
+			Nonce:     tx.Nonce,
+			GasPrice:  tx.GasPrice,
+			GasLimit:  tx.GasLimit,
+			Size:      len(tx.Raw),
+			Contracts: make([]string, 0, len(contracts)),
+			Verifying: entry.Verifying,
+			State:     []TXNAttrInfo{},
+		}
+		if !entry.Time.IsZero() && now.After(entry.Time) {
+			txInfo.Age = uint64(now.Sub(entry.Time) / time.Second)
+		}
+		for _, addr := range contracts {
+			txInfo.Contracts = append(txInfo.Contracts, addr.ToHexString())
+		}
+		for _, t := range entry.Attrs {
+			txInfo.State = append(txInfo.State, TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
+		}
+		info.Txs = append(info.Txs, txInfo)
+	}
+	return info
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+//TxContracts returns the contract deployed by a transaction, or the contracts
+//it invokes directly, the contracts called by them are not included
+func TxContracts(tx *types.Transaction) []common.Address {
+	switch pl := tx.Payload.(type) {
+	case *payload.DeployCode:
+		return []common.Address{pl.Address()}
+	case *payload.InvokeCode:
+		if tx.TxType == types.InvokeWasm {
+			param := new(states.ContractInvokeParam)
+			if err := param.Deserialize(bytes.NewBuffer(pl.Code)); err != nil {
+				return nil
+			}
+			return []common.Address{param.Address}
+		}
+		return neovmInvokedContracts(pl.Code)
+	}
+	return nil
+}
+
+//neovmInvokedContracts scans the invoke code for the contracts called by
+//APPCALL and TAILCALL, and the native contracts invoked by the native invoke
+//syscall, whose address is the last 20 bytes pushed before the version
+func neovmInvokedContracts(code []byte) []common.Address {
+	contracts := make([]common.Address, 0)
+	add := func(buf []byte) {
+		addr, err := common.AddressParseFromBytes(buf)
+		if err == nil && !containsAddress(contracts, addr) {
+			contracts = append(contracts, addr)
+		}
+	}
+
+	reader := vmutils.NewVmReader(code)
+	var lastAddr []byte
+	for reader.Length() > 0 {
+		b, err := reader.ReadByte()
+		if err != nil {
+			break
+		}
+		op := neovm.OpCode(b)
+		var data []byte
+		switch {
+		case op >= neovm.PUSHBYTES1 && op <= neovm.PUSHBYTES75:
+			data, err = reader.ReadBytes(int(op))
+		case op == neovm.PUSHDATA1:
+			var n byte
+			if n, err = reader.ReadByte(); err == nil {
+				data, err = reader.ReadBytes(int(n))
+			}
+		case op == neovm.PUSHDATA2:
+			var n uint16
+			if n, err = reader.ReadUint16(); err == nil {
+				data, err = reader.ReadBytes(int(n))
+			}
+		case op == neovm.PUSHDATA4:
+			var n uint32
+			if n, err = reader.ReadUint32(); err == nil {
+				data, err = reader.ReadBytes(int(n))
+			}
+		case op == neovm.JMP || op == neovm.JMPIF || op == neovm.JMPIFNOT || op == neovm.CALL:
+			_, err = reader.ReadInt16()
+		case op == neovm.APPCALL || op == neovm.TAILCALL:
+			if data, err = reader.ReadBytes(common.ADDR_LEN); err == nil {
+				if !bytes.Equal(data, neovms.BYTE_ZERO_20) {
+					add(data)
+				} else if lastAddr != nil {
+					//dynamic call to the address on the stack
+					add(lastAddr)
+				}
+				data = nil
+			}
+		case op == neovm.SYSCALL:
+			var name string
+			name, err = reader.ReadVarString(neovm.MAX_BYTEARRAY_SIZE)
+			if err == nil && name == neovms.NATIVE_INVOKE_NAME && lastAddr != nil {
+				add(lastAddr)
+			}
+		}
+		if err != nil {
+			break
+		}
+		if len(data) == common.ADDR_LEN {
+			lastAddr = data
+		}
+	}
+	return contracts
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	tcomn "github.com/dnaproject2/DNA/txnpool/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListMemPool(t *testing.T) {
+	from, to := common.Address{1}, common.Address{2}
+	neovmContract := common.Address{3}
+
+	mutable, err := NewNativeInvokeTransaction(500, 20000, utils.OntContractAddress, 0, "transfer",
+		[]interface{}{[]*ont.State{{From: from, To: to, Value: 1}}})
+	assert.Nil(t, err)
+	mutable.Payer = from
+	nativeTx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{utils.OntContractAddress}, TxContracts(nativeTx))
+
+	mutable, err = NewNeovmInvokeTransaction(600, 20000, neovmContract, []interface{}{"put", []interface{}{to[:]}})
+	assert.Nil(t, err)
+	mutable.Payer = to
+	neovmTx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{neovmContract}, TxContracts(neovmTx))
+
+	now := time.Now()
+	entries := []*tcomn.PoolTxEntry{
+		{Tx: neovmTx, Time: now.Add(-time.Minute), Attrs: []*tcomn.TXAttr{{Height: 10}}},
+		{Tx: nativeTx, Time: now.Add(-time.Second), Verifying: true},
+	}
+
+	info := ListMemPool(entries, &MemPoolFilter{}, 0, 10, now)
+	assert.Equal(t, 2, info.Total)
+	assert.Equal(t, 2, len(info.Txs))
+	assert.Equal(t, uint64(60), info.Txs[0].Age)
+	assert.Equal(t, len(neovmTx.Raw), info.Txs[0].Size)
+	assert.Equal(t, []TXNAttrInfo{{Height: 10}}, info.Txs[0].State)
+	assert.True(t, info.Txs[1].Verifying)
+
+	info = ListMemPool(entries, &MemPoolFilter{Payer: &from}, 0, 10, now)
+	assert.Equal(t, 1, info.Total)
+	hash := nativeTx.Hash()
+	assert.Equal(t, hash.ToHexString(), info.Txs[0].Hash)
+
+	info = ListMemPool(entries, &MemPoolFilter{Contract: &neovmContract}, 0, 10, now)
+	assert.Equal(t, 1, info.Total)
+	assert.Equal(t, []string{neovmContract.ToHexString()}, info.Txs[0].Contracts)
+
+	info = ListMemPool(entries, &MemPoolFilter{}, 1, 10, now)
+	assert.Equal(t, 2, info.Total)
+	assert.Equal(t, 1, len(info.Txs))
+	assert.Equal(t, hash.ToHexString(), info.Txs[0].Hash)
+
+	info = ListMemPool(entries, &MemPoolFilter{}, 0, 1, now)
+	assert.Equal(t, 2, info.Total)
+	assert.Equal(t, 1, len(info.Txs))
+}
@@ -20,6 +20,7 @@ package common
 import (
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/ontio/ontology-crypto/keypair"
 )
@@ -42,6 +43,20 @@ type DeployCodeInfo struct {
 	Author      string
 	Email       string
 	Description string
+	Abi         string
+}
+
+type ContractUpgradeInfo struct {
+	From   string
+	To     string
+	Height uint32
+	TxHash string
+}
+
+type ContractStateInfo struct {
+	DeployCodeInfo
+	Successor string
+	Upgrades  []ContractUpgradeInfo
 }
 
 type RecordInfo struct {
@@ -106,7 +121,31 @@ func TransPayloadToHex(p types.Payload) PayloadInfo {
 		obj.Author = object.Author
 		obj.Email = object.Email
 		obj.Description = object.Description
+		obj.Abi = object.Abi
 		return obj
 	}
 	return nil
 }
+
+//TransContractState return the contract with its upgrade history, info is nil if the contract is never upgraded
+func TransContractState(contract *payload.DeployCode, info *states.ContractUpgradeInfo) *ContractStateInfo {
+	obj := &ContractStateInfo{
+		DeployCodeInfo: *TransPayloadToHex(contract).(*DeployCodeInfo),
+		Upgrades:       make([]ContractUpgradeInfo, 0),
+	}
+	if info == nil {
+		return obj
+	}
+	if info.Successor != common.ADDRESS_EMPTY {
+		obj.Successor = info.Successor.ToHexString()
+	}
+	for _, upgrade := range info.History {
+		obj.Upgrades = append(obj.Upgrades, ContractUpgradeInfo{
+			From:   upgrade.From.ToHexString(),
+			To:     upgrade.To.ToHexString(),
+			Height: upgrade.Height,
+			TxHash: upgrade.TxHash.ToHexString(),
+		})
+	}
+	return obj
+}
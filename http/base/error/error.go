@@ -39,12 +39,45 @@ const (
 	UNKNOWN_ASSET       int64 = 44002
 	UNKNOWN_BLOCK       int64 = 44003
 	UNKNOWN_CONTRACT    int64 = 44004
+	UNKNOWN_STATE       int64 = 44005
 
 	INTERNAL_ERROR  int64 = 45001
 	SMARTCODE_ERROR int64 = 47001
 	PRE_EXEC_ERROR  int64 = 47002
 )
 
+//standard JSON-RPC 2.0 error codes
+const (
+	RPC_PARSE_ERROR      int64 = -32700
+	RPC_INVALID_REQUEST  int64 = -32600
+	RPC_METHOD_NOT_FOUND int64 = -32601
+	RPC_INVALID_PARAMS   int64 = -32602
+	RPC_INTERNAL_ERROR   int64 = -32603
+)
+
+//RpcErrMap contains the messages of the standard JSON-RPC 2.0 error codes
+var RpcErrMap = map[int64]string{
+	RPC_PARSE_ERROR:      "Parse error",
+	RPC_INVALID_REQUEST:  "Invalid Request",
+	RPC_METHOD_NOT_FOUND: "Method not found",
+	RPC_INVALID_PARAMS:   "Invalid params",
+	RPC_INTERNAL_ERROR:   "Internal error",
+}
+
+//RpcErrCode maps an http error code to the JSON-RPC 2.0 error code reported for it.
+//Codes without a standard equivalent are reported unchanged
+func RpcErrCode(errcode int64) int64 {
+	switch errcode {
+	case INVALID_METHOD:
+		return RPC_METHOD_NOT_FOUND
+	case INVALID_PARAMS:
+		return RPC_INVALID_PARAMS
+	case INTERNAL_ERROR:
+		return RPC_INTERNAL_ERROR
+	}
+	return errcode
+}
+
 var ErrMap = map[int64]string{
 	SUCCESS:            "SUCCESS",
 	SESSION_EXPIRED:    "SESSION EXPIRED",
@@ -63,6 +96,7 @@ var ErrMap = map[int64]string{
 	UNKNOWN_ASSET:       "UNKNOWN ASSET",
 	UNKNOWN_BLOCK:       "UNKNOWN BLOCK",
 	UNKNOWN_CONTRACT:    "UNKNOWN CONTRACT",
+	UNKNOWN_STATE:       "UNKNOWN STATE, NOT ARCHIVED",
 
 	INTERNAL_ERROR:                           "INTERNAL ERROR",
 	SMARTCODE_ERROR:                          "SMARTCODE EXEC ERROR",
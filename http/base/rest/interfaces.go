@@ -20,6 +20,8 @@ package rest
 
 import (
 	"bytes"
+	"encoding/hex"
+	"github.com/dnaproject2/DNA/account"
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
@@ -262,7 +264,7 @@ func SendRawTransaction(cmd map[string]interface{}) map[string]interface{} {
 	var hash common.Uint256
 	hash = txn.Hash()
 	log.Debugf("SendRawTransaction recv %s", hash.ToHexString())
-	if txn.TxType == types.Invoke || txn.TxType == types.Deploy {
+	if txn.TxType == types.Invoke || txn.TxType == types.InvokeWasm || txn.TxType == types.Deploy {
 		if preExec, ok := cmd["PreExec"].(string); ok && preExec == "1" {
 			rst, err := bactor.PreExecuteContract(txn)
 			if err != nil {
@@ -287,6 +289,47 @@ func SendRawTransaction(cmd map[string]interface{}) map[string]interface{} {
 	return resp
 }
 
+//simulate a transaction on the current state or the state after a block height
+func SimulateTransaction(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+
+	str, ok := cmd["Data"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	bys, err := common.HexToBytes(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	txn, err := types.TransactionFromRawBytes(bys)
+	if err != nil {
+		return ResponsePack(berr.INVALID_TRANSACTION)
+	}
+	if txn.TxType != types.Invoke && txn.TxType != types.InvokeWasm && txn.TxType != types.Deploy {
+		return ResponsePack(berr.INVALID_TRANSACTION)
+	}
+	height := bactor.GetCurrentBlockHeight()
+	if cmd["Height"] != nil {
+		h, ok := cmd["Height"].(float64)
+		if !ok || h < 0 || h != float64(uint32(h)) {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		height = uint32(h)
+	}
+	result, err := bactor.SimulateTransaction(txn, height)
+	if err != nil {
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
+		log.Infof("SimulateTransaction: %s", err)
+		resp = ResponsePack(berr.SMARTCODE_ERROR)
+		resp["Result"] = err.Error()
+		return resp
+	}
+	resp["Result"] = bcomn.ConvertSimulateResult(result)
+	return resp
+}
+
 //get smartcontract event by height
 func GetSmartCodeEventTxsByHeight(cmd map[string]interface{}) map[string]interface{} {
 	resp := ResponsePack(berr.SUCCESS)
@@ -374,7 +417,11 @@ func GetContractState(cmd map[string]interface{}) map[string]interface{} {
 		resp["Result"] = common.ToHexString(w.Bytes())
 		return resp
 	}
-	resp["Result"] = bcomn.TransPayloadToHex(contract)
+	info, err := bactor.GetContractUpgradeInfo(contract.Address())
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = bcomn.TransContractState(contract, info)
 	return resp
 }
 
@@ -394,11 +441,23 @@ func GetStorage(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	value, err := bactor.GetStorageItem(address, item)
+	var value []byte
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, e := strconv.ParseUint(param, 10, 32)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		value, err = bactor.GetStorageItemAtHeight(address, item, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, item)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return ResponsePack(berr.SUCCESS)
 		}
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
 		return ResponsePack(berr.INTERNAL_ERROR)
 	}
 	resp["Result"] = common.ToHexString(value)
@@ -416,7 +475,33 @@ func GetBalance(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	balance, err := bcomn.GetBalance(address)
+	var asset *common.Address
+	if param, ok := cmd["Asset"].(string); ok && len(param) > 0 {
+		addr, e := bcomn.GetAddress(param)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		asset = &addr
+	}
+	var balance interface{}
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, e := strconv.ParseUint(param, 10, 32)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		if asset != nil {
+			balance, err = bcomn.GetAssetBalanceAtHeight(*asset, address, uint32(height))
+		} else {
+			balance, err = bcomn.GetBalanceAtHeight(address, uint32(height))
+		}
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
+	} else if asset != nil {
+		balance, err = bcomn.GetAssetBalance(*asset, address)
+	} else {
+		balance, err = bcomn.GetBalance(address)
+	}
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
@@ -499,7 +584,19 @@ func GetAllowance(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	rsp, err := bcomn.GetAllowance(asset, fromAddr, toAddr)
+	var rsp string
+	if param, ok := cmd["Height"].(string); ok && len(param) > 0 {
+		height, e := strconv.ParseUint(param, 10, 32)
+		if e != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+		rsp, err = bcomn.GetAllowanceAtHeight(asset, fromAddr, toAddr, uint32(height))
+		if err == scom.ErrNotArchived {
+			return ResponsePack(berr.UNKNOWN_STATE)
+		}
+	} else {
+		rsp, err = bcomn.GetAllowance(asset, fromAddr, toAddr)
+	}
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
@@ -569,14 +666,56 @@ func GetMemPoolTxState(cmd map[string]interface{}) map[string]interface{} {
 	if err != nil {
 		return ResponsePack(berr.INVALID_PARAMS)
 	}
-	txEntry, err := bactor.GetTxFromPool(hash)
+	txStatus, err := bactor.GetTxStatusFromPool(hash)
 	if err != nil {
 		return ResponsePack(berr.UNKNOWN_TRANSACTION)
 	}
-	attrs := []bcomn.TXNAttrInfo{}
-	for _, t := range txEntry.Attrs {
-		attrs = append(attrs, bcomn.TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
+	resp["Result"] = bcomn.ConvertTxStatus(txStatus)
+	return resp
+}
+
+//get W3C DID document of a DNA ID
+func GetDIDDocument(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	did, ok := cmd["DID"].(string)
+	if !ok || !account.VerifyID(did) {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	doc, err := bcomn.GetDIDDocument(did)
+	if err != nil {
+		log.Infof("GetDIDDocument: %s", err)
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	if doc == nil {
+		return ResponsePack(berr.UNKNOWN_STATE)
+	}
+	resp["Result"] = doc
+	return resp
+}
+
+//get credential record by credential ID and issuer
+func GetCredential(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Id"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	id, err := hex.DecodeString(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	issuer, ok := cmd["Issuer"].(string)
+	if !ok || !account.VerifyID(issuer) {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetCredential(id, issuer)
+	if err != nil {
+		log.Infof("GetCredential: %s", err)
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	if info == nil {
+		return ResponsePack(berr.UNKNOWN_STATE)
 	}
-	resp["Result"] = bcomn.TXNEntryInfo{attrs}
+	resp["Result"] = info
 	return resp
 }
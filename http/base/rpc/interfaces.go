@@ -21,6 +21,7 @@ package rpc
 import (
 	"bytes"
 	"encoding/hex"
+	"github.com/dnaproject2/DNA/account"
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
@@ -32,6 +33,7 @@ import (
 	bcomn "github.com/dnaproject2/DNA/http/base/common"
 	berr "github.com/dnaproject2/DNA/http/base/error"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"time"
 )
 
 //get best block hash
@@ -112,6 +114,27 @@ func GetBlockHash(params []interface{}) map[string]interface{} {
 	}
 }
 
+//get state merkle root by height
+func GetStateMerkleRoot(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	switch params[0].(type) {
+	case float64:
+		height := uint32(params[0].(float64))
+		if height > bactor.GetCurrentBlockHeight() {
+			return responsePack(berr.UNKNOWN_BLOCK, "")
+		}
+		root, err := bactor.GetStateMerkleRoot(height)
+		if err != nil {
+			return responsePack(berr.UNKNOWN_BLOCK, "")
+		}
+		return responseSuccess(root.ToHexString())
+	default:
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+}
+
 //get node connection count
 func GetConnectionCount(params []interface{}) map[string]interface{} {
 	count, err := bactor.GetConnectionCnt()
@@ -122,16 +145,59 @@ func GetConnectionCount(params []interface{}) map[string]interface{} {
 	return responseSuccess(count)
 }
 
+// list the transactions in memory pool, the params are all optional:
+// payer address in base58, contract address in hex, offset and limit of the page.
+// An empty address matches everything.
+// A JSON example for getrawmempool method as following:
+//   {"jsonrpc": "2.0", "method": "getrawmempool", "params": ["", "0100000000000000000000000000000000000000", 0, 100], "id": 0}
 func GetRawMemPool(params []interface{}) map[string]interface{} {
-	txs := []*bcomn.Transactions{}
-	txpool := bactor.GetTxsFromPool(false)
-	for _, t := range txpool {
-		txs = append(txs, bcomn.TransArryByteToHexString(t))
+	filter := &bcomn.MemPoolFilter{}
+	offset, limit := 0, bcomn.DEFAULT_MEMPOOL_PAGE_SIZE
+	if len(params) > 0 {
+		str, ok := params[0].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if str != "" {
+			payer, err := common.AddressFromBase58(str)
+			if err != nil {
+				return responsePack(berr.INVALID_PARAMS, "")
+			}
+			filter.Payer = &payer
+		}
 	}
-	if len(txs) == 0 {
-		return responsePack(berr.INVALID_PARAMS, nil)
+	if len(params) > 1 {
+		str, ok := params[1].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if str != "" {
+			contract, err := common.AddressFromHexString(str)
+			if err != nil {
+				return responsePack(berr.INVALID_PARAMS, "")
+			}
+			filter.Contract = &contract
+		}
+	}
+	if len(params) > 2 {
+		n, ok := params[2].(float64)
+		if !ok || n < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		offset = int(n)
+	}
+	if len(params) > 3 {
+		n, ok := params[3].(float64)
+		if !ok || n <= 0 || n > bcomn.MAX_MEMPOOL_PAGE_SIZE {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		limit = int(n)
+	}
+	txs, err := bactor.GetTxListFromPool()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, nil)
 	}
-	return responseSuccess(txs)
+	return responseSuccess(bcomn.ListMemPool(txs, filter, offset, limit, time.Now()))
 }
 
 //get memory pool transaction count
@@ -155,16 +221,11 @@ func GetMemPoolTxState(params []interface{}) map[string]interface{} {
 		if err != nil {
 			return responsePack(berr.INVALID_PARAMS, "")
 		}
-		txEntry, err := bactor.GetTxFromPool(hash)
+		txStatus, err := bactor.GetTxStatusFromPool(hash)
 		if err != nil {
 			return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
 		}
-		attrs := []bcomn.TXNAttrInfo{}
-		for _, t := range txEntry.Attrs {
-			attrs = append(attrs, bcomn.TXNAttrInfo{t.Height, int(t.Type), int(t.ErrCode)})
-		}
-		info := bcomn.TXNEntryInfo{attrs}
-		return responseSuccess(info)
+		return responseSuccess(bcomn.ConvertTxStatus(txStatus))
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
@@ -214,8 +275,8 @@ func GetRawTransaction(params []interface{}) map[string]interface{} {
 	return responseSuccess(common.ToHexString(w.Bytes()))
 }
 
-//get storage from contract
-//   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key"], "id": 0}
+//get storage from contract, at the optional block height in archive mode
+//   {"jsonrpc": "2.0", "method": "getstorage", "params": ["code hash", "key", height], "id": 0}
 func GetStorage(params []interface{}) map[string]interface{} {
 	if len(params) < 2 {
 		return responsePack(berr.INVALID_PARAMS, nil)
@@ -246,11 +307,24 @@ func GetStorage(params []interface{}) map[string]interface{} {
 	default:
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	value, err := bactor.GetStorageItem(address, key)
+	var value []byte
+	var err error
+	if len(params) > 2 {
+		height, ok := params[2].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		value, err = bactor.GetStorageItemAtHeight(address, key, uint32(height))
+	} else {
+		value, err = bactor.GetStorageItem(address, key)
+	}
 	if err != nil {
 		if err == scom.ErrNotFound {
 			return responseSuccess(nil)
 		}
+		if err == scom.ErrNotArchived {
+			return responsePack(berr.UNKNOWN_STATE, "")
+		}
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responseSuccess(common.ToHexString(value))
@@ -277,7 +351,7 @@ func SendRawTransaction(params []interface{}) map[string]interface{} {
 		}
 		hash = txn.Hash()
 		log.Debugf("SendRawTransaction recv %s", hash.ToHexString())
-		if txn.TxType == types.Invoke || txn.TxType == types.Deploy {
+		if txn.TxType == types.Invoke || txn.TxType == types.InvokeWasm || txn.TxType == types.Deploy {
 			if len(params) > 1 {
 				preExec, ok := params[1].(float64)
 				if ok && preExec == 1 {
@@ -303,6 +377,80 @@ func SendRawTransaction(params []interface{}) map[string]interface{} {
 	return responseSuccess(hash.ToHexString())
 }
 
+//simulate a transaction on the current state or the state after a block height
+//   {"jsonrpc": "2.0", "method": "simulatetransaction", "params": ["raw transactioin in hex", height], "id": 0}
+func SimulateTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	raw, err := common.HexToBytes(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	txn, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		return responsePack(berr.INVALID_TRANSACTION, "")
+	}
+	if txn.TxType != types.Invoke && txn.TxType != types.InvokeWasm && txn.TxType != types.Deploy {
+		return responsePack(berr.INVALID_TRANSACTION, "")
+	}
+	height := bactor.GetCurrentBlockHeight()
+	if len(params) > 1 {
+		h, ok := params[1].(float64)
+		if !ok || h < 0 || h != float64(uint32(h)) {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		height = uint32(h)
+	}
+	result, err := bactor.SimulateTransaction(txn, height)
+	if err != nil {
+		if err == scom.ErrNotArchived {
+			return responsePack(berr.UNKNOWN_STATE, "")
+		}
+		log.Infof("SimulateTransaction: %s", err)
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.ConvertSimulateResult(result))
+}
+
+//re-execute a committed transaction on the state of its parent block and trace the NeoVM opcodes,
+//the evaluation stacks are recorded if the second param is 1. Only served to the local rpc calls
+//   {"jsonrpc": "2.0", "method": "tracetransaction", "params": ["transaction hash in hex", 1], "id": 0}
+func TraceTransaction(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	withStack := false
+	if len(params) > 1 {
+		flag, ok := params[1].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		withStack = flag == 1
+	}
+	result, err := bactor.TraceTransaction(hash, withStack)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return responsePack(berr.UNKNOWN_TRANSACTION, "")
+		}
+		log.Infof("TraceTransaction: %s", err)
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.ConvertTraceResult(result))
+}
+
 //get node version
 func GetNodeVersion(params []interface{}) map[string]interface{} {
 	return responseSuccess(config.Version)
@@ -339,7 +487,11 @@ func GetContractState(params []interface{}) map[string]interface{} {
 		case float64:
 			json := uint32(params[1].(float64))
 			if json == 1 {
-				return responseSuccess(bcomn.TransPayloadToHex(contract))
+				info, err := bactor.GetContractUpgradeInfo(contract.Address())
+				if err != nil {
+					return responsePack(berr.INTERNAL_ERROR, "")
+				}
+				return responseSuccess(bcomn.TransContractState(contract, info))
 			}
 		default:
 			return responsePack(berr.INVALID_PARAMS, "")
@@ -423,7 +575,7 @@ func GetBlockHeightByTxHash(params []interface{}) map[string]interface{} {
 	return responsePack(berr.INVALID_PARAMS, "")
 }
 
-//get balance of address
+//get balance of address, at the optional block height in archive mode
 func GetBalance(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -436,14 +588,45 @@ func GetBalance(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	rsp, err := bcomn.GetBalance(address)
+	// an optional asset address issued by the asset factory may come before the height
+	params = params[1:]
+	var asset *common.Address
+	if len(params) > 0 {
+		if str, ok := params[0].(string); ok {
+			addr, err := bcomn.GetAddress(str)
+			if err != nil {
+				return responsePack(berr.INVALID_PARAMS, "")
+			}
+			asset = &addr
+			params = params[1:]
+		}
+	}
+	var rsp interface{}
+	if len(params) > 0 {
+		height, ok := params[0].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		if asset != nil {
+			rsp, err = bcomn.GetAssetBalanceAtHeight(*asset, address, uint32(height))
+		} else {
+			rsp, err = bcomn.GetBalanceAtHeight(address, uint32(height))
+		}
+		if err == scom.ErrNotArchived {
+			return responsePack(berr.UNKNOWN_STATE, "")
+		}
+	} else if asset != nil {
+		rsp, err = bcomn.GetAssetBalance(*asset, address)
+	} else {
+		rsp, err = bcomn.GetBalance(address)
+	}
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
 	return responseSuccess(rsp)
 }
 
-//get allowance
+//get allowance, at the optional block height in archive mode
 func GetAllowance(params []interface{}) map[string]interface{} {
 	if len(params) < 3 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -468,7 +651,19 @@ func GetAllowance(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
-	rsp, err := bcomn.GetAllowance(asset, fromAddr, toAddr)
+	var rsp string
+	if len(params) > 3 {
+		height, ok := params[3].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		rsp, err = bcomn.GetAllowanceAtHeight(asset, fromAddr, toAddr, uint32(height))
+		if err == scom.ErrNotArchived {
+			return responsePack(berr.UNKNOWN_STATE, "")
+		}
+	} else {
+		rsp, err = bcomn.GetAllowance(asset, fromAddr, toAddr)
+	}
 	if err != nil {
 		return responsePack(berr.INVALID_PARAMS, "")
 	}
@@ -585,3 +780,150 @@ func GetGrantOng(params []interface{}) map[string]interface{} {
 	}
 	return responseSuccess(rsp)
 }
+
+// get W3C DID document of a DNA ID
+func GetDIDDocument(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	did, ok := params[0].(string)
+	if !ok || !account.VerifyID(did) {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	doc, err := bcomn.GetDIDDocument(did)
+	if err != nil {
+		log.Infof("GetDIDDocument: %s", err)
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	if doc == nil {
+		return responsePack(berr.UNKNOWN_STATE, "")
+	}
+	return responseSuccess(doc)
+}
+
+// get credential record by credential ID and issuer
+//   {"jsonrpc": "2.0", "method": "getcredential", "params": ["credential ID in hex", "issuer DID"], "id": 0}
+func GetCredential(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	id, err := hex.DecodeString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	issuer, ok := params[1].(string)
+	if !ok || !account.VerifyID(issuer) {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	info, err := bcomn.GetCredential(id, issuer)
+	if err != nil {
+		log.Infof("GetCredential: %s", err)
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	if info == nil {
+		return responsePack(berr.UNKNOWN_STATE, "")
+	}
+	return responseSuccess(info)
+}
+
+// get roles of a contract managed by the auth contract
+func GetAuthRoles(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	roles, err := bcomn.GetAuthRoles(contract)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(roles)
+}
+
+// get DNA IDs holding a role of a contract
+// A JSON example for getauthrolemembers method as following:
+//   {"jsonrpc": "2.0", "method": "getauthrolemembers", "params": ["0100000000000000000000000000000000000000", "role", 0, 100], "id": 0}
+func GetAuthRoleMembers(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	role, ok := params[1].(string)
+	if !ok || role == "" {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var offset, limit uint64
+	if len(params) > 2 {
+		n, ok := params[2].(float64)
+		if !ok || n < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		offset = uint64(n)
+	}
+	if len(params) > 3 {
+		n, ok := params[3].(float64)
+		if !ok || n < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		limit = uint64(n)
+	}
+	members, err := bcomn.GetAuthRoleMembers(contract, role, offset, limit)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(members)
+}
+
+// get auth history of a contract
+// A JSON example for getauthhistory method as following:
+//   {"jsonrpc": "2.0", "method": "getauthhistory", "params": ["0100000000000000000000000000000000000000", 0, 100], "id": 0}
+func GetAuthHistory(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var offset, limit uint64
+	if len(params) > 1 {
+		n, ok := params[1].(float64)
+		if !ok || n < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		offset = uint64(n)
+	}
+	if len(params) > 2 {
+		n, ok := params[2].(float64)
+		if !ok || n < 0 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		limit = uint64(n)
+	}
+	history, err := bcomn.GetAuthHistory(contract, offset, limit)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(history)
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 
+	comm "github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/log"
 	bactor "github.com/dnaproject2/DNA/http/base/actor"
 	"github.com/dnaproject2/DNA/http/base/common"
@@ -107,6 +108,25 @@ func StopConsensus(params []interface{}) map[string]interface{} {
 	return responsePack(berr.SUCCESS, true)
 }
 
+//EvictMemPoolTx removes a transaction from memory pool, it is only served by the local rpc
+func EvictMemPoolTx(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hash, err := comm.Uint256FromHexString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if _, err := bactor.EvictTxFromPool(hash); err != nil {
+		return responsePack(berr.UNKNOWN_TRANSACTION, "unknown transaction")
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
 func SetDebugInfo(params []interface{}) map[string]interface{} {
 	if len(params) < 1 {
 		return responsePack(berr.INVALID_PARAMS, "")
@@ -20,6 +20,7 @@
 package rpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/dnaproject2/DNA/common/log"
@@ -27,6 +28,7 @@ import (
 	berr "github.com/dnaproject2/DNA/http/base/error"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"strings"
@@ -35,11 +37,15 @@ import (
 
 func init() {
 	mainMux.m = make(map[string]func([]interface{}) map[string]interface{})
+	localMux.m = make(map[string]func([]interface{}) map[string]interface{})
 }
 
 //an instance of the multiplexer
 var mainMux ServeMux
 
+//the multiplexer of the administrative functions only served by the local rpc
+var localMux ServeMux
+
 //multiplexer that keeps track of every function to be called on specific rpc call
 type ServeMux struct {
 	sync.RWMutex
@@ -54,16 +60,83 @@ func HandleFunc(pattern string, handler func([]interface{}) map[string]interface
 	mainMux.m[pattern] = handler
 }
 
+//a function to register administrative functions, which are only served to
+//the local rpc calls from the loopback addresses
+func HandleLocalFunc(pattern string, handler func([]interface{}) map[string]interface{}) {
+	localMux.Lock()
+	defer localMux.Unlock()
+	localMux.m[pattern] = handler
+}
+
 //a function to be called if the request is not a HTTP JSON RPC call
 func SetDefaultFunc(def func(http.ResponseWriter, *http.Request)) {
 	mainMux.defaultFunction = def
 }
 
+//max number of requests accepted in one batch call
+const MAX_BATCH_REQUESTS = 1000
+
+//RpcError is the error object of a JSON-RPC 2.0 response
+type RpcError struct {
+	Code    int64       `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+//RpcResponse is a JSON-RPC 2.0 response, exactly one of Result and Error is set
+type RpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RpcError       `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+var nullId = json.RawMessage("null")
+
+func newRpcError(code int64, data interface{}) *RpcError {
+	if str, ok := data.(string); ok && str == "" {
+		data = nil
+	}
+	msg, ok := berr.RpcErrMap[code]
+	if !ok {
+		msg = berr.ErrMap[code]
+	}
+	return &RpcError{Code: code, Message: msg, Data: data}
+}
+
+func errorResponse(id json.RawMessage, code int64, data interface{}) *RpcResponse {
+	if id == nil {
+		id = nullId
+	}
+	return &RpcResponse{Version: "2.0", Error: newRpcError(code, data), Id: id}
+}
+
 // this is the function that should be called in order to answer an rpc call
 // should be registered like "http.HandleFunc("/", httpjsonrpc.Handle)"
 func Handle(w http.ResponseWriter, r *http.Request) {
+	handle(w, r, false)
+}
+
+// HandleLocal answers the local rpc calls, the functions registered by
+// HandleLocalFunc are served only if the request is from a loopback address
+func HandleLocal(w http.ResponseWriter, r *http.Request) {
+	handle(w, r, isLoopback(r.RemoteAddr))
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func handle(w http.ResponseWriter, r *http.Request, admin bool) {
 	mainMux.RLock()
 	defer mainMux.RUnlock()
+	localMux.RLock()
+	defer localMux.RUnlock()
 	if r.Method == "OPTIONS" {
 		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
 		w.Header().Set("content-type", "application/json;charset=utf-8")
@@ -92,63 +165,148 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	request := make(map[string]interface{})
 	defer r.Body.Close()
-	decoder := json.NewDecoder(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
-	err := decoder.Decode(&request)
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, common.MAX_REQUEST_BODY_SIZE))
 	if err != nil {
+		log.Error("HTTP JSON RPC Handle - read body: ", err)
+		writeResponse(w, errorResponse(nil, berr.RPC_PARSE_ERROR, err.Error()))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		if resp := handleRequest(body, admin); resp != nil {
+			writeResponse(w, resp)
+		}
+		return
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
 		log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+		writeResponse(w, errorResponse(nil, berr.RPC_PARSE_ERROR, err.Error()))
 		return
 	}
-	if request["method"] == nil {
-		log.Error("HTTP JSON RPC Handle - method not found: ")
+	if len(batch) == 0 {
+		writeResponse(w, errorResponse(nil, berr.RPC_INVALID_REQUEST, "empty batch"))
 		return
 	}
-	method, ok := request["method"].(string)
-	if !ok {
-		log.Error("HTTP JSON RPC Handle - method is not string: ")
+	if len(batch) > MAX_BATCH_REQUESTS {
+		writeResponse(w, errorResponse(nil, berr.RPC_INVALID_REQUEST,
+			fmt.Sprintf("batch size exceeds %d", MAX_BATCH_REQUESTS)))
 		return
 	}
-	//get the corresponding function
-	function, ok := mainMux.m[method]
-	if ok {
-		response := function(request["params"].([]interface{}))
-		data, err := json.Marshal(map[string]interface{}{
-			"jsonrpc": "2.0",
-			"error":   response["error"],
-			"desc":    response["desc"],
-			"result":  response["result"],
-			"id":      request["id"],
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
+	resps := make([]*RpcResponse, 0, len(batch))
+	for _, req := range batch {
+		if resp := handleRequest(req, admin); resp != nil {
+			resps = append(resps, resp)
 		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
+	}
+	//a batch of notifications is not answered
+	if len(resps) > 0 {
+		writeResponse(w, resps)
+	}
+}
+
+//handleRequest executes a single request and returns its response,
+//or nil if the request is a notification. The administrative functions
+//are called only if admin is set
+func handleRequest(data []byte, admin bool) *RpcResponse {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(data, &request); err != nil {
+		if !json.Valid(data) {
+			log.Error("HTTP JSON RPC Handle - json.Unmarshal: ", err)
+			return errorResponse(nil, berr.RPC_PARSE_ERROR, err.Error())
+		}
+		return errorResponse(nil, berr.RPC_INVALID_REQUEST, "request is not an object")
+	}
+	id, hasId := request["id"]
+	if hasId && !validId(id) {
+		return errorResponse(nil, berr.RPC_INVALID_REQUEST, "id must be a string, number or null")
+	}
+	var method string
+	if err := json.Unmarshal(request["method"], &method); err != nil || method == "" {
+		log.Error("HTTP JSON RPC Handle - method is not string: ")
+		return errorResponse(id, berr.RPC_INVALID_REQUEST, "method must be a string")
+	}
+
+	var resp *RpcResponse
+	function, ok := mainMux.m[method]
+	if !ok && admin {
+		function, ok = localMux.m[method]
+	}
+	if !ok {
+		log.Warn("HTTP JSON RPC Handle - No function to call for ", method)
+		resp = errorResponse(id, berr.RPC_METHOD_NOT_FOUND, "The called method was not found on the server")
+	} else if params, err := parseParams(request["params"]); err != nil {
+		resp = errorResponse(id, berr.RPC_INVALID_PARAMS, err.Error())
 	} else {
-		//if the function does not exist
-		log.Warn("HTTP JSON RPC Handle - No function to call for ", request["method"])
-		data, err := json.Marshal(map[string]interface{}{
-			"error": berr.INVALID_METHOD,
-			"result": map[string]interface{}{
-				"code":    -32601,
-				"message": "Method not found",
-				"data":    "The called method was not found on the server",
-			},
-			"id": request["id"],
-		})
-		if err != nil {
-			log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
-			return
+		resp = callFunction(method, function, params, id)
+	}
+	if !hasId {
+		return nil
+	}
+	return resp
+}
+
+//validId checks the request id is a string, number or null
+func validId(id json.RawMessage) bool {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
+
+//parseParams decodes positional params, a missing params member means no params
+func parseParams(data json.RawMessage) ([]interface{}, error) {
+	params := make([]interface{}, 0)
+	if len(data) == 0 || string(data) == "null" {
+		return params, nil
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("params must be an array")
+	}
+	return params, nil
+}
+
+func callFunction(method string, function func([]interface{}) map[string]interface{},
+	params []interface{}, id json.RawMessage) (resp *RpcResponse) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Errorf("HTTP JSON RPC Handle - %s panic: %v", method, e)
+			resp = errorResponse(id, berr.RPC_INTERNAL_ERROR, nil)
 		}
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("content-type", "application/json;charset=utf-8")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Write(data)
+	}()
+	if id == nil {
+		id = nullId
+	}
+	result := function(params)
+	errcode, _ := result["error"].(int64)
+	if errcode != berr.SUCCESS {
+		return errorResponse(id, berr.RpcErrCode(errcode), result["result"])
+	}
+	data, err := json.Marshal(result["result"])
+	if err != nil {
+		log.Errorf("HTTP JSON RPC Handle - %s json.Marshal: %s", method, err)
+		return errorResponse(id, berr.RPC_INTERNAL_ERROR, nil)
+	}
+	return &RpcResponse{Version: "2.0", Result: data, Id: id}
+}
+
+func writeResponse(w http.ResponseWriter, resp interface{}) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Error("HTTP JSON RPC Handle - json.Marshal: ", err)
+		data, _ = json.Marshal(errorResponse(nil, berr.RPC_INTERNAL_ERROR, nil))
 	}
+	w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("content-type", "application/json;charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(data)
 }
 
 // Call sends RPC request to server
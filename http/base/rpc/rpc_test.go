@@ -0,0 +1,152 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	berr "github.com/dnaproject2/DNA/http/base/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	HandleFunc("test_echo", func(params []interface{}) map[string]interface{} {
+		return responseSuccess(params)
+	})
+	HandleFunc("test_fail", func(params []interface{}) map[string]interface{} {
+		return responsePack(berr.INVALID_PARAMS, "bad param")
+	})
+	HandleFunc("test_panic", func(params []interface{}) map[string]interface{} {
+		panic("boom")
+	})
+}
+
+func doRequest(body string) []byte {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	Handle(rec, req)
+	return rec.Body.Bytes()
+}
+
+func decodeResponse(t *testing.T, data []byte) map[string]interface{} {
+	resp := make(map[string]interface{})
+	assert.Nil(t, json.Unmarshal(data, &resp), string(data))
+	return resp
+}
+
+func assertError(t *testing.T, resp map[string]interface{}, code int64) {
+	_, ok := resp["result"]
+	assert.False(t, ok)
+	rpcErr, ok := resp["error"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, float64(code), rpcErr["code"])
+		assert.NotEmpty(t, rpcErr["message"])
+	}
+}
+
+func TestHandleSingle(t *testing.T) {
+	resp := decodeResponse(t, doRequest(`{"jsonrpc":"2.0","method":"test_echo","params":[1,"a"],"id":7}`))
+	assert.Equal(t, "2.0", resp["jsonrpc"])
+	assert.Equal(t, float64(7), resp["id"])
+	assert.Equal(t, []interface{}{float64(1), "a"}, resp["result"])
+	_, ok := resp["error"]
+	assert.False(t, ok)
+
+	resp = decodeResponse(t, doRequest(`{"jsonrpc":"2.0","method":"test_echo","id":"x"}`))
+	assert.Equal(t, "x", resp["id"])
+	assert.Equal(t, []interface{}{}, resp["result"])
+}
+
+func TestHandleErrors(t *testing.T) {
+	cases := []struct {
+		body string
+		code int64
+		id   interface{}
+	}{
+		{`{"method":`, berr.RPC_PARSE_ERROR, nil},
+		{``, berr.RPC_PARSE_ERROR, nil},
+		{`"test_echo"`, berr.RPC_INVALID_REQUEST, nil},
+		{`{"params":[],"id":1}`, berr.RPC_INVALID_REQUEST, float64(1)},
+		{`{"method":1,"id":1}`, berr.RPC_INVALID_REQUEST, float64(1)},
+		{`{"method":"test_echo","id":{}}`, berr.RPC_INVALID_REQUEST, nil},
+		{`{"method":"test_none","id":1}`, berr.RPC_METHOD_NOT_FOUND, float64(1)},
+		{`{"method":"test_echo","params":{"a":1},"id":1}`, berr.RPC_INVALID_PARAMS, float64(1)},
+		{`{"method":"test_fail","id":1}`, berr.RPC_INVALID_PARAMS, float64(1)},
+		{`{"method":"test_panic","id":1}`, berr.RPC_INTERNAL_ERROR, float64(1)},
+		{`[]`, berr.RPC_INVALID_REQUEST, nil},
+		{`[{"method":"test_echo"}`, berr.RPC_PARSE_ERROR, nil},
+	}
+	for _, c := range cases {
+		resp := decodeResponse(t, doRequest(c.body))
+		assertError(t, resp, c.code)
+		assert.Equal(t, c.id, resp["id"], c.body)
+	}
+
+	resp := decodeResponse(t, doRequest(`{"method":"test_fail","id":1}`))
+	assert.Equal(t, "bad param", resp["error"].(map[string]interface{})["data"])
+}
+
+func TestHandleBatch(t *testing.T) {
+	data := doRequest(`[
+		{"jsonrpc":"2.0","method":"test_echo","params":[1],"id":1},
+		{"jsonrpc":"2.0","method":"test_echo","params":[2]},
+		{"jsonrpc":"2.0","method":"test_fail","id":2},
+		1,
+		{"jsonrpc":"2.0","method":"test_none","id":3}
+	]`)
+	var resps []map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &resps), string(data))
+	assert.Equal(t, 4, len(resps))
+	assert.Equal(t, float64(1), resps[0]["id"])
+	assert.Equal(t, []interface{}{float64(1)}, resps[0]["result"])
+	assertError(t, resps[1], berr.RPC_INVALID_PARAMS)
+	assert.Equal(t, float64(2), resps[1]["id"])
+	assertError(t, resps[2], berr.RPC_INVALID_REQUEST)
+	assert.Nil(t, resps[2]["id"])
+	assertError(t, resps[3], berr.RPC_METHOD_NOT_FOUND)
+
+	//notifications are not answered
+	assert.Empty(t, doRequest(`{"method":"test_echo","params":[1]}`))
+	assert.Empty(t, doRequest(`[{"method":"test_echo"},{"method":"test_fail"}]`))
+}
+
+func TestHandleLocal(t *testing.T) {
+	HandleLocalFunc("test_admin", func(params []interface{}) map[string]interface{} {
+		return responseSuccess(true)
+	})
+	body := `{"jsonrpc":"2.0","method":"test_admin","id":1}`
+
+	// only served to the local rpc from loopback addresses
+	assertError(t, decodeResponse(t, doRequest(body)), berr.RPC_METHOD_NOT_FOUND)
+
+	req := httptest.NewRequest("POST", "/local", strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:20337"
+	rec := httptest.NewRecorder()
+	HandleLocal(rec, req)
+	assertError(t, decodeResponse(t, rec.Body.Bytes()), berr.RPC_METHOD_NOT_FOUND)
+
+	req = httptest.NewRequest("POST", "/local", strings.NewReader(body))
+	req.RemoteAddr = "127.0.0.1:20337"
+	rec = httptest.NewRecorder()
+	HandleLocal(rec, req)
+	assert.Equal(t, true, decodeResponse(t, rec.Body.Bytes())["result"])
+}
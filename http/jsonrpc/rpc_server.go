@@ -38,10 +38,11 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getblockcount", rpc.GetBlockCount)
 	rpc.HandleFunc("getblockhash", rpc.GetBlockHash)
 	rpc.HandleFunc("getconnectioncount", rpc.GetConnectionCount)
-	//HandleFunc("getrawmempool", GetRawMemPool)
+	rpc.HandleFunc("getrawmempool", rpc.GetRawMemPool)
 
 	rpc.HandleFunc("getrawtransaction", rpc.GetRawTransaction)
 	rpc.HandleFunc("sendrawtransaction", rpc.SendRawTransaction)
+	rpc.HandleFunc("simulatetransaction", rpc.SimulateTransaction)
 	rpc.HandleFunc("getstorage", rpc.GetStorage)
 	rpc.HandleFunc("getversion", rpc.GetNodeVersion)
 	rpc.HandleFunc("getnetworkid", rpc.GetNetworkId)
@@ -55,10 +56,16 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getbalance", rpc.GetBalance)
 	rpc.HandleFunc("getallowance", rpc.GetAllowance)
 	rpc.HandleFunc("getmerkleproof", rpc.GetMerkleProof)
+	rpc.HandleFunc("getstatemerkleroot", rpc.GetStateMerkleRoot)
 	rpc.HandleFunc("getblocktxsbyheight", rpc.GetBlockTxsByHeight)
 	rpc.HandleFunc("getgasprice", rpc.GetGasPrice)
 	rpc.HandleFunc("getunboundong", rpc.GetUnboundOng)
 	rpc.HandleFunc("getgrantong", rpc.GetGrantOng)
+	rpc.HandleFunc("getdiddocument", rpc.GetDIDDocument)
+	rpc.HandleFunc("getcredential", rpc.GetCredential)
+	rpc.HandleFunc("getauthroles", rpc.GetAuthRoles)
+	rpc.HandleFunc("getauthrolemembers", rpc.GetAuthRoleMembers)
+	rpc.HandleFunc("getauthhistory", rpc.GetAuthHistory)
 
 	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpJsonPort)), nil)
 	if err != nil {
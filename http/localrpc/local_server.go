@@ -36,16 +36,20 @@ const (
 
 func StartLocalServer() error {
 	log.Debug()
-	http.HandleFunc(LOCAL_DIR, rpc.Handle)
+	// the local server has its own multiplexer, so the administrative functions are not served on the public ports
+	mux := http.NewServeMux()
+	mux.HandleFunc(LOCAL_DIR, rpc.HandleLocal)
 
 	rpc.HandleFunc("getneighbor", rpc.GetNeighbor)
 	rpc.HandleFunc("getnodestate", rpc.GetNodeState)
 	rpc.HandleFunc("startconsensus", rpc.StartConsensus)
 	rpc.HandleFunc("stopconsensus", rpc.StopConsensus)
 	rpc.HandleFunc("setdebuginfo", rpc.SetDebugInfo)
+	rpc.HandleLocalFunc("evictmempooltx", rpc.EvictMemPoolTx)
+	rpc.HandleLocalFunc("tracetransaction", rpc.TraceTransaction)
 
 	// TODO: only listen to local host
-	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), nil)
+	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), mux)
 	if err != nil {
 		return fmt.Errorf("ListenAndServe error:%s", err)
 	}
@@ -23,6 +23,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"github.com/dnaproject2/DNA/account"
 	cfg "github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/http/base/common"
@@ -75,8 +76,11 @@ const (
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_DID_DOCUMENT      = "/api/v1/diddocument/:id"
+	GET_CREDENTIAL        = "/api/v1/credential/:issuer/:id"
 
-	POST_RAW_TX = "/api/v1/transaction"
+	POST_RAW_TX      = "/api/v1/transaction"
+	POST_SIMULATE_TX = "/api/v1/transaction/simulate"
 )
 
 //init restful server
@@ -155,15 +159,21 @@ func (this *restServer) registryMethod() {
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_DID_DOCUMENT:      {name: "getdiddocument", handler: rest.GetDIDDocument},
+		GET_CREDENTIAL:        {name: "getcredential", handler: rest.GetCredential},
 	}
 
 	postMethodMap := map[string]Action{
-		POST_RAW_TX: {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_RAW_TX:      {name: "sendrawtransaction", handler: rest.SendRawTransaction},
+		POST_SIMULATE_TX: {name: "simulatetransaction", handler: rest.SimulateTransaction},
 	}
 	this.postMap = postMethodMap
 	this.getMap = getMethodMap
 }
 func (this *restServer) getPath(url string) string {
+	if url == POST_SIMULATE_TX {
+		return POST_SIMULATE_TX
+	}
 
 	if strings.Contains(url, strings.TrimRight(GET_BLK_TXS_BY_HEIGHT, ":height")) {
 		return GET_BLK_TXS_BY_HEIGHT
@@ -197,6 +207,10 @@ func (this *restServer) getPath(url string) string {
 		return GET_GRANTONG
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_DID_DOCUMENT, ":id")) {
+		return GET_DID_DOCUMENT
+	} else if strings.Contains(url, strings.TrimRight(GET_CREDENTIAL, ":issuer/:id")) {
+		return GET_CREDENTIAL
 	}
 	return url
 }
@@ -222,6 +236,7 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["PreExec"] = r.FormValue("preExec")
 	case GET_STORAGE:
 		req["Hash"], req["Key"] = getParam(r, "hash"), getParam(r, "key")
+		req["Height"] = r.FormValue("height")
 	case GET_SMTCOCE_EVT_TXS:
 		req["Height"] = getParam(r, "height")
 	case GET_SMTCOCE_EVTS:
@@ -229,18 +244,28 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 	case GET_BLK_HGT_BY_TXHASH:
 		req["Hash"] = getParam(r, "hash")
 	case GET_BALANCE:
-		req["Addr"] = getParam(r, "addr")
+		req["Addr"], req["Height"] = getParam(r, "addr"), r.FormValue("height")
+		req["Asset"] = r.FormValue("asset")
 	case GET_MERKLE_PROOF:
 		req["Hash"] = getParam(r, "hash")
 	case GET_ALLOWANCE:
 		req["Asset"] = getParam(r, "asset")
 		req["From"], req["To"] = getParam(r, "from"), getParam(r, "to")
+		req["Height"] = r.FormValue("height")
 	case GET_UNBOUNDONG:
 		req["Addr"] = getParam(r, "addr")
 	case GET_GRANTONG:
 		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_DID_DOCUMENT:
+		// route params can not contain ':', so only the method specific
+		// part of the DID is given
+		req["DID"] = account.SCHEME + ":" + account.METHOD + ":" + getParam(r, "id")
+	case GET_CREDENTIAL:
+		// only the method specific part of the issuer DID is given, as for GET_DID_DOCUMENT
+		req["Issuer"] = account.SCHEME + ":" + account.METHOD + ":" + getParam(r, "issuer")
+		req["Id"] = getParam(r, "id")
 	default:
 	}
 	return req
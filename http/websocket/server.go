@@ -38,6 +38,7 @@ var ws *websocket.WsServer
 func StartServer() {
 	bactor.SubscribeEvent(message.TOPIC_SAVE_BLOCK_COMPLETE, sendBlock2WSclient)
 	bactor.SubscribeEvent(message.TOPIC_SMART_CODE_EVENT, pushSmartCodeEvent)
+	bactor.SubscribeEvent(message.TOPIC_TXPOOL_CHANGE, pushTxPoolChange)
 	go func() {
 		ws = websocket.InitWsServer()
 		ws.Start()
@@ -48,6 +49,7 @@ func sendBlock2WSclient(v interface{}) {
 		go func() {
 			pushBlock(v)
 			pushBlockTransactions(v)
+			pushBlockActivity(v)
 		}()
 	}
 }
@@ -78,25 +80,25 @@ func pushSmartCodeEvent(v interface{}) {
 	go func() {
 		switch object := rs.Result.(type) {
 		case *event.LogEventArgs:
-			contractAddrs, evts := bcomn.GetLogEvent(object)
-			pushEvent(contractAddrs, rs.TxHash.ToHexString(), rs.Error, rs.Action, evts)
+			_, evts := bcomn.GetLogEvent(object)
+			pushEvent(websocket.NewLogActivity(object), rs.TxHash.ToHexString(), rs.Error, rs.Action, evts)
 		case *event.ExecuteNotify:
-			contractAddrs, notify := bcomn.GetExecuteNotify(object)
-			pushEvent(contractAddrs, rs.TxHash.ToHexString(), rs.Error, rs.Action, notify)
+			_, notify := bcomn.GetExecuteNotify(object)
+			pushEvent(websocket.NewTxActivity(nil, object), rs.TxHash.ToHexString(), rs.Error, rs.Action, notify)
 		default:
 		}
 	}()
 }
 
-func pushEvent(contractAddrs map[string]bool, txHash string, errcode int64, action string, result interface{}) {
+func pushEvent(act *websocket.TxActivity, txHash string, errcode int64, action string, result interface{}) {
 	if ws != nil {
 		resp := rest.ResponsePack(Err.SUCCESS)
 		resp["Result"] = result
 		resp["Error"] = errcode
 		resp["Action"] = action
 		resp["Desc"] = Err.ErrMap[resp["Error"].(int64)]
-		ws.PushTxResult(contractAddrs, txHash, resp)
-		ws.BroadcastToSubscribers(contractAddrs, websocket.WSTOPIC_EVENT, resp)
+		ws.PushTxResult(act, txHash, resp)
+		ws.BroadcastToSubscribers(act, websocket.WSTOPIC_EVENT, resp)
 	}
 }
 
@@ -126,3 +128,20 @@ func pushBlockTransactions(v interface{}) {
 		ws.BroadcastToSubscribers(nil, websocket.WSTOPIC_TXHASHS, resp)
 	}
 }
+func pushBlockActivity(v interface{}) {
+	if ws == nil {
+		return
+	}
+	if block, ok := v.(types.Block); ok {
+		ws.PushBlockActivity(&block)
+	}
+}
+
+func pushTxPoolChange(v interface{}) {
+	if ws == nil || cfg.DefConfig.Ws.HttpWsPort == 0 {
+		return
+	}
+	if msg, ok := v.(message.TxPoolChangeMsg); ok {
+		go ws.PushTxPoolChange(&msg)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"encoding/hex"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+)
+
+//TxActivity records what a transaction touched, it is matched against the subscribe filters
+type TxActivity struct {
+	Contracts  map[string]bool //hex contract addresses which notified
+	EventNames map[string]bool //names of the notified events
+	Addresses  map[string]bool //base58 addresses of the payer and the ones mentioned in notifies
+}
+
+func newTxActivity() *TxActivity {
+	return &TxActivity{
+		Contracts:  make(map[string]bool),
+		EventNames: make(map[string]bool),
+		Addresses:  make(map[string]bool),
+	}
+}
+
+//NewTxActivity collects the activity of a transaction from itself and its execute notify,
+//both of them may be nil
+func NewTxActivity(tx *types.Transaction, notify *event.ExecuteNotify) *TxActivity {
+	act := newTxActivity()
+	if tx != nil {
+		act.Addresses[tx.Payer.ToBase58()] = true
+	}
+	if notify != nil {
+		for _, n := range notify.Notify {
+			act.AddNotify(n)
+		}
+	}
+	return act
+}
+
+//NewLogActivity returns the activity of a runtime log event
+func NewLogActivity(log *event.LogEventArgs) *TxActivity {
+	act := newTxActivity()
+	act.Contracts[log.ContractAddress.ToHexString()] = true
+	return act
+}
+
+//AddNotify adds the contract, event name and addresses of a notify.
+//The event name is the first state, native contracts notify it as a string
+//and neovm contracts as its hex encoding
+func (self *TxActivity) AddNotify(n *event.NotifyEventInfo) {
+	self.Contracts[n.ContractAddress.ToHexString()] = true
+	if states, ok := n.States.([]interface{}); ok && len(states) > 0 {
+		if name, ok := states[0].(string); ok {
+			self.EventNames[name] = true
+			if buf, err := hex.DecodeString(name); err == nil && len(buf) > 0 {
+				self.EventNames[string(buf)] = true
+			}
+		}
+	}
+	self.addStateAddresses(n.States)
+}
+
+func (self *TxActivity) addStateAddresses(state interface{}) {
+	switch v := state.(type) {
+	case []interface{}:
+		for _, s := range v {
+			self.addStateAddresses(s)
+		}
+	case string:
+		if addr, err := common.AddressFromBase58(v); err == nil {
+			self.Addresses[addr.ToBase58()] = true
+		} else if buf, err := hex.DecodeString(v); err == nil && len(buf) == common.ADDR_LEN {
+			addr, _ := common.AddressParseFromBytes(buf)
+			self.Addresses[addr.ToBase58()] = true
+		}
+	}
+}
+
+//matchFilter reports whether any filter value is in the set, an empty filter matches everything
+func matchFilter(filter []string, set map[string]bool) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, v := range filter {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
+
+//matchActivity checks the activity against all filters of the subscription
+func (self *subscribe) matchActivity(act *TxActivity) bool {
+	return matchFilter(self.ContractsFilter, act.Contracts) &&
+		matchFilter(self.EventNameFilter, act.EventNames) &&
+		matchFilter(self.AddressFilter, act.Addresses)
+}
+
+//matchAddress checks the activity against the address filter only,
+//it is used for tx pool changes which have no notifies yet
+func (self *subscribe) matchAddress(act *TxActivity) bool {
+	return matchFilter(self.AddressFilter, act.Addresses)
+}
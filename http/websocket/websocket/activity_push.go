@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/events/message"
+	bactor "github.com/dnaproject2/DNA/http/base/actor"
+	bcomn "github.com/dnaproject2/DNA/http/base/common"
+	Err "github.com/dnaproject2/DNA/http/base/error"
+	"github.com/dnaproject2/DNA/http/base/rest"
+	"github.com/dnaproject2/DNA/http/websocket/session"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+)
+
+//TxPoolChangeInfo is pushed to the tx pool subscribers
+type TxPoolChangeInfo struct {
+	TxHash string
+	Change string
+	Reason string `json:",omitempty"`
+	Payer  string
+}
+
+//AddressTxInfo is pushed to the address activity subscribers
+type AddressTxInfo struct {
+	TxHash string
+	Height uint32
+	Payer  string
+	Notify *bcomn.ExecuteNotify `json:",omitempty"`
+}
+
+func addressTxResp(tx *types.Transaction, height uint32, notify *event.ExecuteNotify) map[string]interface{} {
+	hash := tx.Hash()
+	info := AddressTxInfo{
+		TxHash: hash.ToHexString(),
+		Height: height,
+		Payer:  tx.Payer.ToBase58(),
+	}
+	if notify != nil {
+		_, n := bcomn.GetExecuteNotify(notify)
+		info.Notify = &n
+	}
+	resp := rest.ResponsePack(Err.SUCCESS)
+	resp["Action"] = "addresstx"
+	resp["Result"] = info
+	return resp
+}
+
+//PushTxPoolChange pushes a tx entering or leaving the tx pool to the subscribers
+func (self *WsServer) PushTxPoolChange(msg *message.TxPoolChangeMsg) {
+	hash := msg.Tx.Hash()
+	resp := rest.ResponsePack(Err.SUCCESS)
+	resp["Action"] = "txpool"
+	resp["Result"] = TxPoolChangeInfo{
+		TxHash: hash.ToHexString(),
+		Change: msg.Change,
+		Reason: msg.Reason,
+		Payer:  msg.Tx.Payer.ToBase58(),
+	}
+	self.BroadcastToSubscribers(NewTxActivity(msg.Tx, nil), WSTOPIC_TXPOOL, resp)
+}
+
+//PushBlockActivity pushes the transactions of a saved block to the address activity subscribers
+func (self *WsServer) PushBlockActivity(block *types.Block) {
+	if !self.hasSubscriber(func(sub subscribe) bool { return sub.SubscribeAddressTx }) {
+		return
+	}
+	for _, tx := range block.Transactions {
+		notify, _ := bactor.GetEventNotifyByTxHash(tx.Hash())
+		resp := addressTxResp(tx, block.Header.Height, notify)
+		self.BroadcastToSubscribers(NewTxActivity(tx, notify), WSTOPIC_ADDRESS_TX, resp)
+	}
+}
+
+func (self *WsServer) hasSubscriber(f func(sub subscribe) bool) bool {
+	self.RLock()
+	defer self.RUnlock()
+	for _, sub := range self.SubscribeMap {
+		if f(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+//replay sends the events and address activities of the blocks between the heights, so a
+//client resuming its subscription does not miss anything. The live pushes held back since
+//the subscription are sent afterwards, keeping them in block order.
+func (self *WsServer) replay(s *session.Session, from, to uint32) {
+	sessionId := s.GetSessionId()
+	for height := from; height <= to; height++ {
+		self.RLock()
+		sub, ok := self.SubscribeMap[sessionId]
+		self.RUnlock()
+		if !ok {
+			self.Lock()
+			delete(self.Replaying, sessionId)
+			self.Unlock()
+			return
+		}
+		block, err := bactor.GetBlockByHeight(height)
+		if err != nil {
+			log.Errorf("websocket replay: get block %d error: %s", height, err)
+			to = height - 1
+			break
+		}
+		for _, tx := range block.Transactions {
+			notify, _ := bactor.GetEventNotifyByTxHash(tx.Hash())
+			act := NewTxActivity(tx, notify)
+			if sub.SubscribeEvent && notify != nil && sub.matchActivity(act) {
+				_, n := bcomn.GetExecuteNotify(notify)
+				resp := rest.ResponsePack(Err.SUCCESS)
+				resp["Action"] = event.EVENT_NOTIFY
+				resp["Result"] = n
+				resp["Height"] = height
+				s.Send(marshalResp(resp))
+			}
+			if sub.SubscribeAddressTx && sub.matchActivity(act) {
+				s.Send(marshalResp(addressTxResp(tx, height, notify)))
+			}
+		}
+	}
+	resp := rest.ResponsePack(Err.SUCCESS)
+	resp["Action"] = "replay"
+	resp["Result"] = map[string]uint32{"FromHeight": from, "ToHeight": to}
+	s.Send(marshalResp(resp))
+
+	self.Lock()
+	defer self.Unlock()
+	for _, data := range self.Replaying[sessionId] {
+		s.Send(data)
+	}
+	delete(self.Replaying, sessionId)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package websocket
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxActivity(t *testing.T) {
+	payer := common.Address{1}
+	from := common.Address{2}
+	to := common.Address{3}
+	native := common.Address{4}
+	neovm := common.Address{5}
+	notify := &event.ExecuteNotify{
+		Notify: []*event.NotifyEventInfo{
+			{
+				ContractAddress: native,
+				States:          []interface{}{"transfer", from.ToBase58(), to.ToBase58(), uint64(10)},
+			},
+			{
+				ContractAddress: neovm,
+				States:          []interface{}{hex.EncodeToString([]byte("mint")), []interface{}{hex.EncodeToString(to[:])}},
+			},
+		},
+	}
+	act := NewTxActivity(&types.Transaction{Payer: payer}, notify)
+
+	assert.Equal(t, map[string]bool{native.ToHexString(): true, neovm.ToHexString(): true}, act.Contracts)
+	assert.True(t, act.EventNames["transfer"])
+	assert.True(t, act.EventNames["mint"])
+	assert.Equal(t, map[string]bool{payer.ToBase58(): true, from.ToBase58(): true, to.ToBase58(): true}, act.Addresses)
+
+	sub := subscribe{}
+	assert.True(t, sub.matchActivity(act))
+	sub.AddressFilter = []string{to.ToBase58()}
+	assert.True(t, sub.matchActivity(act))
+	sub.EventNameFilter = []string{"approve"}
+	assert.False(t, sub.matchActivity(act))
+	assert.True(t, sub.matchAddress(act))
+	sub.EventNameFilter = []string{"approve", "mint"}
+	sub.ContractsFilter = []string{neovm.ToHexString()}
+	assert.True(t, sub.matchActivity(act))
+	other := common.Address{6}
+	sub.AddressFilter = []string{other.ToBase58()}
+	assert.False(t, sub.matchActivity(act))
+
+	poolAct := NewTxActivity(&types.Transaction{Payer: payer}, nil)
+	sub = subscribe{AddressFilter: []string{payer.ToBase58()}}
+	assert.True(t, sub.matchAddress(poolAct))
+	sub.AddressFilter = []string{to.ToBase58()}
+	assert.False(t, sub.matchAddress(poolAct))
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/dnaproject2/DNA/common"
 	cfg "github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
+	bactor "github.com/dnaproject2/DNA/http/base/actor"
 	Err "github.com/dnaproject2/DNA/http/base/error"
 	"github.com/dnaproject2/DNA/http/base/rest"
 	"github.com/dnaproject2/DNA/http/websocket/session"
@@ -43,8 +44,13 @@ const (
 	WSTOPIC_JSON_BLOCK = 2
 	WSTOPIC_RAW_BLOCK  = 3
 	WSTOPIC_TXHASHS    = 4
+	WSTOPIC_TXPOOL     = 5
+	WSTOPIC_ADDRESS_TX = 6
 )
 
+//max number of blocks a subscription can replay after reconnect
+const MAX_REPLAY_BLOCKS = 10000
+
 type handler func(map[string]interface{}) map[string]interface{}
 type Handler struct {
 	handler  handler
@@ -54,10 +60,14 @@ type Handler struct {
 //subscribe event for client
 type subscribe struct {
 	ContractsFilter       []string `json:"ContractsFilter"`
+	EventNameFilter       []string `json:"EventNameFilter"`
+	AddressFilter         []string `json:"AddressFilter"`
 	SubscribeEvent        bool     `json:"SubscribeEvent"`
 	SubscribeJsonBlock    bool     `json:"SubscribeJsonBlock"`
 	SubscribeRawBlock     bool     `json:"SubscribeRawBlock"`
 	SubscribeBlockTxHashs bool     `json:"SubscribeBlockTxHashs"`
+	SubscribeTxPool       bool     `json:"SubscribeTxPool"`
+	SubscribeAddressTx    bool     `json:"SubscribeAddressTx"`
 }
 type WsServer struct {
 	sync.RWMutex
@@ -68,6 +78,7 @@ type WsServer struct {
 	ActionMap    map[string]Handler   //handler functions
 	TxHashMap    map[string]string    //key: txHash   value:sessionid
 	SubscribeMap map[string]subscribe //key: sessionId   value:subscribeInfo
+	Replaying    map[string][][]byte  //key: sessionId   value:pushes held back until the replay is sent
 }
 
 //init websocket server
@@ -77,6 +88,7 @@ func InitWsServer() *WsServer {
 		SessionList:  session.NewSessionList(),
 		TxHashMap:    make(map[string]string),
 		SubscribeMap: make(map[string]subscribe),
+		Replaying:    make(map[string][][]byte),
 	}
 	return ws
 }
@@ -157,14 +169,43 @@ func (self *WsServer) registryMethod() {
 		if b, ok := cmd["SubscribeBlockTxHashs"].(bool); ok {
 			sub.SubscribeBlockTxHashs = b
 		}
+		if b, ok := cmd["SubscribeTxPool"].(bool); ok {
+			sub.SubscribeTxPool = b
+		}
+		if b, ok := cmd["SubscribeAddressTx"].(bool); ok {
+			sub.SubscribeAddressTx = b
+		}
 		if ctsf, ok := cmd["ContractsFilter"].([]interface{}); ok {
-			sub.ContractsFilter = []string{}
-			for _, v := range ctsf {
-				if addr, k := v.(string); k {
-					sub.ContractsFilter = append(sub.ContractsFilter, addr)
+			sub.ContractsFilter = stringFilter(ctsf)
+		}
+		if names, ok := cmd["EventNameFilter"].([]interface{}); ok {
+			sub.EventNameFilter = stringFilter(names)
+		}
+		if addrs, ok := cmd["AddressFilter"].([]interface{}); ok {
+			sub.AddressFilter = stringFilter(addrs)
+			for _, v := range sub.AddressFilter {
+				if _, err := common.AddressFromBase58(v); err != nil {
+					return rest.ResponsePack(Err.INVALID_PARAMS)
 				}
 			}
 		}
+		if cmd["FromHeight"] != nil {
+			from, ok := cmd["FromHeight"].(float64)
+			if !ok || from < 0 || from != float64(uint32(from)) {
+				return rest.ResponsePack(Err.INVALID_PARAMS)
+			}
+			height := bactor.GetCurrentBlockHeight()
+			if uint32(from) > height+1 || (uint32(from) <= height && height-uint32(from) >= MAX_REPLAY_BLOCKS) {
+				return rest.ResponsePack(Err.INVALID_PARAMS)
+			}
+			if _, ok := self.Replaying[sessionId]; ok {
+				return rest.ResponsePack(Err.INVALID_PARAMS)
+			}
+			//replayed once the response is sent, live pushes are held back until then
+			self.Replaying[sessionId] = nil
+			cmd["ReplayFrom"] = uint32(from)
+			cmd["ReplayTo"] = height
+		}
 		self.SubscribeMap[sessionId] = sub
 
 		resp["Action"] = "subscribe"
@@ -203,6 +244,8 @@ func (self *WsServer) registryMethod() {
 		"getmempooltxstate":         {handler: rest.GetMemPoolTxState},
 		"getversion":                {handler: rest.GetNodeVersion},
 		"getnetworkid":              {handler: rest.GetNetworkId},
+		"getdiddocument":            {handler: rest.GetDIDDocument},
+		"getcredential":             {handler: rest.GetCredential},
 
 		"getsessioncount": {handler: getsessioncount},
 	}
@@ -354,6 +397,9 @@ func (self *WsServer) OnDataHandle(curSession *session.Session, bysMsg []byte, r
 		}
 	}
 	curSession.Send(marshalResp(resp))
+	if from, ok := req["ReplayFrom"].(uint32); ok {
+		go self.replay(curSession, from, req["ReplayTo"].(uint32))
+	}
 
 	return true
 }
@@ -375,6 +421,18 @@ func (self *WsServer) deleteSubscribe(sessionId string) {
 	self.Lock()
 	defer self.Unlock()
 	delete(self.SubscribeMap, sessionId)
+	delete(self.Replaying, sessionId)
+}
+
+//stringFilter keeps the string values of a filter list
+func stringFilter(list []interface{}) []string {
+	filter := []string{}
+	for _, v := range list {
+		if str, ok := v.(string); ok {
+			filter = append(filter, str)
+		}
+	}
+	return filter
 }
 
 func marshalResp(resp map[string]interface{}) []byte {
@@ -388,23 +446,15 @@ func marshalResp(resp map[string]interface{}) []byte {
 	return data
 }
 
-func (self *WsServer) PushTxResult(contractAddrs map[string]bool, txHashStr string, resp map[string]interface{}) {
+func (self *WsServer) PushTxResult(act *TxActivity, txHashStr string, resp map[string]interface{}) {
 	self.Lock()
 	sessionId := self.TxHashMap[txHashStr]
 	delete(self.TxHashMap, txHashStr)
 	//avoid twice, will send in BroadcastToSubscribers
 	sub := self.SubscribeMap[sessionId]
-	if sub.SubscribeEvent {
-		if len(sub.ContractsFilter) == 0 {
-			self.Unlock()
-			return
-		}
-		for _, addr := range sub.ContractsFilter {
-			if contractAddrs[addr] {
-				self.Unlock()
-				return
-			}
-		}
+	if sub.SubscribeEvent && sub.matchActivity(act) {
+		self.Unlock()
+		return
 	}
 	self.Unlock()
 
@@ -413,7 +463,7 @@ func (self *WsServer) PushTxResult(contractAddrs map[string]bool, txHashStr stri
 		s.Send(marshalResp(resp))
 	}
 }
-func (self *WsServer) BroadcastToSubscribers(contractAddrs map[string]bool, sub int, resp map[string]interface{}) {
+func (self *WsServer) BroadcastToSubscribers(act *TxActivity, sub int, resp map[string]interface{}) {
 	// broadcast SubscribeMap
 	self.Lock()
 	defer self.Unlock()
@@ -424,26 +474,30 @@ func (self *WsServer) BroadcastToSubscribers(contractAddrs map[string]bool, sub
 			continue
 		}
 		if sub == WSTOPIC_JSON_BLOCK && v.SubscribeJsonBlock {
-			s.Send(data)
+			self.sendLocked(sid, s, data)
 		} else if sub == WSTOPIC_RAW_BLOCK && v.SubscribeRawBlock {
-			s.Send(data)
+			self.sendLocked(sid, s, data)
 		} else if sub == WSTOPIC_TXHASHS && v.SubscribeBlockTxHashs {
-			s.Send(data)
-		} else if sub == WSTOPIC_EVENT && v.SubscribeEvent {
-			if len(v.ContractsFilter) == 0 {
-				s.Send(data)
-				continue
-			}
-			for _, addr := range v.ContractsFilter {
-				if contractAddrs[addr] {
-					s.Send(data)
-					break
-				}
-			}
+			self.sendLocked(sid, s, data)
+		} else if sub == WSTOPIC_EVENT && v.SubscribeEvent && v.matchActivity(act) {
+			self.sendLocked(sid, s, data)
+		} else if sub == WSTOPIC_ADDRESS_TX && v.SubscribeAddressTx && v.matchActivity(act) {
+			self.sendLocked(sid, s, data)
+		} else if sub == WSTOPIC_TXPOOL && v.SubscribeTxPool && v.matchAddress(act) {
+			self.sendLocked(sid, s, data)
 		}
 	}
 }
 
+//sendLocked sends a push to the session, or holds it back while the session is replaying
+func (self *WsServer) sendLocked(sessionId string, s *session.Session, data []byte) {
+	if held, ok := self.Replaying[sessionId]; ok {
+		self.Replaying[sessionId] = append(held, data)
+		return
+	}
+	s.Send(data)
+}
+
 func (self *WsServer) initTlsListen() (net.Listener, error) {
 
 	certPath := cfg.DefConfig.Ws.HttpCertPath
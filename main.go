@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -73,6 +74,8 @@ func setupAPP() *cli.App {
 		cmd.ContractCommand,
 		cmd.ImportCommand,
 		cmd.ExportCommand,
+		cmd.RollbackCommand,
+		cmd.SnapshotCommand,
 		cmd.TxCommond,
 		cmd.SigTxCommand,
 		cmd.MultiSigAddrCommand,
@@ -85,6 +88,9 @@ func setupAPP() *cli.App {
 		utils.ConfigFlag,
 		utils.LogLevelFlag,
 		utils.DisableEventLogFlag,
+		utils.EnableArchiveFlag,
+		utils.UndoBlocksFlag,
+		utils.StoreBackendFlag,
 		utils.DataDirFlag,
 		//account setting
 		utils.ExecutorFileFlag,
@@ -93,12 +99,18 @@ func setupAPP() *cli.App {
 		//consensus setting
 		utils.EnableConsensusFlag,
 		utils.MaxTxInBlockFlag,
+		utils.ConsensusPolicyFlag,
 		//txpool setting
 		utils.GasPriceFlag,
 		utils.GasLimitFlag,
+		utils.MaxTxInPoolFlag,
+		utils.MaxTxPerPayerFlag,
+		utils.MaxTxLifetimeFlag,
+		utils.DisableReplaceByNonceFlag,
 		utils.TxpoolPreExecDisableFlag,
 		utils.DisableSyncVerifyTxFlag,
 		utils.DisableBroadcastNetTxFlag,
+		utils.DisableTxPoolJournalFlag,
 		//p2p setting
 		utils.ReservedPeersOnlyFlag,
 		utils.ReservedPeersFileFlag,
@@ -281,6 +293,14 @@ func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	stfValidator, _ := stateful.NewValidator("stateful_validator")
 	stfValidator.Register(txPoolServer.GetPID(tc.VerifyRspActor))
 
+	if !ctx.GlobalBool(utils.GetFlagName(utils.DisableTxPoolJournalFlag)) {
+		dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
+		err = txPoolServer.StartJournal(filepath.Join(dbDir, tc.JOURNAL_FILE))
+		if err != nil {
+			return nil, fmt.Errorf("Init txpool journal error:%s", err)
+		}
+	}
+
 	hserver.SetTxnPoolPid(txPoolServer.GetPID(tc.TxPoolActor))
 	hserver.SetTxPid(txPoolServer.GetPID(tc.TxActor))
 
@@ -321,6 +341,10 @@ func initConsensus(ctx *cli.Context, p2pPid *actor.PID, txpoolSvr *proc.TXPoolSe
 	}
 	pool := txpoolSvr.GetPID(tc.TxPoolActor)
 
+	if err := consensus.InitPolicy(config.DefConfig.Consensus.PolicyFile); err != nil {
+		return nil, fmt.Errorf("InitPolicy error:%s", err)
+	}
+
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
 	consensusService, err := consensus.NewConsensusService(consensusType, acc, pool, nil, p2pPid)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/dnaproject2/DNA/common"
 )
@@ -65,6 +66,11 @@ func NewFileHashStore(name string, tree_size uint32) (HashStore, error) {
 	return store, nil
 }
 
+// StoredHashNum returns the number of hashes a hash store keeps for a tree of tree_size leaves
+func StoredHashNum(tree_size uint32) int64 {
+	return getStoredHashNum(tree_size)
+}
+
 func getStoredHashNum(tree_size uint32) int64 {
 	subtreesize := getSubTreeSize(tree_size)
 	sum := int64(0)
@@ -128,6 +134,7 @@ func (self *fileHashStore) GetHash(pos uint32) (common.Uint256, error) {
 }
 
 type memHashStore struct {
+	lock   sync.RWMutex
 	hashes []common.Uint256
 }
 
@@ -137,11 +144,18 @@ func NewMemHashStore() HashStore {
 }
 
 func (self *memHashStore) Append(hash []common.Uint256) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
 	self.hashes = append(self.hashes, hash...)
 	return nil
 }
 
 func (self *memHashStore) GetHash(pos uint32) (common.Uint256, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if int(pos) >= len(self.hashes) {
+		return EMPTY_HASH, errors.New("hash position out of range")
+	}
 	return self.hashes[pos], nil
 }
 
@@ -503,6 +503,8 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID uint64, blockSize uint32, block
 	}
 	curBlockHeight := this.ledger.GetCurrentBlockHeight()
 	if height <= curBlockHeight {
+		//a competing block of a saved height, the ledger decides by the fork choice rule of poa
+		go this.ledger.AddBlock(block, merkleRoot)
 		return
 	}
 
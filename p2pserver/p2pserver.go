@@ -359,6 +359,10 @@ func (this *P2PServer) reachMinConnection() bool {
 		minCount = config.SOLO_MIN_NODE_NUM
 	case "vbft":
 		minCount = config.VBFT_MIN_NODE_NUM
+	case "sbft":
+		minCount = config.SBFT_MIN_NODE_NUM
+	case "poa":
+		minCount = config.POA_MIN_NODE_NUM
 
 	}
 	return int(this.GetConnectionCnt())+1 >= minCount
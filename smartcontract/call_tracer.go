@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package smartcontract
+
+import (
+	"bytes"
+
+	"github.com/dnaproject2/DNA/smartcontract/context"
+	"github.com/dnaproject2/DNA/smartcontract/states"
+)
+
+var wasmMagic = []byte("\x00asm")
+
+// CallTracer observes the contexts pushed and popped by the smart contract execute engines
+type CallTracer interface {
+	// CaptureEnter is called when a contract context is pushed, gas is the gas left
+	CaptureEnter(ctx *context.Context, gas uint64)
+	// CaptureExit is called when a contract context is popped, gas is the gas left
+	CaptureExit(gas uint64)
+}
+
+// CallTreeTracer builds the call tree of an execution
+type CallTreeTracer struct {
+	root     *states.CallFrame
+	stack    []*states.CallFrame
+	gasStack []uint64
+}
+
+func NewCallTreeTracer() *CallTreeTracer {
+	return &CallTreeTracer{}
+}
+
+func (this *CallTreeTracer) CaptureEnter(ctx *context.Context, gas uint64) {
+	frame := &states.CallFrame{
+		Contract: ctx.ContractAddress,
+		VmType:   vmTypeOfCode(ctx.Code),
+		Method:   ctx.Method,
+	}
+	if len(this.stack) == 0 {
+		if this.root != nil {
+			return
+		}
+		this.root = frame
+	} else {
+		parent := this.stack[len(this.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	this.stack = append(this.stack, frame)
+	this.gasStack = append(this.gasStack, gas)
+}
+
+func (this *CallTreeTracer) CaptureExit(gas uint64) {
+	if len(this.stack) == 0 {
+		return
+	}
+	last := len(this.stack) - 1
+	this.stack[last].GasUsed = this.gasStack[last] - gas
+	this.stack = this.stack[:last]
+	this.gasStack = this.gasStack[:last]
+}
+
+// Finish closes the calls left open by an aborted execution as failed, and returns the call tree
+func (this *CallTreeTracer) Finish(gas uint64) *states.CallFrame {
+	for len(this.stack) > 0 {
+		this.stack[len(this.stack)-1].Failed = true
+		this.CaptureExit(gas)
+	}
+	return this.root
+}
+
+func vmTypeOfCode(code []byte) string {
+	if len(code) == 0 {
+		return states.VM_TYPE_NATIVE
+	}
+	if bytes.HasPrefix(code, wasmMagic) {
+		return states.VM_TYPE_WASM
+	}
+	return states.VM_TYPE_NEOVM
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package smartcontract
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/context"
+	"github.com/dnaproject2/DNA/smartcontract/states"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallTreeTracer(t *testing.T) {
+	tracer := NewCallTreeTracer()
+	sc := &SmartContract{Gas: 1000, Tracer: tracer}
+	entry := common.Address{1}
+	native := common.Address{2}
+	wasm := common.Address{3}
+
+	sc.PushContext(&context.Context{ContractAddress: entry, Code: []byte{0x51}})
+	sc.CheckUseGas(10)
+	sc.PushContext(&context.Context{ContractAddress: native, Method: "transfer"})
+	sc.CheckUseGas(100)
+	sc.PopContext()
+	sc.PushContext(&context.Context{ContractAddress: wasm, Code: []byte("\x00asm\x01"), Method: "run"})
+	sc.CheckUseGas(200)
+	// the wasm call fails without popping its context
+
+	root := tracer.Finish(sc.Gas)
+	assert.Equal(t, &states.CallFrame{
+		Contract: entry,
+		VmType:   states.VM_TYPE_NEOVM,
+		GasUsed:  310,
+		Failed:   true,
+		Calls: []*states.CallFrame{
+			{Contract: native, VmType: states.VM_TYPE_NATIVE, Method: "transfer", GasUsed: 100},
+			{Contract: wasm, VmType: states.VM_TYPE_WASM, Method: "run", GasUsed: 200, Failed: true},
+		},
+	}, root)
+
+	tracer = NewCallTreeTracer()
+	sc = &SmartContract{Gas: 1000, Tracer: tracer}
+	sc.PushContext(&context.Context{ContractAddress: entry, Code: []byte{0x51}})
+	sc.CheckUseGas(10)
+	sc.PopContext()
+	root = tracer.Finish(sc.Gas)
+	assert.False(t, root.Failed)
+	assert.Equal(t, uint64(10), root.GasUsed)
+}
@@ -29,6 +29,7 @@ import (
 // when need to check authorization, use CheckWitness
 // when smart contract execute trigger event, use PushNotifications push it to smart contract notifications
 // when need to invoke a smart contract, use AppCall to invoke it
+// when need to invoke a wasm smart contract, use NewWasmExecuteEngine to launch it
 type ContextRef interface {
 	PushContext(context *Context)
 	CurrentContext() *Context
@@ -38,6 +39,7 @@ type ContextRef interface {
 	CheckWitness(address common.Address) bool
 	PushNotifications(notifications []*event.NotifyEventInfo)
 	NewExecuteEngine(code []byte) (Engine, error)
+	NewWasmExecuteEngine(code []byte) (Engine, error)
 	CheckUseGas(gas uint64) bool
 	CheckExecStep() bool
 }
@@ -50,4 +52,5 @@ type Engine interface {
 type Context struct {
 	ContractAddress common.Address
 	Code            []byte
+	Method          string // invoked method, only known by native and wasm contracts
 }
@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package smartcontract
+
+import (
+	"fmt"
+
+	scommon "github.com/dnaproject2/DNA/smartcontract/common"
+	"github.com/dnaproject2/DNA/smartcontract/states"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/dnaproject2/DNA/vm/neovm/types"
+)
+
+// MAX_TRACE_STEPS is the max number of NeoVM opcodes recorded by an ExecTracer
+const MAX_TRACE_STEPS = 10000
+
+// ExecTracer records the call tree and every NeoVM opcode executed, with the evaluation stack if withStack is set
+type ExecTracer struct {
+	*CallTreeTracer
+	steps     []*states.ExecStep
+	gasUsed   uint64
+	truncated bool
+	withStack bool
+}
+
+func NewExecTracer(withStack bool) *ExecTracer {
+	return &ExecTracer{CallTreeTracer: NewCallTreeTracer(), withStack: withStack}
+}
+
+func (this *ExecTracer) CaptureStep(engine *vm.ExecutionEngine, ip int, op vm.OpCode, gas uint64) {
+	this.gasUsed += gas
+	if len(this.steps) >= MAX_TRACE_STEPS {
+		this.truncated = true
+		return
+	}
+	step := &states.ExecStep{
+		Depth:   len(this.stack),
+		Pc:      ip,
+		Op:      opCodeName(op),
+		Gas:     gas,
+		GasUsed: this.gasUsed,
+	}
+	if len(this.stack) > 0 {
+		step.Contract = this.stack[len(this.stack)-1].Contract
+	}
+	if this.withStack {
+		count := engine.EvaluationStack.Count()
+		step.Stack = make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			step.Stack = append(step.Stack, stackItemValue(engine.EvaluationStack.Peek(i)))
+		}
+	}
+	this.steps = append(this.steps, step)
+}
+
+func (this *ExecTracer) CaptureSysCall(name string, gas uint64) {
+	this.gasUsed += gas
+	if this.truncated || len(this.steps) == 0 {
+		return
+	}
+	step := this.steps[len(this.steps)-1]
+	step.SysCall = name
+	step.Gas += gas
+	step.GasUsed = this.gasUsed
+}
+
+// End closes the calls left open and marks the last recorded opcode with the error aborting the execution
+func (this *ExecTracer) End(gas uint64, err error) {
+	this.Finish(gas)
+	if err != nil && len(this.steps) > 0 && !this.truncated {
+		this.steps[len(this.steps)-1].Error = err.Error()
+	}
+}
+
+// CallTree return the root call of the execution
+func (this *ExecTracer) CallTree() *states.CallFrame {
+	return this.root
+}
+
+// Steps return the recorded opcodes and whether some of them are dropped
+func (this *ExecTracer) Steps() ([]*states.ExecStep, bool) {
+	return this.steps, this.truncated
+}
+
+func opCodeName(op vm.OpCode) string {
+	if op >= vm.PUSHBYTES1 && op <= vm.PUSHBYTES75 {
+		return fmt.Sprintf("PUSHBYTES%d", op)
+	}
+	if name := vm.OpExecList[op].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(op))
+}
+
+func stackItemValue(item types.StackItems) interface{} {
+	if _, ok := item.(*types.Map); ok {
+		return "<map>"
+	}
+	value, err := scommon.ConvertNeoVmTypeHexString(item)
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	return value
+}
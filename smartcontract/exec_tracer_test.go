@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package smartcontract
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/states"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecTracer(t *testing.T) {
+	// PUSH1 PUSH2 ADD PUSHBYTES1 0x05 THROW
+	code := []byte{0x51, 0x52, 0x93, 0x01, 0x05, 0xf0}
+	tracer := NewExecTracer(true)
+	sc := SmartContract{
+		Config: &Config{Time: 10, Height: 10, Tx: &types.Transaction{}},
+		Gas:    10000,
+		Tracer: tracer,
+	}
+	engine, err := sc.NewExecuteEngine(code)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.NotNil(t, err)
+	tracer.End(sc.Gas, err)
+
+	steps, truncated := tracer.Steps()
+	assert.False(t, truncated)
+	assert.Equal(t, 5, len(steps))
+	contract := common.AddressFromVmCode(code)
+	ops := []string{"PUSH1", "PUSH2", "ADD", "PUSHBYTES1", "THROW"}
+	pcs := []int{0, 1, 2, 3, 5}
+	for i, step := range steps {
+		assert.Equal(t, contract, step.Contract)
+		assert.Equal(t, 1, step.Depth)
+		assert.Equal(t, ops[i], step.Op)
+		assert.Equal(t, pcs[i], step.Pc)
+		assert.Equal(t, steps[0].Gas*uint64(i+1), step.GasUsed)
+	}
+	assert.Equal(t, []interface{}{}, steps[0].Stack)
+	assert.Equal(t, []interface{}{"01"}, steps[1].Stack)
+	assert.Equal(t, []interface{}{"02", "01"}, steps[2].Stack)
+	assert.Equal(t, []interface{}{"05", "03"}, steps[4].Stack)
+	assert.Equal(t, "", steps[3].Error)
+	assert.NotEqual(t, "", steps[4].Error)
+
+	root := tracer.CallTree()
+	assert.Equal(t, contract, root.Contract)
+	assert.Equal(t, states.VM_TYPE_NEOVM, root.VmType)
+	assert.True(t, root.Failed)
+	assert.Equal(t, 10000-sc.Gas, root.GasUsed)
+}
+
+func TestExecTracerWithoutStack(t *testing.T) {
+	// PUSH1 PUSH2 ADD
+	code := []byte{0x51, 0x52, 0x93}
+	tracer := NewExecTracer(false)
+	sc := SmartContract{
+		Config: &Config{Time: 10, Height: 10, Tx: &types.Transaction{}},
+		Gas:    10000,
+		Tracer: tracer,
+	}
+	engine, err := sc.NewExecuteEngine(code)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+	tracer.End(sc.Gas, err)
+
+	steps, _ := tracer.Steps()
+	assert.Equal(t, 3, len(steps))
+	for _, step := range steps {
+		assert.Nil(t, step.Stack)
+	}
+}
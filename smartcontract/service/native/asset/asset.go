@@ -0,0 +1,399 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+// Package asset implements the native asset factory, which issues fungible
+// assets beside ONT and ONG. Every asset lives at its own derived address and
+// supports the same interface as the ONT contract.
+package asset
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+const (
+	REGISTER_ASSET = "registerAsset"
+	GET_ASSET      = "getAsset"
+	MINT           = "mint"
+	BURN           = "burn"
+	FREEZE         = "freeze"
+	UNFREEZE       = "unfreeze"
+	IS_FROZEN      = "isFrozen"
+
+	MAX_NAME_LENGTH   = 64
+	MAX_SYMBOL_LENGTH = 16
+	MAX_DECIMALS      = 18
+	// balances are returned as neovm integers, so the supply must fit in int64
+	MAX_SUPPLY = math.MaxInt64
+)
+
+func InitAssetFactory() {
+	native.Contracts[utils.AssetFactoryContractAddress] = RegisterAssetFactoryContract
+	native.ContractResolver = resolveAsset
+}
+
+func RegisterAssetFactoryContract(srvc *native.NativeService) {
+	srvc.Register(REGISTER_ASSET, RegisterAsset)
+	srvc.Register(GET_ASSET, GetAsset)
+}
+
+func RegisterAssetContract(srvc *native.NativeService) {
+	srvc.Register(ont.TRANSFER_NAME, AssetTransfer)
+	srvc.Register(ont.APPROVE_NAME, AssetApprove)
+	srvc.Register(ont.TRANSFERFROM_NAME, AssetTransferFrom)
+	srvc.Register(ont.NAME_NAME, AssetName)
+	srvc.Register(ont.SYMBOL_NAME, AssetSymbol)
+	srvc.Register(ont.DECIMALS_NAME, AssetDecimals)
+	srvc.Register(ont.TOTALSUPPLY_NAME, AssetTotalSupply)
+	srvc.Register(ont.BALANCEOF_NAME, AssetBalanceOf)
+	srvc.Register(ont.ALLOWANCE_NAME, AssetAllowance)
+	srvc.Register(MINT, AssetMint)
+	srvc.Register(BURN, AssetBurn)
+	srvc.Register(FREEZE, AssetFreeze)
+	srvc.Register(UNFREEZE, AssetUnfreeze)
+	srvc.Register(IS_FROZEN, AssetIsFrozen)
+}
+
+// RegisterAsset issues a new asset and returns its address. The initial
+// supply is credited to the issuer, who must sign the transaction.
+func RegisterAsset(srvc *native.NativeService) ([]byte, error) {
+	var param RegisterAssetParam
+	if err := param.Deserialization(common.NewZeroCopySource(srvc.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[RegisterAsset] param deserialize error!")
+	}
+	if err := utils.ValidateOwner(srvc, param.Issuer); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] %v", err)
+	}
+	if len(param.Name) == 0 || len(param.Name) > MAX_NAME_LENGTH {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] invalid name length:%d", len(param.Name))
+	}
+	if len(param.Symbol) == 0 || len(param.Symbol) > MAX_SYMBOL_LENGTH {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] invalid symbol length:%d", len(param.Symbol))
+	}
+	if param.Decimals > MAX_DECIMALS {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] decimals:%d over max:%d", param.Decimals, MAX_DECIMALS)
+	}
+	if param.Supply > MAX_SUPPLY {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] supply:%d over max:%d", param.Supply, uint64(MAX_SUPPLY))
+	}
+	if param.Supply == 0 && param.MintAuthority == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, errors.NewErr("[RegisterAsset] asset without mint authority must have initial supply")
+	}
+
+	count, err := utils.GetStorageUInt64(srvc, genAssetCountKey())
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] get asset count error:%v", err)
+	}
+	asset := genAssetAddress(param.Issuer, param.Symbol, count)
+	if _, ok := native.GetContract(srvc.CacheDB, asset); ok {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] address %s already in use", asset.ToHexString())
+	}
+	deployed, err := srvc.CacheDB.GetContract(asset)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] get contract error:%v", err)
+	}
+	if deployed != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[RegisterAsset] address %s already in use", asset.ToHexString())
+	}
+
+	putAssetInfo(srvc, asset, &AssetInfo{
+		Name:            param.Name,
+		Symbol:          param.Symbol,
+		Decimals:        param.Decimals,
+		Issuer:          param.Issuer,
+		MintAuthority:   param.MintAuthority,
+		FreezeAuthority: param.FreezeAuthority,
+		Height:          srvc.Height,
+	})
+	srvc.CacheDB.Put(genAssetCountKey(), utils.GenUInt64StorageItem(count+1).ToArray())
+	if param.Supply > 0 {
+		srvc.CacheDB.Put(ont.GenBalanceKey(asset, param.Issuer), utils.GenUInt64StorageItem(param.Supply).ToArray())
+		srvc.CacheDB.Put(ont.GenTotalSupplyKey(asset), utils.GenUInt64StorageItem(param.Supply).ToArray())
+		ont.AddNotifications(srvc, asset, &ont.State{To: param.Issuer, Value: param.Supply})
+	}
+	utils.AddCommonEvent(srvc, utils.AssetFactoryContractAddress, REGISTER_ASSET,
+		[]interface{}{asset.ToHexString(), param.Symbol, param.Issuer.ToBase58()})
+	return asset[:], nil
+}
+
+// GetAsset returns the serialized metadata of an issued asset
+func GetAsset(srvc *native.NativeService) ([]byte, error) {
+	asset, err := utils.DecodeAddress(common.NewZeroCopySource(srvc.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetAsset] decode asset address error:%v", err)
+	}
+	info, err := getAssetInfo(srvc, asset)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetAsset] %v", err)
+	}
+	if info == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetAsset] asset %s not found", asset.ToHexString())
+	}
+	sink := common.NewZeroCopySink(nil)
+	info.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func AssetTransfer(srvc *native.NativeService) ([]byte, error) {
+	var transfers ont.Transfers
+	if err := transfers.Deserialization(common.NewZeroCopySource(srvc.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetTransfer] Transfers deserialize error!")
+	}
+	asset, _, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetTransfer] %v", err)
+	}
+	for _, v := range transfers.States {
+		if v.Value == 0 {
+			continue
+		}
+		if err := checkNotFrozen(srvc, asset, v.From); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[AssetTransfer] %v", err)
+		}
+		if _, _, err := ont.Transfer(srvc, asset, &v); err != nil {
+			return utils.BYTE_FALSE, err
+		}
+		ont.AddNotifications(srvc, asset, &v)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func AssetTransferFrom(srvc *native.NativeService) ([]byte, error) {
+	var state ont.TransferFrom
+	if err := state.Deserialization(common.NewZeroCopySource(srvc.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetTransferFrom] State deserialize error!")
+	}
+	if state.Value == 0 {
+		return utils.BYTE_FALSE, nil
+	}
+	asset, _, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetTransferFrom] %v", err)
+	}
+	//neither the owner nor the spender of the allowance can be frozen
+	for _, addr := range []common.Address{state.From, state.Sender} {
+		if err := checkNotFrozen(srvc, asset, addr); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("[AssetTransferFrom] %v", err)
+		}
+	}
+	if _, _, err := ont.TransferedFrom(srvc, asset, &state); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	ont.AddNotifications(srvc, asset, &ont.State{From: state.From, To: state.To, Value: state.Value})
+	return utils.BYTE_TRUE, nil
+}
+
+func AssetApprove(srvc *native.NativeService) ([]byte, error) {
+	var state ont.State
+	if err := state.Deserialization(common.NewZeroCopySource(srvc.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetApprove] state deserialize error!")
+	}
+	if state.Value > MAX_SUPPLY {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetApprove] amount:%d over max supply", state.Value)
+	}
+	if !srvc.ContextRef.CheckWitness(state.From) {
+		return utils.BYTE_FALSE, errors.NewErr("authentication failed!")
+	}
+	asset, _, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetApprove] %v", err)
+	}
+	srvc.CacheDB.Put(ont.GenApproveKey(asset, state.From, state.To), utils.GenUInt64StorageItem(state.Value).ToArray())
+	return utils.BYTE_TRUE, nil
+}
+
+func AssetName(srvc *native.NativeService) ([]byte, error) {
+	_, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetName] %v", err)
+	}
+	return []byte(info.Name), nil
+}
+
+func AssetSymbol(srvc *native.NativeService) ([]byte, error) {
+	_, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetSymbol] %v", err)
+	}
+	return []byte(info.Symbol), nil
+}
+
+func AssetDecimals(srvc *native.NativeService) ([]byte, error) {
+	_, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetDecimals] %v", err)
+	}
+	return common.BigIntToNeoBytes(big.NewInt(int64(info.Decimals))), nil
+}
+
+func AssetTotalSupply(srvc *native.NativeService) ([]byte, error) {
+	asset := srvc.ContextRef.CurrentContext().ContractAddress
+	amount, err := utils.GetStorageUInt64(srvc, ont.GenTotalSupplyKey(asset))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetTotalSupply] get totalSupply error!")
+	}
+	return common.BigIntToNeoBytes(big.NewInt(int64(amount))), nil
+}
+
+func AssetBalanceOf(srvc *native.NativeService) ([]byte, error) {
+	return ont.GetBalanceValue(srvc, ont.TRANSFER_FLAG)
+}
+
+func AssetAllowance(srvc *native.NativeService) ([]byte, error) {
+	return ont.GetBalanceValue(srvc, ont.APPROVE_FLAG)
+}
+
+// AssetMint issues new tokens to an account, signed by the mint authority
+func AssetMint(srvc *native.NativeService) ([]byte, error) {
+	var param MintParam
+	if err := param.Deserialization(common.NewZeroCopySource(srvc.Input)); err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetMint] param deserialize error!")
+	}
+	asset, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetMint] %v", err)
+	}
+	if info.MintAuthority == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, errors.NewErr("[AssetMint] the supply of the asset is fixed")
+	}
+	if err := utils.ValidateOwner(srvc, info.MintAuthority); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetMint] %v", err)
+	}
+	if param.Value == 0 {
+		return utils.BYTE_FALSE, nil
+	}
+	supply, err := utils.GetStorageUInt64(srvc, ont.GenTotalSupplyKey(asset))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetMint] get totalSupply error:%v", err)
+	}
+	supply, overflow := common.SafeAdd(supply, param.Value)
+	if overflow || supply > MAX_SUPPLY {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetMint] total supply over max:%d", uint64(MAX_SUPPLY))
+	}
+	balanceKey := ont.GenBalanceKey(asset, param.To)
+	balance, err := utils.GetStorageUInt64(srvc, balanceKey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetMint] get balance error:%v", err)
+	}
+	srvc.CacheDB.Put(balanceKey, utils.GenUInt64StorageItem(balance+param.Value).ToArray())
+	srvc.CacheDB.Put(ont.GenTotalSupplyKey(asset), utils.GenUInt64StorageItem(supply).ToArray())
+	ont.AddNotifications(srvc, asset, &ont.State{To: param.To, Value: param.Value})
+	return utils.BYTE_TRUE, nil
+}
+
+// AssetBurn destroys tokens held by the mint authority
+func AssetBurn(srvc *native.NativeService) ([]byte, error) {
+	value, err := utils.DecodeVarUint(common.NewZeroCopySource(srvc.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, errors.NewDetailErr(err, errors.ErrNoCode, "[AssetBurn] value deserialize error!")
+	}
+	asset, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetBurn] %v", err)
+	}
+	if info.MintAuthority == common.ADDRESS_EMPTY {
+		return utils.BYTE_FALSE, errors.NewErr("[AssetBurn] the supply of the asset is fixed")
+	}
+	if err := utils.ValidateOwner(srvc, info.MintAuthority); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetBurn] %v", err)
+	}
+	if value == 0 {
+		return utils.BYTE_FALSE, nil
+	}
+	balanceKey := ont.GenBalanceKey(asset, info.MintAuthority)
+	balance, err := utils.GetStorageUInt64(srvc, balanceKey)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetBurn] get balance error:%v", err)
+	}
+	if balance < value {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetBurn] balance insufficient. balance:%d, burn amount:%d", balance, value)
+	} else if balance == value {
+		srvc.CacheDB.Delete(balanceKey)
+	} else {
+		srvc.CacheDB.Put(balanceKey, utils.GenUInt64StorageItem(balance-value).ToArray())
+	}
+	supply, err := utils.GetStorageUInt64(srvc, ont.GenTotalSupplyKey(asset))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetBurn] get totalSupply error:%v", err)
+	}
+	srvc.CacheDB.Put(ont.GenTotalSupplyKey(asset), utils.GenUInt64StorageItem(supply-value).ToArray())
+	ont.AddNotifications(srvc, asset, &ont.State{From: info.MintAuthority, Value: value})
+	return utils.BYTE_TRUE, nil
+}
+
+// AssetFreeze stops an account from sending the asset, signed by the freeze
+// authority
+func AssetFreeze(srvc *native.NativeService) ([]byte, error) {
+	asset, addr, err := checkFreezeAuthority(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetFreeze] %v", err)
+	}
+	utils.PutBytes(srvc, genFrozenKey(asset, addr), utils.BYTE_TRUE)
+	utils.AddCommonEvent(srvc, asset, FREEZE, addr.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func AssetUnfreeze(srvc *native.NativeService) ([]byte, error) {
+	asset, addr, err := checkFreezeAuthority(srvc)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetUnfreeze] %v", err)
+	}
+	srvc.CacheDB.Delete(genFrozenKey(asset, addr))
+	utils.AddCommonEvent(srvc, asset, UNFREEZE, addr.ToBase58())
+	return utils.BYTE_TRUE, nil
+}
+
+func AssetIsFrozen(srvc *native.NativeService) ([]byte, error) {
+	addr, err := utils.DecodeAddress(common.NewZeroCopySource(srvc.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetIsFrozen] decode address error:%v", err)
+	}
+	asset := srvc.ContextRef.CurrentContext().ContractAddress
+	frozen, err := isFrozen(srvc, asset, addr)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[AssetIsFrozen] %v", err)
+	}
+	if frozen {
+		return utils.BYTE_TRUE, nil
+	}
+	return utils.BYTE_FALSE, nil
+}
+
+func checkFreezeAuthority(srvc *native.NativeService) (common.Address, common.Address, error) {
+	addr, err := utils.DecodeAddress(common.NewZeroCopySource(srvc.Input))
+	if err != nil {
+		return common.ADDRESS_EMPTY, common.ADDRESS_EMPTY, fmt.Errorf("decode address error:%v", err)
+	}
+	asset, info, err := getCurrentAsset(srvc)
+	if err != nil {
+		return asset, addr, err
+	}
+	if info.FreezeAuthority == common.ADDRESS_EMPTY {
+		return asset, addr, errors.NewErr("the asset can not be frozen")
+	}
+	if err := utils.ValidateOwner(srvc, info.FreezeAuthority); err != nil {
+		return asset, addr, err
+	}
+	return asset, addr, nil
+}
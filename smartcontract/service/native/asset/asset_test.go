@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package asset
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/nativetest"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func call(srvc *native.NativeService, signer, contract common.Address, method string, args ...interface{}) ([]byte, error) {
+	srvc.ContextRef = nativetest.NewContextRef([]common.Address{signer})
+	return nativetest.Call(srvc, contract, method, args...)
+}
+
+func callUint(t *testing.T, srvc *native.NativeService, contract common.Address, method string, args ...interface{}) uint64 {
+	ret, err := call(srvc, common.ADDRESS_EMPTY, contract, method, args...)
+	assert.Nil(t, err)
+	return common.BigIntFromNeoBytes(ret).Uint64()
+}
+
+func transfer(srvc *native.NativeService, asset, from, to common.Address, value uint64) error {
+	_, err := call(srvc, from, asset, ont.TRANSFER_NAME,
+		&ont.Transfers{States: []ont.State{{From: from, To: to, Value: value}}})
+	return err
+}
+
+func TestAsset(t *testing.T) {
+	InitAssetFactory()
+	srvc := nativetest.NewNativeService(t)
+	issuer := common.AddressFromVmCode([]byte("issuer"))
+	minter := common.AddressFromVmCode([]byte("minter"))
+	freezer := common.AddressFromVmCode([]byte("freezer"))
+	alice := common.AddressFromVmCode([]byte("alice"))
+	bob := common.AddressFromVmCode([]byte("bob"))
+	factory := utils.AssetFactoryContractAddress
+
+	param := &RegisterAssetParam{
+		Issuer:          issuer,
+		Name:            "Test Token",
+		Symbol:          "TT",
+		Decimals:        2,
+		Supply:          1000,
+		MintAuthority:   minter,
+		FreezeAuthority: freezer,
+	}
+	_, err := call(srvc, alice, factory, REGISTER_ASSET, param)
+	assert.NotNil(t, err)
+	ret, err := call(srvc, issuer, factory, REGISTER_ASSET, param)
+	assert.Nil(t, err)
+	asset, err := common.AddressParseFromBytes(ret)
+	assert.Nil(t, err)
+
+	_, ok := native.GetContract(srvc.CacheDB, asset)
+	assert.True(t, ok)
+	_, ok = native.GetContract(srvc.CacheDB, alice)
+	assert.False(t, ok)
+	ret, err = call(srvc, alice, factory, GET_ASSET, asset)
+	assert.Nil(t, err)
+	info := new(AssetInfo)
+	assert.Nil(t, info.Deserialization(common.NewZeroCopySource(ret)))
+	assert.Equal(t, AssetInfo{Name: "Test Token", Symbol: "TT", Decimals: 2, Issuer: issuer,
+		MintAuthority: minter, FreezeAuthority: freezer, Height: 1}, *info)
+	ret, err = call(srvc, alice, asset, ont.SYMBOL_NAME)
+	assert.Nil(t, err)
+	assert.Equal(t, "TT", string(ret))
+	assert.Equal(t, uint64(2), callUint(t, srvc, asset, ont.DECIMALS_NAME))
+	assert.Equal(t, uint64(1000), callUint(t, srvc, asset, ont.TOTALSUPPLY_NAME))
+	assert.Equal(t, uint64(1000), callUint(t, srvc, asset, ont.BALANCEOF_NAME, issuer))
+
+	// the same issuer and symbol derive a different address
+	ret, err = call(srvc, issuer, factory, REGISTER_ASSET, param)
+	assert.Nil(t, err)
+	assert.NotEqual(t, asset[:], ret)
+
+	assert.NotNil(t, transfer(srvc, asset, issuer, alice, 1001))
+	assert.Nil(t, transfer(srvc, asset, issuer, alice, 300))
+	assert.Equal(t, uint64(700), callUint(t, srvc, asset, ont.BALANCEOF_NAME, issuer))
+	assert.Equal(t, uint64(300), callUint(t, srvc, asset, ont.BALANCEOF_NAME, alice))
+
+	// approve and transferFrom
+	_, err = call(srvc, alice, asset, ont.APPROVE_NAME, &ont.State{From: alice, To: bob, Value: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), callUint(t, srvc, asset, ont.ALLOWANCE_NAME, alice, bob))
+	_, err = call(srvc, bob, asset, ont.TRANSFERFROM_NAME, &ont.TransferFrom{Sender: bob, From: alice, To: bob, Value: 101})
+	assert.NotNil(t, err)
+	_, err = call(srvc, bob, asset, ont.TRANSFERFROM_NAME, &ont.TransferFrom{Sender: bob, From: alice, To: bob, Value: 40})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(40), callUint(t, srvc, asset, ont.BALANCEOF_NAME, bob))
+	assert.Equal(t, uint64(60), callUint(t, srvc, asset, ont.ALLOWANCE_NAME, alice, bob))
+
+	// frozen accounts can receive but not send
+	_, err = call(srvc, issuer, asset, FREEZE, alice)
+	assert.NotNil(t, err)
+	_, err = call(srvc, freezer, asset, FREEZE, alice)
+	assert.Nil(t, err)
+	ret, err = call(srvc, bob, asset, IS_FROZEN, alice)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	assert.NotNil(t, transfer(srvc, asset, alice, bob, 10))
+	_, err = call(srvc, bob, asset, ont.TRANSFERFROM_NAME, &ont.TransferFrom{Sender: bob, From: alice, To: bob, Value: 10})
+	assert.NotNil(t, err)
+	assert.Nil(t, transfer(srvc, asset, bob, alice, 10))
+	_, err = call(srvc, freezer, asset, UNFREEZE, alice)
+	assert.Nil(t, err)
+	assert.Nil(t, transfer(srvc, asset, alice, bob, 10))
+
+	// a frozen spender can not move approved funds either
+	_, err = call(srvc, freezer, asset, FREEZE, bob)
+	assert.Nil(t, err)
+	_, err = call(srvc, bob, asset, ont.TRANSFERFROM_NAME, &ont.TransferFrom{Sender: bob, From: alice, To: bob, Value: 10})
+	assert.NotNil(t, err)
+	_, err = call(srvc, freezer, asset, UNFREEZE, bob)
+	assert.Nil(t, err)
+	_, err = call(srvc, bob, asset, ont.TRANSFERFROM_NAME, &ont.TransferFrom{Sender: bob, From: alice, To: bob, Value: 10})
+	assert.Nil(t, err)
+
+	// mint and burn
+	_, err = call(srvc, issuer, asset, MINT, &MintParam{To: minter, Value: 500})
+	assert.NotNil(t, err)
+	_, err = call(srvc, minter, asset, MINT, &MintParam{To: minter, Value: 500})
+	assert.Nil(t, err)
+	_, err = call(srvc, minter, asset, MINT, &MintParam{To: minter, Value: MAX_SUPPLY})
+	assert.NotNil(t, err)
+	assert.Equal(t, uint64(1500), callUint(t, srvc, asset, ont.TOTALSUPPLY_NAME))
+	_, err = call(srvc, minter, asset, BURN, uint64(501))
+	assert.NotNil(t, err)
+	_, err = call(srvc, minter, asset, BURN, uint64(200))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1300), callUint(t, srvc, asset, ont.TOTALSUPPLY_NAME))
+	assert.Equal(t, uint64(300), callUint(t, srvc, asset, ont.BALANCEOF_NAME, minter))
+
+	// assets without authorities have a fixed supply and can not be frozen
+	fixed := &RegisterAssetParam{Issuer: issuer, Name: "Fixed Token", Symbol: "FT", Supply: 10}
+	ret, err = call(srvc, issuer, factory, REGISTER_ASSET, fixed)
+	assert.Nil(t, err)
+	fixedAsset, err := common.AddressParseFromBytes(ret)
+	assert.Nil(t, err)
+	_, err = call(srvc, common.ADDRESS_EMPTY, fixedAsset, MINT, &MintParam{To: alice, Value: 1})
+	assert.NotNil(t, err)
+	_, err = call(srvc, common.ADDRESS_EMPTY, fixedAsset, FREEZE, issuer)
+	assert.NotNil(t, err)
+	fixed.Supply = 0
+	_, err = call(srvc, issuer, factory, REGISTER_ASSET, fixed)
+	assert.NotNil(t, err)
+}
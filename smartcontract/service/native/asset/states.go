@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package asset
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+// RegisterAssetParam is the parameter of registerAsset. A zero mint authority
+// makes the supply fixed, and a zero freeze authority disables freezing.
+type RegisterAssetParam struct {
+	Issuer          common.Address
+	Name            string
+	Symbol          string
+	Decimals        uint64
+	Supply          uint64
+	MintAuthority   common.Address
+	FreezeAuthority common.Address
+}
+
+func (this *RegisterAssetParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Issuer)
+	sink.WriteString(this.Name)
+	sink.WriteString(this.Symbol)
+	utils.EncodeVarUint(sink, this.Decimals)
+	utils.EncodeVarUint(sink, this.Supply)
+	utils.EncodeAddress(sink, this.MintAuthority)
+	utils.EncodeAddress(sink, this.FreezeAuthority)
+}
+
+func (this *RegisterAssetParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	this.Issuer, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize issuer error:%v", err)
+	}
+	this.Name, err = decodeString(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize name error:%v", err)
+	}
+	this.Symbol, err = decodeString(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize symbol error:%v", err)
+	}
+	this.Decimals, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize decimals error:%v", err)
+	}
+	this.Supply, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize supply error:%v", err)
+	}
+	this.MintAuthority, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize mint authority error:%v", err)
+	}
+	this.FreezeAuthority, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[RegisterAssetParam] deserialize freeze authority error:%v", err)
+	}
+	return nil
+}
+
+// MintParam is the parameter of mint
+type MintParam struct {
+	To    common.Address
+	Value uint64
+}
+
+func (this *MintParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.To)
+	utils.EncodeVarUint(sink, this.Value)
+}
+
+func (this *MintParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	this.To, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[MintParam] deserialize to error:%v", err)
+	}
+	this.Value, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("[MintParam] deserialize value error:%v", err)
+	}
+	return nil
+}
+
+// AssetInfo is the metadata of an asset issued by the asset factory
+type AssetInfo struct {
+	Name            string
+	Symbol          string
+	Decimals        uint64
+	Issuer          common.Address
+	MintAuthority   common.Address
+	FreezeAuthority common.Address
+	Height          uint32
+}
+
+func (this *AssetInfo) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.Name)
+	sink.WriteString(this.Symbol)
+	utils.EncodeVarUint(sink, this.Decimals)
+	utils.EncodeAddress(sink, this.Issuer)
+	utils.EncodeAddress(sink, this.MintAuthority)
+	utils.EncodeAddress(sink, this.FreezeAuthority)
+	sink.WriteUint32(this.Height)
+}
+
+func (this *AssetInfo) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	this.Name, err = decodeString(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize name error:%v", err)
+	}
+	this.Symbol, err = decodeString(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize symbol error:%v", err)
+	}
+	this.Decimals, err = utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize decimals error:%v", err)
+	}
+	this.Issuer, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize issuer error:%v", err)
+	}
+	this.MintAuthority, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize mint authority error:%v", err)
+	}
+	this.FreezeAuthority, err = utils.DecodeAddress(source)
+	if err != nil {
+		return fmt.Errorf("[AssetInfo] deserialize freeze authority error:%v", err)
+	}
+	var eof bool
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("[AssetInfo] deserialize height error:%v", io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+func decodeString(source *common.ZeroCopySource) (string, error) {
+	data, _, irregular, eof := source.NextString()
+	if eof {
+		return "", io.ErrUnexpectedEOF
+	}
+	if irregular {
+		return "", common.ErrIrregularData
+	}
+	return data, nil
+}
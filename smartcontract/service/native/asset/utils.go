@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package asset
+
+import (
+	"fmt"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/dnaproject2/DNA/smartcontract/storage"
+)
+
+const (
+	ASSET_COUNT = "assetCount"
+	ASSET       = "asset"
+	FROZEN      = "frozen"
+)
+
+func genAssetCountKey() []byte {
+	return utils.ConcatKey(utils.AssetFactoryContractAddress, []byte(ASSET_COUNT))
+}
+
+func genAssetKey(asset common.Address) []byte {
+	return utils.ConcatKey(utils.AssetFactoryContractAddress, []byte(ASSET), asset[:])
+}
+
+func genFrozenKey(asset, addr common.Address) []byte {
+	return utils.ConcatKey(asset, []byte(FROZEN), addr[:])
+}
+
+// resolveAsset resolves the addresses of issued assets to the asset contract
+func resolveAsset(db *storage.CacheDB, address common.Address) (native.RegisterService, bool) {
+	value, err := db.Get(genAssetKey(address))
+	if err != nil || value == nil {
+		return nil, false
+	}
+	return RegisterAssetContract, true
+}
+
+// genAssetAddress derives the address of a new asset from the issuer, the
+// symbol and the number of assets issued so far
+func genAssetAddress(issuer common.Address, symbol string, count uint64) common.Address {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteAddress(utils.AssetFactoryContractAddress)
+	sink.WriteAddress(issuer)
+	sink.WriteString(symbol)
+	sink.WriteUint64(count)
+	return common.AddressFromVmCode(sink.Bytes())
+}
+
+func getAssetInfo(native *native.NativeService, asset common.Address) (*AssetInfo, error) {
+	item, err := utils.GetStorageItem(native, genAssetKey(asset))
+	if err != nil {
+		return nil, fmt.Errorf("get asset info error:%v", err)
+	}
+	if item == nil {
+		return nil, nil
+	}
+	info := new(AssetInfo)
+	if err := info.Deserialization(common.NewZeroCopySource(item.Value)); err != nil {
+		return nil, fmt.Errorf("deserialize asset info error:%v", err)
+	}
+	return info, nil
+}
+
+// getCurrentAsset returns the metadata of the asset being invoked
+func getCurrentAsset(native *native.NativeService) (common.Address, *AssetInfo, error) {
+	asset := native.ContextRef.CurrentContext().ContractAddress
+	info, err := getAssetInfo(native, asset)
+	if err != nil {
+		return asset, nil, err
+	}
+	if info == nil {
+		return asset, nil, fmt.Errorf("asset %s not found", asset.ToHexString())
+	}
+	return asset, info, nil
+}
+
+func putAssetInfo(native *native.NativeService, asset common.Address, info *AssetInfo) {
+	sink := common.NewZeroCopySink(nil)
+	info.Serialization(sink)
+	utils.PutBytes(native, genAssetKey(asset), sink.Bytes())
+}
+
+func isFrozen(native *native.NativeService, asset, addr common.Address) (bool, error) {
+	item, err := utils.GetStorageItem(native, genFrozenKey(asset, addr))
+	if err != nil {
+		return false, err
+	}
+	return item != nil, nil
+}
+
+func checkNotFrozen(native *native.NativeService, asset, addr common.Address) error {
+	frozen, err := isFrozen(native, asset, addr)
+	if err != nil {
+		return err
+	}
+	if frozen {
+		return fmt.Errorf("account %s is frozen", addr.ToBase58())
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ package auth
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -37,6 +38,9 @@ var (
 	future = time.Date(2100, 1, 1, 12, 0, 0, 0, time.UTC)
 )
 
+// max count of records returned by getAuthHistory and listRoleMembers
+const MAX_QUERY_LIMIT = 100
+
 func Init() {
 	native.Contracts[utils.AuthContractAddress] = RegisterAuthContract
 }
@@ -83,6 +87,10 @@ func InitContractAdmin(native *native.NativeService) ([]byte, error) {
 	if !ret {
 		return utils.BYTE_FALSE, nil
 	}
+	err = pushHistory(native, invokeAddr, "initContractAdmin", nil, param.AdminOntID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("[initContractAdmin] pushHistory failed: %v", err)
+	}
 
 	msg := []interface{}{"initContractAdmin", invokeAddr.ToHexString(), string(param.AdminOntID)}
 	pushEvent(native, msg)
@@ -110,6 +118,10 @@ func transfer(native *native.NativeService, contractAddr common.Address, newAdmi
 
 	adminKey := concatContractAdminKey(native, contractAddr)
 	utils.PutBytes(native, adminKey, newAdminOntID)
+	err = pushHistory(native, contractAddr, "transfer", nil, newAdminOntID, admin)
+	if err != nil {
+		return false, fmt.Errorf("pushHistory failed: %v", err)
+	}
 	return true, nil
 }
 
@@ -202,45 +214,65 @@ func AssignFuncsToRole(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("[assignFuncsToRole] putRoleFunc failed: %v", err)
 	}
+	err = addRole(native, param.ContractAddr, param.Role)
+	if err != nil {
+		return nil, fmt.Errorf("[assignFuncsToRole] addRole failed: %v", err)
+	}
+	err = pushHistory(native, param.ContractAddr, "assignFuncsToRole", param.Role,
+		[]byte(strings.Join(param.FuncNames, ",")), param.AdminOntID)
+	if err != nil {
+		return nil, fmt.Errorf("[assignFuncsToRole] pushHistory failed: %v", err)
+	}
 
 	pushEvent(native, sucState)
 	return utils.BYTE_TRUE, nil
 }
 
-func assignToRole(native *native.NativeService, param *OntIDsToRoleParam) (bool, error) {
-	//check admin's permission
-	admin, err := getContractAdmin(native, param.ContractAddr)
+func verifyAdmin(native *native.NativeService, contractAddr common.Address, adminOntID []byte,
+	keyNo uint64) (bool, error) {
+	admin, err := getContractAdmin(native, contractAddr)
 	if err != nil {
 		return false, fmt.Errorf("getContractAdmin failed: %v", err)
 	}
 	if admin == nil {
-		return false, fmt.Errorf("admin of contract %s is not set", param.ContractAddr.ToHexString())
+		return false, fmt.Errorf("admin of contract %s is not set", contractAddr.ToHexString())
 	}
-	if bytes.Compare(admin, param.AdminOntID) != 0 {
-		log.Debugf("param's adminOntID doesn't match: %s != %s", string(param.AdminOntID),
+	if bytes.Compare(admin, adminOntID) != 0 {
+		log.Debugf("param's adminOntID doesn't match: %s != %s", string(adminOntID),
 			string(admin))
 		return false, nil
 	}
-	valid, err := verifySig(native, param.AdminOntID, param.KeyNo)
+	valid, err := verifySig(native, adminOntID, keyNo)
 	if err != nil {
 		return false, fmt.Errorf("verify admin's signature failed: %v", err)
 	}
 	if !valid {
-		log.Debugf("[assignDnaIDsToRole] verifySig return false: adminOntID=%s, keyNo=%d",
-			string(admin), param.KeyNo)
+		log.Debugf("verifySig return false: adminOntID=%s, keyNo=%d", string(admin), keyNo)
 		return false, nil
 	}
+	return true, nil
+}
 
-	//init a permanent auth token
-	token := new(AuthToken)
-	token.expireTime = uint32(future.Unix())
-	token.level = 2
-	token.role = param.Role
+/*
+ * assign role to persons with a token expiring at expireTime, the expire
+ * time of the token is updated if a person already holds one
+ */
+func assignToRole(native *native.NativeService, param *OntIDsToRoleParam, expireTime uint32) (bool, error) {
+	//check admin's permission
+	valid, err := verifyAdmin(native, param.ContractAddr, param.AdminOntID, param.KeyNo)
+	if err != nil || !valid {
+		return false, err
+	}
 
 	for _, p := range param.Persons {
 		if p == nil {
 			continue
 		}
+		token := &AuthToken{
+			role:       param.Role,
+			expireTime: expireTime,
+			level:      2,
+		}
 		tokens, err := getOntIDToken(native, param.ContractAddr, p)
 		if err != nil {
 			return false, fmt.Errorf("getOntIDToken failed: %v", err)
@@ -249,6 +281,8 @@ func assignToRole(native *native.NativeService, param *OntIDsToRoleParam) (bool,
 			tokens = new(roleTokens)
 			tokens.tokens = make([]*AuthToken, 1)
 			tokens.tokens[0] = token
+		} else if old := findToken(tokens, param.Role); old != nil {
+			old.expireTime = expireTime
 		} else {
 			ret, err := hasRole(native, param.ContractAddr, p, param.Role)
 			if err != nil {
@@ -265,10 +299,27 @@ func assignToRole(native *native.NativeService, param *OntIDsToRoleParam) (bool,
 		if err != nil {
 			return false, err
 		}
+		err = addRoleMember(native, param.ContractAddr, param.Role, p)
+		if err != nil {
+			return false, fmt.Errorf("addRoleMember failed: %v", err)
+		}
+		err = pushHistory(native, param.ContractAddr, "assignDnaIDsToRole", param.Role, p, param.AdminOntID)
+		if err != nil {
+			return false, fmt.Errorf("pushHistory failed: %v", err)
+		}
 	}
 	return true, nil
 }
 
+func findToken(tokens *roleTokens, role []byte) *AuthToken {
+	for _, token := range tokens.tokens {
+		if bytes.Compare(token.role, role) == 0 {
+			return token
+		}
+	}
+	return nil
+}
+
 func AssignDnaIDsToRole(native *native.NativeService) ([]byte, error) {
 	//deserialize param
 	param := new(OntIDsToRoleParam)
@@ -276,25 +327,54 @@ func AssignDnaIDsToRole(native *native.NativeService) ([]byte, error) {
 	if err := param.Deserialize(rd); err != nil {
 		return nil, fmt.Errorf("[assignDnaIDsToRole] deserialize param failed: %v", err)
 	}
+	return assignDnaIDsToRole(native, "assignDnaIDsToRole", param, uint32(future.Unix()))
+}
+
+func AssignDnaIDsToRoleWithExpiry(native *native.NativeService) ([]byte, error) {
+	//deserialize param
+	param := new(OntIDsToRoleWithExpiryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[assignDnaIDsToRoleWithExpiry] deserialize param failed: %v", err)
+	}
+	if param.ExpireTime <= uint64(native.Time) {
+		return nil, fmt.Errorf("[assignDnaIDsToRoleWithExpiry] invalid param: expireTime %d has passed",
+			param.ExpireTime)
+	}
+	if param.ExpireTime > math.MaxUint32 {
+		return nil, fmt.Errorf("[assignDnaIDsToRoleWithExpiry] invalid param: expireTime %d too large",
+			param.ExpireTime)
+	}
+	rolesParam := &OntIDsToRoleParam{
+		ContractAddr: param.ContractAddr,
+		AdminOntID:   param.AdminOntID,
+		Role:         param.Role,
+		Persons:      param.Persons,
+		KeyNo:        param.KeyNo,
+	}
+	return assignDnaIDsToRole(native, "assignDnaIDsToRoleWithExpiry", rolesParam, uint32(param.ExpireTime))
+}
 
+func assignDnaIDsToRole(native *native.NativeService, method string, param *OntIDsToRoleParam,
+	expireTime uint32) ([]byte, error) {
 	if param.Role == nil {
-		return nil, fmt.Errorf("[assignDnaIDsToRole] invalid param: role is nil")
+		return nil, fmt.Errorf("[%s] invalid param: role is nil", method)
 	}
 	for i, ontID := range param.Persons {
 		if !account.VerifyID(string(ontID)) {
-			return nil, fmt.Errorf("[assignDnaIDsToRole] invalid param: param.Persons[%d]=%s",
-				i, string(ontID))
+			return nil, fmt.Errorf("[%s] invalid param: param.Persons[%d]=%s",
+				method, i, string(ontID))
 		}
 	}
 
-	ret, err := assignToRole(native, param)
+	ret, err := assignToRole(native, param, expireTime)
 	if err != nil {
-		return nil, fmt.Errorf("[assignDnaIDsToRole] failed: %v", err)
+		return nil, fmt.Errorf("[%s] failed: %v", method, err)
 	}
 
 	contract := param.ContractAddr.ToHexString()
-	failState := []interface{}{"assignDnaIDsToRole", contract, false}
-	sucState := []interface{}{"assignDnaIDsToRole", contract, true}
+	failState := []interface{}{method, contract, false}
+	sucState := []interface{}{method, contract, true}
 	if ret {
 		pushEvent(native, sucState)
 		return utils.BYTE_TRUE, nil
@@ -310,10 +390,9 @@ func getAuthToken(native *native.NativeService, contractAddr common.Address, ont
 		return nil, fmt.Errorf("get token failed, caused by %v", err)
 	}
 	if tokens != nil {
-		for _, token := range tokens.tokens {
-			if bytes.Compare(token.role, role) == 0 { //permanent token
-				return token, nil
-			}
+		token := findToken(tokens, role)
+		if token != nil && token.expireTime >= native.Time { //assigned token
+			return token, nil
 		}
 	}
 	status, err := getDelegateStatus(native, contractAddr, ontID)
@@ -452,6 +531,14 @@ func delegate(native *native.NativeService, contractAddr common.Address, from []
 			if err != nil {
 				return false, fmt.Errorf("putDelegateStatus failed: %v", err)
 			}
+			err = addRoleMember(native, contractAddr, role, to)
+			if err != nil {
+				return false, fmt.Errorf("addRoleMember failed: %v", err)
+			}
+			err = pushHistory(native, contractAddr, "delegate", role, to, from)
+			if err != nil {
+				return false, fmt.Errorf("pushHistory failed: %v", err)
+			}
 			return true, nil
 		}
 	}
@@ -530,6 +617,21 @@ func withdraw(native *native.NativeService, contractAddr common.Address, initiat
 			if err != nil {
 				return false, err
 			}
+			//the delegate may still hold the role by assignment
+			member, err := getRoleMember(native, contractAddr, delegate, role)
+			if err != nil {
+				return false, err
+			}
+			if member == nil {
+				err = removeRoleMember(native, contractAddr, role, delegate)
+				if err != nil {
+					return false, fmt.Errorf("removeRoleMember failed: %v", err)
+				}
+			}
+			err = pushHistory(native, contractAddr, "withdraw", role, delegate, initiator)
+			if err != nil {
+				return false, fmt.Errorf("pushHistory failed: %v", err)
+			}
 			return true, nil
 		}
 	}
@@ -591,7 +693,14 @@ func verifyToken(native *native.NativeService, contractAddr common.Address, call
 			}
 			for _, f := range funcs.funcNames {
 				if strings.Compare(fn, f) == 0 {
-					return true, nil
+					ret, err := useQuota(native, contractAddr, token.role, caller)
+					if err != nil {
+						return false, fmt.Errorf("useQuota failed: %v", err)
+					}
+					if ret {
+						return true, nil
+					}
+					break
 				}
 			}
 		}
@@ -612,7 +721,14 @@ func verifyToken(native *native.NativeService, contractAddr common.Address, call
 			}
 			for _, f := range funcs.funcNames {
 				if strings.Compare(fn, f) == 0 {
-					return true, nil
+					ret, err := useQuota(native, contractAddr, s.role, caller)
+					if err != nil {
+						return false, fmt.Errorf("useQuota failed: %v", err)
+					}
+					if ret {
+						return true, nil
+					}
+					break
 				}
 			}
 		}
@@ -645,6 +761,219 @@ func VerifyToken(native *native.NativeService) ([]byte, error) {
 	return utils.BYTE_FALSE, nil
 }
 
+/*
+ * limit the calls of each member of role to its funcs, at most param.Quota
+ * calls in every param.Window blocks. a zero quota removes the limit
+ */
+func SetRoleQuota(native *native.NativeService) ([]byte, error) {
+	//deserialize param
+	param := new(RoleQuotaParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[setRoleQuota] deserialize param failed: %v", err)
+	}
+	if param.Role == nil {
+		return nil, fmt.Errorf("[setRoleQuota] invalid param: role is nil")
+	}
+	if param.Quota > 0 && param.Window == 0 {
+		return nil, fmt.Errorf("[setRoleQuota] invalid param: window is 0")
+	}
+
+	contract := param.ContractAddr.ToHexString()
+	failState := []interface{}{"setRoleQuota", contract, false}
+	sucState := []interface{}{"setRoleQuota", contract, true}
+
+	ret, err := verifyAdmin(native, param.ContractAddr, param.AdminOntID, param.KeyNo)
+	if err != nil {
+		return nil, fmt.Errorf("[setRoleQuota] %v", err)
+	}
+	if !ret {
+		pushEvent(native, failState)
+		return utils.BYTE_FALSE, nil
+	}
+
+	quota := &roleQuota{quota: param.Quota, window: uint32(param.Window)}
+	if err := putRoleQuota(native, param.ContractAddr, param.Role, quota); err != nil {
+		return nil, fmt.Errorf("[setRoleQuota] putRoleQuota failed: %v", err)
+	}
+	if err := addRole(native, param.ContractAddr, param.Role); err != nil {
+		return nil, fmt.Errorf("[setRoleQuota] addRole failed: %v", err)
+	}
+	err = pushHistory(native, param.ContractAddr, "setRoleQuota", param.Role,
+		[]byte(fmt.Sprintf("%d/%d", param.Quota, param.Window)), param.AdminOntID)
+	if err != nil {
+		return nil, fmt.Errorf("[setRoleQuota] pushHistory failed: %v", err)
+	}
+	pushEvent(native, sucState)
+	return utils.BYTE_TRUE, nil
+}
+
+func ListRoles(native *native.NativeService) ([]byte, error) {
+	param := new(ListRolesParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[listRoles] deserialize param failed: %v", err)
+	}
+	key := concatRoleListKey(native, param.ContractAddr)
+	count, err := getListCount(native, key)
+	if err != nil {
+		return nil, fmt.Errorf("[listRoles] getListCount failed: %v", err)
+	}
+
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(count)); err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		role, err := getListItem(native, key, i)
+		if err != nil {
+			return nil, fmt.Errorf("[listRoles] getListItem failed: %v", err)
+		}
+		info := &RoleInfo{Role: role, FuncNames: []string{}}
+		funcs, err := getRoleFunc(native, param.ContractAddr, role)
+		if err != nil {
+			return nil, fmt.Errorf("[listRoles] getRoleFunc failed: %v", err)
+		}
+		if funcs != nil {
+			info.FuncNames = funcs.funcNames
+		}
+		quota, err := getRoleQuota(native, param.ContractAddr, role)
+		if err != nil {
+			return nil, fmt.Errorf("[listRoles] getRoleQuota failed: %v", err)
+		}
+		if quota != nil {
+			info.Quota = quota.quota
+			info.Window = quota.window
+		}
+		if err := info.Serialize(bf); err != nil {
+			return nil, err
+		}
+	}
+	return bf.Bytes(), nil
+}
+
+func getRoleMember(native *native.NativeService, contractAddr common.Address, ontID, role []byte) (*RoleMember, error) {
+	tokens, err := getOntIDToken(native, contractAddr, ontID)
+	if err != nil {
+		return nil, fmt.Errorf("getOntIDToken failed: %v", err)
+	}
+	if tokens != nil {
+		token := findToken(tokens, role)
+		if token != nil && token.expireTime >= native.Time {
+			return &RoleMember{OntID: ontID, ExpireTime: token.expireTime, Level: token.level}, nil
+		}
+	}
+	status, err := getDelegateStatus(native, contractAddr, ontID)
+	if err != nil {
+		return nil, fmt.Errorf("getDelegateStatus failed: %v", err)
+	}
+	if status != nil {
+		for _, s := range status.status {
+			if bytes.Compare(s.role, role) == 0 && native.Time < s.expireTime {
+				return &RoleMember{OntID: ontID, ExpireTime: s.expireTime, Level: s.level, Root: s.root}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+//pageRange returns the range of at most limit items from offset in a list of count items
+func pageRange(count uint32, offset, limit uint64) (uint64, uint64) {
+	if limit == 0 || limit > MAX_QUERY_LIMIT {
+		limit = MAX_QUERY_LIMIT
+	}
+	start, end := offset, offset+limit
+	if start > uint64(count) {
+		start = uint64(count)
+	}
+	if end > uint64(count) || end < start {
+		end = uint64(count)
+	}
+	return start, end
+}
+
+/*
+ * return the total count of persons recorded for the role, followed by those
+ * of at most param.Limit persons from param.Offset who currently hold the
+ * role, either assigned or delegated
+ */
+func ListRoleMembers(native *native.NativeService) ([]byte, error) {
+	param := new(ListRoleMembersParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[listRoleMembers] deserialize param failed: %v", err)
+	}
+	key := concatRoleMembersKey(native, param.ContractAddr, param.Role)
+	count, err := getListCount(native, key)
+	if err != nil {
+		return nil, fmt.Errorf("[listRoleMembers] getListCount failed: %v", err)
+	}
+	start, end := pageRange(count, param.Offset, param.Limit)
+
+	members := make([]*RoleMember, 0, end-start)
+	for i := start; i < end; i++ {
+		p, err := getListItem(native, key, uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("[listRoleMembers] getListItem failed: %v", err)
+		}
+		member, err := getRoleMember(native, param.ContractAddr, p, param.Role)
+		if err != nil {
+			return nil, fmt.Errorf("[listRoleMembers] %v", err)
+		}
+		if member != nil {
+			members = append(members, member)
+		}
+	}
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(count)); err != nil {
+		return nil, err
+	}
+	if err := utils.WriteVarUint(bf, uint64(len(members))); err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if err := m.Serialize(bf); err != nil {
+			return nil, err
+		}
+	}
+	return bf.Bytes(), nil
+}
+
+/*
+ * return the total count of history records of the contract, followed by at
+ * most param.Limit records from param.Offset
+ */
+func GetAuthHistory(native *native.NativeService) ([]byte, error) {
+	param := new(GetAuthHistoryParam)
+	rd := bytes.NewReader(native.Input)
+	if err := param.Deserialize(rd); err != nil {
+		return nil, fmt.Errorf("[getAuthHistory] deserialize param failed: %v", err)
+	}
+	count, err := getHistoryCount(native, param.ContractAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[getAuthHistory] getHistoryCount failed: %v", err)
+	}
+	start, end := pageRange(count, param.Offset, param.Limit)
+
+	bf := new(bytes.Buffer)
+	if err := utils.WriteVarUint(bf, uint64(count)); err != nil {
+		return nil, err
+	}
+	if err := utils.WriteVarUint(bf, end-start); err != nil {
+		return nil, err
+	}
+	for i := start; i < end; i++ {
+		record, err := getHistoryRecord(native, param.ContractAddr, uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("[getAuthHistory] getHistoryRecord failed: %v", err)
+		}
+		if err := record.Serialize(bf); err != nil {
+			return nil, err
+		}
+	}
+	return bf.Bytes(), nil
+}
+
 func verifySig(native *native.NativeService, ontID []byte, keyNo uint64) (bool, error) {
 	bf := new(bytes.Buffer)
 	if err := serialization.WriteVarBytes(bf, ontID); err != nil {
@@ -677,4 +1006,9 @@ func RegisterAuthContract(native *native.NativeService) {
 	native.Register("assignDnaIDsToRole", AssignDnaIDsToRole)
 	native.Register("verifyToken", VerifyToken)
 	native.Register("transfer", Transfer)
+	native.Register("assignDnaIDsToRoleWithExpiry", AssignDnaIDsToRoleWithExpiry)
+	native.Register("setRoleQuota", SetRoleQuota)
+	native.Register("listRoles", ListRoles)
+	native.Register("listRoleMembers", ListRoleMembers)
+	native.Register("getAuthHistory", GetAuthHistory)
 }
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package auth
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/nativetest"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ontid"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func invoke(t *testing.T, srvc *native.NativeService, method native.Handler, signer common.Address,
+	param interface{}) []byte {
+	srvc.ContextRef = nativetest.NewContextRef([]common.Address{signer}, OntContractAddr,
+		utils.AuthContractAddress)
+	ret, err := nativetest.Invoke(srvc, method, param)
+	assert.Nil(t, err)
+	return ret
+}
+
+func listRoleMembers(t *testing.T, srvc *native.NativeService, offset, limit uint64) (uint64, map[string]*RoleMember) {
+	ret := invoke(t, srvc, ListRoleMembers, common.ADDRESS_EMPTY,
+		&ListRoleMembersParam{ContractAddr: OntContractAddr, Role: []byte(role), Offset: offset, Limit: limit})
+	rd := bytes.NewReader(ret)
+	total, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	n, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	members := make(map[string]*RoleMember)
+	for i := uint64(0); i < n; i++ {
+		m := new(RoleMember)
+		assert.Nil(t, m.Deserialize(rd))
+		members[string(m.OntID)] = m
+	}
+	return total, members
+}
+
+func TestRoleExpiryAndQuota(t *testing.T) {
+	ontid.Init()
+	srvc := nativetest.NewNativeService(t)
+	srvc.Time = 1000
+	admin, adminAddr := nativetest.NewTestID(t, srvc)
+	alice, aliceAddr := nativetest.NewTestID(t, srvc)
+	bob, bobAddr := nativetest.NewTestID(t, srvc)
+
+	ret := invoke(t, srvc, InitContractAdmin, adminAddr, &InitContractAdminParam{AdminOntID: admin})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	ret = invoke(t, srvc, AssignFuncsToRole, adminAddr, &FuncsToRoleParam{
+		ContractAddr: OntContractAddr, AdminOntID: admin, Role: []byte(role), FuncNames: funcs, KeyNo: 1})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+
+	// only the admin can assign roles
+	ret = invoke(t, srvc, AssignDnaIDsToRoleWithExpiry, aliceAddr, &OntIDsToRoleWithExpiryParam{
+		ContractAddr: OntContractAddr, AdminOntID: alice, Role: []byte(role), Persons: [][]byte{alice},
+		KeyNo: 1, ExpireTime: 1100})
+	assert.Equal(t, utils.BYTE_FALSE, ret)
+	// expire time beyond uint32 is rejected rather than truncated
+	srvc.ContextRef = nativetest.NewContextRef([]common.Address{adminAddr}, OntContractAddr,
+		utils.AuthContractAddress)
+	_, err := nativetest.Invoke(srvc, AssignDnaIDsToRoleWithExpiry, &OntIDsToRoleWithExpiryParam{
+		ContractAddr: OntContractAddr, AdminOntID: admin, Role: []byte(role), Persons: [][]byte{alice},
+		KeyNo: 1, ExpireTime: 1<<32 + 1100})
+	assert.NotNil(t, err)
+	ret = invoke(t, srvc, AssignDnaIDsToRoleWithExpiry, adminAddr, &OntIDsToRoleWithExpiryParam{
+		ContractAddr: OntContractAddr, AdminOntID: admin, Role: []byte(role), Persons: [][]byte{alice},
+		KeyNo: 1, ExpireTime: 1100})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	ret = invoke(t, srvc, AssignDnaIDsToRole, adminAddr, &OntIDsToRoleParam{
+		ContractAddr: OntContractAddr, AdminOntID: admin, Role: []byte(role), Persons: [][]byte{bob},
+		KeyNo: 1})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+
+	aliceToken := &VerifyTokenParam{ContractAddr: OntContractAddr, Caller: alice, Fn: "foo1", KeyNo: 1}
+	assert.Equal(t, utils.BYTE_TRUE, invoke(t, srvc, VerifyToken, aliceAddr, aliceToken))
+	total, members := listRoleMembers(t, srvc, 0, 0)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, 2, len(members))
+	total, members = listRoleMembers(t, srvc, 1, 1)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, 1, len(members))
+	assert.NotNil(t, members[string(bob)])
+
+	// alice's role expires
+	srvc.Time = 1101
+	assert.Equal(t, utils.BYTE_FALSE, invoke(t, srvc, VerifyToken, aliceAddr, aliceToken))
+	total, members = listRoleMembers(t, srvc, 0, 0)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, 1, len(members))
+	assert.NotNil(t, members[string(bob)])
+
+	// bob can call at most twice in every 10 blocks
+	ret = invoke(t, srvc, SetRoleQuota, adminAddr, &RoleQuotaParam{
+		ContractAddr: OntContractAddr, AdminOntID: admin, Role: []byte(role), Quota: 2, Window: 10, KeyNo: 1})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	bobToken := &VerifyTokenParam{ContractAddr: OntContractAddr, Caller: bob, Fn: "foo2", KeyNo: 1}
+	assert.Equal(t, utils.BYTE_TRUE, invoke(t, srvc, VerifyToken, bobAddr, bobToken))
+	assert.Equal(t, utils.BYTE_TRUE, invoke(t, srvc, VerifyToken, bobAddr, bobToken))
+	assert.Equal(t, utils.BYTE_FALSE, invoke(t, srvc, VerifyToken, bobAddr, bobToken))
+	srvc.Height = 10
+	assert.Equal(t, utils.BYTE_TRUE, invoke(t, srvc, VerifyToken, bobAddr, bobToken))
+
+	// bob delegates the role to alice
+	ret = invoke(t, srvc, Delegate, bobAddr, &DelegateParam{
+		ContractAddr: OntContractAddr, From: bob, To: alice, Role: []byte(role), Period: 50, Level: 1, KeyNo: 1})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	total, members = listRoleMembers(t, srvc, 0, 0)
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, 2, len(members))
+	assert.Equal(t, bob, members[string(alice)].Root)
+	assert.Equal(t, uint8(1), members[string(alice)].Level)
+
+	// alice no longer holds the role after the withdraw, so she is removed from the members
+	ret = invoke(t, srvc, Withdraw, bobAddr, &WithdrawParam{
+		ContractAddr: OntContractAddr, Initiator: bob, Delegate: alice, Role: []byte(role), KeyNo: 1})
+	assert.Equal(t, utils.BYTE_TRUE, ret)
+	total, members = listRoleMembers(t, srvc, 0, 0)
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, 1, len(members))
+	assert.NotNil(t, members[string(bob)])
+
+	ret = invoke(t, srvc, ListRoles, common.ADDRESS_EMPTY, &ListRolesParam{ContractAddr: OntContractAddr})
+	rd := bytes.NewReader(ret)
+	n, err := utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), n)
+	info := new(RoleInfo)
+	assert.Nil(t, info.Deserialize(rd))
+	assert.Equal(t, []byte(role), info.Role)
+	assert.True(t, testEq(funcs, info.FuncNames))
+	assert.Equal(t, uint64(2), info.Quota)
+	assert.Equal(t, uint32(10), info.Window)
+
+	ret = invoke(t, srvc, GetAuthHistory, common.ADDRESS_EMPTY,
+		&GetAuthHistoryParam{ContractAddr: OntContractAddr, Offset: 1, Limit: 3})
+	rd = bytes.NewReader(ret)
+	total, err = utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), total)
+	n, err = utils.ReadVarUint(rd)
+	assert.Nil(t, err)
+	ops := make([]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		record := new(AuthRecord)
+		assert.Nil(t, record.Deserialize(rd))
+		ops = append(ops, record.Op)
+	}
+	assert.Equal(t, []string{"assignFuncsToRole", "assignDnaIDsToRole", "assignDnaIDsToRole"}, ops)
+}
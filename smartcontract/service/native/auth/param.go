@@ -358,3 +358,191 @@ func (this *VerifyTokenParam) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+type OntIDsToRoleWithExpiryParam struct {
+	ContractAddr common.Address
+	AdminOntID   []byte
+	Role         []byte
+	Persons      [][]byte
+	KeyNo        uint64
+	ExpireTime   uint64
+}
+
+func (this *OntIDsToRoleWithExpiryParam) Serialize(w io.Writer) error {
+	param := &OntIDsToRoleParam{
+		ContractAddr: this.ContractAddr,
+		AdminOntID:   this.AdminOntID,
+		Role:         this.Role,
+		Persons:      this.Persons,
+		KeyNo:        this.KeyNo,
+	}
+	if err := param.Serialize(w); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.ExpireTime); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *OntIDsToRoleWithExpiryParam) Deserialize(rd io.Reader) error {
+	param := new(OntIDsToRoleParam)
+	if err := param.Deserialize(rd); err != nil {
+		return err
+	}
+	expireTime, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return err
+	}
+	this.ContractAddr = param.ContractAddr
+	this.AdminOntID = param.AdminOntID
+	this.Role = param.Role
+	this.Persons = param.Persons
+	this.KeyNo = param.KeyNo
+	this.ExpireTime = expireTime
+	return nil
+}
+
+type RoleQuotaParam struct {
+	ContractAddr common.Address
+	AdminOntID   []byte
+	Role         []byte
+	Quota        uint64
+	Window       uint64
+	KeyNo        uint64
+}
+
+func (this *RoleQuotaParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.AdminOntID); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Quota); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Window); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.KeyNo); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *RoleQuotaParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.AdminOntID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Quota, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.Window, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.KeyNo, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.Window > math.MaxUint32 {
+		return fmt.Errorf("window too large: %d", this.Window)
+	}
+	return nil
+}
+
+type ListRolesParam struct {
+	ContractAddr common.Address
+}
+
+func (this *ListRolesParam) Serialize(w io.Writer) error {
+	return serializeAddress(w, this.ContractAddr)
+}
+
+func (this *ListRolesParam) Deserialize(rd io.Reader) error {
+	var err error
+	this.ContractAddr, err = utils.ReadAddress(rd)
+	return err
+}
+
+type ListRoleMembersParam struct {
+	ContractAddr common.Address
+	Role         []byte
+	Offset       uint64
+	Limit        uint64
+}
+
+func (this *ListRoleMembersParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Offset); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Limit); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *ListRoleMembersParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Offset, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.Limit, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
+
+type GetAuthHistoryParam struct {
+	ContractAddr common.Address
+	Offset       uint64
+	Limit        uint64
+}
+
+func (this *GetAuthHistoryParam) Serialize(w io.Writer) error {
+	if err := serializeAddress(w, this.ContractAddr); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Offset); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, this.Limit); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (this *GetAuthHistoryParam) Deserialize(rd io.Reader) error {
+	var err error
+	if this.ContractAddr, err = utils.ReadAddress(rd); err != nil {
+		return err
+	}
+	if this.Offset, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	if this.Limit, err = utils.ReadVarUint(rd); err != nil {
+		return err
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 
 	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
 )
 
 /*
@@ -187,3 +188,181 @@ func (this *roleTokens) Deserialize(rd io.Reader) error {
 	}
 	return nil
 }
+
+/*
+ * each role may limit the calls of each member to its funcs in a window
+ * of blocks
+ */
+type roleQuota struct {
+	quota  uint64
+	window uint32
+}
+
+func (this *roleQuota) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint64(w, this.quota); err != nil {
+		return err
+	}
+	return serialization.WriteUint32(w, this.window)
+}
+
+func (this *roleQuota) Deserialize(rd io.Reader) error {
+	var err error
+	if this.quota, err = serialization.ReadUint64(rd); err != nil {
+		return err
+	}
+	this.window, err = serialization.ReadUint32(rd)
+	return err
+}
+
+// calls of a member in the window with the index
+type quotaUsage struct {
+	index uint32
+	count uint64
+}
+
+func (this *quotaUsage) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.index); err != nil {
+		return err
+	}
+	return serialization.WriteUint64(w, this.count)
+}
+
+func (this *quotaUsage) Deserialize(rd io.Reader) error {
+	var err error
+	if this.index, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	this.count, err = serialization.ReadUint64(rd)
+	return err
+}
+
+/*
+ * query results of listRoles, listRoleMembers and getAuthHistory
+ */
+type RoleInfo struct {
+	Role      []byte
+	FuncNames []string
+	Quota     uint64
+	Window    uint32
+}
+
+func (this *RoleInfo) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(w, uint64(len(this.FuncNames))); err != nil {
+		return err
+	}
+	for _, fn := range this.FuncNames {
+		if err := serialization.WriteString(w, fn); err != nil {
+			return err
+		}
+	}
+	if err := serialization.WriteUint64(w, this.Quota); err != nil {
+		return err
+	}
+	return serialization.WriteUint32(w, this.Window)
+}
+
+func (this *RoleInfo) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	n, err := utils.ReadVarUint(rd)
+	if err != nil {
+		return err
+	}
+	this.FuncNames = make([]string, 0)
+	for i := uint64(0); i < n; i++ {
+		fn, err := serialization.ReadString(rd)
+		if err != nil {
+			return err
+		}
+		this.FuncNames = append(this.FuncNames, fn)
+	}
+	if this.Quota, err = serialization.ReadUint64(rd); err != nil {
+		return err
+	}
+	this.Window, err = serialization.ReadUint32(rd)
+	return err
+}
+
+// Root is the delegator of a delegated member, empty for direct members
+type RoleMember struct {
+	OntID      []byte
+	ExpireTime uint32
+	Level      uint8
+	Root       []byte
+}
+
+func (this *RoleMember) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.OntID); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.ExpireTime); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint8(w, this.Level); err != nil {
+		return err
+	}
+	return serialization.WriteVarBytes(w, this.Root)
+}
+
+func (this *RoleMember) Deserialize(rd io.Reader) error {
+	var err error
+	if this.OntID, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.ExpireTime, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.Level, err = serialization.ReadUint8(rd); err != nil {
+		return err
+	}
+	this.Root, err = serialization.ReadVarBytes(rd)
+	return err
+}
+
+// AuthRecord is an entry of the auth history of a contract
+type AuthRecord struct {
+	Height   uint32
+	Op       string
+	Role     []byte
+	Subject  []byte
+	Operator []byte
+}
+
+func (this *AuthRecord) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.Height); err != nil {
+		return err
+	}
+	if err := serialization.WriteString(w, this.Op); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Role); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Subject); err != nil {
+		return err
+	}
+	return serialization.WriteVarBytes(w, this.Operator)
+}
+
+func (this *AuthRecord) Deserialize(rd io.Reader) error {
+	var err error
+	if this.Height, err = serialization.ReadUint32(rd); err != nil {
+		return err
+	}
+	if this.Op, err = serialization.ReadString(rd); err != nil {
+		return err
+	}
+	if this.Role, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	if this.Subject, err = serialization.ReadVarBytes(rd); err != nil {
+		return err
+	}
+	this.Operator, err = serialization.ReadVarBytes(rd)
+	return err
+}
@@ -35,6 +35,11 @@ var (
 	PreRoleFunc       = []byte{0x02}
 	PreRoleToken      = []byte{0x03}
 	PreDelegateStatus = []byte{0x04}
+	PreRoleQuota      = []byte{0x05}
+	PreQuotaUsage     = []byte{0x06}
+	PreRoleList       = []byte{0x07}
+	PreRoleMembers    = []byte{0x08}
+	PreHistory        = []byte{0x09}
 )
 
 //type(this.contractAddr.Admin) = []byte
@@ -181,6 +186,290 @@ func putDelegateStatus(native *native.NativeService, contractAddr common.Address
 	return nil
 }
 
+//type(this.contractAddr.RoleQuota.role) = roleQuota
+func concatRoleQuotaKey(native *native.NativeService, contractAddr common.Address, role []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreRoleQuota...)
+	key = append(key, role...)
+
+	return key
+}
+
+func getRoleQuota(native *native.NativeService, contractAddr common.Address, role []byte) (*roleQuota, error) {
+	key := concatRoleQuotaKey(native, contractAddr, role)
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, nil
+	}
+	quota := new(roleQuota)
+	err = quota.Deserialize(bytes.NewReader(item.Value))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize roleQuota object failed. data: %x", item.Value)
+	}
+	return quota, nil
+}
+
+func putRoleQuota(native *native.NativeService, contractAddr common.Address, role []byte, quota *roleQuota) error {
+	key := concatRoleQuotaKey(native, contractAddr, role)
+	if quota.quota == 0 {
+		native.CacheDB.Delete(key)
+		return nil
+	}
+	bf := new(bytes.Buffer)
+	err := quota.Serialize(bf)
+	if err != nil {
+		return fmt.Errorf("serialize roleQuota failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+//type(this.contractAddr.QuotaUsage.role.ontID) = quotaUsage
+func concatQuotaUsageKey(native *native.NativeService, contractAddr common.Address, role, ontID []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreQuotaUsage...)
+	bf := bytes.NewBuffer(key)
+	serialization.WriteVarBytes(bf, role)
+	bf.Write(ontID)
+
+	return bf.Bytes()
+}
+
+func getQuotaUsage(native *native.NativeService, contractAddr common.Address, role, ontID []byte) (*quotaUsage, error) {
+	key := concatQuotaUsageKey(native, contractAddr, role, ontID)
+	item, err := utils.GetStorageItem(native, key)
+	if err != nil {
+		return nil, err
+	}
+	usage := new(quotaUsage)
+	if item == nil {
+		return usage, nil
+	}
+	err = usage.Deserialize(bytes.NewReader(item.Value))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize quotaUsage object failed. data: %x", item.Value)
+	}
+	return usage, nil
+}
+
+func putQuotaUsage(native *native.NativeService, contractAddr common.Address, role, ontID []byte, usage *quotaUsage) error {
+	key := concatQuotaUsageKey(native, contractAddr, role, ontID)
+	bf := new(bytes.Buffer)
+	err := usage.Serialize(bf)
+	if err != nil {
+		return fmt.Errorf("serialize quotaUsage failed, caused by %v", err)
+	}
+	utils.PutBytes(native, key, bf.Bytes())
+	return nil
+}
+
+//useQuota counts a call of ontID through role, false is returned if the
+//quota of the role in current window has been used up
+func useQuota(native *native.NativeService, contractAddr common.Address, role, ontID []byte) (bool, error) {
+	quota, err := getRoleQuota(native, contractAddr, role)
+	if err != nil {
+		return false, err
+	}
+	if quota == nil {
+		return true, nil
+	}
+	usage, err := getQuotaUsage(native, contractAddr, role, ontID)
+	if err != nil {
+		return false, err
+	}
+	index := native.Height / quota.window
+	if usage.index != index {
+		usage.index = index
+		usage.count = 0
+	}
+	if usage.count >= quota.quota {
+		return false, nil
+	}
+	usage.count += 1
+	return true, putQuotaUsage(native, contractAddr, role, ontID, usage)
+}
+
+/*
+ * an indexed list keeps the count of items at key, the item at index i at
+ * key.0x01.i and the position of an item plus one at key.0x02.item, so that
+ * items can be added, removed and paged without rewriting the whole list
+ */
+func concatListItemKey(key []byte, index uint32) []byte {
+	bf := bytes.NewBuffer(nil)
+	bf.Write(key)
+	bf.WriteByte(0x01)
+	serialization.WriteUint32(bf, index)
+	return bf.Bytes()
+}
+
+func concatListPosKey(key []byte, item []byte) []byte {
+	bf := bytes.NewBuffer(nil)
+	bf.Write(key)
+	bf.WriteByte(0x02)
+	bf.Write(item)
+	return bf.Bytes()
+}
+
+func getListCount(native *native.NativeService, key []byte) (uint32, error) {
+	return utils.GetStorageUInt32(native, key)
+}
+
+func getListItem(native *native.NativeService, key []byte, index uint32) ([]byte, error) {
+	item, err := utils.GetStorageItem(native, concatListItemKey(key, index))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("list item %d not found", index)
+	}
+	return item.Value, nil
+}
+
+func addToList(native *native.NativeService, key []byte, item []byte) error {
+	pos, err := utils.GetStorageUInt32(native, concatListPosKey(key, item))
+	if err != nil {
+		return err
+	}
+	if pos != 0 {
+		return nil
+	}
+	count, err := getListCount(native, key)
+	if err != nil {
+		return err
+	}
+	utils.PutBytes(native, concatListItemKey(key, count), item)
+	native.CacheDB.Put(concatListPosKey(key, item), utils.GenUInt32StorageItem(count+1).ToArray())
+	native.CacheDB.Put(key, utils.GenUInt32StorageItem(count+1).ToArray())
+	return nil
+}
+
+//removeFromList moves the last item into the position of the removed one
+func removeFromList(native *native.NativeService, key []byte, item []byte) error {
+	pos, err := utils.GetStorageUInt32(native, concatListPosKey(key, item))
+	if err != nil {
+		return err
+	}
+	if pos == 0 {
+		return nil
+	}
+	count, err := getListCount(native, key)
+	if err != nil {
+		return err
+	}
+	if pos != count {
+		last, err := getListItem(native, key, count-1)
+		if err != nil {
+			return err
+		}
+		utils.PutBytes(native, concatListItemKey(key, pos-1), last)
+		native.CacheDB.Put(concatListPosKey(key, last), utils.GenUInt32StorageItem(pos).ToArray())
+	}
+	native.CacheDB.Delete(concatListItemKey(key, count-1))
+	native.CacheDB.Delete(concatListPosKey(key, item))
+	native.CacheDB.Put(key, utils.GenUInt32StorageItem(count-1).ToArray())
+	return nil
+}
+
+//type(this.contractAddr.RoleList) = indexed list of roles
+func concatRoleListKey(native *native.NativeService, contractAddr common.Address) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreRoleList...)
+
+	return key
+}
+
+func addRole(native *native.NativeService, contractAddr common.Address, role []byte) error {
+	return addToList(native, concatRoleListKey(native, contractAddr), role)
+}
+
+//type(this.contractAddr.RoleMembers.role) = indexed list of ontIDs,
+//role is written with its length so that the lists of different roles do not overlap
+func concatRoleMembersKey(native *native.NativeService, contractAddr common.Address, role []byte) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	bf := bytes.NewBuffer(nil)
+	bf.Write(this[:])
+	bf.Write(contractAddr[:])
+	bf.Write(PreRoleMembers)
+	serialization.WriteVarBytes(bf, role)
+
+	return bf.Bytes()
+}
+
+func addRoleMember(native *native.NativeService, contractAddr common.Address, role, ontID []byte) error {
+	if err := addRole(native, contractAddr, role); err != nil {
+		return err
+	}
+	return addToList(native, concatRoleMembersKey(native, contractAddr, role), ontID)
+}
+
+func removeRoleMember(native *native.NativeService, contractAddr common.Address, role, ontID []byte) error {
+	return removeFromList(native, concatRoleMembersKey(native, contractAddr, role), ontID)
+}
+
+//type(this.contractAddr.History) = count of records,
+//type(this.contractAddr.History.index) = AuthRecord
+func concatHistoryKey(native *native.NativeService, contractAddr common.Address) []byte {
+	this := native.ContextRef.CurrentContext().ContractAddress
+	key := append(this[:], contractAddr[:]...)
+	key = append(key, PreHistory...)
+
+	return key
+}
+
+func concatHistoryRecordKey(native *native.NativeService, contractAddr common.Address, index uint32) []byte {
+	bf := bytes.NewBuffer(concatHistoryKey(native, contractAddr))
+	serialization.WriteUint32(bf, index)
+	return bf.Bytes()
+}
+
+func getHistoryCount(native *native.NativeService, contractAddr common.Address) (uint32, error) {
+	return utils.GetStorageUInt32(native, concatHistoryKey(native, contractAddr))
+}
+
+func getHistoryRecord(native *native.NativeService, contractAddr common.Address, index uint32) (*AuthRecord, error) {
+	item, err := utils.GetStorageItem(native, concatHistoryRecordKey(native, contractAddr, index))
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("history record %d not found", index)
+	}
+	record := new(AuthRecord)
+	err = record.Deserialize(bytes.NewReader(item.Value))
+	if err != nil {
+		return nil, fmt.Errorf("deserialize AuthRecord object failed. data: %x", item.Value)
+	}
+	return record, nil
+}
+
+//pushHistory appends a record to the auth history of the contract
+func pushHistory(native *native.NativeService, contractAddr common.Address, op string, role, subject, operator []byte) error {
+	count, err := getHistoryCount(native, contractAddr)
+	if err != nil {
+		return fmt.Errorf("getHistoryCount failed: %v", err)
+	}
+	record := &AuthRecord{
+		Height:   native.Height,
+		Op:       op,
+		Role:     role,
+		Subject:  subject,
+		Operator: operator,
+	}
+	bf := new(bytes.Buffer)
+	if err := record.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize AuthRecord failed, caused by %v", err)
+	}
+	utils.PutBytes(native, concatHistoryRecordKey(native, contractAddr, count), bf.Bytes())
+	native.CacheDB.Put(concatHistoryKey(native, contractAddr), utils.GenUInt32StorageItem(count+1).ToArray())
+	return nil
+}
+
 //remote duplicates in the slice of string
 func stringSliceUniq(s []string) []string {
 	smap := make(map[string]int)
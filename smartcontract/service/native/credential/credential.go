@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package credential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+const (
+	COMMIT         = "commit"
+	REVOKE         = "revoke"
+	GET_STATUS     = "getStatus"
+	GET_CREDENTIAL = "getCredential"
+
+	MAX_ID_LENGTH = 255
+)
+
+func Init() {
+	native.Contracts[utils.CredentialContractAddress] = RegisterCredentialContract
+}
+
+func RegisterCredentialContract(srvc *native.NativeService) {
+	srvc.Register(COMMIT, Commit)
+	srvc.Register(REVOKE, Revoke)
+	srvc.Register(GET_STATUS, GetStatus)
+	srvc.Register(GET_CREDENTIAL, GetCredential)
+}
+
+// Commit records the commit hash of a credential issued by a DNA ID. The
+// transaction must be signed by the key of the issuer at the given index.
+// Credential IDs are unique per issuer.
+func Commit(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: credential ID
+	arg0, err := readID(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: argument 0 error, %s", err)
+	}
+	// arg1: issuer
+	arg1, err := readIssuer(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: argument 1 error, %s", err)
+	}
+	// arg2: index of the issuer's key
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: argument 2 error, %s", err)
+	}
+	// arg3: holder
+	arg3, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: argument 3 error, %s", err)
+	}
+	if !account.VerifyID(string(arg3)) {
+		return utils.BYTE_FALSE, errors.New("commit error: invalid holder")
+	}
+
+	if err := verifySignature(srvc, arg1, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: %s", err)
+	}
+	old, err := getCredential(srvc, arg1, arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("commit error: %s", err)
+	} else if old != nil {
+		return utils.BYTE_FALSE, errors.New("commit error: credential already exists")
+	}
+
+	cred := &Credential{
+		Issuer:       arg1,
+		Holder:       arg3,
+		Status:       STATUS_COMMITTED,
+		CommitHeight: srvc.Height,
+	}
+	putCredential(srvc, arg0, cred)
+	triggerCredentialEvent(srvc, "Commit", arg0, cred)
+	return utils.BYTE_TRUE, nil
+}
+
+// Revoke marks a committed credential as revoked. Only the issuer of the
+// credential is able to revoke it.
+func Revoke(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: credential ID
+	arg0, err := readID(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke error: argument 0 error, %s", err)
+	}
+	// arg1: issuer
+	arg1, err := readIssuer(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke error: argument 1 error, %s", err)
+	}
+	// arg2: index of the issuer's key
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke error: argument 2 error, %s", err)
+	}
+
+	cred, err := getCredential(srvc, arg1, arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke error: %s", err)
+	} else if cred == nil {
+		return utils.BYTE_FALSE, errors.New("revoke error: credential not found")
+	} else if cred.Status != STATUS_COMMITTED {
+		return utils.BYTE_FALSE, errors.New("revoke error: credential already revoked")
+	}
+	if err := verifySignature(srvc, arg1, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke error: %s", err)
+	}
+
+	cred.Status = STATUS_REVOKED
+	cred.RevokeHeight = srvc.Height
+	putCredential(srvc, arg0, cred)
+	triggerCredentialEvent(srvc, "Revoke", arg0, cred)
+	return utils.BYTE_TRUE, nil
+}
+
+// GetStatus returns the status of a credential of an issuer, STATUS_NONE if
+// it has never been committed.
+func GetStatus(srvc *native.NativeService) ([]byte, error) {
+	id, issuer, err := readQuery(bytes.NewBuffer(srvc.Input))
+	if err != nil {
+		return nil, fmt.Errorf("get status error: invalid argument, %s", err)
+	}
+	cred, err := getCredential(srvc, issuer, id)
+	if err != nil {
+		return nil, fmt.Errorf("get status error: %s", err)
+	} else if cred == nil {
+		return []byte{STATUS_NONE}, nil
+	}
+	return []byte{cred.Status}, nil
+}
+
+// GetCredential returns the serialized record of a credential of an issuer,
+// or nil if it has never been committed.
+func GetCredential(srvc *native.NativeService) ([]byte, error) {
+	id, issuer, err := readQuery(bytes.NewBuffer(srvc.Input))
+	if err != nil {
+		return nil, fmt.Errorf("get credential error: invalid argument, %s", err)
+	}
+	cred, err := getCredential(srvc, issuer, id)
+	if err != nil {
+		return nil, fmt.Errorf("get credential error: %s", err)
+	} else if cred == nil {
+		return nil, nil
+	}
+	return cred.ToArray(), nil
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package credential
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/nativetest"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ontid"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func invoke(srvc *native.NativeService, method native.Handler, signer common.Address,
+	args ...interface{}) ([]byte, error) {
+	srvc.ContextRef = nativetest.NewContextRef([]common.Address{signer}, utils.CredentialContractAddress)
+	return nativetest.Invoke(srvc, method, args...)
+}
+
+func TestCredential(t *testing.T) {
+	ontid.Init()
+	srvc := nativetest.NewNativeService(t)
+	issuer, issuerAddr := nativetest.NewTestID(t, srvc)
+	holder, holderAddr := nativetest.NewTestID(t, srvc)
+	credID := []byte("credential commit hash")
+
+	status, err := invoke(srvc, GetStatus, issuerAddr, credID, issuer)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{STATUS_NONE}, status)
+
+	_, err = invoke(srvc, Commit, holderAddr, credID, issuer, uint64(1), holder)
+	assert.NotNil(t, err)
+	_, err = invoke(srvc, Commit, issuerAddr, credID, issuer, uint64(1), []byte("not an ID"))
+	assert.NotNil(t, err)
+	// another issuer committing the same ID first does not block the issuer
+	_, err = invoke(srvc, Commit, holderAddr, credID, holder, uint64(1), holder)
+	assert.Nil(t, err)
+	_, err = invoke(srvc, Commit, issuerAddr, credID, issuer, uint64(1), holder)
+	assert.Nil(t, err)
+	_, err = invoke(srvc, Commit, issuerAddr, credID, issuer, uint64(1), holder)
+	assert.NotNil(t, err)
+
+	res, err := invoke(srvc, GetCredential, issuerAddr, credID, issuer)
+	assert.Nil(t, err)
+	cred := new(Credential)
+	assert.Nil(t, cred.Deserialize(bytes.NewBuffer(res)))
+	assert.Equal(t, Credential{Issuer: issuer, Holder: holder, Status: STATUS_COMMITTED, CommitHeight: 1}, *cred)
+
+	// only the issuer can revoke, and only once
+	srvc.Height = 5
+	_, err = invoke(srvc, Revoke, holderAddr, credID, issuer, uint64(1))
+	assert.NotNil(t, err)
+	_, err = invoke(srvc, Revoke, issuerAddr, credID, issuer, uint64(1))
+	assert.Nil(t, err)
+	_, err = invoke(srvc, Revoke, issuerAddr, credID, issuer, uint64(1))
+	assert.NotNil(t, err)
+
+	status, err = invoke(srvc, GetStatus, issuerAddr, credID, issuer)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{STATUS_REVOKED}, status)
+	res, err = invoke(srvc, GetCredential, issuerAddr, credID, issuer)
+	assert.Nil(t, err)
+	assert.Nil(t, cred.Deserialize(bytes.NewBuffer(res)))
+	assert.Equal(t, uint32(5), cred.RevokeHeight)
+}
+
+func TestCredentialRevokedKey(t *testing.T) {
+	ontid.Init()
+	srvc := nativetest.NewNativeService(t)
+	holder, _ := nativetest.NewTestID(t, srvc)
+	id, err := account.GenerateID()
+	assert.Nil(t, err)
+	issuer := []byte(id)
+	_, pub1, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	_, pub2, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	pk1, pk2 := keypair.SerializePublicKey(pub1), keypair.SerializePublicKey(pub2)
+	addr1, addr2 := types.AddressFromPubKey(pub1), types.AddressFromPubKey(pub2)
+
+	// the issuer adds a second key and removes the first one with it
+	ontidCall := func(signer common.Address, method string, args ...interface{}) {
+		srvc.ContextRef = nativetest.NewContextRef([]common.Address{signer})
+		_, err := nativetest.Call(srvc, utils.OntIDContractAddress, method, args...)
+		assert.Nil(t, err)
+	}
+	ontidCall(addr1, "regIDWithPublicKey", issuer, pk1)
+	ontidCall(addr1, "addKey", issuer, pk2, pk1)
+	ontidCall(addr2, "removeKey", issuer, pk1, pk2)
+
+	_, err = invoke(srvc, Commit, addr1, []byte("credential"), issuer, uint64(1), holder)
+	assert.NotNil(t, err)
+	_, err = invoke(srvc, Commit, addr2, []byte("credential"), issuer, uint64(2), holder)
+	assert.Nil(t, err)
+}
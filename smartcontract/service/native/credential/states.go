@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package credential
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/dnaproject2/DNA/common/serialization"
+)
+
+const (
+	STATUS_NONE      byte = 0
+	STATUS_COMMITTED byte = 1
+	STATUS_REVOKED   byte = 2
+)
+
+// Credential is the on-chain record of a verifiable credential. Only the
+// commit hash, which also serves as the credential ID, is kept on chain,
+// the credential itself is held off chain by its holder.
+type Credential struct {
+	Issuer       []byte
+	Holder       []byte
+	Status       byte
+	CommitHeight uint32
+	RevokeHeight uint32
+}
+
+func (this *Credential) Serialize(w io.Writer) error {
+	if err := serialization.WriteVarBytes(w, this.Issuer); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, this.Holder); err != nil {
+		return err
+	}
+	if err := serialization.WriteByte(w, this.Status); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, this.CommitHeight); err != nil {
+		return err
+	}
+	return serialization.WriteUint32(w, this.RevokeHeight)
+}
+
+func (this *Credential) Deserialize(r io.Reader) error {
+	var err error
+	if this.Issuer, err = serialization.ReadVarBytes(r); err != nil {
+		return err
+	}
+	if this.Holder, err = serialization.ReadVarBytes(r); err != nil {
+		return err
+	}
+	if this.Status, err = serialization.ReadByte(r); err != nil {
+		return err
+	}
+	if this.CommitHeight, err = serialization.ReadUint32(r); err != nil {
+		return err
+	}
+	this.RevokeHeight, err = serialization.ReadUint32(r)
+	return err
+}
+
+func (this *Credential) ToArray() []byte {
+	var buf bytes.Buffer
+	this.Serialize(&buf)
+	return buf.Bytes()
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package credential
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+const CREDENTIAL = "credential"
+
+func readID(r io.Reader) ([]byte, error) {
+	id, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(id) == 0 || len(id) > MAX_ID_LENGTH {
+		return nil, errors.New("invalid credential ID")
+	}
+	return id, nil
+}
+
+func readIssuer(r io.Reader) ([]byte, error) {
+	issuer, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if !account.VerifyID(string(issuer)) {
+		return nil, errors.New("invalid issuer")
+	}
+	return issuer, nil
+}
+
+// readQuery reads the credential ID and the issuer of a query
+func readQuery(r io.Reader) ([]byte, []byte, error) {
+	id, err := readID(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	issuer, err := readIssuer(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, issuer, nil
+}
+
+// credentialKey is the storage key of a credential, the credential IDs are
+// scoped by issuer so that nobody can take the ID of another issuer's credential
+func credentialKey(issuer, id []byte) []byte {
+	return utils.ConcatKey(utils.CredentialContractAddress, []byte(CREDENTIAL), []byte{byte(len(issuer))}, issuer, id)
+}
+
+func getCredential(srvc *native.NativeService, issuer, id []byte) (*Credential, error) {
+	item, err := utils.GetStorageItem(srvc, credentialKey(issuer, id))
+	if err != nil {
+		return nil, errors.New("get credential failed: " + err.Error())
+	} else if item == nil {
+		return nil, nil
+	}
+	cred := new(Credential)
+	if err := cred.Deserialize(bytes.NewBuffer(item.Value)); err != nil {
+		return nil, errors.New("deserialize credential failed: " + err.Error())
+	}
+	return cred, nil
+}
+
+func putCredential(srvc *native.NativeService, id []byte, cred *Credential) {
+	utils.PutBytes(srvc, credentialKey(cred.Issuer, id), cred.ToArray())
+}
+
+// verifySignature checks the transaction is signed by the key of the DNA ID
+// at the given index.
+func verifySignature(srvc *native.NativeService, id []byte, index uint64) error {
+	var buf bytes.Buffer
+	if err := serialization.WriteVarBytes(&buf, id); err != nil {
+		return err
+	}
+	if err := utils.WriteVarUint(&buf, index); err != nil {
+		return err
+	}
+	ret, err := srvc.NativeCall(utils.OntIDContractAddress, "verifySignature", buf.Bytes())
+	if err != nil {
+		return err
+	}
+	valid, ok := ret.([]byte)
+	if !ok || !bytes.Equal(valid, utils.BYTE_TRUE) {
+		return errors.New("verify signature failed")
+	}
+	return nil
+}
+
+func triggerCredentialEvent(srvc *native.NativeService, op string, id []byte, cred *Credential) {
+	st := []interface{}{"Credential", op, hex.EncodeToString(id), string(cred.Issuer), string(cred.Holder)}
+	srvc.Notifications = append(srvc.Notifications, &event.NotifyEventInfo{
+		ContractAddress: utils.CredentialContractAddress,
+		States:          st,
+	})
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/dnaproject2/DNA/errors"
 	"github.com/dnaproject2/DNA/smartcontract/service/native"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
 )
 
 type paramType byte
@@ -44,6 +45,9 @@ const (
 	CREATE_SNAPSHOT_NAME                     = "createSnapshot"
 )
 
+// PROPOSAL_PARAM_NAMES are the params besides the neovm gas table which can be changed by governance proposals
+var PROPOSAL_PARAM_NAMES = []string{"gasPrice", "consensusPolicyLevel", "consensusPolicyList"}
+
 func InitGlobalParams() {
 	native.Contracts[utils.ParamContractAddress] = RegisterParamContract
 }
@@ -241,3 +245,53 @@ func CreateSnapshot(native *native.NativeService) ([]byte, error) {
 	NotifyParamChange(native, contract, CREATE_SNAPSHOT_NAME, prepareParam)
 	return utils.BYTE_TRUE, nil
 }
+
+// CheckProposalParam checks that the given params are not empty and are all allowed to be changed
+// by governance proposals, which are the gas price, the consensus policy and the neovm gas table.
+func CheckProposalParam(params Params) error {
+	if len(params) == 0 {
+		return errors.NewErr("check param, params is nil!")
+	}
+	for _, param := range params {
+		if !isProposalParam(param.Key) {
+			return fmt.Errorf("check param, param %s can not be changed by proposal", param.Key)
+		}
+	}
+	return nil
+}
+
+func isProposalParam(key string) bool {
+	for _, k := range PROPOSAL_PARAM_NAMES {
+		if k == key {
+			return true
+		}
+	}
+	for _, k := range neovm.GAS_TABLE_KEYS {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyGlobalParam updates both the prepare value and the current value of the given params, so they take
+// effect without an operator snapshot. It is used by governance proposals which have been approved by peers.
+func ApplyGlobalParam(native *native.NativeService, params Params) error {
+	if err := CheckProposalParam(params); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "apply param, check params error!")
+	}
+	contract := utils.ParamContractAddress
+	for _, valueType := range []paramType{PREPARE_VALUE, CURRENT_VALUE} {
+		storageParams, err := getStorageParam(native, generateParamKey(contract, valueType))
+		if err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "apply param, read storage param error!")
+		}
+		for _, param := range params {
+			storageParams.SetParam(param)
+		}
+		native.CacheDB.Put(generateParamKey(contract, valueType), getParamStorageItem(storageParams).ToArray())
+	}
+
+	NotifyParamChange(native, contract, CREATE_SNAPSHOT_NAME, params)
+	return nil
+}
@@ -47,6 +47,25 @@ const (
 	BlackStatus
 )
 
+const (
+	//proposal type
+	GlobalParamProposal ProposalType = iota
+	GlobalParam2Proposal
+	SplitCurveProposal
+	BlackNodeProposal
+	WhiteNodeProposal
+	ChainParamProposal
+)
+
+const (
+	//proposal status
+	ProposalVotingStatus ProposalStatus = iota
+	ProposalPassedStatus
+	ProposalExecutedStatus
+	ProposalExpiredStatus
+	ProposalFailedStatus
+)
+
 const (
 	//function name
 	INIT_CONFIG                      = "initConfig"
@@ -77,6 +96,11 @@ const (
 	SET_PROMISE_POS                  = "setPromisePos"
 	SET_GAS_ADDRESS                  = "setGasAddress"
 	DESTROY_CONTRACT                 = "destroyContract"
+	SUBMIT_PROPOSAL                  = "submitProposal"
+	VOTE_PROPOSAL                    = "voteProposal"
+	EXECUTE_PROPOSAL                 = "executeProposal"
+	GET_PROPOSAL                     = "getProposal"
+	GET_PROPOSAL_LIST                = "getProposalList"
 
 	//key prefix
 	GLOBAL_PARAM      = "globalParam"
@@ -96,12 +120,20 @@ const (
 	PROMISE_POS       = "promisePos"
 	PRE_CONFIG        = "preConfig"
 	GAS_ADDRESS       = "gasAddress"
+	PROPOSAL          = "proposal"
+	PROPOSAL_INDEX    = "proposalIndex"
+	PROPOSAL_LIST     = "proposalList"
 
 	//global
 	PRECISE            = 1000000
 	NEW_VERSION_VIEW   = 6
 	NEW_VERSION_BLOCK  = 414100
 	NEW_WITHDRAW_BLOCK = 2800000
+	PROPOSAL_QUORUM    = 67 //percent of total weight needed to pass a proposal
+	MAX_PEER_PROPOSAL  = 5  //max num of unfinished proposals submitted by one peer
+
+	PROPOSAL_MIN_VOTING_BLOCKS  = 1000    //min num of blocks from submitting a proposal to its execute height
+	PROPOSAL_MAX_EXECUTE_BLOCKS = 2000000 //max num of blocks from submitting a proposal to its execute height
 )
 
 // candidate fee must >= 1 ONG
@@ -155,6 +187,12 @@ func RegisterGovernanceContract(native *native.NativeService) {
 	native.Register(SET_GAS_ADDRESS, SetGasAddress)
 
 	native.Register(DESTROY_CONTRACT, DestroyContract)
+
+	native.Register(SUBMIT_PROPOSAL, SubmitProposal)
+	native.Register(VOTE_PROPOSAL, VoteProposal)
+	native.Register(EXECUTE_PROPOSAL, ExecuteProposal)
+	native.Register(GET_PROPOSAL, GetProposal)
+	native.Register(GET_PROPOSAL_LIST, GetProposalList)
 }
 
 //Init governance contract, include vbft config, global param and ontid admin.
@@ -612,48 +650,9 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	}
 	contract := native.ContextRef.CurrentContext().ContractAddress
 
-	//get current view
-	view, err := GetView(native, contract)
-	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
-	}
-	//get peerPoolMap
-	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
-	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
-	}
-	commit := false
-	for _, peerPubkey := range params.PeerPubkeyList {
-		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
-		}
-		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
-		if !ok {
-			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
-		}
-
-		blackListItem := &BlackListItem{
-			PeerPubkey: peerPoolItem.PeerPubkey,
-			Address:    peerPoolItem.Address,
-			InitPos:    peerPoolItem.InitPos,
-		}
-		bf := new(bytes.Buffer)
-		if err := blackListItem.Serialize(bf); err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize blackListItem error: %v", err)
-		}
-		//put peer into black list
-		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(bf.Bytes()))
-		//change peerPool status
-		if peerPoolItem.Status == ConsensusStatus {
-			commit = true
-		}
-		peerPoolItem.Status = BlackStatus
-		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
-	}
-	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	commit, err := blackNode(native, contract, params.PeerPubkeyList)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+		return utils.BYTE_FALSE, fmt.Errorf("blackNode, blackNode error: %v", err)
 	}
 
 	//commitDpos
@@ -687,23 +686,11 @@ func WhiteNode(native *native.NativeService) ([]byte, error) {
 	}
 	contract := native.ContextRef.CurrentContext().ContractAddress
 
-	peerPubkeyPrefix, err := hex.DecodeString(params.PeerPubkey)
-	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
-	}
-
-	//check black list
-	blackListBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix))
+	err = whiteNode(native, contract, params.PeerPubkey)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("native.CacheDB.Get, get BlackList error: %v", err)
-	}
-	if blackListBytes == nil {
-		return utils.BYTE_FALSE, fmt.Errorf("whiteNode, this Peer is not in BlackList: %v", err)
+		return utils.BYTE_FALSE, fmt.Errorf("whiteNode, whiteNode error: %v", err)
 	}
 
-	//remove peer from black list
-	native.CacheDB.Delete(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix))
-
 	return utils.BYTE_TRUE, nil
 }
 
@@ -1008,6 +995,12 @@ func CommitDpos(native *native.NativeService) ([]byte, error) {
 		}
 	}
 
+	//execute proposals which reach execute height, peer changes are committed below
+	_, err = executeProposals(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("executeProposals, executeProposals error: %v", err)
+	}
+
 	err = executeCommitDpos(native, contract)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
@@ -1129,23 +1122,8 @@ func UpdateGlobalParam(native *native.NativeService) ([]byte, error) {
 	}
 
 	//check the globalParam
-	if (globalParam.A + globalParam.B) != 100 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. A + B must equal to 100")
-	}
-	if globalParam.Yita == 0 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. Yita must > 0")
-	}
-	if globalParam.Penalty > 100 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. Penalty must <= 100")
-	}
-	if globalParam.PosLimit < 1 {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. PosLimit must >= 1")
-	}
-	if globalParam.CandidateNum < 4*config.K {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. CandidateNum must >= 4*K")
-	}
-	if globalParam.CandidateFee != 0 && globalParam.CandidateFee < MIN_CANDIDATE_FEE {
-		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. CandidateFee must >= %d", MIN_CANDIDATE_FEE)
+	if err := checkGlobalParam(globalParam, config); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("updateGlobalParam. %v", err)
 	}
 	err = putGlobalParam(native, contract, globalParam)
 	if err != nil {
@@ -1712,3 +1690,215 @@ func DestroyContract(native *native.NativeService) ([]byte, error) {
 
 	return utils.BYTE_TRUE, nil
 }
+
+//Submit a proposal to change global params, split curve or black list, used by candidate and consensus peers.
+//Proposal is executed at execute height if peers holding enough weight voted for it before that.
+func SubmitProposal(native *native.NativeService) ([]byte, error) {
+	params := new(SubmitProposalParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("validateOwner, checkWitness error: %v", err)
+	}
+
+	//check peer
+	err = checkProposalPeer(native, contract, params.PeerPubkey, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, %v", err)
+	}
+
+	//leave peers time to vote, and do not let unfinished proposals occupy the peer quota for too long
+	if uint64(params.ExecuteHeight) < uint64(native.Height)+PROPOSAL_MIN_VOTING_BLOCKS {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, executeHeight must be at least %d blocks after current height",
+			PROPOSAL_MIN_VOTING_BLOCKS)
+	}
+	if uint64(params.ExecuteHeight) > uint64(native.Height)+PROPOSAL_MAX_EXECUTE_BLOCKS {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, executeHeight must be at most %d blocks after current height",
+			PROPOSAL_MAX_EXECUTE_BLOCKS)
+	}
+	err = checkProposal(native, contract, params.Type, params.Content)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("checkProposal, check proposal error: %v", err)
+	}
+
+	//check num of unfinished proposals of this peer
+	proposalList, err := getProposalList(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalList, get proposalList error: %v", err)
+	}
+	num := 0
+	for _, id := range proposalList.Ids {
+		proposal, err := getProposal(native, contract, id)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+		}
+		if proposal.PeerPubkey == params.PeerPubkey {
+			num = num + 1
+		}
+	}
+	if num >= MAX_PEER_PROPOSAL {
+		return utils.BYTE_FALSE, fmt.Errorf("submitProposal, peer has too many unfinished proposals")
+	}
+
+	index, err := getProposalIndex(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalIndex, get proposalIndex error: %v", err)
+	}
+	proposal := &Proposal{
+		Id:            index,
+		Type:          params.Type,
+		Content:       params.Content,
+		PeerPubkey:    params.PeerPubkey,
+		Address:       params.Address,
+		SubmitHeight:  native.Height,
+		ExecuteHeight: params.ExecuteHeight,
+		Status:        ProposalVotingStatus,
+		Voters:        []string{params.PeerPubkey},
+	}
+	err = updateProposalStatus(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("updateProposalStatus, update proposal status error: %v", err)
+	}
+	err = putProposal(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposal, put proposal error: %v", err)
+	}
+	proposalList.Ids = append(proposalList.Ids, index)
+	err = putProposalList(native, contract, proposalList)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposalList, put proposalList error: %v", err)
+	}
+	err = putProposalIndex(native, contract, index+1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposalIndex, put proposalIndex error: %v", err)
+	}
+
+	notifyProposal(native, contract, SUBMIT_PROPOSAL, proposal, params.PeerPubkey)
+	return utils.BYTE_TRUE, nil
+}
+
+//Vote for a proposal with the weight of a candidate or consensus peer, used by peer owner.
+func VoteProposal(native *native.NativeService) ([]byte, error) {
+	params := new(VoteProposalParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("validateOwner, checkWitness error: %v", err)
+	}
+
+	//check peer
+	err = checkProposalPeer(native, contract, params.PeerPubkey, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, %v", err)
+	}
+
+	proposal, err := getProposal(native, contract, params.Id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	if proposal.Status != ProposalVotingStatus && proposal.Status != ProposalPassedStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, proposal is finished")
+	}
+	if native.Height >= proposal.ExecuteHeight {
+		return utils.BYTE_FALSE, fmt.Errorf("voteProposal, voting of proposal is closed")
+	}
+	for _, voter := range proposal.Voters {
+		if voter == params.PeerPubkey {
+			return utils.BYTE_FALSE, fmt.Errorf("voteProposal, peer has already voted")
+		}
+	}
+
+	proposal.Voters = append(proposal.Voters, params.PeerPubkey)
+	err = updateProposalStatus(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("updateProposalStatus, update proposal status error: %v", err)
+	}
+	err = putProposal(native, contract, proposal)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("putProposal, put proposal error: %v", err)
+	}
+
+	notifyProposal(native, contract, VOTE_PROPOSAL, proposal, params.PeerPubkey)
+	return utils.BYTE_TRUE, nil
+}
+
+//Execute proposals which reach execute height, can be invoked by anyone.
+//Proposals are also executed by the ledger before the transactions of each block, and when dpos is committed.
+func ExecuteProposal(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	commit, err := executeProposals(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("executeProposals, executeProposals error: %v", err)
+	}
+
+	//commitDpos
+	if commit {
+		err = executeCommitDpos(native, contract)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("executeCommitDpos, executeCommitDpos error: %v", err)
+		}
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+//Get a proposal with its current approve weight and total weight
+func GetProposal(native *native.NativeService) ([]byte, error) {
+	params := new(GetProposalParam)
+	if err := params.Deserialize(bytes.NewBuffer(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("deserialize, contract params deserialize error: %v", err)
+	}
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	proposal, err := getProposal(native, contract, params.Id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	approveWeight, totalWeight := countProposalWeight(proposal, peerPoolMap)
+	proposalInfo := &ProposalInfo{
+		Proposal:      proposal,
+		ApproveWeight: approveWeight,
+		TotalWeight:   totalWeight,
+	}
+	bf := new(bytes.Buffer)
+	if err := proposalInfo.Serialize(bf); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize proposalInfo error: %v", err)
+	}
+	return bf.Bytes(), nil
+}
+
+//Get ids of proposals which are not executed or expired yet
+func GetProposalList(native *native.NativeService) ([]byte, error) {
+	contract := native.ContextRef.CurrentContext().ContractAddress
+
+	proposalList, err := getProposalList(native, contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("getProposalList, get proposalList error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := proposalList.Serialize(bf); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("serialize, serialize proposalList error: %v", err)
+	}
+	return bf.Bytes(), nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package governance
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/nativetest"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNativeService(t *testing.T, peers []*PeerPoolItem) *native.NativeService {
+	service := nativetest.NewNativeService(t)
+	service.ContextRef = nativetest.NewContextRef(nil, utils.GovernanceContractAddress)
+	contract := utils.GovernanceContractAddress
+	peerPoolMap := &PeerPoolMap{
+		PeerPoolMap: make(map[string]*PeerPoolItem),
+	}
+	for _, peer := range peers {
+		peerPoolMap.PeerPoolMap[peer.PeerPubkey] = peer
+	}
+	assert.Nil(t, putPeerPoolMap(service, contract, 1, peerPoolMap))
+	assert.Nil(t, putGovernanceView(service, contract, &GovernanceView{View: 1}))
+	assert.Nil(t, putConfig(service, contract, &Configuration{N: 7, C: 2, K: 7, L: 112}))
+	return service
+}
+
+func invokeAs(service *native.NativeService, address common.Address, input []byte) {
+	service.ContextRef = nativetest.NewContextRef([]common.Address{address}, utils.GovernanceContractAddress)
+	service.Input = input
+}
+
+func TestProposal(t *testing.T) {
+	peers := []*PeerPoolItem{
+		{Index: 1, PeerPubkey: "0201", Address: common.Address{1}, Status: ConsensusStatus, InitPos: 100},
+		{Index: 2, PeerPubkey: "0202", Address: common.Address{2}, Status: CandidateStatus, InitPos: 200},
+		{Index: 3, PeerPubkey: "0203", Address: common.Address{3}, Status: ConsensusStatus, InitPos: 300, TotalPos: 100},
+		{Index: 4, PeerPubkey: "0204", Address: common.Address{4}, Status: RegisterCandidateStatus, InitPos: 1000},
+	}
+	service := newTestNativeService(t, peers)
+	contract := utils.GovernanceContractAddress
+	executeHeight := service.Height + PROPOSAL_MIN_VOTING_BLOCKS
+
+	globalParam := &GlobalParam{CandidateNum: 28, PosLimit: 20, A: 50, B: 50, Yita: 5, Penalty: 10}
+	bf := new(bytes.Buffer)
+	assert.Nil(t, globalParam.Serialize(bf))
+	submit := &SubmitProposalParam{
+		PeerPubkey:    "0201",
+		Address:       common.Address{1},
+		Type:          GlobalParamProposal,
+		Content:       bf.Bytes(),
+		ExecuteHeight: executeHeight - 1,
+	}
+	bf = new(bytes.Buffer)
+	assert.Nil(t, submit.Serialize(bf))
+
+	// execute height must leave enough blocks to vote, but not too many
+	invokeAs(service, common.Address{1}, bf.Bytes())
+	_, err := SubmitProposal(service)
+	assert.NotNil(t, err)
+	submit.ExecuteHeight = service.Height + PROPOSAL_MAX_EXECUTE_BLOCKS + 1
+	bf = new(bytes.Buffer)
+	assert.Nil(t, submit.Serialize(bf))
+	invokeAs(service, common.Address{1}, bf.Bytes())
+	_, err = SubmitProposal(service)
+	assert.NotNil(t, err)
+	submit.ExecuteHeight = executeHeight
+	bf = new(bytes.Buffer)
+	assert.Nil(t, submit.Serialize(bf))
+
+	// only the owner of a candidate or consensus peer can submit
+	invokeAs(service, common.Address{2}, bf.Bytes())
+	_, err = SubmitProposal(service)
+	assert.NotNil(t, err)
+	invokeAs(service, common.Address{1}, bf.Bytes())
+	_, err = SubmitProposal(service)
+	assert.Nil(t, err)
+
+	vote := func(id uint32, peerPubkey string, address common.Address) error {
+		bf := new(bytes.Buffer)
+		assert.Nil(t, (&VoteProposalParam{Id: id, PeerPubkey: peerPubkey, Address: address}).Serialize(bf))
+		invokeAs(service, address, bf.Bytes())
+		_, err := VoteProposal(service)
+		return err
+	}
+	// peer not in candidate or consensus status has no vote
+	assert.NotNil(t, vote(0, "0204", common.Address{4}))
+	assert.NotNil(t, vote(0, "0201", common.Address{1}))
+
+	proposal, err := getProposal(service, contract, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalVotingStatus, proposal.Status)
+
+	// 100 + 300 + 100 of 700 reaches the quorum
+	assert.Nil(t, vote(0, "0203", common.Address{3}))
+	bf = new(bytes.Buffer)
+	assert.Nil(t, (&GetProposalParam{Id: 0}).Serialize(bf))
+	service.Input = bf.Bytes()
+	res, err := GetProposal(service)
+	assert.Nil(t, err)
+	info := new(ProposalInfo)
+	assert.Nil(t, info.Deserialize(bytes.NewBuffer(res)))
+	assert.Equal(t, ProposalPassedStatus, info.Proposal.Status)
+	assert.Equal(t, []string{"0201", "0203"}, info.Proposal.Voters)
+	assert.Equal(t, uint64(500), info.ApproveWeight)
+	assert.Equal(t, uint64(700), info.TotalWeight)
+
+	// a proposal nobody else supports expires
+	bf = new(bytes.Buffer)
+	assert.Nil(t, (&WhiteNodeParam{PeerPubkey: "0201"}).Serialize(bf))
+	submit.Type = WhiteNodeProposal
+	submit.Content = bf.Bytes()
+	bf = new(bytes.Buffer)
+	assert.Nil(t, submit.Serialize(bf))
+	invokeAs(service, common.Address{1}, bf.Bytes())
+	_, err = SubmitProposal(service)
+	assert.NotNil(t, err)
+	_, err = blackNode(service, contract, []string{"0204"})
+	assert.Nil(t, err)
+	bf = new(bytes.Buffer)
+	assert.Nil(t, (&WhiteNodeParam{PeerPubkey: "0204"}).Serialize(bf))
+	submit.Content = bf.Bytes()
+	bf = new(bytes.Buffer)
+	assert.Nil(t, submit.Serialize(bf))
+	service.Input = bf.Bytes()
+	_, err = SubmitProposal(service)
+	assert.Nil(t, err)
+
+	// nothing is executed before execute height
+	service.Height = executeHeight - 1
+	_, err = ExecuteProposal(service)
+	assert.Nil(t, err)
+	proposalList, err := getProposalList(service, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 1}, proposalList.Ids)
+	assert.Nil(t, vote(1, "0202", common.Address{2}))
+
+	service.Height = executeHeight
+	assert.NotNil(t, vote(1, "0203", common.Address{3}))
+	_, err = ExecuteProposal(service)
+	assert.Nil(t, err)
+	proposalList, err = getProposalList(service, contract)
+	assert.Nil(t, err)
+	assert.Empty(t, proposalList.Ids)
+
+	proposal, err = getProposal(service, contract, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalExecutedStatus, proposal.Status)
+	newGlobalParam, err := getGlobalParam(service, contract)
+	assert.Nil(t, err)
+	assert.Equal(t, globalParam, newGlobalParam)
+
+	proposal, err = getProposal(service, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalExpiredStatus, proposal.Status)
+}
+
+func TestChainParamProposal(t *testing.T) {
+	peers := []*PeerPoolItem{
+		{Index: 1, PeerPubkey: "0201", Address: common.Address{1}, Status: ConsensusStatus, InitPos: 100},
+		{Index: 2, PeerPubkey: "0202", Address: common.Address{2}, Status: ConsensusStatus, InitPos: 100},
+		{Index: 3, PeerPubkey: "0203", Address: common.Address{3}, Status: ConsensusStatus, InitPos: 100},
+	}
+	service := newTestNativeService(t, peers)
+	contract := utils.GovernanceContractAddress
+	executeHeight := service.Height + PROPOSAL_MIN_VOTING_BLOCKS
+
+	submit := func(params global_params.Params, height uint32) error {
+		bf := new(bytes.Buffer)
+		assert.Nil(t, params.Serialize(bf))
+		submit := &SubmitProposalParam{
+			PeerPubkey:    "0201",
+			Address:       common.Address{1},
+			Type:          ChainParamProposal,
+			Content:       bf.Bytes(),
+			ExecuteHeight: height,
+		}
+		bf = new(bytes.Buffer)
+		assert.Nil(t, submit.Serialize(bf))
+		invokeAs(service, common.Address{1}, bf.Bytes())
+		_, err := SubmitProposal(service)
+		return err
+	}
+	vote := func(id uint32, peerPubkey string, address common.Address) {
+		bf := new(bytes.Buffer)
+		assert.Nil(t, (&VoteProposalParam{Id: id, PeerPubkey: peerPubkey, Address: address}).Serialize(bf))
+		invokeAs(service, address, bf.Bytes())
+		_, err := VoteProposal(service)
+		assert.Nil(t, err)
+	}
+	gasPrice := func() string {
+		key := utils.ConcatKey(utils.ParamContractAddress, []byte(global_params.PARAM),
+			[]byte{byte(global_params.CURRENT_VALUE)})
+		item, err := utils.GetStorageItem(service, key)
+		assert.Nil(t, err)
+		params := global_params.Params{}
+		assert.Nil(t, params.Deserialize(bytes.NewBuffer(item.Value)))
+		_, param := params.GetParam("gasPrice")
+		return param.Value
+	}
+
+	// only allowed params can be changed by proposals
+	assert.NotNil(t, submit(global_params.Params{{Key: "unknown", Value: "1"}}, executeHeight))
+	assert.NotNil(t, global_params.ApplyGlobalParam(service, global_params.Params{{Key: "unknown", Value: "1"}}))
+	assert.Nil(t, submit(global_params.Params{{Key: "gasPrice", Value: "500"}}, executeHeight))
+	assert.Nil(t, submit(global_params.Params{{Key: "gasPrice", Value: "600"}}, executeHeight+10))
+	for _, id := range []uint32{0, 1} {
+		vote(id, "0202", common.Address{2})
+		vote(id, "0203", common.Address{3})
+	}
+
+	service.Height = executeHeight
+	_, err := ExecuteProposal(service)
+	assert.Nil(t, err)
+	proposal, err := getProposal(service, contract, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalExecutedStatus, proposal.Status)
+	assert.Equal(t, "500", gasPrice())
+
+	// a new peer joins before the execute height of proposal 1, so it no longer reaches the quorum
+	peerPoolMap, err := GetPeerPoolMap(service, contract, 1)
+	assert.Nil(t, err)
+	peerPoolMap.PeerPoolMap["0204"] = &PeerPoolItem{Index: 4, PeerPubkey: "0204", Address: common.Address{4},
+		Status: ConsensusStatus, InitPos: 1000}
+	assert.Nil(t, putPeerPoolMap(service, contract, 1, peerPoolMap))
+	proposal, err = getProposal(service, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalPassedStatus, proposal.Status)
+
+	service.Height = executeHeight + 10
+	_, err = ExecuteProposal(service)
+	assert.Nil(t, err)
+	proposal, err = getProposal(service, contract, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalExpiredStatus, proposal.Status)
+	assert.Equal(t, "500", gasPrice())
+}
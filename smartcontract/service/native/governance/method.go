@@ -29,6 +29,7 @@ import (
 	"github.com/dnaproject2/DNA/common/constants"
 	cstates "github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
 )
 
@@ -1368,3 +1369,397 @@ func splitNodeFee(native *native.NativeService, contract common.Address, peerPub
 	}
 	return nil
 }
+
+//Put peers into black list and change their status in current peer pool, return whether a consensus peer is blacked
+func blackNode(native *native.NativeService, contract common.Address, peerPubkeyList []string) (bool, error) {
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return false, fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return false, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	commit := false
+	for _, peerPubkey := range peerPubkeyList {
+		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+		if err != nil {
+			return false, fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+		}
+		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+		if !ok {
+			return false, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
+		}
+
+		blackListItem := &BlackListItem{
+			PeerPubkey: peerPoolItem.PeerPubkey,
+			Address:    peerPoolItem.Address,
+			InitPos:    peerPoolItem.InitPos,
+		}
+		bf := new(bytes.Buffer)
+		if err := blackListItem.Serialize(bf); err != nil {
+			return false, fmt.Errorf("serialize, serialize blackListItem error: %v", err)
+		}
+		//put peer into black list
+		native.CacheDB.Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(bf.Bytes()))
+		//change peerPool status
+		if peerPoolItem.Status == ConsensusStatus {
+			commit = true
+		}
+		peerPoolItem.Status = BlackStatus
+		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	}
+	err = putPeerPoolMap(native, contract, view, peerPoolMap)
+	if err != nil {
+		return false, fmt.Errorf("putPeerPoolMap, put peerPoolMap error: %v", err)
+	}
+	return commit, nil
+}
+
+//Remove a peer from black list
+func whiteNode(native *native.NativeService, contract common.Address, peerPubkey string) error {
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+	}
+
+	//check black list
+	blackListBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix))
+	if err != nil {
+		return fmt.Errorf("native.CacheDB.Get, get BlackList error: %v", err)
+	}
+	if blackListBytes == nil {
+		return fmt.Errorf("whiteNode, this Peer is not in BlackList: %v", err)
+	}
+
+	//remove peer from black list
+	native.CacheDB.Delete(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix))
+	return nil
+}
+
+//Check global params before they are updated
+func checkGlobalParam(globalParam *GlobalParam, config *Configuration) error {
+	if (globalParam.A + globalParam.B) != 100 {
+		return fmt.Errorf("A + B must equal to 100")
+	}
+	if globalParam.Yita == 0 {
+		return fmt.Errorf("Yita must > 0")
+	}
+	if globalParam.Penalty > 100 {
+		return fmt.Errorf("Penalty must <= 100")
+	}
+	if globalParam.PosLimit < 1 {
+		return fmt.Errorf("PosLimit must >= 1")
+	}
+	if globalParam.CandidateNum < 4*config.K {
+		return fmt.Errorf("CandidateNum must >= 4*K")
+	}
+	if globalParam.CandidateFee != 0 && globalParam.CandidateFee < MIN_CANDIDATE_FEE {
+		return fmt.Errorf("CandidateFee must >= %d", MIN_CANDIDATE_FEE)
+	}
+	return nil
+}
+
+//Check whether a peer can submit or vote proposals, only candidate and consensus peers are allowed
+func checkProposalPeer(native *native.NativeService, contract common.Address, peerPubkey string, address common.Address) error {
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+	if !ok {
+		return fmt.Errorf("peerPubkey is not in peerPoolMap")
+	}
+	if peerPoolItem.Address != address {
+		return fmt.Errorf("address is not peer owner")
+	}
+	if peerPoolItem.Status != CandidateStatus && peerPoolItem.Status != ConsensusStatus {
+		return fmt.Errorf("peer status is not CandidateStatus or ConsensusStatus")
+	}
+	return nil
+}
+
+//Check the content of a proposal can be applied to current state
+func checkProposal(native *native.NativeService, contract common.Address, proposalType ProposalType, content []byte) error {
+	switch proposalType {
+	case GlobalParamProposal:
+		globalParam := new(GlobalParam)
+		if err := globalParam.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize globalParam error: %v", err)
+		}
+		config, err := getConfig(native, contract)
+		if err != nil {
+			return fmt.Errorf("getConfig, get config error: %v", err)
+		}
+		return checkGlobalParam(globalParam, config)
+	case GlobalParam2Proposal:
+		if native.Height < NEW_VERSION_BLOCK {
+			return fmt.Errorf("block num is not reached for globalParam2")
+		}
+		globalParam2 := new(GlobalParam2)
+		if err := globalParam2.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize globalParam2 error: %v", err)
+		}
+		config, err := getConfig(native, contract)
+		if err != nil {
+			return fmt.Errorf("getConfig, get config error: %v", err)
+		}
+		if globalParam2.CandidateFeeSplitNum < config.K {
+			return fmt.Errorf("globalParam2.CandidateFeeSplitNum can not be less than config.K")
+		}
+	case SplitCurveProposal:
+		splitCurve := new(SplitCurve)
+		if err := splitCurve.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize splitCurve error: %v", err)
+		}
+		if len(splitCurve.Yi) != 101 {
+			return fmt.Errorf("length of split curve != 101")
+		}
+	case BlackNodeProposal:
+		params := new(BlackNodeParam)
+		if err := params.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize blackNodeParam error: %v", err)
+		}
+		if len(params.PeerPubkeyList) == 0 {
+			return fmt.Errorf("peerPubkeyList is empty")
+		}
+		view, err := GetView(native, contract)
+		if err != nil {
+			return fmt.Errorf("getView, get view error: %v", err)
+		}
+		peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+		if err != nil {
+			return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+		}
+		for _, peerPubkey := range params.PeerPubkeyList {
+			if _, err := hex.DecodeString(peerPubkey); err != nil {
+				return fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+			}
+			if _, ok := peerPoolMap.PeerPoolMap[peerPubkey]; !ok {
+				return fmt.Errorf("peerPubkey %s is not in peerPoolMap", peerPubkey)
+			}
+		}
+	case WhiteNodeProposal:
+		params := new(WhiteNodeParam)
+		if err := params.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize whiteNodeParam error: %v", err)
+		}
+		peerPubkeyPrefix, err := hex.DecodeString(params.PeerPubkey)
+		if err != nil {
+			return fmt.Errorf("hex.DecodeString, peerPubkey format error: %v", err)
+		}
+		blackListBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix))
+		if err != nil {
+			return fmt.Errorf("native.CacheDB.Get, get BlackList error: %v", err)
+		}
+		if blackListBytes == nil {
+			return fmt.Errorf("peer %s is not in BlackList", params.PeerPubkey)
+		}
+	case ChainParamProposal:
+		params := new(global_params.Params)
+		if err := params.Deserialize(bytes.NewBuffer(content)); err != nil {
+			return fmt.Errorf("deserialize, deserialize params error: %v", err)
+		}
+		if err := global_params.CheckProposalParam(*params); err != nil {
+			return fmt.Errorf("global_params.CheckProposalParam, check params error: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown proposal type: %d", proposalType)
+	}
+	return nil
+}
+
+//Apply an approved proposal, return whether a consensus peer is blacked and dpos need to be committed
+func applyProposal(native *native.NativeService, contract common.Address, proposal *Proposal) (bool, error) {
+	//check again, state may be changed since the proposal is submitted
+	if err := checkProposal(native, contract, proposal.Type, proposal.Content); err != nil {
+		return false, err
+	}
+	switch proposal.Type {
+	case GlobalParamProposal:
+		globalParam := new(GlobalParam)
+		if err := globalParam.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize globalParam error: %v", err)
+		}
+		if err := putGlobalParam(native, contract, globalParam); err != nil {
+			return false, fmt.Errorf("putGlobalParam, put globalParam error: %v", err)
+		}
+	case GlobalParam2Proposal:
+		globalParam2 := new(GlobalParam2)
+		if err := globalParam2.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize globalParam2 error: %v", err)
+		}
+		if err := putGlobalParam2(native, contract, globalParam2); err != nil {
+			return false, fmt.Errorf("putGlobalParam2, put globalParam2 error: %v", err)
+		}
+	case SplitCurveProposal:
+		splitCurve := new(SplitCurve)
+		if err := splitCurve.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize splitCurve error: %v", err)
+		}
+		if err := putSplitCurve(native, contract, splitCurve); err != nil {
+			return false, fmt.Errorf("putSplitCurve, put splitCurve error: %v", err)
+		}
+	case BlackNodeProposal:
+		params := new(BlackNodeParam)
+		if err := params.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize blackNodeParam error: %v", err)
+		}
+		return blackNode(native, contract, params.PeerPubkeyList)
+	case WhiteNodeProposal:
+		params := new(WhiteNodeParam)
+		if err := params.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize whiteNodeParam error: %v", err)
+		}
+		if err := whiteNode(native, contract, params.PeerPubkey); err != nil {
+			return false, fmt.Errorf("whiteNode, whiteNode error: %v", err)
+		}
+	case ChainParamProposal:
+		params := new(global_params.Params)
+		if err := params.Deserialize(bytes.NewBuffer(proposal.Content)); err != nil {
+			return false, fmt.Errorf("deserialize, deserialize params error: %v", err)
+		}
+		if err := global_params.ApplyGlobalParam(native, *params); err != nil {
+			return false, fmt.Errorf("global_params.ApplyGlobalParam, apply params error: %v", err)
+		}
+	}
+	return false, nil
+}
+
+//Count the approve weight of a proposal and the total weight of all candidate and consensus peers,
+//weight of a peer is its initPos plus pos authorized to it
+func countProposalWeight(proposal *Proposal, peerPoolMap *PeerPoolMap) (uint64, uint64) {
+	var approveWeight, totalWeight uint64
+	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
+		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
+			totalWeight = totalWeight + peerPoolItem.InitPos + peerPoolItem.TotalPos
+		}
+	}
+	for _, voter := range proposal.Voters {
+		peerPoolItem, ok := peerPoolMap.PeerPoolMap[voter]
+		if !ok {
+			continue
+		}
+		if peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus {
+			approveWeight = approveWeight + peerPoolItem.InitPos + peerPoolItem.TotalPos
+		}
+	}
+	return approveWeight, totalWeight
+}
+
+//Check whether the approve weight reaches the quorum
+func reachQuorum(approveWeight uint64, totalWeight uint64) bool {
+	if totalWeight == 0 {
+		return false
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(approveWeight), big.NewInt(100)).Cmp(
+		new(big.Int).Mul(new(big.Int).SetUint64(totalWeight), big.NewInt(PROPOSAL_QUORUM))) >= 0
+}
+
+//Mark a voting proposal as passed if its approve weight reaches the quorum
+func updateProposalStatus(native *native.NativeService, contract common.Address, proposal *Proposal) error {
+	if proposal.Status != ProposalVotingStatus {
+		return nil
+	}
+	//get current view
+	view, err := GetView(native, contract)
+	if err != nil {
+		return fmt.Errorf("getView, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, contract, view)
+	if err != nil {
+		return fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+	}
+	if reachQuorum(countProposalWeight(proposal, peerPoolMap)) {
+		proposal.Status = ProposalPassedStatus
+	}
+	return nil
+}
+
+//Execute proposals whose execute height is reached if they still reach the quorum, expire the others,
+//return whether dpos need to be committed
+//applyProposalInChild apply the proposal in a child cache, which is committed only if the proposal succeeds,
+//so a failed proposal leaves neither its partial changes nor its events
+func applyProposalInChild(native *native.NativeService, contract common.Address, proposal *Proposal) (bool, error) {
+	cache, notifications := native.CacheDB, len(native.Notifications)
+	native.CacheDB = cache.NewChild()
+	defer func() { native.CacheDB = cache }()
+	commit, err := applyProposal(native, contract, proposal)
+	if err != nil {
+		native.Notifications = native.Notifications[:notifications]
+		return false, err
+	}
+	native.CacheDB.Commit()
+	return commit, nil
+}
+
+func executeProposals(native *native.NativeService, contract common.Address) (bool, error) {
+	proposalList, err := getProposalList(native, contract)
+	if err != nil {
+		return false, fmt.Errorf("getProposalList, get proposalList error: %v", err)
+	}
+	if len(proposalList.Ids) == 0 {
+		return false, nil
+	}
+	commit := false
+	ids := make([]uint32, 0, len(proposalList.Ids))
+	var peerPoolMap *PeerPoolMap
+	for _, id := range proposalList.Ids {
+		proposal, err := getProposal(native, contract, id)
+		if err != nil {
+			return false, fmt.Errorf("getProposal, get proposal error: %v", err)
+		}
+		if native.Height < proposal.ExecuteHeight {
+			ids = append(ids, id)
+			continue
+		}
+		if peerPoolMap == nil {
+			//get current view
+			view, err := GetView(native, contract)
+			if err != nil {
+				return false, fmt.Errorf("getView, get view error: %v", err)
+			}
+			//get peerPoolMap
+			peerPoolMap, err = GetPeerPoolMap(native, contract, view)
+			if err != nil {
+				return false, fmt.Errorf("getPeerPoolMap, get peerPoolMap error: %v", err)
+			}
+		}
+		//weights may be changed since the votes, check the quorum again
+		if reachQuorum(countProposalWeight(proposal, peerPoolMap)) {
+			c, err := applyProposalInChild(native, contract, proposal)
+			if err != nil {
+				proposal.Status = ProposalFailedStatus
+				notifyProposal(native, contract, EXECUTE_PROPOSAL, proposal, err.Error())
+			} else {
+				proposal.Status = ProposalExecutedStatus
+				commit = commit || c
+				notifyProposal(native, contract, EXECUTE_PROPOSAL, proposal)
+			}
+		} else {
+			proposal.Status = ProposalExpiredStatus
+			notifyProposal(native, contract, EXECUTE_PROPOSAL, proposal)
+		}
+		err = putProposal(native, contract, proposal)
+		if err != nil {
+			return false, fmt.Errorf("putProposal, put proposal error: %v", err)
+		}
+	}
+	if len(ids) != len(proposalList.Ids) {
+		proposalList.Ids = ids
+		err = putProposalList(native, contract, proposalList)
+		if err != nil {
+			return false, fmt.Errorf("putProposalList, put proposalList error: %v", err)
+		}
+	}
+	return commit, nil
+}
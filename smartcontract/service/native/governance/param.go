@@ -1040,3 +1040,129 @@ func (this *DestroyContractParam) Deserialize(r io.Reader) error {
 	this.ContractAddress = contractAddress
 	return nil
 }
+
+type SubmitProposalParam struct {
+	PeerPubkey    string
+	Address       common.Address
+	Type          ProposalType
+	Content       []byte
+	ExecuteHeight uint32
+}
+
+func (this *SubmitProposalParam) Serialize(w io.Writer) error {
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Address[:]); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize address error: %v", err)
+	}
+	if err := utils.WriteVarUint(w, uint64(this.Type)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize type error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Content); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize content error: %v", err)
+	}
+	if err := utils.WriteVarUint(w, uint64(this.ExecuteHeight)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize executeHeight error: %v", err)
+	}
+	return nil
+}
+
+func (this *SubmitProposalParam) Deserialize(r io.Reader) error {
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address, err := utils.ReadAddress(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	proposalType, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize type error: %v", err)
+	}
+	if proposalType > math.MaxUint8 {
+		return fmt.Errorf("type larger than max of uint8")
+	}
+	content, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize content error: %v", err)
+	}
+	executeHeight, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize executeHeight error: %v", err)
+	}
+	if executeHeight > math.MaxUint32 {
+		return fmt.Errorf("executeHeight larger than max of uint32")
+	}
+	this.PeerPubkey = peerPubkey
+	this.Address = address
+	this.Type = ProposalType(proposalType)
+	this.Content = content
+	this.ExecuteHeight = uint32(executeHeight)
+	return nil
+}
+
+type VoteProposalParam struct {
+	Id         uint32
+	PeerPubkey string
+	Address    common.Address
+}
+
+func (this *VoteProposalParam) Serialize(w io.Writer) error {
+	if err := utils.WriteVarUint(w, uint64(this.Id)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize id error: %v", err)
+	}
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Address[:]); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize address error: %v", err)
+	}
+	return nil
+}
+
+func (this *VoteProposalParam) Deserialize(r io.Reader) error {
+	id, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize id error: %v", err)
+	}
+	if id > math.MaxUint32 {
+		return fmt.Errorf("id larger than max of uint32")
+	}
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address, err := utils.ReadAddress(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadAddress, deserialize address error: %v", err)
+	}
+	this.Id = uint32(id)
+	this.PeerPubkey = peerPubkey
+	this.Address = address
+	return nil
+}
+
+type GetProposalParam struct {
+	Id uint32
+}
+
+func (this *GetProposalParam) Serialize(w io.Writer) error {
+	if err := utils.WriteVarUint(w, uint64(this.Id)); err != nil {
+		return fmt.Errorf("utils.WriteVarUint, serialize id error: %v", err)
+	}
+	return nil
+}
+
+func (this *GetProposalParam) Deserialize(r io.Reader) error {
+	id, err := utils.ReadVarUint(r)
+	if err != nil {
+		return fmt.Errorf("utils.ReadVarUint, deserialize id error: %v", err)
+	}
+	if id > math.MaxUint32 {
+		return fmt.Errorf("id larger than max of uint32")
+	}
+	this.Id = uint32(id)
+	return nil
+}
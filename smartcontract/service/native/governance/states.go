@@ -547,3 +547,184 @@ func (this *SplitFeeAddress) Deserialize(r io.Reader) error {
 	this.Amount = amount
 	return nil
 }
+
+type ProposalType uint8
+
+type ProposalStatus uint8
+
+type Proposal struct {
+	Id            uint32         //proposal id
+	Type          ProposalType   //proposal type
+	Content       []byte         //serialized param to apply when proposal is executed
+	PeerPubkey    string         //peer which submitted this proposal
+	Address       common.Address //owner of the submitting peer
+	SubmitHeight  uint32         //block height when proposal is submitted
+	ExecuteHeight uint32         //block height from which proposal can be executed, voting ends here
+	Status        ProposalStatus //proposal status
+	Voters        []string       //peers which voted for this proposal
+}
+
+func (this *Proposal) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, this.Id); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize id error: %v", err)
+	}
+	if err := serialization.WriteUint8(w, uint8(this.Type)); err != nil {
+		return fmt.Errorf("serialization.WriteUint8, serialize type error: %v", err)
+	}
+	if err := serialization.WriteVarBytes(w, this.Content); err != nil {
+		return fmt.Errorf("serialization.WriteVarBytes, serialize content error: %v", err)
+	}
+	if err := serialization.WriteString(w, this.PeerPubkey); err != nil {
+		return fmt.Errorf("serialization.WriteString, serialize peerPubkey error: %v", err)
+	}
+	if err := this.Address.Serialize(w); err != nil {
+		return fmt.Errorf("address.Serialize, serialize address error: %v", err)
+	}
+	if err := serialization.WriteUint32(w, this.SubmitHeight); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize submitHeight error: %v", err)
+	}
+	if err := serialization.WriteUint32(w, this.ExecuteHeight); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize executeHeight error: %v", err)
+	}
+	if err := serialization.WriteUint8(w, uint8(this.Status)); err != nil {
+		return fmt.Errorf("serialization.WriteUint8, serialize status error: %v", err)
+	}
+	if err := serialization.WriteUint32(w, uint32(len(this.Voters))); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize voters length error: %v", err)
+	}
+	for _, v := range this.Voters {
+		if err := serialization.WriteString(w, v); err != nil {
+			return fmt.Errorf("serialization.WriteString, serialize voter error: %v", err)
+		}
+	}
+	return nil
+}
+
+func (this *Proposal) Deserialize(r io.Reader) error {
+	id, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize id error: %v", err)
+	}
+	proposalType, err := serialization.ReadUint8(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint8, deserialize type error: %v", err)
+	}
+	content, err := serialization.ReadVarBytes(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadVarBytes, deserialize content error: %v", err)
+	}
+	peerPubkey, err := serialization.ReadString(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadString, deserialize peerPubkey error: %v", err)
+	}
+	address := new(common.Address)
+	err = address.Deserialize(r)
+	if err != nil {
+		return fmt.Errorf("address.Deserialize, deserialize address error: %v", err)
+	}
+	submitHeight, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize submitHeight error: %v", err)
+	}
+	executeHeight, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize executeHeight error: %v", err)
+	}
+	status, err := serialization.ReadUint8(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint8, deserialize status error: %v", err)
+	}
+	n, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize voters length error: %v", err)
+	}
+	voters := make([]string, 0)
+	for i := 0; uint32(i) < n; i++ {
+		voter, err := serialization.ReadString(r)
+		if err != nil {
+			return fmt.Errorf("serialization.ReadString, deserialize voter error: %v", err)
+		}
+		voters = append(voters, voter)
+	}
+	this.Id = id
+	this.Type = ProposalType(proposalType)
+	this.Content = content
+	this.PeerPubkey = peerPubkey
+	this.Address = *address
+	this.SubmitHeight = submitHeight
+	this.ExecuteHeight = executeHeight
+	this.Status = ProposalStatus(status)
+	this.Voters = voters
+	return nil
+}
+
+type ProposalInfo struct {
+	Proposal      *Proposal
+	ApproveWeight uint64 //sum of initPos and authorized pos of current voters
+	TotalWeight   uint64 //sum of initPos and authorized pos of all candidate and consensus peers
+}
+
+func (this *ProposalInfo) Serialize(w io.Writer) error {
+	if err := this.Proposal.Serialize(w); err != nil {
+		return fmt.Errorf("proposal.Serialize, serialize proposal error: %v", err)
+	}
+	if err := serialization.WriteUint64(w, this.ApproveWeight); err != nil {
+		return fmt.Errorf("serialization.WriteUint64, serialize approveWeight error: %v", err)
+	}
+	if err := serialization.WriteUint64(w, this.TotalWeight); err != nil {
+		return fmt.Errorf("serialization.WriteUint64, serialize totalWeight error: %v", err)
+	}
+	return nil
+}
+
+func (this *ProposalInfo) Deserialize(r io.Reader) error {
+	proposal := new(Proposal)
+	if err := proposal.Deserialize(r); err != nil {
+		return fmt.Errorf("proposal.Deserialize, deserialize proposal error: %v", err)
+	}
+	approveWeight, err := serialization.ReadUint64(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint64, deserialize approveWeight error: %v", err)
+	}
+	totalWeight, err := serialization.ReadUint64(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint64, deserialize totalWeight error: %v", err)
+	}
+	this.Proposal = proposal
+	this.ApproveWeight = approveWeight
+	this.TotalWeight = totalWeight
+	return nil
+}
+
+type ProposalList struct {
+	Ids []uint32 //ids of proposals which are not executed or expired yet
+}
+
+func (this *ProposalList) Serialize(w io.Writer) error {
+	if err := serialization.WriteUint32(w, uint32(len(this.Ids))); err != nil {
+		return fmt.Errorf("serialization.WriteUint32, serialize ids length error: %v", err)
+	}
+	for _, id := range this.Ids {
+		if err := serialization.WriteUint32(w, id); err != nil {
+			return fmt.Errorf("serialization.WriteUint32, serialize id error: %v", err)
+		}
+	}
+	return nil
+}
+
+func (this *ProposalList) Deserialize(r io.Reader) error {
+	n, err := serialization.ReadUint32(r)
+	if err != nil {
+		return fmt.Errorf("serialization.ReadUint32, deserialize ids length error: %v", err)
+	}
+	ids := make([]uint32, 0)
+	for i := 0; uint32(i) < n; i++ {
+		id, err := serialization.ReadUint32(r)
+		if err != nil {
+			return fmt.Errorf("serialization.ReadUint32, deserialize id error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	this.Ids = ids
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/dnaproject2/DNA/common/serialization"
 	vbftconfig "github.com/dnaproject2/DNA/consensus/vbft/config"
 	cstates "github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/smartcontract/event"
 	"github.com/dnaproject2/DNA/smartcontract/service/native"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/auth"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
@@ -817,3 +818,108 @@ func putGasAddress(native *native.NativeService, contract common.Address, gasAdd
 		cstates.GenRawStorageItem(sink.Bytes()))
 	return nil
 }
+
+func getProposalIndex(native *native.NativeService, contract common.Address) (uint32, error) {
+	proposalIndexBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL_INDEX)))
+	if err != nil {
+		return 0, fmt.Errorf("native.CacheDB.Get, get proposalIndex error: %v", err)
+	}
+	var proposalIndex uint32 = 0
+	if proposalIndexBytes != nil {
+		proposalIndexStore, err := cstates.GetValueFromRawStorageItem(proposalIndexBytes)
+		if err != nil {
+			return 0, fmt.Errorf("getProposalIndex, deserialize from raw storage item err:%v", err)
+		}
+		proposalIndex, err = GetBytesUint32(proposalIndexStore)
+		if err != nil {
+			return 0, fmt.Errorf("GetBytesUint32, get proposalIndex error: %v", err)
+		}
+	}
+	return proposalIndex, nil
+}
+
+func putProposalIndex(native *native.NativeService, contract common.Address, proposalIndex uint32) error {
+	proposalIndexBytes, err := GetUint32Bytes(proposalIndex)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get proposalIndexBytes error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL_INDEX)), cstates.GenRawStorageItem(proposalIndexBytes))
+	return nil
+}
+
+func getProposal(native *native.NativeService, contract common.Address, id uint32) (*Proposal, error) {
+	idBytes, err := GetUint32Bytes(id)
+	if err != nil {
+		return nil, fmt.Errorf("GetUint32Bytes, get idBytes error: %v", err)
+	}
+	proposalBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL), idBytes))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get proposalBytes error: %v", err)
+	}
+	if proposalBytes == nil {
+		return nil, fmt.Errorf("getProposal, proposal %d is not found", id)
+	}
+	proposalStore, err := cstates.GetValueFromRawStorageItem(proposalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("getProposal, deserialize from raw storage item err:%v", err)
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialize(bytes.NewBuffer(proposalStore)); err != nil {
+		return nil, fmt.Errorf("deserialize, deserialize proposal error: %v", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, contract common.Address, proposal *Proposal) error {
+	idBytes, err := GetUint32Bytes(proposal.Id)
+	if err != nil {
+		return fmt.Errorf("GetUint32Bytes, get idBytes error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	if err := proposal.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize, serialize proposal error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL), idBytes), cstates.GenRawStorageItem(bf.Bytes()))
+	return nil
+}
+
+func getProposalList(native *native.NativeService, contract common.Address) (*ProposalList, error) {
+	proposalListBytes, err := native.CacheDB.Get(utils.ConcatKey(contract, []byte(PROPOSAL_LIST)))
+	if err != nil {
+		return nil, fmt.Errorf("native.CacheDB.Get, get proposalListBytes error: %v", err)
+	}
+	proposalList := &ProposalList{
+		Ids: make([]uint32, 0),
+	}
+	if proposalListBytes != nil {
+		proposalListStore, err := cstates.GetValueFromRawStorageItem(proposalListBytes)
+		if err != nil {
+			return nil, fmt.Errorf("getProposalList, deserialize from raw storage item err:%v", err)
+		}
+		if err := proposalList.Deserialize(bytes.NewBuffer(proposalListStore)); err != nil {
+			return nil, fmt.Errorf("deserialize, deserialize proposalList error: %v", err)
+		}
+	}
+	return proposalList, nil
+}
+
+func putProposalList(native *native.NativeService, contract common.Address, proposalList *ProposalList) error {
+	bf := new(bytes.Buffer)
+	if err := proposalList.Serialize(bf); err != nil {
+		return fmt.Errorf("serialize, serialize proposalList error: %v", err)
+	}
+	native.CacheDB.Put(utils.ConcatKey(contract, []byte(PROPOSAL_LIST)), cstates.GenRawStorageItem(bf.Bytes()))
+	return nil
+}
+
+func notifyProposal(native *native.NativeService, contract common.Address, functionName string, proposal *Proposal,
+	states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: contract,
+			States:          append([]interface{}{functionName, proposal.Id, uint8(proposal.Status)}, states...),
+		})
+}
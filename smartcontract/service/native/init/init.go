@@ -23,7 +23,9 @@ import (
 	"math/big"
 
 	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/asset"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/auth"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/credential"
 	params "github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/governance"
 	"github.com/dnaproject2/DNA/smartcontract/service/native/ong"
@@ -45,6 +47,8 @@ func init() {
 	ontid.Init()
 	auth.Init()
 	governance.InitGovernance()
+	credential.Init()
+	asset.InitAssetFactory()
 }
 
 func InitBytes(addr common.Address, method string) []byte {
@@ -38,8 +38,23 @@ type (
 
 var (
 	Contracts = make(map[common.Address]RegisterService)
+	// ContractResolver resolves native contracts created at runtime, such as
+	// the assets issued by the asset factory, from the contract state
+	ContractResolver func(db *storage.CacheDB, address common.Address) (RegisterService, bool)
 )
 
+// GetContract returns the native contract registered at the address, or the
+// one resolved by ContractResolver if it was created at runtime
+func GetContract(db *storage.CacheDB, address common.Address) (RegisterService, bool) {
+	if services, ok := Contracts[address]; ok {
+		return services, true
+	}
+	if ContractResolver == nil || db == nil {
+		return nil, false
+	}
+	return ContractResolver(db, address)
+}
+
 // Native service struct
 // Invoke a native smart contract, new a native service
 type NativeService struct {
@@ -61,7 +76,7 @@ func (this *NativeService) Register(methodName string, handler Handler) {
 
 func (this *NativeService) Invoke() (interface{}, error) {
 	contract := this.InvokeParam
-	services, ok := Contracts[contract.Address]
+	services, ok := GetContract(this.CacheDB, contract.Address)
 	if !ok {
 		return false, fmt.Errorf("Native contract address %x haven't been registered.", contract.Address)
 	}
@@ -73,7 +88,7 @@ func (this *NativeService) Invoke() (interface{}, error) {
 	}
 	args := this.Input
 	this.Input = contract.Args
-	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address})
+	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address, Method: contract.Method})
 	notifications := this.Notifications
 	this.Notifications = []*event.NotifyEventInfo{}
 	result, err := service(this)
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package nativetest provides the fixture shared by the tests of native contracts
+package nativetest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/store/leveldbstore"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/context"
+	"github.com/dnaproject2/DNA/smartcontract/event"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/dnaproject2/DNA/smartcontract/storage"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+// ContextRef is a stub of context.ContextRef keeping a stack of contexts, which passes CheckWitness
+// for its signers only and drops notifications
+type ContextRef struct {
+	context.ContextRef
+	contexts []*context.Context
+	witness  map[common.Address]bool
+}
+
+// NewContextRef returns a ContextRef signed by signers, with contracts pushed in order as the initial
+// contexts, so the last one is the current contract and the one before it is the calling contract
+func NewContextRef(signers []common.Address, contracts ...common.Address) *ContextRef {
+	ref := &ContextRef{witness: make(map[common.Address]bool)}
+	for _, signer := range signers {
+		ref.witness[signer] = true
+	}
+	for _, contract := range contracts {
+		ref.PushContext(&context.Context{ContractAddress: contract})
+	}
+	return ref
+}
+
+func (this *ContextRef) PushContext(ctx *context.Context) {
+	this.contexts = append(this.contexts, ctx)
+}
+
+func (this *ContextRef) PopContext() {
+	this.contexts = this.contexts[:len(this.contexts)-1]
+}
+
+func (this *ContextRef) CurrentContext() *context.Context {
+	return this.contexts[len(this.contexts)-1]
+}
+
+func (this *ContextRef) CallingContext() *context.Context {
+	if len(this.contexts) < 2 {
+		return nil
+	}
+	return this.contexts[len(this.contexts)-2]
+}
+
+func (this *ContextRef) CheckWitness(address common.Address) bool {
+	return this.witness[address]
+}
+
+func (this *ContextRef) PushNotifications([]*event.NotifyEventInfo) {}
+
+// NewNativeService returns a NativeService at height 1 on an empty memory store
+func NewNativeService(t *testing.T) *native.NativeService {
+	store, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	return &native.NativeService{
+		CacheDB:    storage.NewCacheDB(overlaydb.NewOverlayDB(store)),
+		ServiceMap: make(map[string]native.Handler),
+		Height:     1,
+	}
+}
+
+// NewTestID registers a new DNA ID with a new key, the ontid contract must have been initialized.
+// It returns the ID and the address of the key.
+func NewTestID(t *testing.T, srvc *native.NativeService) ([]byte, common.Address) {
+	id, err := account.GenerateID()
+	assert.Nil(t, err)
+	_, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	addr := types.AddressFromPubKey(pub)
+	srvc.ContextRef = NewContextRef([]common.Address{addr})
+	_, err = Call(srvc, utils.OntIDContractAddress, "regIDWithPublicKey", []byte(id), keypair.SerializePublicKey(pub))
+	assert.Nil(t, err)
+	return []byte(id), addr
+}
+
+// Raw is an argument written to the input as it is, without a length prefix
+type Raw []byte
+
+// EncodeArgs encodes args in order as the input of a native method. Byte slices and addresses are
+// written as var bytes, uint64 as var uint, and other values by their own Serialization or Serialize.
+func EncodeArgs(args ...interface{}) []byte {
+	sink := common.NewZeroCopySink(nil)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case Raw:
+			sink.WriteBytes(v)
+		case []byte:
+			sink.WriteVarBytes(v)
+		case uint64:
+			utils.EncodeVarUint(sink, v)
+		case common.Address:
+			utils.EncodeAddress(sink, v)
+		case interface{ Serialization(*common.ZeroCopySink) }:
+			v.Serialization(sink)
+		case interface{ Serialize(io.Writer) error }:
+			bf := new(bytes.Buffer)
+			if err := v.Serialize(bf); err != nil {
+				panic(fmt.Sprintf("serialize %T error: %v", arg, err))
+			}
+			sink.WriteBytes(bf.Bytes())
+		default:
+			panic(fmt.Sprintf("unsupported arg type %T", arg))
+		}
+	}
+	return sink.Bytes()
+}
+
+// Invoke calls method directly with the input encoded from args
+func Invoke(srvc *native.NativeService, method native.Handler, args ...interface{}) ([]byte, error) {
+	srvc.Input = EncodeArgs(args...)
+	return method(srvc)
+}
+
+// Call invokes method of contract by NativeCall with the input encoded from args
+func Call(srvc *native.NativeService, contract common.Address, method string, args ...interface{}) ([]byte, error) {
+	ret, err := srvc.NativeCall(contract, method, EncodeArgs(args...))
+	if err != nil {
+		return nil, err
+	}
+	return ret.([]byte), nil
+}
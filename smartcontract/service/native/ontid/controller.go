@@ -0,0 +1,246 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ontid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+func regIdWithController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: argument 0 error")
+	}
+	// arg1: controller group
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: argument 1 error")
+	}
+	// arg2: signers
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: argument 2 error")
+	}
+
+	if !account.VerifyID(string(arg0)) {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: invalid ID")
+	}
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: " + err.Error())
+	}
+	if checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: already registered")
+	}
+	controller, err := parseGroup(arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: invalid controller, " + err.Error())
+	}
+	for _, m := range controller.members {
+		if bytes.Equal(m, arg0) {
+			return utils.BYTE_FALSE, errors.New("register ID with controller error: ID can not control itself")
+		}
+	}
+	if !verifyGroup(srvc, controller, arg2) {
+		return utils.BYTE_FALSE, errors.New("register ID with controller error: verify signers failed")
+	}
+
+	setGroup(srvc, key, FIELD_CONTROLLER, controller)
+	srvc.CacheDB.Put(key, states.GenRawStorageItem([]byte{flag_exist}))
+
+	triggerRegisterEvent(srvc, arg0)
+	triggerGroupEvent(srvc, "Controller", "set", arg0, controller)
+	return utils.BYTE_TRUE, nil
+}
+
+// checkController verifies the signers against the controller of the ID,
+// and returns the encoded ID
+func checkController(srvc *native.NativeService, id []byte, signers []signer) ([]byte, error) {
+	key, err := encodeID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !checkIDExistence(srvc, key) {
+		return nil, errors.New("ID not registered")
+	}
+	controller, err := getGroup(srvc, key, FIELD_CONTROLLER)
+	if err != nil {
+		return nil, err
+	} else if controller == nil {
+		return nil, errors.New("ID has no controller")
+	}
+	if !verifyGroup(srvc, controller, signers) {
+		return nil, errors.New("verify signers failed")
+	}
+	return key, nil
+}
+
+func changeController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change controller failed: argument 0 error")
+	}
+	// arg1: new controller group
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change controller failed: argument 1 error")
+	}
+	// arg2: signers of the current controller
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change controller failed: argument 2 error")
+	}
+
+	key, err := checkController(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change controller failed: " + err.Error())
+	}
+	controller, err := parseGroup(arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change controller failed: invalid controller, " + err.Error())
+	}
+	for _, m := range controller.members {
+		if bytes.Equal(m, arg0) {
+			return utils.BYTE_FALSE, errors.New("change controller failed: ID can not control itself")
+		}
+	}
+
+	setGroup(srvc, key, FIELD_CONTROLLER, controller)
+	triggerGroupEvent(srvc, "Controller", "change", arg0, controller)
+	return utils.BYTE_TRUE, nil
+}
+
+func addKeyByController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: argument 0 error")
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: argument 1 error")
+	}
+	// arg2: signers of the controller
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: argument 2 error")
+	}
+
+	key, err := checkController(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: " + err.Error())
+	}
+	item, _, err := findPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: " + err.Error())
+	} else if item != 0 {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: already exists")
+	}
+	keyID, err := insertPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by controller failed: insert public key error, " + err.Error())
+	}
+
+	triggerPublicEvent(srvc, "add", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
+
+func removeKeyByController(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: argument 0 error")
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: argument 1 error")
+	}
+	// arg2: signers of the controller
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: argument 2 error")
+	}
+
+	key, err := checkController(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: " + err.Error())
+	}
+	if err = checkInstantRevocation(srvc, key); err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: " + err.Error())
+	}
+	keyID, err := revokePk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by controller failed: " + err.Error())
+	}
+
+	triggerPublicEvent(srvc, "remove", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
+
+// verifyMultiSignature checks a set of signers against the controller of
+// the ID. For an ID without controller, the signers should be the ID itself
+// with the index of its public key.
+func verifyMultiSignature(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("verify multi-signature error: argument 0 error, %s", err)
+	}
+	// arg1: signers
+	arg1, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("verify multi-signature error: argument 1 error, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("verify multi-signature error: " + err.Error())
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("verify multi-signature error: ID not registered")
+	}
+	controller, err := getGroup(srvc, key, FIELD_CONTROLLER)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("verify multi-signature error: " + err.Error())
+	}
+	if controller == nil {
+		controller = &group{members: [][]byte{arg0}, threshold: 1}
+	}
+	if !verifyGroup(srvc, controller, arg1) {
+		return utils.BYTE_FALSE, errors.New("verify multi-signature failed")
+	}
+	return utils.BYTE_TRUE, nil
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ontid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/nativetest"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+type testKey struct {
+	pub  []byte
+	addr common.Address
+}
+
+func newTestKey(t *testing.T) *testKey {
+	_, pub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	return &testKey{keypair.SerializePublicKey(pub), types.AddressFromPubKey(pub)}
+}
+
+func newTestID(t *testing.T) []byte {
+	id, err := account.GenerateID()
+	assert.Nil(t, err)
+	return []byte(id)
+}
+
+func invoke(srvc *native.NativeService, method native.Handler, signed []*testKey, args ...interface{}) error {
+	signers := make([]common.Address, 0, len(signed))
+	for _, k := range signed {
+		signers = append(signers, k.addr)
+	}
+	srvc.ContextRef = nativetest.NewContextRef(signers, utils.OntIDContractAddress)
+	for i, arg := range args {
+		if v, ok := arg.([]signer); ok {
+			args[i] = nativetest.Raw(encodeSigners(v))
+		}
+	}
+	_, err := nativetest.Invoke(srvc, method, args...)
+	return err
+}
+
+func encodeSigners(signers []signer) []byte {
+	var buf bytes.Buffer
+	utils.WriteVarUint(&buf, uint64(len(signers)))
+	for _, s := range signers {
+		serialization.WriteVarBytes(&buf, s.id)
+		utils.WriteVarUint(&buf, uint64(s.index))
+	}
+	return buf.Bytes()
+}
+
+func TestController(t *testing.T) {
+	srvc := nativetest.NewNativeService(t)
+	k1, k2, k3, k4 := newTestKey(t), newTestKey(t), newTestKey(t), newTestKey(t)
+
+	// a member ID controlled by k3
+	member := newTestID(t)
+	assert.Nil(t, invoke(srvc, regIdWithPublicKey, []*testKey{k3}, member, k3.pub))
+
+	id := newTestID(t)
+	controller := &group{members: [][]byte{k1.pub, k2.pub, member}, threshold: 2}
+	signers := []signer{{k1.pub, 0}, {member, 1}}
+	assert.NotNil(t, invoke(srvc, regIdWithController, []*testKey{k1}, id, controller.ToArray(), signers))
+	assert.NotNil(t, invoke(srvc, regIdWithController, []*testKey{k1, k3}, id, controller.ToArray(), signers[:1]))
+	assert.NotNil(t, invoke(srvc, regIdWithController, []*testKey{k1, k3}, id,
+		(&group{members: [][]byte{k1.pub, k1.pub}, threshold: 1}).ToArray(), signers))
+	assert.Nil(t, invoke(srvc, regIdWithController, []*testKey{k1, k3}, id, controller.ToArray(), signers))
+
+	// the same member only counts once
+	assert.NotNil(t, invoke(srvc, verifyMultiSignature, []*testKey{k1}, id, []signer{{k1.pub, 0}, {k1.pub, 0}}))
+	assert.Nil(t, invoke(srvc, verifyMultiSignature, []*testKey{k1, k2}, id, []signer{{k1.pub, 0}, {k2.pub, 0}}))
+	assert.Nil(t, invoke(srvc, verifyMultiSignature, []*testKey{k3}, member, []signer{{member, 1}}))
+
+	assert.NotNil(t, invoke(srvc, addKeyByController, []*testKey{k2}, id, k4.pub, []signer{{k2.pub, 0}}))
+	assert.Nil(t, invoke(srvc, addKeyByController, []*testKey{k2, k3}, id, k4.pub,
+		[]signer{{k2.pub, 0}, {member, 1}}))
+	key, err := encodeID(id)
+	assert.Nil(t, err)
+	assert.True(t, isOwner(srvc, key, k4.pub))
+
+	// k4 is revoked from height 11, and can not cancel the revocation itself
+	key, err = encodeID(member)
+	assert.Nil(t, err)
+	assert.Nil(t, invoke(srvc, addKey, []*testKey{k3}, member, k4.pub, k3.pub))
+	assert.Nil(t, invoke(srvc, revokeKeyWithTimelock, []*testKey{k3}, member, k4.pub, uint64(10), k3.pub))
+	assert.NotNil(t, invoke(srvc, revokeKeyWithTimelock, []*testKey{k3}, member, k4.pub, uint64(10), k3.pub))
+	assert.NotNil(t, invoke(srvc, cancelKeyRevocation, []*testKey{k4}, member, k4.pub, k4.pub))
+	srvc.Height = 10
+	assert.True(t, isOwner(srvc, key, k4.pub))
+	srvc.Height = 11
+	assert.False(t, isOwner(srvc, key, k4.pub))
+	assert.NotNil(t, invoke(srvc, cancelKeyRevocation, []*testKey{k3}, member, k4.pub, k3.pub))
+
+	// threshold recovery
+	recovery := &group{members: [][]byte{k1.pub, k2.pub}, threshold: 2}
+	assert.Nil(t, invoke(srvc, setRecoveryGroup, []*testKey{k3}, member, recovery.ToArray(), k3.pub))
+	assert.NotNil(t, invoke(srvc, addRecovery, []*testKey{k3}, member, k1.addr[:], k3.pub))
+	assert.NotNil(t, invoke(srvc, removeKeyByRecovery, []*testKey{k1}, member, k3.pub, []signer{{k1.pub, 0}}))
+	assert.Nil(t, invoke(srvc, removeKeyByRecovery, []*testKey{k1, k2}, member, k3.pub,
+		[]signer{{k1.pub, 0}, {k2.pub, 0}}))
+	assert.False(t, isOwner(srvc, key, k3.pub))
+}
+
+func TestNestedController(t *testing.T) {
+	srvc := nativetest.NewNativeService(t)
+	k1, k2 := newTestKey(t), newTestKey(t)
+
+	// a chain of IDs, each controlled by the previous one, nested up to MAX_GROUP_DEPTH
+	chain := [][]byte{k1.pub}
+	signers := []signer{{k1.pub, 0}}
+	for i := 0; i <= MAX_GROUP_DEPTH+1; i++ {
+		id := newTestID(t)
+		controller := &group{members: [][]byte{chain[len(chain)-1]}, threshold: 1}
+		err := invoke(srvc, regIdWithController, []*testKey{k1}, id, controller.ToArray(), signers)
+		if i <= MAX_GROUP_DEPTH {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+		chain = append(chain, id)
+		signers = append(signers, signer{id, 0})
+	}
+
+	// a member ID without keys signs with its controller
+	id := newTestID(t)
+	controller := &group{members: [][]byte{chain[1], k2.pub}, threshold: 2}
+	signers = []signer{{chain[1], 0}, {k1.pub, 0}, {k2.pub, 0}}
+	assert.NotNil(t, invoke(srvc, regIdWithController, []*testKey{k2}, id, controller.ToArray(), signers))
+	assert.Nil(t, invoke(srvc, regIdWithController, []*testKey{k1, k2}, id, controller.ToArray(), signers))
+
+	// IDs controlling each other do not loop
+	assert.Nil(t, invoke(srvc, changeController, []*testKey{k1}, chain[1],
+		(&group{members: [][]byte{chain[2]}, threshold: 1}).ToArray(), []signer{{k1.pub, 0}}))
+	assert.NotNil(t, invoke(srvc, verifyMultiSignature, []*testKey{k1}, chain[1],
+		[]signer{{chain[2], 0}, {chain[1], 0}}))
+}
+
+func TestVerifyRevokedKey(t *testing.T) {
+	srvc := nativetest.NewNativeService(t)
+	k1, k2, k3 := newTestKey(t), newTestKey(t), newTestKey(t)
+	id := newTestID(t)
+	assert.Nil(t, invoke(srvc, regIdWithPublicKey, []*testKey{k1}, id, k1.pub))
+	assert.Nil(t, invoke(srvc, addKey, []*testKey{k1}, id, k2.pub, k1.pub))
+	assert.Nil(t, invoke(srvc, addKey, []*testKey{k1}, id, k3.pub, k1.pub))
+	assert.Nil(t, invoke(srvc, verifySignature, []*testKey{k2}, id, uint64(2)))
+
+	// removed at once
+	assert.Nil(t, invoke(srvc, removeKey, []*testKey{k1}, id, k2.pub, k1.pub))
+	assert.NotNil(t, invoke(srvc, verifySignature, []*testKey{k2}, id, uint64(2)))
+
+	// revoked after the timelock
+	assert.Nil(t, invoke(srvc, revokeKeyWithTimelock, []*testKey{k1}, id, k3.pub, uint64(5), k1.pub))
+	assert.Nil(t, invoke(srvc, verifySignature, []*testKey{k3}, id, uint64(3)))
+	srvc.Height = 6
+	assert.NotNil(t, invoke(srvc, verifySignature, []*testKey{k3}, id, uint64(3)))
+}
+
+func TestRevocationDelay(t *testing.T) {
+	srvc := nativetest.NewNativeService(t)
+	k1, k2, k3 := newTestKey(t), newTestKey(t), newTestKey(t)
+	id := newTestID(t)
+	assert.Nil(t, invoke(srvc, regIdWithPublicKey, []*testKey{k1}, id, k1.pub))
+	assert.Nil(t, invoke(srvc, addKey, []*testKey{k1}, id, k2.pub, k1.pub))
+	recovery := &group{members: [][]byte{k3.pub}, threshold: 1}
+	assert.Nil(t, invoke(srvc, setRecoveryGroup, []*testKey{k1}, id, recovery.ToArray(), k1.pub))
+
+	assert.NotNil(t, invoke(srvc, setRevocationDelay, []*testKey{k1}, id, uint64(MAX_REVOCATION_DELAY+1), k1.pub))
+	// the recovery group should also sign
+	assert.NotNil(t, invoke(srvc, setRevocationDelay, []*testKey{k1}, id, uint64(10), k1.pub))
+	assert.NotNil(t, invoke(srvc, setRevocationDelay, []*testKey{k1}, id, uint64(10), k1.pub,
+		[]signer{{k3.pub, 0}}))
+	assert.Nil(t, invoke(srvc, setRevocationDelay, []*testKey{k1, k3}, id, uint64(10), k1.pub,
+		[]signer{{k3.pub, 0}}))
+	// the delay can not be lowered by a stolen key
+	assert.NotNil(t, invoke(srvc, setRevocationDelay, []*testKey{k2, k3}, id, uint64(5), k2.pub,
+		[]signer{{k3.pub, 0}}))
+
+	// no instant revocation any more
+	assert.NotNil(t, invoke(srvc, removeKey, []*testKey{k2}, id, k1.pub, k2.pub))
+	assert.NotNil(t, invoke(srvc, removeKeyByRecovery, []*testKey{k3}, id, k1.pub, []signer{{k3.pub, 0}}))
+	assert.NotNil(t, invoke(srvc, revokeKeyWithTimelock, []*testKey{k2}, id, k1.pub, uint64(9), k2.pub))
+
+	// the owner cancels the revocation by a stolen key in time with another key
+	k4 := newTestKey(t)
+	assert.Nil(t, invoke(srvc, addKey, []*testKey{k1}, id, k4.pub, k1.pub))
+	assert.Nil(t, invoke(srvc, revokeKeyWithTimelock, []*testKey{k2}, id, k1.pub, uint64(10), k2.pub))
+	assert.Nil(t, invoke(srvc, cancelKeyRevocation, []*testKey{k4}, id, k1.pub, k4.pub))
+}
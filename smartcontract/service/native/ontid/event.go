@@ -61,3 +61,13 @@ func triggerRecoveryEvent(srvc *native.NativeService, op string, id []byte, addr
 	st := []string{"Recovery", op, string(id), addr.ToHexString()}
 	newEvent(srvc, st)
 }
+
+func triggerGroupEvent(srvc *native.NativeService, name, op string, id []byte, g *group) {
+	st := []string{name, op, string(id), hex.EncodeToString(g.ToArray())}
+	newEvent(srvc, st)
+}
+
+func triggerRevocationEvent(srvc *native.NativeService, op string, id, pub []byte, keyID, height uint32) {
+	st := []interface{}{"Revocation", op, string(id), keyID, hex.EncodeToString(pub), height}
+	newEvent(srvc, st)
+}
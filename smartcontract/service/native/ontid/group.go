@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ontid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+const MAX_GROUP_MEMBERS = 16
+
+// MAX_GROUP_DEPTH bounds the nesting of controlled IDs when verifying a group,
+// which also breaks the cycles of IDs controlling each other
+const MAX_GROUP_DEPTH = 3
+
+// group is an m-of-n policy, each member is either a public key or
+// another DNA ID.
+type group struct {
+	members   [][]byte
+	threshold uint32
+}
+
+func (this *group) Serialize(w io.Writer) error {
+	err := utils.WriteVarUint(w, uint64(len(this.members)))
+	if err != nil {
+		return err
+	}
+	for _, m := range this.members {
+		err = serialization.WriteVarBytes(w, m)
+		if err != nil {
+			return err
+		}
+	}
+	return utils.WriteVarUint(w, uint64(this.threshold))
+}
+
+func (this *group) Deserialize(r io.Reader) error {
+	n, err := utils.ReadVarUint(r)
+	if err != nil {
+		return err
+	}
+	if n > MAX_GROUP_MEMBERS {
+		return errors.New("too many group members")
+	}
+	members := make([][]byte, 0, n)
+	for i := 0; i < int(n); i++ {
+		m, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return err
+		}
+		members = append(members, m)
+	}
+	threshold, err := utils.ReadVarUint(r)
+	if err != nil {
+		return err
+	}
+	if threshold > MAX_GROUP_MEMBERS {
+		return errors.New("invalid group threshold")
+	}
+	this.members = members
+	this.threshold = uint32(threshold)
+	return nil
+}
+
+func (this *group) Validate() error {
+	if len(this.members) == 0 {
+		return errors.New("empty group")
+	}
+	if this.threshold == 0 || int(this.threshold) > len(this.members) {
+		return errors.New("invalid group threshold")
+	}
+	for i, m := range this.members {
+		if !account.VerifyID(string(m)) {
+			if _, err := keypair.DeserializePublicKey(m); err != nil {
+				return fmt.Errorf("invalid group member %d", i)
+			}
+		}
+		for _, v := range this.members[:i] {
+			if bytes.Equal(m, v) {
+				return fmt.Errorf("duplicated group member %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+func (this *group) ToArray() []byte {
+	var buf bytes.Buffer
+	this.Serialize(&buf)
+	return buf.Bytes()
+}
+
+// signer refers to a group member who signed the transaction. id is the
+// public key of the member, or the DNA ID of the member together with the
+// index of the key it signed with.
+type signer struct {
+	id    []byte
+	index uint32
+}
+
+func deserializeSigners(r io.Reader) ([]signer, error) {
+	n, err := utils.ReadVarUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > MAX_GROUP_MEMBERS {
+		return nil, errors.New("too many signers")
+	}
+	signers := make([]signer, 0, n)
+	for i := 0; i < int(n); i++ {
+		id, err := serialization.ReadVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		index, err := utils.ReadVarUint(r)
+		if err != nil {
+			return nil, err
+		}
+		if index > 0xFFFFFFFF {
+			return nil, errors.New("invalid key index")
+		}
+		signers = append(signers, signer{id, uint32(index)})
+	}
+	return signers, nil
+}
+
+func parseGroup(data []byte) (*group, error) {
+	g := new(group)
+	err := g.Deserialize(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse group error, %s", err)
+	}
+	err = g.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// verifySigner checks the signer has witnessed the transaction. A DNA ID
+// signs with one of its keys, or with its own controller group satisfied by
+// the other signers.
+func verifySigner(srvc *native.NativeService, s signer, signers []signer, depth int) bool {
+	if !account.VerifyID(string(s.id)) {
+		return checkWitness(srvc, s.id) == nil
+	}
+	key, err := encodeID(s.id)
+	if err != nil || !checkIDExistence(srvc, key) {
+		return false
+	}
+	pk, err := getPk(srvc, key, s.index)
+	if err == nil && pk != nil && !pk.revoked && checkWitness(srvc, pk.key) == nil {
+		return true
+	}
+	if depth >= MAX_GROUP_DEPTH {
+		return false
+	}
+	controller, err := getGroup(srvc, key, FIELD_CONTROLLER)
+	if err != nil || controller == nil {
+		return false
+	}
+	return verifyGroupDepth(srvc, controller, signers, depth+1)
+}
+
+// verifyGroup checks the signers satisfy the threshold of the group
+func verifyGroup(srvc *native.NativeService, g *group, signers []signer) bool {
+	return verifyGroupDepth(srvc, g, signers, 0)
+}
+
+func verifyGroupDepth(srvc *native.NativeService, g *group, signers []signer, depth int) bool {
+	signed := make([]bool, len(g.members))
+	var count uint32 = 0
+	for _, s := range signers {
+		for i, m := range g.members {
+			if signed[i] || !bytes.Equal(m, s.id) {
+				continue
+			}
+			if verifySigner(srvc, s, signers, depth) {
+				signed[i] = true
+				count += 1
+			}
+			break
+		}
+	}
+	return count >= g.threshold
+}
+
+func setGroup(srvc *native.NativeService, encID []byte, field byte, g *group) {
+	key := append(encID, field)
+	val := states.StorageItem{Value: g.ToArray()}
+	srvc.CacheDB.Put(key, val.ToArray())
+}
+
+func getGroup(srvc *native.NativeService, encID []byte, field byte) (*group, error) {
+	key := append(encID, field)
+	item, err := utils.GetStorageItem(srvc, key)
+	if err != nil {
+		return nil, errors.New("get group error: " + err.Error())
+	} else if item == nil {
+		return nil, nil
+	}
+	g := new(group)
+	err = g.Deserialize(bytes.NewBuffer(item.Value))
+	if err != nil {
+		return nil, errors.New("get group error: " + err.Error())
+	}
+	return g, nil
+}
@@ -32,13 +32,27 @@ func RegisterIDContract(srvc *native.NativeService) {
 	srvc.Register("removeKey", removeKey)
 	srvc.Register("addRecovery", addRecovery)
 	srvc.Register("changeRecovery", changeRecovery)
+	srvc.Register("setRecoveryGroup", setRecoveryGroup)
+	srvc.Register("changeRecoveryGroup", changeRecoveryGroup)
+	srvc.Register("addKeyByRecovery", addKeyByRecovery)
+	srvc.Register("removeKeyByRecovery", removeKeyByRecovery)
+	srvc.Register("revokeKeyWithTimelock", revokeKeyWithTimelock)
+	srvc.Register("cancelKeyRevocation", cancelKeyRevocation)
+	srvc.Register("setRevocationDelay", setRevocationDelay)
+	srvc.Register("regIDWithController", regIdWithController)
+	srvc.Register("changeController", changeController)
+	srvc.Register("addKeyByController", addKeyByController)
+	srvc.Register("removeKeyByController", removeKeyByController)
 	srvc.Register("regIDWithAttributes", regIdWithAttributes)
 	srvc.Register("addAttributes", addAttributes)
 	srvc.Register("removeAttribute", removeAttribute)
 	srvc.Register("verifySignature", verifySignature)
+	srvc.Register("verifyMultiSignature", verifyMultiSignature)
 	srvc.Register("getPublicKeys", GetPublicKeys)
 	srvc.Register("getKeyState", GetKeyState)
 	srvc.Register("getAttributes", GetAttributes)
 	srvc.Register("getDDO", GetDDO)
+	srvc.Register("getController", GetController)
+	srvc.Register("getRecoveryGroup", GetRecoveryGroup)
 	return
 }
@@ -209,7 +209,9 @@ func addKey(srvc *native.NativeService) ([]byte, error) {
 	}
 
 	item, _, err := findPk(srvc, key, arg1)
-	if item != 0 {
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key failed: " + err.Error())
+	} else if item != 0 {
 		return utils.BYTE_FALSE, errors.New("add key failed: already exists")
 	}
 
@@ -264,6 +266,9 @@ func removeKey(srvc *native.NativeService) ([]byte, error) {
 		}
 	}
 
+	if err = checkInstantRevocation(srvc, key); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
+	}
 	keyID, err := revokePk(srvc, key, arg1)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("remove key failed: %s", err)
@@ -274,6 +279,188 @@ func removeKey(srvc *native.NativeService) ([]byte, error) {
 	return utils.BYTE_TRUE, nil
 }
 
+func revokeKeyWithTimelock(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: id
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: argument 0 error, %s", err)
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: argument 1 error, %s", err)
+	}
+	// arg2: delay in blocks
+	arg2, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: argument 2 error, %s", err)
+	}
+	// arg3: operator's public key / address
+	arg3, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: argument 3 error, %s", err)
+	}
+	if arg2 == 0 || arg2 > MAX_REVOCATION_DELAY {
+		return utils.BYTE_FALSE, errors.New("revoke key with timelock failed: invalid delay")
+	}
+	if err = checkWitness(srvc, arg3); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: check witness failed, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("revoke key with timelock failed: ID not registered")
+	}
+	var auth = false
+	rec, err := getRecovery(srvc, key)
+	if len(rec) > 0 {
+		auth = bytes.Equal(rec, arg3)
+	}
+	if !auth {
+		if !isOwner(srvc, key, arg3) {
+			return utils.BYTE_FALSE, errors.New("revoke key with timelock failed: operator has no authorization")
+		}
+	}
+
+	minDelay, err := getRevocationDelay(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: %s", err)
+	} else if uint32(arg2) < minDelay {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: delay less than %d blocks", minDelay)
+	}
+
+	height := srvc.Height + uint32(arg2)
+	keyID, err := scheduleRevocation(srvc, key, arg1, height)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("revoke key with timelock failed: %s", err)
+	}
+
+	triggerRevocationEvent(srvc, "schedule", arg0, arg1, keyID, height)
+	return utils.BYTE_TRUE, nil
+}
+
+// setRevocationDelay sets the min delay in blocks of revoking a public key of the ID. Once set, the keys can
+// only be revoked by revokeKeyWithTimelock, and the delay can only be raised, so that a stolen key can not
+// revoke the other keys before the owner cancels the revocation. If the ID has a controller or recovery group,
+// the group should also sign, otherwise a stolen key could lock the other keys with the max delay.
+func setRevocationDelay(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: id
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: argument 0 error, %s", err)
+	}
+	// arg1: delay in blocks
+	arg1, err := utils.ReadVarUint(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: argument 1 error, %s", err)
+	}
+	// arg2: operator's public key
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: argument 2 error, %s", err)
+	}
+	if arg1 == 0 || arg1 > MAX_REVOCATION_DELAY {
+		return utils.BYTE_FALSE, errors.New("set revocation delay failed: invalid delay")
+	}
+	if err = checkWitness(srvc, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: check witness failed, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("set revocation delay failed: ID not registered")
+	}
+	if !isOwner(srvc, key, arg2) {
+		return utils.BYTE_FALSE, errors.New("set revocation delay failed: operator has no authorization")
+	}
+	g, err := getGroup(srvc, key, FIELD_CONTROLLER)
+	if err == nil && g == nil {
+		g, err = getGroup(srvc, key, FIELD_RECOVERY_GROUP)
+	}
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: %s", err)
+	}
+	if g != nil {
+		// arg3: signers of the controller, or of the recovery group if the ID has no controller
+		arg3, err := deserializeSigners(args)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: argument 3 error, %s", err)
+		}
+		if !verifyGroup(srvc, g, arg3) {
+			return utils.BYTE_FALSE, errors.New("set revocation delay failed: verify group signers failed")
+		}
+	}
+	delay, err := getRevocationDelay(srvc, key)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: %s", err)
+	} else if uint32(arg1) <= delay {
+		return utils.BYTE_FALSE, fmt.Errorf("set revocation delay failed: delay should be more than %d blocks", delay)
+	}
+
+	putRevocationDelay(srvc, key, uint32(arg1))
+	triggerRevocationEvent(srvc, "delay", arg0, arg2, 0, uint32(arg1))
+	return utils.BYTE_TRUE, nil
+}
+
+func cancelKeyRevocation(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: id
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: argument 0 error, %s", err)
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: argument 1 error, %s", err)
+	}
+	// arg2: operator's public key / address, which should not be the key being revoked
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: argument 2 error, %s", err)
+	}
+	if bytes.Equal(arg1, arg2) {
+		return utils.BYTE_FALSE, errors.New("cancel key revocation failed: key can not cancel its own revocation")
+	}
+	if err = checkWitness(srvc, arg2); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: check witness failed, %s", err)
+	}
+
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: %s", err)
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("cancel key revocation failed: ID not registered")
+	}
+	var auth = false
+	rec, err := getRecovery(srvc, key)
+	if len(rec) > 0 {
+		auth = bytes.Equal(rec, arg2)
+	}
+	if !auth {
+		if !isOwner(srvc, key, arg2) {
+			return utils.BYTE_FALSE, errors.New("cancel key revocation failed: operator has no authorization")
+		}
+	}
+
+	keyID, err := cancelRevocation(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("cancel key revocation failed: %s", err)
+	}
+
+	triggerRevocationEvent(srvc, "cancel", arg0, arg1, keyID, srvc.Height)
+	return utils.BYTE_TRUE, nil
+}
+
 func addRecovery(srvc *native.NativeService) ([]byte, error) {
 	args := bytes.NewBuffer(srvc.Input)
 	// arg0: ID
@@ -312,6 +499,10 @@ func addRecovery(srvc *native.NativeService) ([]byte, error) {
 	if err == nil && len(re) > 0 {
 		return utils.BYTE_FALSE, errors.New("add recovery failed: already set recovery")
 	}
+	g, err := getGroup(srvc, key, FIELD_RECOVERY_GROUP)
+	if err != nil || g != nil {
+		return utils.BYTE_FALSE, errors.New("add recovery failed: already set recovery")
+	}
 
 	err = setRecovery(srvc, key, arg1)
 	if err != nil {
@@ -492,6 +683,8 @@ func verifySignature(srvc *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, errors.New("verify signature error: get key failed, " + err.Error())
 	} else if owner == nil {
 		return utils.BYTE_FALSE, errors.New("verify signature error: public key not found")
+	} else if owner.revoked {
+		return utils.BYTE_FALSE, errors.New("verify signature error: public key revoked")
 	}
 
 	err = checkWitness(srvc, owner.key)
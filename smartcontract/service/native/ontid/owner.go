@@ -77,6 +77,16 @@ func getAllPk(srvc *native.NativeService, key []byte) ([]*owner, error) {
 		}
 		owners = append(owners, t)
 	}
+	// apply the timelocked revocations which have taken effect
+	revs, err := getRevocations(srvc, key[:len(key)-1])
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range revs {
+		if r.height <= srvc.Height && r.index >= 1 && r.index <= uint32(len(owners)) {
+			owners[r.index-1].revoked = true
+		}
+	}
 	return owners, nil
 }
 
@@ -174,3 +184,146 @@ func isOwner(srvc *native.NativeService, encID, pub []byte) bool {
 	}
 	return kID != 0 && !revoked
 }
+
+// revocation is a pending revocation of a public key, which takes effect
+// from the given block height
+type revocation struct {
+	index  uint32
+	height uint32
+}
+
+func revocationKey(encID []byte) []byte {
+	// encID may be a slice of a longer key, copy it before appending
+	key := make([]byte, 0, len(encID)+1)
+	key = append(key, encID...)
+	return append(key, FIELD_REVOCATION)
+}
+
+func getRevocations(srvc *native.NativeService, encID []byte) ([]revocation, error) {
+	item, err := utils.GetStorageItem(srvc, revocationKey(encID))
+	if err != nil {
+		return nil, fmt.Errorf("get storage error, %s", err)
+	} else if item == nil {
+		return nil, nil
+	}
+	buf := bytes.NewBuffer(item.Value)
+	revs := make([]revocation, 0)
+	for buf.Len() > 0 {
+		index, err := serialization.ReadUint32(buf)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize revocations error, %s", err)
+		}
+		height, err := serialization.ReadUint32(buf)
+		if err != nil {
+			return nil, fmt.Errorf("deserialize revocations error, %s", err)
+		}
+		revs = append(revs, revocation{index, height})
+	}
+	return revs, nil
+}
+
+func putRevocations(srvc *native.NativeService, encID []byte, revs []revocation) {
+	key := revocationKey(encID)
+	if len(revs) == 0 {
+		srvc.CacheDB.Delete(key)
+		return
+	}
+	var buf bytes.Buffer
+	for _, r := range revs {
+		serialization.WriteUint32(&buf, r.index)
+		serialization.WriteUint32(&buf, r.height)
+	}
+	var v states.StorageItem
+	v.Value = buf.Bytes()
+	srvc.CacheDB.Put(key, v.ToArray())
+}
+
+// scheduleRevocation revokes the public key from the given height, the
+// revocation can be cancelled before that
+func scheduleRevocation(srvc *native.NativeService, encID, pub []byte, height uint32) (uint32, error) {
+	index, revoked, err := findPk(srvc, encID, pub)
+	if err != nil {
+		return 0, err
+	} else if index == 0 {
+		return 0, errors.New("public key not found")
+	} else if revoked {
+		return 0, errors.New("public key has already been revoked")
+	}
+	revs, err := getRevocations(srvc, encID)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range revs {
+		if r.index == index {
+			return 0, errors.New("public key is already being revoked")
+		}
+	}
+	revs = append(revs, revocation{index, height})
+	putRevocations(srvc, encID, revs)
+	return index, nil
+}
+
+// cancelRevocation removes a pending revocation which has not taken effect
+func cancelRevocation(srvc *native.NativeService, encID, pub []byte) (uint32, error) {
+	index, revoked, err := findPk(srvc, encID, pub)
+	if err != nil {
+		return 0, err
+	} else if index == 0 {
+		return 0, errors.New("public key not found")
+	} else if revoked {
+		return 0, errors.New("public key has already been revoked")
+	}
+	revs, err := getRevocations(srvc, encID)
+	if err != nil {
+		return 0, err
+	}
+	for i, r := range revs {
+		if r.index == index {
+			revs = append(revs[:i], revs[i+1:]...)
+			putRevocations(srvc, encID, revs)
+			return index, nil
+		}
+	}
+	return 0, errors.New("public key is not being revoked")
+}
+
+func revocationDelayKey(encID []byte) []byte {
+	key := make([]byte, 0, len(encID)+1)
+	key = append(key, encID...)
+	return append(key, FIELD_REVOCATION_DELAY)
+}
+
+// getRevocationDelay returns the min delay in blocks of revoking a public key
+// of the ID, 0 means the keys can be removed at once
+func getRevocationDelay(srvc *native.NativeService, encID []byte) (uint32, error) {
+	item, err := utils.GetStorageItem(srvc, revocationDelayKey(encID))
+	if err != nil {
+		return 0, fmt.Errorf("get storage error, %s", err)
+	} else if item == nil {
+		return 0, nil
+	}
+	delay, err := serialization.ReadUint32(bytes.NewBuffer(item.Value))
+	if err != nil {
+		return 0, fmt.Errorf("deserialize revocation delay error, %s", err)
+	}
+	return delay, nil
+}
+
+func putRevocationDelay(srvc *native.NativeService, encID []byte, delay uint32) {
+	var buf bytes.Buffer
+	serialization.WriteUint32(&buf, delay)
+	var v states.StorageItem
+	v.Value = buf.Bytes()
+	srvc.CacheDB.Put(revocationDelayKey(encID), v.ToArray())
+}
+
+// checkInstantRevocation fails if the keys of the ID can only be revoked with a timelock
+func checkInstantRevocation(srvc *native.NativeService, encID []byte) error {
+	delay, err := getRevocationDelay(srvc, encID)
+	if err != nil {
+		return err
+	} else if delay > 0 {
+		return fmt.Errorf("keys can only be revoked with a timelock of at least %d blocks", delay)
+	}
+	return nil
+}
@@ -64,7 +64,14 @@ func GetDDO(srvc *native.NativeService) ([]byte, error) {
 	var0, err := GetPublicKeys(srvc)
 	if err != nil {
 		return nil, fmt.Errorf("get DDO error: %s", err)
-	} else if var0 == nil {
+	}
+	args := bytes.NewBuffer(srvc.Input)
+	did, _ := serialization.ReadVarBytes(args)
+	key, _ := encodeID(did)
+	controller, err := getGroup(srvc, key, FIELD_CONTROLLER)
+	if err != nil {
+		return nil, fmt.Errorf("get DDO error: %s", err)
+	} else if var0 == nil && controller == nil {
 		log.Debug("DDO: null")
 		return nil, nil
 	}
@@ -74,12 +81,27 @@ func GetDDO(srvc *native.NativeService) ([]byte, error) {
 	var1, err := GetAttributes(srvc)
 	serialization.WriteVarBytes(&buf, var1)
 
-	args := bytes.NewBuffer(srvc.Input)
-	did, _ := serialization.ReadVarBytes(args)
-	key, _ := encodeID(did)
 	var2, err := getRecovery(srvc, key)
 	serialization.WriteVarBytes(&buf, var2)
 
+	// controller and recovery group are appended for IDs which have them
+	recovery, err := getGroup(srvc, key, FIELD_RECOVERY_GROUP)
+	if err != nil {
+		return nil, fmt.Errorf("get DDO error: %s", err)
+	}
+	if controller != nil || recovery != nil {
+		var3 := []byte{}
+		if controller != nil {
+			var3 = controller.ToArray()
+		}
+		serialization.WriteVarBytes(&buf, var3)
+		var4 := []byte{}
+		if recovery != nil {
+			var4 = recovery.ToArray()
+		}
+		serialization.WriteVarBytes(&buf, var4)
+	}
+
 	res := buf.Bytes()
 	log.Debug("DDO:", hex.EncodeToString(res))
 	return res, nil
@@ -181,3 +203,30 @@ func GetKeyState(srvc *native.NativeService) ([]byte, error) {
 		return []byte("in use"), nil
 	}
 }
+
+func GetController(srvc *native.NativeService) ([]byte, error) {
+	return getGroupByID(srvc, FIELD_CONTROLLER)
+}
+
+func GetRecoveryGroup(srvc *native.NativeService) ([]byte, error) {
+	return getGroupByID(srvc, FIELD_RECOVERY_GROUP)
+}
+
+func getGroupByID(srvc *native.NativeService, field byte) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	did, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return nil, fmt.Errorf("get group error: invalid argument, %s", err)
+	}
+	key, err := encodeID(did)
+	if err != nil {
+		return nil, fmt.Errorf("get group error: %s", err)
+	}
+	g, err := getGroup(srvc, key, field)
+	if err != nil {
+		return nil, fmt.Errorf("get group error: %s", err)
+	} else if g == nil {
+		return nil, nil
+	}
+	return g.ToArray(), nil
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ontid
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dnaproject2/DNA/common/serialization"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+)
+
+func setRecoveryGroup(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: argument 0 error")
+	}
+	// arg1: recovery group
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: argument 1 error")
+	}
+	// arg2: operator's public key
+	arg2, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: argument 2 error")
+	}
+
+	err = checkWitness(srvc, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: " + err.Error())
+	}
+	key, err := encodeID(arg0)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: " + err.Error())
+	}
+	if !checkIDExistence(srvc, key) {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: ID not registered")
+	}
+	if !isOwner(srvc, key, arg2) {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: not authorized")
+	}
+	re, err := getRecovery(srvc, key)
+	if err == nil && len(re) > 0 {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: already set recovery")
+	}
+	g, err := getGroup(srvc, key, FIELD_RECOVERY_GROUP)
+	if err != nil || g != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: already set recovery")
+	}
+	g, err = parseGroup(arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("set recovery group failed: invalid group, " + err.Error())
+	}
+
+	setGroup(srvc, key, FIELD_RECOVERY_GROUP, g)
+	triggerGroupEvent(srvc, "Recovery", "add", arg0, g)
+	return utils.BYTE_TRUE, nil
+}
+
+// checkRecoveryGroup verifies the signers against the recovery group of the
+// ID, and returns the encoded ID
+func checkRecoveryGroup(srvc *native.NativeService, id []byte, signers []signer) ([]byte, error) {
+	key, err := encodeID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !checkIDExistence(srvc, key) {
+		return nil, errors.New("ID not registered")
+	}
+	g, err := getGroup(srvc, key, FIELD_RECOVERY_GROUP)
+	if err != nil {
+		return nil, err
+	} else if g == nil {
+		return nil, errors.New("recovery group not set")
+	}
+	if !verifyGroup(srvc, g, signers) {
+		return nil, errors.New("verify signers failed")
+	}
+	return key, nil
+}
+
+func changeRecoveryGroup(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change recovery group failed: argument 0 error")
+	}
+	// arg1: new recovery group
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change recovery group failed: argument 1 error")
+	}
+	// arg2: signers of the current recovery group
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change recovery group failed: argument 2 error")
+	}
+
+	key, err := checkRecoveryGroup(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change recovery group failed: " + err.Error())
+	}
+	g, err := parseGroup(arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("change recovery group failed: invalid group, " + err.Error())
+	}
+
+	setGroup(srvc, key, FIELD_RECOVERY_GROUP, g)
+	triggerGroupEvent(srvc, "Recovery", "change", arg0, g)
+	return utils.BYTE_TRUE, nil
+}
+
+func addKeyByRecovery(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: argument 0 error")
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: argument 1 error")
+	}
+	// arg2: signers of the recovery group
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: argument 2 error")
+	}
+
+	key, err := checkRecoveryGroup(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: " + err.Error())
+	}
+	item, _, err := findPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: " + err.Error())
+	} else if item != 0 {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: already exists")
+	}
+	keyID, err := insertPk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("add key by recovery failed: insert public key error, " + err.Error())
+	}
+
+	triggerPublicEvent(srvc, "add", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
+
+func removeKeyByRecovery(srvc *native.NativeService) ([]byte, error) {
+	args := bytes.NewBuffer(srvc.Input)
+	// arg0: ID
+	arg0, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: argument 0 error")
+	}
+	// arg1: public key
+	arg1, err := serialization.ReadVarBytes(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: argument 1 error")
+	}
+	// arg2: signers of the recovery group
+	arg2, err := deserializeSigners(args)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: argument 2 error")
+	}
+
+	key, err := checkRecoveryGroup(srvc, arg0, arg2)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: " + err.Error())
+	}
+	if err = checkInstantRevocation(srvc, key); err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: " + err.Error())
+	}
+	keyID, err := revokePk(srvc, key, arg1)
+	if err != nil {
+		return utils.BYTE_FALSE, errors.New("remove key by recovery failed: " + err.Error())
+	}
+
+	triggerPublicEvent(srvc, "remove", arg0, arg1, keyID)
+	return utils.BYTE_TRUE, nil
+}
@@ -48,9 +48,16 @@ const (
 	FIELD_VERSION byte = 0
 	FLAG_VERSION  byte = 0x01
 
-	FIELD_PK       byte = 1
-	FIELD_ATTR     byte = 2
-	FIELD_RECOVERY byte = 3
+	FIELD_PK               byte = 1
+	FIELD_ATTR             byte = 2
+	FIELD_RECOVERY         byte = 3
+	FIELD_CONTROLLER       byte = 4
+	FIELD_RECOVERY_GROUP   byte = 5
+	FIELD_REVOCATION       byte = 6
+	FIELD_REVOCATION_DELAY byte = 7
+
+	// max delay in blocks of a timelocked key revocation, about 30 days at the default 6 seconds block interval
+	MAX_REVOCATION_DELAY = 30 * 24 * 60 * 60 / 6
 )
 
 func encodeID(id []byte) ([]byte, error) {
@@ -29,12 +29,16 @@ var (
 	BLOCKCHAIN_GETCONTRACT_GAS    uint64 = 100
 	CONTRACT_CREATE_GAS           uint64 = 20000000
 	CONTRACT_MIGRATE_GAS          uint64 = 20000000
+	CONTRACT_UPGRADE_GAS          uint64 = 20000000
 	UINT_DEPLOY_CODE_LEN_GAS      uint64 = 200000
 	UINT_INVOKE_CODE_LEN_GAS      uint64 = 20000
 	NATIVE_INVOKE_GAS             uint64 = 1000
 	STORAGE_GET_GAS               uint64 = 200
 	STORAGE_PUT_GAS               uint64 = 4000
 	STORAGE_DELETE_GAS            uint64 = 100
+	STORAGE_FIND_GAS              uint64 = 200
+	ITERATOR_NEXT_GAS             uint64 = 100
+	CRYPTO_VERIFYSIGNATURE_GAS    uint64 = 400
 	RUNTIME_CHECKWITNESS_GAS      uint64 = 200
 	RUNTIME_ADDRESSTOBASE58_GAS   uint64 = 40
 	RUNTIME_BASE58TOADDRESS_GAS   uint64 = 30
@@ -51,6 +55,12 @@ var (
 	DUPLICATE_STACK_SIZE int = 1024 * 2
 	VM_STEP_LIMIT        int = 400000
 
+	// function name assigned to an auth contract role to authorize contract upgrade
+	UPGRADE_AUTH_FUNC = "upgrade"
+
+	// invoke version of calling wasm contract from other contract, version 0 is reserved for test
+	WASM_CONTRACT_VERSION byte = 1
+
 	// API Name
 	ATTRIBUTE_GETUSAGE_NAME = "DNA.Attribute.GetUsage"
 	ATTRIBUTE_GETDATA_NAME  = "DNA.Attribute.GetData"
@@ -80,6 +90,7 @@ var (
 
 	CONTRACT_CREATE_NAME            = "DNA.Contract.Create"
 	CONTRACT_MIGRATE_NAME           = "DNA.Contract.Migrate"
+	CONTRACT_UPGRADE_NAME           = "DNA.Contract.Upgrade"
 	CONTRACT_GETSTORAGECONTEXT_NAME = "System.Contract.GetStorageContext"
 	CONTRACT_DESTROY_NAME           = "System.Contract.Destroy"
 	CONTRACT_GETSCRIPT_NAME         = "DNA.Contract.GetScript"
@@ -89,9 +100,17 @@ var (
 	STORAGE_DELETE_NAME             = "System.Storage.Delete"
 	STORAGE_GETCONTEXT_NAME         = "System.Storage.GetContext"
 	STORAGE_GETREADONLYCONTEXT_NAME = "System.Storage.GetReadOnlyContext"
+	STORAGE_FIND_NAME               = "System.Storage.Find"
 
 	STORAGECONTEXT_ASREADONLY_NAME = "System.StorageContext.AsReadOnly"
 
+	ITERATOR_NEXT_NAME  = "System.Iterator.Next"
+	ITERATOR_KEY_NAME   = "System.Iterator.Key"
+	ITERATOR_VALUE_NAME = "System.Iterator.Value"
+
+	CRYPTO_VERIFYSIGNATURE_NAME = "System.Crypto.VerifySignature"
+	CRYPTO_VERIFYTHRESHOLD_NAME = "System.Crypto.VerifyThreshold"
+
 	RUNTIME_GETTIME_NAME             = "System.Runtime.GetTime"
 	RUNTIME_CHECKWITNESS_NAME        = "System.Runtime.CheckWitness"
 	RUNTIME_NOTIFY_NAME              = "System.Runtime.Notify"
@@ -190,6 +209,10 @@ func initGAS_TABLE() *sync.Map {
 
 	m.Store(RUNTIME_BASE58TOADDRESS_NAME, RUNTIME_BASE58TOADDRESS_GAS)
 	m.Store(RUNTIME_ADDRESSTOBASE58_NAME, RUNTIME_ADDRESSTOBASE58_GAS)
+	m.Store(STORAGE_FIND_NAME, STORAGE_FIND_GAS)
+	m.Store(CONTRACT_UPGRADE_NAME, CONTRACT_UPGRADE_GAS)
+	m.Store(ITERATOR_NEXT_NAME, ITERATOR_NEXT_GAS)
+	m.Store(CRYPTO_VERIFYSIGNATURE_NAME, CRYPTO_VERIFYSIGNATURE_GAS)
 
 	return &m
 }
@@ -19,11 +19,17 @@
 package neovm
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/auth"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/utils"
+	sstates "github.com/dnaproject2/DNA/smartcontract/states"
 	vm "github.com/dnaproject2/DNA/vm/neovm"
 )
 
@@ -39,6 +45,13 @@ func ContractCreate(service *NeoVmService, engine *vm.ExecutionEngine) error {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractCreate] GetOrAdd error!")
 	}
 	if dep == nil {
+		upgraded, err := service.CacheDB.IsContractUpgraded(contractAddress)
+		if err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractCreate] get contract upgrade error!")
+		}
+		if upgraded {
+			return fmt.Errorf("[ContractCreate] contract %s has been upgraded", contractAddress.ToHexString())
+		}
 		service.CacheDB.PutContract(contract)
 		dep = contract
 	}
@@ -60,27 +73,147 @@ func ContractMigrate(service *NeoVmService, engine *vm.ExecutionEngine) error {
 	context := service.ContextRef.CurrentContext()
 	oldAddr := context.ContractAddress
 
+	addrs, err := storageAddresses(service, NewStorageContext(oldAddr))
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractMigrate] get storage addresses error!")
+	}
+
 	service.CacheDB.PutContract(contract)
 	service.CacheDB.DeleteContract(oldAddr)
 
-	iter := service.CacheDB.NewIterator(oldAddr[:])
-	for has := iter.First(); has; has = iter.Next() {
-		key := iter.Key()
-		val := iter.Value()
+	// storage not migrated from predecessors yet is moved too, items of the latest address win
+	for i := len(addrs) - 1; i >= 0; i-- {
+		iter := service.CacheDB.NewIterator(addrs[i][:])
+		for has := iter.First(); has; has = iter.Next() {
+			key := iter.Key()
+			val := iter.Value()
+
+			newKey := genStorageKey(newAddr, key[20:])
+			service.CacheDB.Put(newKey, val)
+			service.CacheDB.Delete(key)
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	vm.PushData(engine, contract)
+	return nil
+}
+
+// ContractUpgrade upgrade current contract to a new contract. The upgrade must be authorized by the auth contract,
+// the caller's DNA ID should be assigned a role with function UPGRADE_AUTH_FUNC of current contract. The storage is
+// migrated lazily, the new contract reads items from the predecessors until they are written again.
+func ContractUpgrade(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	if vm.EvaluationStackCount(engine) < 9 {
+		return errors.NewErr("[ContractUpgrade] Too few input parameters")
+	}
+	contract, err := isContractParamValid(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] contract parameters invalid!")
+	}
+	caller, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	keyNo, err := vm.PopBigInt(engine)
+	if err != nil {
+		return err
+	}
+	if keyNo.Sign() < 0 || !keyNo.IsUint64() {
+		return errors.NewErr("[ContractUpgrade] key number invalid!")
+	}
 
-		newKey := genStorageKey(newAddr, key[20:])
-		service.CacheDB.Put(newKey, val)
-		service.CacheDB.Delete(key)
+	context := service.ContextRef.CurrentContext()
+	if context == nil {
+		return errors.NewErr("[ContractUpgrade] current contract context invalid!")
+	}
+	oldAddr := context.ContractAddress
+	newAddr := contract.Address()
+	if old, err := service.CacheDB.GetContract(oldAddr); err != nil || old == nil {
+		return errors.NewErr("[ContractUpgrade] get current contract fail!")
+	}
+	if err := isContractExist(service, newAddr); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] contract invalid!")
 	}
-	iter.Release()
-	if err := iter.Error(); err != nil {
+	newInfo, err := service.CacheDB.GetContractUpgrade(newAddr)
+	if err != nil {
 		return err
 	}
+	if newInfo != nil {
+		return fmt.Errorf("[ContractUpgrade] contract %s has been used", newAddr.ToHexString())
+	}
+	oldInfo, err := service.CacheDB.GetContractUpgrade(oldAddr)
+	if err != nil {
+		return err
+	}
+	if oldInfo == nil {
+		oldInfo = new(states.ContractUpgradeInfo)
+	}
+
+	authorized, err := verifyUpgradeToken(service, oldAddr, caller, keyNo.Uint64())
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] verify auth token error!")
+	}
+	if !authorized {
+		return errors.NewErr("[ContractUpgrade] upgrade is not authorized!")
+	}
+
+	upgrade := states.ContractUpgrade{From: oldAddr, To: newAddr, Height: service.Height}
+	if service.Tx != nil {
+		upgrade.TxHash = service.Tx.Hash()
+	}
+	newInfo = &states.ContractUpgradeInfo{
+		History: append(append([]states.ContractUpgrade{}, oldInfo.History...), upgrade),
+	}
+	oldInfo.Successor = newAddr
+
+	service.CacheDB.PutContract(contract)
+	service.CacheDB.DeleteContract(oldAddr)
+	service.CacheDB.PutContractUpgrade(oldAddr, oldInfo)
+	service.CacheDB.PutContractUpgrade(newAddr, newInfo)
 
 	vm.PushData(engine, contract)
 	return nil
 }
 
+// verifyUpgradeToken check whether caller is authorized to upgrade the contract by the auth contract
+func verifyUpgradeToken(service *NeoVmService, contractAddr common.Address, caller []byte, keyNo uint64) (bool, error) {
+	param := &auth.VerifyTokenParam{
+		ContractAddr: contractAddr,
+		Caller:       caller,
+		Fn:           UPGRADE_AUTH_FUNC,
+		KeyNo:        keyNo,
+	}
+	bf := new(bytes.Buffer)
+	if err := param.Serialize(bf); err != nil {
+		return false, err
+	}
+	native := &native.NativeService{
+		CacheDB: service.CacheDB,
+		InvokeParam: sstates.ContractInvokeParam{
+			Address: utils.AuthContractAddress,
+			Method:  "verifyToken",
+			Args:    bf.Bytes(),
+		},
+		Tx:         service.Tx,
+		Height:     service.Height,
+		Time:       service.Time,
+		ContextRef: service.ContextRef,
+		ServiceMap: make(map[string]native.Handler),
+	}
+	result, err := native.Invoke()
+	if err != nil {
+		return false, err
+	}
+	ret, ok := result.([]byte)
+	if !ok {
+		return false, errors.NewErr("verifyToken return non-bool value")
+	}
+	return bytes.Equal(ret, utils.BYTE_TRUE), nil
+}
+
 // ContractDestory destroy a contract
 func ContractDestory(service *NeoVmService, engine *vm.ExecutionEngine) error {
 	context := service.ContextRef.CurrentContext()
@@ -93,16 +226,23 @@ func ContractDestory(service *NeoVmService, engine *vm.ExecutionEngine) error {
 		return errors.NewErr("[ContractDestory] get current contract fail!")
 	}
 
+	addrs, err := storageAddresses(service, NewStorageContext(addr))
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractDestory] get storage addresses error!")
+	}
+
 	service.CacheDB.DeleteContract(addr)
 
-	iter := service.CacheDB.NewIterator(addr[:])
-	for has := iter.First(); has; has = iter.Next() {
-		key := iter.Key()
-		service.CacheDB.Delete(key)
-	}
-	iter.Release()
-	if err := iter.Error(); err != nil {
-		return err
+	for _, addr := range addrs {
+		iter := service.CacheDB.NewIterator(addr[:])
+		for has := iter.First(); has; has = iter.Next() {
+			key := iter.Key()
+			service.CacheDB.Delete(key)
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -214,5 +354,9 @@ func isContractExist(service *NeoVmService, contractAddress common.Address) erro
 	if err != nil || item != nil {
 		return fmt.Errorf("[Contract] Get contract %x error or contract exist!", contractAddress)
 	}
+	upgraded, err := service.CacheDB.IsContractUpgraded(contractAddress)
+	if err != nil || upgraded {
+		return fmt.Errorf("[Contract] Get contract %x error or contract has been upgraded!", contractAddress)
+	}
 	return nil
 }
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package neovm
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/core/store/leveldbstore"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+	"github.com/dnaproject2/DNA/smartcontract/storage"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func contractCreate(service *NeoVmService, contract *payload.DeployCode) error {
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, []byte(contract.Description))
+	vm.PushData(engine, []byte(contract.Email))
+	vm.PushData(engine, []byte(contract.Author))
+	vm.PushData(engine, []byte(contract.Version))
+	vm.PushData(engine, []byte(contract.Name))
+	vm.PushData(engine, contract.NeedStorage)
+	vm.PushData(engine, contract.Code)
+	return ContractCreate(service, engine)
+}
+
+func TestCreateUpgradedContract(t *testing.T) {
+	memback, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	service := &NeoVmService{CacheDB: storage.NewCacheDB(overlaydb.NewOverlayDB(memback))}
+
+	old := &payload.DeployCode{Code: []byte{1}, NeedStorage: true}
+	successor := &payload.DeployCode{Code: []byte{2}, NeedStorage: true}
+	assert.Nil(t, contractCreate(service, old))
+	// creating an existing contract is a no-op
+	assert.Nil(t, contractCreate(service, old))
+
+	// the state left by ContractUpgrade from old to successor
+	service.CacheDB.PutContract(successor)
+	service.CacheDB.DeleteContract(old.Address())
+	service.CacheDB.PutContractUpgrade(old.Address(), &states.ContractUpgradeInfo{Successor: successor.Address()})
+	service.CacheDB.PutContractUpgrade(successor.Address(), &states.ContractUpgradeInfo{
+		History: []states.ContractUpgrade{{From: old.Address(), To: successor.Address(), Height: 1}},
+	})
+
+	upgraded, err := service.CacheDB.IsContractUpgraded(old.Address())
+	assert.Nil(t, err)
+	assert.True(t, upgraded)
+	upgraded, err = service.CacheDB.IsContractUpgraded(successor.Address())
+	assert.Nil(t, err)
+	assert.False(t, upgraded)
+
+	assert.NotNil(t, contractCreate(service, old))
+	dep, err := service.CacheDB.GetContract(old.Address())
+	assert.Nil(t, err)
+	assert.Nil(t, dep)
+	assert.NotNil(t, isContractExist(service, old.Address()))
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"bytes"
+
+	"github.com/dnaproject2/DNA/core/signature"
+	"github.com/dnaproject2/DNA/errors"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/dnaproject2/DNA/vm/neovm/types"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+// CryptoVerifySignature push whether the signature of message is signed by the public key to vm stack,
+// the parameters on vm stack are message, public key and signature from top to bottom
+func CryptoVerifySignature(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	data, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	pubKey, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	sig, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	key, err := keypair.DeserializePublicKey(pubKey)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[CryptoVerifySignature] deserialize public key error!")
+	}
+	vm.PushData(engine, signature.Verify(key, data, sig) == nil)
+	return nil
+}
+
+// CryptoVerifyThreshold push whether at least m of the signatures of message are signed by distinct public keys
+// to vm stack, the parameters on vm stack are message, public key array, signature array and m from top to bottom
+func CryptoVerifyThreshold(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	data, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	keyItems, err := vm.PopArray(engine)
+	if err != nil {
+		return err
+	}
+	sigItems, err := vm.PopArray(engine)
+	if err != nil {
+		return err
+	}
+	m, err := vm.PopInt(engine)
+	if err != nil {
+		return err
+	}
+	keys := make([]keypair.PublicKey, 0, len(keyItems))
+	for _, item := range keyItems {
+		pubKey, err := item.GetByteArray()
+		if err != nil {
+			return err
+		}
+		key, err := keypair.DeserializePublicKey(pubKey)
+		if err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "[CryptoVerifyThreshold] deserialize public key error!")
+		}
+		for _, k := range keys {
+			if bytes.Equal(keypair.SerializePublicKey(k), keypair.SerializePublicKey(key)) {
+				return errors.NewErr("[CryptoVerifyThreshold] duplicated public key!")
+			}
+		}
+		keys = append(keys, key)
+	}
+	sigs := make([][]byte, 0, len(sigItems))
+	for _, item := range sigItems {
+		sig, err := item.GetByteArray()
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+	vm.PushData(engine, signature.VerifyMultiSignature(data, keys, m, sigs) == nil)
+	return nil
+}
+
+func peekThresholdParams(engine *vm.ExecutionEngine) (keys []types.StackItems, sigs []types.StackItems, m int, err error) {
+	if keys, err = vm.PeekNStackItem(1, engine).GetArray(); err != nil {
+		return
+	}
+	if sigs, err = vm.PeekNStackItem(2, engine).GetArray(); err != nil {
+		return
+	}
+	bm, err := vm.PeekNBigInt(3, engine)
+	if err != nil {
+		return
+	}
+	if !bm.IsInt64() {
+		err = errors.NewErr("[CryptoVerifyThreshold] m out of range!")
+		return
+	}
+	m = int(bm.Int64())
+	return
+}
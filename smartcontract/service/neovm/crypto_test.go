@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/account"
+	"github.com/dnaproject2/DNA/core/signature"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/dnaproject2/DNA/vm/neovm/types"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/stretchr/testify/assert"
+)
+
+func verifySignature(t *testing.T, data, pubKey, sig []byte) (bool, error) {
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, sig)
+	vm.PushData(engine, pubKey)
+	vm.PushData(engine, data)
+	assert.Nil(t, validatorVerifySignature(engine))
+	if err := CryptoVerifySignature(nil, engine); err != nil {
+		return false, err
+	}
+	return vm.PopBoolean(engine)
+}
+
+func TestCryptoVerifySignature(t *testing.T) {
+	data := []byte("oracle attestation")
+	for _, scheme := range []string{"SHA256withECDSA", "SM3withSM2", "SHA512withEdDSA"} {
+		acct := account.NewAccount(scheme)
+		pubKey := keypair.SerializePublicKey(acct.PublicKey)
+		sig, err := signature.Sign(acct, data)
+		assert.Nil(t, err)
+
+		ok, err := verifySignature(t, data, pubKey, sig)
+		assert.Nil(t, err)
+		assert.True(t, ok, scheme)
+
+		ok, err = verifySignature(t, []byte("other message"), pubKey, sig)
+		assert.Nil(t, err)
+		assert.False(t, ok, scheme)
+
+		other := keypair.SerializePublicKey(account.NewAccount(scheme).PublicKey)
+		ok, err = verifySignature(t, data, other, sig)
+		assert.Nil(t, err)
+		assert.False(t, ok, scheme)
+
+		ok, err = verifySignature(t, data, pubKey, []byte{1, 2, 3})
+		assert.Nil(t, err)
+		assert.False(t, ok, scheme)
+	}
+
+	_, err := verifySignature(t, data, []byte{1, 2, 3}, []byte{1, 2, 3})
+	assert.NotNil(t, err)
+}
+
+func pushThresholdParams(engine *vm.ExecutionEngine, data []byte, pubKeys, sigs [][]byte, m int) {
+	var keyItems, sigItems []types.StackItems
+	for _, k := range pubKeys {
+		keyItems = append(keyItems, types.NewByteArray(k))
+	}
+	for _, s := range sigs {
+		sigItems = append(sigItems, types.NewByteArray(s))
+	}
+	vm.PushData(engine, m)
+	vm.PushData(engine, types.NewArray(sigItems))
+	vm.PushData(engine, types.NewArray(keyItems))
+	vm.PushData(engine, data)
+}
+
+func TestCryptoVerifyThreshold(t *testing.T) {
+	data := []byte("meta transaction")
+	var pubKeys, sigs [][]byte
+	for _, scheme := range []string{"SHA256withECDSA", "SM3withSM2", "SHA512withEdDSA"} {
+		acct := account.NewAccount(scheme)
+		pubKeys = append(pubKeys, keypair.SerializePublicKey(acct.PublicKey))
+		sig, err := signature.Sign(acct, data)
+		assert.Nil(t, err)
+		sigs = append(sigs, sig)
+	}
+
+	cases := []struct {
+		sigs [][]byte
+		m    int
+		ok   bool
+	}{
+		{[][]byte{sigs[2], sigs[0]}, 2, true},
+		{sigs, 3, true},
+		{[][]byte{sigs[1]}, 2, false},
+		{[][]byte{sigs[1], sigs[1]}, 2, false},
+	}
+	for i, c := range cases {
+		engine := vm.NewExecutionEngine()
+		pushThresholdParams(engine, data, pubKeys, c.sigs, c.m)
+		assert.Nil(t, validatorVerifyThreshold(engine))
+		price, err := GasPrice(engine, CRYPTO_VERIFYTHRESHOLD_NAME)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(c.m)*3*CRYPTO_VERIFYSIGNATURE_GAS, price)
+		assert.Nil(t, CryptoVerifyThreshold(nil, engine))
+		ok, err := vm.PopBoolean(engine)
+		assert.Nil(t, err)
+		assert.Equal(t, c.ok, ok, "case %d", i)
+	}
+
+	engine := vm.NewExecutionEngine()
+	pushThresholdParams(engine, data, pubKeys, sigs, 4)
+	assert.NotNil(t, validatorVerifyThreshold(engine))
+	engine = vm.NewExecutionEngine()
+	pushThresholdParams(engine, data, pubKeys, sigs, 0)
+	assert.NotNil(t, validatorVerifyThreshold(engine))
+
+	engine = vm.NewExecutionEngine()
+	pushThresholdParams(engine, data, [][]byte{pubKeys[0], pubKeys[0]}, sigs[:1], 1)
+	assert.NotNil(t, CryptoVerifyThreshold(nil, engine))
+}
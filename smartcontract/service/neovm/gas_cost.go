@@ -39,10 +39,25 @@ func StoreGasCost(engine *vm.ExecutionEngine) (uint64, error) {
 	}
 }
 
+// VerifyThresholdGasCost charge a signature verification for every pair of public key and checked signature
+func VerifyThresholdGasCost(engine *vm.ExecutionEngine) (uint64, error) {
+	keys, _, m, err := peekThresholdParams(engine)
+	if err != nil {
+		return 0, err
+	}
+	if verifyCost, ok := GAS_TABLE.Load(CRYPTO_VERIFYSIGNATURE_NAME); ok {
+		return uint64(m) * uint64(len(keys)) * verifyCost.(uint64), nil
+	} else {
+		return uint64(0), errors.NewErr("[VerifyThresholdGasCost] get CRYPTO_VERIFYSIGNATURE_NAME gas failed")
+	}
+}
+
 func GasPrice(engine *vm.ExecutionEngine, name string) (uint64, error) {
 	switch name {
 	case STORAGE_PUT_NAME:
 		return StoreGasCost(engine)
+	case CRYPTO_VERIFYTHRESHOLD_NAME:
+		return VerifyThresholdGasCost(engine)
 	default:
 		if value, ok := GAS_TABLE.Load(name); ok {
 			return value.(uint64), nil
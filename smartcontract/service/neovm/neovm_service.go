@@ -24,12 +24,14 @@ import (
 
 	scommon "github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/signature"
 	"github.com/dnaproject2/DNA/core/store"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
 	"github.com/dnaproject2/DNA/smartcontract/context"
 	"github.com/dnaproject2/DNA/smartcontract/event"
+	"github.com/dnaproject2/DNA/smartcontract/states"
 	"github.com/dnaproject2/DNA/smartcontract/storage"
 	vm "github.com/dnaproject2/DNA/vm/neovm"
 	ntypes "github.com/dnaproject2/DNA/vm/neovm/types"
@@ -63,6 +65,7 @@ var (
 		TRANSACTION_GETATTRIBUTES_NAME:       {Execute: TransactionGetAttributes, Validator: validatorTransaction},
 		CONTRACT_CREATE_NAME:                 {Execute: ContractCreate},
 		CONTRACT_MIGRATE_NAME:                {Execute: ContractMigrate},
+		CONTRACT_UPGRADE_NAME:                {Execute: ContractUpgrade},
 		CONTRACT_GETSTORAGECONTEXT_NAME:      {Execute: ContractGetStorageContext},
 		CONTRACT_DESTROY_NAME:                {Execute: ContractDestory},
 		CONTRACT_GETSCRIPT_NAME:              {Execute: ContractGetCode, Validator: validatorGetCode},
@@ -80,6 +83,12 @@ var (
 		STORAGE_GETCONTEXT_NAME:              {Execute: StorageGetContext},
 		STORAGE_GETREADONLYCONTEXT_NAME:      {Execute: StorageGetReadOnlyContext},
 		STORAGECONTEXT_ASREADONLY_NAME:       {Execute: StorageContextAsReadOnly, Validator: validatorContextAsReadOnly},
+		STORAGE_FIND_NAME:                    {Execute: StorageFind},
+		ITERATOR_NEXT_NAME:                   {Execute: IteratorNext},
+		ITERATOR_KEY_NAME:                    {Execute: IteratorKey},
+		ITERATOR_VALUE_NAME:                  {Execute: IteratorValue},
+		CRYPTO_VERIFYSIGNATURE_NAME:          {Execute: CryptoVerifySignature, Validator: validatorVerifySignature},
+		CRYPTO_VERIFYTHRESHOLD_NAME:          {Execute: CryptoVerifyThreshold, Validator: validatorVerifyThreshold},
 		GETSCRIPTCONTAINER_NAME:              {Execute: GetCodeContainer},
 		GETEXECUTINGSCRIPTHASH_NAME:          {Execute: GetExecutingAddress},
 		GETCALLINGSCRIPTHASH_NAME:            {Execute: GetCallingAddress},
@@ -128,6 +137,7 @@ type NeoVmService struct {
 	BlockHash     scommon.Uint256
 	Engine        *vm.ExecutionEngine
 	PreExec       bool
+	iterators     []*StorageIterator
 }
 
 // Invoke a smart contract
@@ -135,6 +145,7 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 	if len(this.Code) == 0 {
 		return nil, ERR_EXECUTE_CODE
 	}
+	defer this.releaseIterators()
 	this.ContextRef.PushContext(&context.Context{ContractAddress: scommon.AddressFromVmCode(this.Code), Code: this.Code})
 	this.Engine.PushContext(vm.NewExecutionContext(this.Engine, this.Code))
 	for {
@@ -148,6 +159,7 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 		if this.Engine.Context.GetInstructionPointer() >= len(this.Engine.Context.Code) {
 			break
 		}
+		ip := this.Engine.Context.GetInstructionPointer()
 		if err := this.Engine.ExecuteCode(); err != nil {
 			return nil, err
 		}
@@ -160,6 +172,9 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			if !this.ContextRef.CheckUseGas(OPCODE_GAS) {
 				return nil, ERR_GAS_INSUFFICIENT
 			}
+			if this.Engine.Tracer != nil {
+				this.Engine.Tracer.CaptureStep(this.Engine, ip, this.Engine.OpCode, OPCODE_GAS)
+			}
 		} else {
 			if err := this.Engine.ValidateOp(); err != nil {
 				return nil, err
@@ -171,6 +186,9 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			if !this.ContextRef.CheckUseGas(price) {
 				return nil, ERR_GAS_INSUFFICIENT
 			}
+			if this.Engine.Tracer != nil {
+				this.Engine.Tracer.CaptureStep(this.Engine, ip, this.Engine.OpCode, price)
+			}
 		}
 		switch this.Engine.OpCode {
 		case vm.VERIFY:
@@ -223,11 +241,17 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
-			code, err := this.getContract(addr)
+			dep, err := this.getContract(addr)
 			if err != nil {
 				return nil, err
 			}
-			service, err := this.ContextRef.NewExecuteEngine(code)
+			if dep.IsWasm() {
+				if err := this.wasmAppCall(addr); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			service, err := this.ContextRef.NewExecuteEngine(dep.Code)
 			if err != nil {
 				return nil, err
 			}
@@ -256,6 +280,14 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 	return nil, nil
 }
 
+// releaseIterators release the storage iterators created by the contract
+func (this *NeoVmService) releaseIterators() {
+	for _, iter := range this.iterators {
+		iter.Release()
+	}
+	this.iterators = nil
+}
+
 // SystemCall provide register service for smart contract to interaction with blockchain
 func (this *NeoVmService) SystemCall(engine *vm.ExecutionEngine) error {
 	serviceName, err := engine.Context.OpReader.ReadVarString(vm.MAX_BYTEARRAY_SIZE)
@@ -278,13 +310,49 @@ func (this *NeoVmService) SystemCall(engine *vm.ExecutionEngine) error {
 	if !this.ContextRef.CheckUseGas(price) {
 		return ERR_GAS_INSUFFICIENT
 	}
+	if engine.Tracer != nil {
+		engine.Tracer.CaptureSysCall(serviceName, price)
+	}
 	if err := service.Execute(this, engine); err != nil {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[SystemCall] service execution error!")
 	}
 	return nil
 }
 
-func (this *NeoVmService) getContract(address scommon.Address) ([]byte, error) {
+// wasmAppCall invoke a wasm contract with the method and args on the evaluation stack,
+// the wasm contract result is pushed back as byte array
+func (this *NeoVmService) wasmAppCall(address scommon.Address) error {
+	if vm.EvaluationStackCount(this.Engine) < 2 {
+		return fmt.Errorf("[Appcall] too few input parameters for wasm contract: %d", vm.EvaluationStackCount(this.Engine))
+	}
+	method, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return fmt.Errorf("[Appcall] pop wasm contract method error: %v", err)
+	}
+	args, err := vm.PopByteArray(this.Engine)
+	if err != nil {
+		return fmt.Errorf("[Appcall] pop wasm contract args error: %v", err)
+	}
+	bf := new(bytes.Buffer)
+	param := &states.ContractInvokeParam{Version: WASM_CONTRACT_VERSION, Address: address, Method: string(method), Args: args}
+	if err := param.Serialize(bf); err != nil {
+		return err
+	}
+	service, err := this.ContextRef.NewWasmExecuteEngine(bf.Bytes())
+	if err != nil {
+		return err
+	}
+	result, err := service.Invoke()
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		vm.PushData(this.Engine, result)
+	}
+	return nil
+}
+
+func (this *NeoVmService) getContract(address scommon.Address) (*payload.DeployCode, error) {
 	dep, err := this.CacheDB.GetContract(address)
 	if err != nil {
 		return nil, errors.NewErr("[getContract] get contract context error!")
@@ -293,7 +361,7 @@ func (this *NeoVmService) getContract(address scommon.Address) ([]byte, error) {
 	if dep == nil {
 		return nil, CONTRACT_NOT_EXIST
 	}
-	return dep.Code, nil
+	return dep, nil
 }
 
 func checkStackSize(engine *vm.ExecutionEngine) bool {
@@ -55,7 +55,15 @@ func StoragePut(service *NeoVmService, engine *vm.ExecutionEngine) error {
 		return err
 	}
 
+	addrs, err := storageAddresses(service, context)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StoragePut] get storage addresses error!")
+	}
 	service.CacheDB.Put(genStorageKey(context.Address, key), states.GenRawStorageItem(value))
+	// migrate the item from predecessors
+	if err := deletePredecessorStorage(service, addrs[1:], key); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -78,7 +86,14 @@ func StorageDelete(service *NeoVmService, engine *vm.ExecutionEngine) error {
 	if err != nil {
 		return err
 	}
+	addrs, err := storageAddresses(service, context)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageDelete] get storage addresses error!")
+	}
 	service.CacheDB.Delete(genStorageKey(context.Address, ba))
+	if err := deletePredecessorStorage(service, addrs[1:], ba); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -97,9 +112,19 @@ func StorageGet(service *NeoVmService, engine *vm.ExecutionEngine) error {
 		return err
 	}
 
-	raw, err := service.CacheDB.Get(genStorageKey(context.Address, ba))
+	addrs, err := storageAddresses(service, context)
 	if err != nil {
-		return err
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageGet] get storage addresses error!")
+	}
+	var raw []byte
+	for _, addr := range addrs {
+		raw, err = service.CacheDB.Get(genStorageKey(addr, ba))
+		if err != nil {
+			return err
+		}
+		if len(raw) != 0 {
+			break
+		}
 	}
 
 	if len(raw) == 0 {
@@ -150,6 +175,38 @@ func getContext(engine *vm.ExecutionEngine) (*StorageContext, error) {
 	return context, nil
 }
 
+// storageAddresses return the addresses whose storage belongs to the context. Storage of an upgraded contract
+// is migrated lazily, the items not written since the upgrade are still kept under the predecessors
+func storageAddresses(service *NeoVmService, context *StorageContext) ([]common.Address, error) {
+	if context.addresses == nil {
+		info, err := service.CacheDB.GetContractUpgrade(context.Address)
+		if err != nil {
+			return nil, err
+		}
+		addrs := []common.Address{context.Address}
+		if info != nil {
+			addrs = append(addrs, info.Predecessors()...)
+		}
+		context.addresses = addrs
+	}
+	return context.addresses, nil
+}
+
+// deletePredecessorStorage delete the item of key left in predecessors
+func deletePredecessorStorage(service *NeoVmService, predecessors []common.Address, key []byte) error {
+	for _, addr := range predecessors {
+		storageKey := genStorageKey(addr, key)
+		raw, err := service.CacheDB.Get(storageKey)
+		if err != nil {
+			return err
+		}
+		if len(raw) != 0 {
+			service.CacheDB.Delete(storageKey)
+		}
+	}
+	return nil
+}
+
 func genStorageKey(address common.Address, key []byte) []byte {
 	res := make([]byte, 0, len(address[:])+len(key))
 	res = append(res, address[:]...)
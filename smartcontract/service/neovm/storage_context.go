@@ -28,6 +28,8 @@ import (
 type StorageContext struct {
 	Address    common.Address
 	IsReadOnly bool
+
+	addresses []common.Address // contract address followed by the predecessors, resolved when first used
 }
 
 // NewStorageContext return a new smart contract storage context
@@ -0,0 +1,209 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/states"
+	scommon "github.com/dnaproject2/DNA/core/store/common"
+	"github.com/dnaproject2/DNA/errors"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+)
+
+// StorageIterator walk through the storage items of a contract, it is pushed to vm stack as interop interface.
+// The storage of an upgraded contract is merged from the iterators of its address and predecessors, the item
+// of the first iterator wins when keys are equal
+type StorageIterator struct {
+	address  common.Address
+	iters    []scommon.StoreIterator
+	valid    []bool
+	current  int
+	started  bool
+	released bool
+}
+
+// NewStorageIterator return a storage iterator of the contract address over iters
+func NewStorageIterator(address common.Address, iters []scommon.StoreIterator) *StorageIterator {
+	return &StorageIterator{address: address, iters: iters, valid: make([]bool, len(iters)), current: -1}
+}
+
+func (this *StorageIterator) key(i int) []byte {
+	key := this.iters[i].Key()
+	if len(key) < common.ADDR_LEN {
+		return nil
+	}
+	return key[common.ADDR_LEN:]
+}
+
+// ToArray return contract address byte array
+func (this *StorageIterator) ToArray() []byte {
+	return this.address[:]
+}
+
+// Next move to the next storage item, the iterator is released when there is no more item
+func (this *StorageIterator) Next() bool {
+	if this.released {
+		return false
+	}
+	if !this.started {
+		this.started = true
+		for i, iter := range this.iters {
+			this.valid[i] = iter.Next()
+		}
+	} else {
+		key := append([]byte{}, this.key(this.current)...)
+		for i, iter := range this.iters {
+			if this.valid[i] && bytes.Equal(this.key(i), key) {
+				this.valid[i] = iter.Next()
+			}
+		}
+	}
+	this.current = -1
+	for i := range this.iters {
+		if this.valid[i] && (this.current == -1 || bytes.Compare(this.key(i), this.key(this.current)) < 0) {
+			this.current = i
+		}
+	}
+	if this.current != -1 {
+		return true
+	}
+	this.Release()
+	return false
+}
+
+// Key return the storage key of current item without the contract address
+func (this *StorageIterator) Key() []byte {
+	if this.released || this.current == -1 {
+		return nil
+	}
+	return this.key(this.current)
+}
+
+// Value return the storage value of current item
+func (this *StorageIterator) Value() ([]byte, error) {
+	if this.released || this.current == -1 {
+		return nil, nil
+	}
+	raw := this.iters[this.current].Value()
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return states.GetValueFromRawStorageItem(raw)
+}
+
+// Release the underlying store iterator
+func (this *StorageIterator) Release() {
+	if !this.released {
+		this.released = true
+		this.current = -1
+		for _, iter := range this.iters {
+			iter.Release()
+		}
+	}
+}
+
+// StorageFind push an iterator of the storage items whose key has the given prefix to vm stack
+func StorageFind(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	if vm.EvaluationStackCount(engine) < 2 {
+		return errors.NewErr("[Context] Too few input parameters ")
+	}
+	context, err := getContext(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageFind] get pop context error!")
+	}
+	prefix, err := vm.PopByteArray(engine)
+	if err != nil {
+		return err
+	}
+	if len(prefix) > 1024 {
+		return errors.NewErr("[StorageFind] Storage key prefix to long")
+	}
+
+	addrs, err := storageAddresses(service, context)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageFind] get storage addresses error!")
+	}
+	iters := make([]scommon.StoreIterator, 0, len(addrs))
+	for _, addr := range addrs {
+		iters = append(iters, service.CacheDB.NewIterator(genStorageKey(addr, prefix)))
+	}
+	iter := NewStorageIterator(context.Address, iters)
+	service.iterators = append(service.iterators, iter)
+	vm.PushData(engine, iter)
+	return nil
+}
+
+// IteratorNext move the iterator on vm stack to the next item, push whether the item is available to vm stack
+func IteratorNext(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	iter, err := popIterator(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[IteratorNext] pop iterator error!")
+	}
+	vm.PushData(engine, iter.Next())
+	return nil
+}
+
+// IteratorKey push the storage key of the current item of iterator to vm stack
+func IteratorKey(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	iter, err := popIterator(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[IteratorKey] pop iterator error!")
+	}
+	key := iter.Key()
+	if key == nil {
+		return fmt.Errorf("%s", "[IteratorKey] iterator is not positioned at an item")
+	}
+	vm.PushData(engine, key)
+	return nil
+}
+
+// IteratorValue push the storage value of the current item of iterator to vm stack
+func IteratorValue(service *NeoVmService, engine *vm.ExecutionEngine) error {
+	iter, err := popIterator(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[IteratorValue] pop iterator error!")
+	}
+	if iter.Key() == nil {
+		return fmt.Errorf("%s", "[IteratorValue] iterator is not positioned at an item")
+	}
+	value, err := iter.Value()
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[IteratorValue] get storage value error!")
+	}
+	vm.PushData(engine, value)
+	return nil
+}
+
+func popIterator(engine *vm.ExecutionEngine) (*StorageIterator, error) {
+	if vm.EvaluationStackCount(engine) < 1 {
+		return nil, errors.NewErr("[Iterator] Too few input parameters ")
+	}
+	opInterface, err := vm.PopInteropInterface(engine)
+	if err != nil {
+		return nil, err
+	}
+	iter, ok := opInterface.(*StorageIterator)
+	if !ok {
+		return nil, errors.NewErr("[Iterator] Get storage iterator invalid")
+	}
+	return iter, nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/core/store/leveldbstore"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+	"github.com/dnaproject2/DNA/smartcontract/storage"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageFind(t *testing.T) {
+	memback, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	service := &NeoVmService{CacheDB: storage.NewCacheDB(overlaydb.NewOverlayDB(memback))}
+	address := common.Address{1}
+	other := common.Address{2}
+	for _, key := range []string{"a1", "a2", "b1"} {
+		service.CacheDB.Put(genStorageKey(address, []byte(key)), states.GenRawStorageItem([]byte("v"+key)))
+	}
+	service.CacheDB.Put(genStorageKey(other, []byte("a3")), states.GenRawStorageItem([]byte("va3")))
+	service.CacheDB.Delete(genStorageKey(address, []byte("a2")))
+
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, []byte("a"))
+	vm.PushData(engine, NewStorageContext(address))
+	assert.Nil(t, StorageFind(service, engine))
+	iter, err := vm.PeekInteropInterface(engine)
+	assert.Nil(t, err)
+
+	var keys, values []string
+	for {
+		assert.Nil(t, IteratorNext(service, engine))
+		next, err := vm.PopBoolean(engine)
+		assert.Nil(t, err)
+		if !next {
+			break
+		}
+		vm.PushData(engine, iter)
+		assert.Nil(t, IteratorKey(service, engine))
+		key, err := vm.PopByteArray(engine)
+		assert.Nil(t, err)
+		keys = append(keys, string(key))
+		vm.PushData(engine, iter)
+		assert.Nil(t, IteratorValue(service, engine))
+		value, err := vm.PopByteArray(engine)
+		assert.Nil(t, err)
+		values = append(values, string(value))
+		vm.PushData(engine, iter)
+	}
+	assert.Equal(t, []string{"a1"}, keys)
+	assert.Equal(t, []string{"va1"}, values)
+
+	// the exhausted iterator is released
+	vm.PushData(engine, iter)
+	assert.NotNil(t, IteratorKey(service, engine))
+	assert.Equal(t, 1, len(service.iterators))
+	service.releaseIterators()
+	assert.Equal(t, 0, len(service.iterators))
+
+	vm.PushData(engine, NewStorageContext(address))
+	assert.NotNil(t, IteratorNext(service, engine))
+}
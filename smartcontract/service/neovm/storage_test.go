@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
+	"github.com/dnaproject2/DNA/core/store/leveldbstore"
+	"github.com/dnaproject2/DNA/core/store/overlaydb"
+	"github.com/dnaproject2/DNA/smartcontract/storage"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+func storageGet(t *testing.T, service *NeoVmService, context *StorageContext, key string) string {
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, []byte(key))
+	vm.PushData(engine, context)
+	assert.Nil(t, StorageGet(service, engine))
+	value, err := vm.PopByteArray(engine)
+	assert.Nil(t, err)
+	return string(value)
+}
+
+func storageFind(t *testing.T, service *NeoVmService, context *StorageContext) []string {
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, []byte{})
+	vm.PushData(engine, context)
+	assert.Nil(t, StorageFind(service, engine))
+	item, err := vm.PopInteropInterface(engine)
+	assert.Nil(t, err)
+	iter := item.(*StorageIterator)
+	var items []string
+	for iter.Next() {
+		value, err := iter.Value()
+		assert.Nil(t, err)
+		items = append(items, string(iter.Key())+"="+string(value))
+	}
+	return items
+}
+
+func TestUpgradedContractStorage(t *testing.T) {
+	memback, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	service := &NeoVmService{CacheDB: storage.NewCacheDB(overlaydb.NewOverlayDB(memback))}
+
+	first := &payload.DeployCode{Code: []byte{1}}
+	second := &payload.DeployCode{Code: []byte{2}}
+	third := &payload.DeployCode{Code: []byte{3}}
+	put := func(contract *payload.DeployCode, key, value string) {
+		service.CacheDB.Put(genStorageKey(contract.Address(), []byte(key)), states.GenRawStorageItem([]byte(value)))
+	}
+	put(first, "a", "1")
+	put(first, "b", "1")
+	put(first, "c", "1")
+	put(second, "b", "2")
+	put(second, "d", "2")
+
+	// third is upgraded from first via second
+	service.CacheDB.PutContract(third)
+	history := []states.ContractUpgrade{
+		{From: first.Address(), To: second.Address(), Height: 1},
+		{From: second.Address(), To: third.Address(), Height: 2},
+	}
+	service.CacheDB.PutContractUpgrade(third.Address(), &states.ContractUpgradeInfo{History: history})
+	info, err := service.CacheDB.GetContractUpgrade(third.Address())
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Address{second.Address(), first.Address()}, info.Predecessors())
+
+	context := NewStorageContext(third.Address())
+	assert.Equal(t, "1", storageGet(t, service, context, "a"))
+	assert.Equal(t, "2", storageGet(t, service, context, "b"))
+	assert.Equal(t, "", storageGet(t, service, context, "x"))
+	assert.Equal(t, []string{"a=1", "b=2", "c=1", "d=2"}, storageFind(t, service, context))
+
+	// writing migrates the item to the new contract
+	engine := vm.NewExecutionEngine()
+	vm.PushData(engine, []byte("3"))
+	vm.PushData(engine, []byte("b"))
+	vm.PushData(engine, context)
+	assert.Nil(t, StoragePut(service, engine))
+	raw, err := service.CacheDB.Get(genStorageKey(second.Address(), []byte("b")))
+	assert.Nil(t, err)
+	assert.Nil(t, raw)
+	raw, err = service.CacheDB.Get(genStorageKey(first.Address(), []byte("b")))
+	assert.Nil(t, err)
+	assert.Nil(t, raw)
+
+	// deleting removes the item left in predecessors
+	engine = vm.NewExecutionEngine()
+	vm.PushData(engine, []byte("c"))
+	vm.PushData(engine, context)
+	assert.Nil(t, StorageDelete(service, engine))
+	assert.Equal(t, "", storageGet(t, service, context, "c"))
+	assert.Equal(t, []string{"a=1", "b=3", "d=2"}, storageFind(t, service, context))
+}
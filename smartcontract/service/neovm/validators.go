@@ -19,6 +19,7 @@
 package neovm
 
 import (
+	"github.com/dnaproject2/DNA/common/constants"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
@@ -209,3 +210,25 @@ func peekBlock(engine *vm.ExecutionEngine) (*types.Block, error) {
 	}
 	return block, nil
 }
+
+func validatorVerifySignature(engine *vm.ExecutionEngine) error {
+	if vm.EvaluationStackCount(engine) < 3 {
+		return errors.NewErr("[validatorVerifySignature] Too few input parameters ")
+	}
+	return nil
+}
+
+func validatorVerifyThreshold(engine *vm.ExecutionEngine) error {
+	if vm.EvaluationStackCount(engine) < 4 {
+		return errors.NewErr("[validatorVerifyThreshold] Too few input parameters ")
+	}
+	keys, _, m, err := peekThresholdParams(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[validatorVerifyThreshold] Validate parameters fail!")
+	}
+	n := len(keys)
+	if !(1 <= m && m <= n && n <= constants.MULTI_SIG_MAX_PUBKEY_SIZE) {
+		return errors.NewErr("[validatorVerifyThreshold] Wrong threshold parameters!")
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/dnaproject2/DNA/core/signature"
 	"github.com/dnaproject2/DNA/errors"
 	"github.com/dnaproject2/DNA/smartcontract/event"
+	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
 	"github.com/dnaproject2/DNA/vm/wasmvm/exec"
 	"github.com/dnaproject2/DNA/vm/wasmvm/util"
 )
@@ -111,6 +112,9 @@ func (this *WasmVmService) runtimeCheckWitness(engine *exec.ExecutionEngine) (bo
 	if len(params) != 1 {
 		return false, errors.NewErr("[CheckWitness]get parameter count error!")
 	}
+	if err := this.useGas(neovm.RUNTIME_CHECKWITNESS_NAME); err != nil {
+		return false, err
+	}
 	data, err := vm.GetPointerMemory(params[0])
 	if err != nil {
 		return false, errors.NewErr("[CheckWitness]" + err.Error())
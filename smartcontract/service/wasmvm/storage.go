@@ -18,10 +18,10 @@
 package wasmvm
 
 import (
-	"bytes"
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
 	"github.com/dnaproject2/DNA/vm/wasmvm/exec"
 	"github.com/dnaproject2/DNA/vm/wasmvm/memory"
 	"github.com/dnaproject2/DNA/vm/wasmvm/util"
@@ -48,10 +48,10 @@ func (this *WasmVmService) putstore(engine *exec.ExecutionEngine) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
-	if err != nil {
+	if err := this.useStorePutGas(len(key) + len(value)); err != nil {
 		return false, err
 	}
+	k := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	this.CacheDB.Put(k, states.GenRawStorageItem(value))
 
 	vm.RestoreCtx()
@@ -72,10 +72,10 @@ func (this *WasmVmService) getstore(engine *exec.ExecutionEngine) (bool, error)
 	if err != nil {
 		return false, err
 	}
-	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
-	if err != nil {
+	if err := this.useGas(neovm.STORAGE_GET_NAME); err != nil {
 		return false, err
 	}
+	k := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	item, err := this.CacheDB.Get(k)
 	if err != nil {
 		return false, err
@@ -119,22 +119,34 @@ func (this *WasmVmService) deletestore(engine *exec.ExecutionEngine) (bool, erro
 		return false, err
 	}
 
-	k, err := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
-	if err != nil {
+	if err := this.useGas(neovm.STORAGE_DELETE_NAME); err != nil {
 		return false, err
 	}
-
+	k := serializeStorageKey(vm.ContractAddress, []byte(util.TrimBuffToString(key)))
 	this.CacheDB.Delete(k)
 	vm.RestoreCtx()
 
 	return true, nil
 }
 
-func serializeStorageKey(contractAddress common.Address, key []byte) ([]byte, error) {
-	bf := new(bytes.Buffer)
-	storageKey := &states.StorageKey{ContractAddress: contractAddress, Key: key}
-	if _, err := storageKey.Serialize(bf); err != nil {
-		return []byte{}, errors.NewErr("[serializeStorageKey] StorageKey serialize error!")
+// useStorePutGas charge storage put gas by every 1024 bytes of key and value like neovm
+func (this *WasmVmService) useStorePutGas(size int) error {
+	price, ok := neovm.GAS_TABLE.Load(neovm.STORAGE_PUT_NAME)
+	if !ok {
+		return errors.NewErr("[useStorePutGas] get STORAGE_PUT_NAME gas failed")
 	}
-	return bf.Bytes(), nil
+	if size == 0 {
+		size = 1
+	}
+	if !this.ContextRef.CheckUseGas(uint64((size-1)/neovm.PER_UNIT_CODE_LEN+1) * price.(uint64)) {
+		return exec.ERR_GAS_INSUFFICIENT
+	}
+	return nil
+}
+
+// serializeStorageKey use the same storage key layout with neovm contract: address + key
+func serializeStorageKey(contractAddress common.Address, key []byte) []byte {
+	buf := make([]byte, 0, common.ADDR_LEN+len(key))
+	buf = append(buf, contractAddress[:]...)
+	return append(buf, key...)
 }
@@ -18,29 +18,42 @@
 package wasmvm
 
 import (
-	//"bytes"
-	//"encoding/binary"
-	//"encoding/hex"
-	//"fmt"
-	//"math/big"
-	//"strconv"
-	//"strings"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 
-	//"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/store"
 	"github.com/dnaproject2/DNA/core/types"
-	//"github.com/dnaproject2/DNA/errors"
-	//sccommon "github.com/dnaproject2/DNA/smartcontract/common"
+	"github.com/dnaproject2/DNA/errors"
 	"github.com/dnaproject2/DNA/smartcontract/context"
 	"github.com/dnaproject2/DNA/smartcontract/event"
-	//nstates "github.com/dnaproject2/DNA/smartcontract/service/native/ont"
-	//"github.com/dnaproject2/DNA/smartcontract/states"
+	"github.com/dnaproject2/DNA/smartcontract/service/native"
+	"github.com/dnaproject2/DNA/smartcontract/service/native/ont"
+	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
+	"github.com/dnaproject2/DNA/smartcontract/states"
 	"github.com/dnaproject2/DNA/smartcontract/storage"
-	//"github.com/dnaproject2/DNA/vm/neovm"
-	//"github.com/dnaproject2/DNA/vm/wasmvm/exec"
-	//"github.com/dnaproject2/DNA/vm/wasmvm/util"
+	vm "github.com/dnaproject2/DNA/vm/neovm"
+	ntypes "github.com/dnaproject2/DNA/vm/neovm/types"
+	"github.com/dnaproject2/DNA/vm/wasmvm/exec"
+	"github.com/dnaproject2/DNA/vm/wasmvm/util"
+	"github.com/dnaproject2/DNA/vm/wasmvm/validate"
+	"github.com/dnaproject2/DNA/vm/wasmvm/wasm"
 )
 
+var (
+	ERR_EXECUTE_CODE      = errors.NewErr("[WasmVmService] wasm invoke param was invalid!")
+	CONTRACT_NOT_EXIST    = errors.NewErr("[WasmVmService] the given contract does not exist!")
+	DEPLOYCODE_TYPE_ERROR = errors.NewErr("[WasmVmService] the given contract is not a wasm contract!")
+	ERR_TEST_VERSION      = errors.NewErr("[WasmVmService] contract version 0 is reserved for test!")
+)
+
+// WasmVmService is a struct for wasm smart contract provide interop service
+// Code is the serialized ContractInvokeParam, the contract code is loaded by the invoke address
 type WasmVmService struct {
 	Store         store.LedgerStore
 	CacheDB       *storage.CacheDB
@@ -49,440 +62,460 @@ type WasmVmService struct {
 	Code          []byte
 	Tx            *types.Transaction
 	Time          uint32
+	Height        uint32
+	BlockHash     common.Uint256
+	PreExec       bool
+}
+
+// Invoke a wasm smart contract
+func (this *WasmVmService) Invoke() (interface{}, error) {
+	if len(this.Code) == 0 {
+		return nil, ERR_EXECUTE_CODE
+	}
+	contract := new(states.ContractInvokeParam)
+	if err := contract.Deserialize(bytes.NewBuffer(this.Code)); err != nil {
+		return nil, errors.NewDetailErr(err, errors.ErrNoCode, "[WasmVmService] invoke param deserialize error!")
+	}
+	if contract.Version == 0 {
+		return nil, ERR_TEST_VERSION
+	}
+	code, err := this.getContract(contract.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	engine := exec.NewExecutionEngine(nil, new(util.ECDsaCrypto), this.newStateMachine())
+	engine.SetGasChecker(this.checkStepGas)
+
+	var caller common.Address
+	if current := this.ContextRef.CurrentContext(); current != nil {
+		caller = current.ContractAddress
+	}
+	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address, Code: code, Method: contract.Method})
+	defer this.ContextRef.PopContext()
+	res, err := engine.Call(caller, code, contract.Method, contract.Args, contract.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	//get the return message
+	var result []byte
+	if len(res) == 4 {
+		result, err = engine.GetVM().GetPointerMemory(uint64(binary.LittleEndian.Uint32(res)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	this.ContextRef.PushNotifications(this.Notifications)
+	return result, nil
+}
+
+func (this *WasmVmService) newStateMachine() *WasmStateMachine {
+	stateMachine := NewWasmStateMachine()
+	//contract call
+	stateMachine.Register("ONT_CallContract", this.callContract)
+	stateMachine.Register("ONT_MarshalNativeParams", this.marshalNativeParams)
+	stateMachine.Register("ONT_MarshalNeoParams", this.marshalNeoParams)
+	//runtime
+	stateMachine.Register("ONT_Runtime_CheckWitness", this.runtimeCheckWitness)
+	stateMachine.Register("ONT_Runtime_Notify", this.runtimeNotify)
+	stateMachine.Register("ONT_Runtime_CheckSig", this.runtimeCheckSig)
+	stateMachine.Register("ONT_Runtime_GetTime", this.runtimeGetTime)
+	stateMachine.Register("ONT_Runtime_Log", this.runtimeLog)
+	//attribute
+	stateMachine.Register("ONT_Attribute_GetUsage", this.attributeGetUsage)
+	stateMachine.Register("ONT_Attribute_GetData", this.attributeGetData)
+	//block
+	stateMachine.Register("ONT_Block_GetCurrentHeaderHash", this.blockGetCurrentHeaderHash)
+	stateMachine.Register("ONT_Block_GetCurrentHeaderHeight", this.blockGetCurrentHeaderHeight)
+	stateMachine.Register("ONT_Block_GetCurrentBlockHash", this.blockGetCurrentBlockHash)
+	stateMachine.Register("ONT_Block_GetCurrentBlockHeight", this.blockGetCurrentBlockHeight)
+	stateMachine.Register("ONT_Block_GetTransactionByHash", this.blockGetTransactionByHash)
+	stateMachine.Register("ONT_Block_GetTransactionCount", this.blockGetTransactionCount)
+	stateMachine.Register("ONT_Block_GetTransactions", this.blockGetTransactions)
+	//blockchain
+	stateMachine.Register("ONT_BlockChain_GetHeight", this.blockChainGetHeight)
+	stateMachine.Register("ONT_BlockChain_GetHeaderByHeight", this.blockChainGetHeaderByHeight)
+	stateMachine.Register("ONT_BlockChain_GetHeaderByHash", this.blockChainGetHeaderByHash)
+	stateMachine.Register("ONT_BlockChain_GetBlockByHeight", this.blockChainGetBlockByHeight)
+	stateMachine.Register("ONT_BlockChain_GetBlockByHash", this.blockChainGetBlockByHash)
+	stateMachine.Register("ONT_BlockChain_GetContract", this.blockChainGetContract)
+	//header
+	stateMachine.Register("ONT_Header_GetHash", this.headerGetHash)
+	stateMachine.Register("ONT_Header_GetVersion", this.headerGetVersion)
+	stateMachine.Register("ONT_Header_GetPrevHash", this.headerGetPrevHash)
+	stateMachine.Register("ONT_Header_GetMerkleRoot", this.headerGetMerkleRoot)
+	stateMachine.Register("ONT_Header_GetIndex", this.headerGetIndex)
+	stateMachine.Register("ONT_Header_GetTimestamp", this.headerGetTimestamp)
+	stateMachine.Register("ONT_Header_GetConsensusData", this.headerGetConsensusData)
+	stateMachine.Register("ONT_Header_GetNextConsensus", this.headerGetNextConsensus)
+	//storage
+	stateMachine.Register("ONT_Storage_Put", this.putstore)
+	stateMachine.Register("ONT_Storage_Get", this.getstore)
+	stateMachine.Register("ONT_Storage_Delete", this.deletestore)
+	//transaction
+	stateMachine.Register("ONT_Transaction_GetHash", this.transactionGetHash)
+	stateMachine.Register("ONT_Transaction_GetType", this.transactionGetType)
+	stateMachine.Register("ONT_Transaction_GetAttributes", this.transactionGetAttributes)
+	return stateMachine
+}
+
+// checkStepGas charge gas for every executed wasm instruction
+func (this *WasmVmService) checkStepGas() bool {
+	if this.PreExec && !this.ContextRef.CheckExecStep() {
+		return false
+	}
+	return this.ContextRef.CheckUseGas(neovm.OPCODE_GAS)
+}
+
+// useGas charge the gas of interop service with the given name in neovm.GAS_TABLE
+func (this *WasmVmService) useGas(name string) error {
+	price, ok := neovm.GAS_TABLE.Load(name)
+	if !ok {
+		return errors.NewErr(fmt.Sprintf("[WasmVmService] get %s gas failed", name))
+	}
+	if !this.ContextRef.CheckUseGas(price.(uint64)) {
+		return exec.ERR_GAS_INSUFFICIENT
+	}
+	return nil
+}
+
+// callContract
+// need 3 parameters
+//0: contract address
+//1: method name
+//2: args
+func (this *WasmVmService) callContract(engine *exec.ExecutionEngine) (bool, error) {
+	vm := engine.GetVM()
+	envCall := vm.GetEnvCall()
+	params := envCall.GetParams()
+	if len(params) != 3 {
+		return false, errors.NewErr("[callContract]parameter count error while call callContract")
+	}
+	addr, err := vm.GetPointerMemory(params[0])
+	if err != nil {
+		return false, errors.NewErr("[callContract]get Contract address failed:" + err.Error())
+	}
+	addrbytes, err := common.HexToBytes(util.TrimBuffToString(addr))
+	if err != nil {
+		return false, errors.NewErr("[callContract]get contract address error:" + err.Error())
+	}
+	contractAddress, err := common.AddressParseFromBytes(addrbytes)
+	if err != nil {
+		return false, errors.NewErr("[callContract]get contract address error:" + err.Error())
+	}
+	methodName, err := vm.GetPointerMemory(params[1])
+	if err != nil {
+		return false, errors.NewErr("[callContract]get Contract methodName failed:" + err.Error())
+	}
+	arg, err := vm.GetPointerMemory(params[2])
+	if err != nil {
+		return false, errors.NewErr("[callContract]get Contract arg failed:" + err.Error())
+	}
+	if err := this.useGas(neovm.APPCALL_NAME); err != nil {
+		return false, err
+	}
+
+	result, err := this.invokeContract(contractAddress, util.TrimBuffToString(methodName), arg)
+	if err != nil {
+		return false, errors.NewErr("[callContract]AppCall failed:" + err.Error())
+	}
+	vm.RestoreCtx()
+	if envCall.GetReturns() {
+		idx, err := vm.SetPointerMemory(result)
+		if err != nil {
+			return false, errors.NewErr("[callContract]SetPointerMemory failed:" + err.Error())
+		}
+		vm.PushResult(uint64(idx))
+	}
+	return true, nil
+}
+
+// nativeServiceRef is the ContextRef able to launch a native service under its engine limit
+type nativeServiceRef interface {
+	NewNativeService() (*native.NativeService, error)
 }
 
-//
-//func (this *WasmVmService) Invoke() (interface{}, error) {
-//	stateMachine := NewWasmStateMachine()
-//	//register the "CallContract" function
-//	stateMachine.Register("ONT_CallContract", this.callContract)
-//	stateMachine.Register("ONT_MarshalNativeParams", this.marshalNativeParams)
-//	stateMachine.Register("ONT_MarshalNeoParams", this.marshalNeoParams)
-//	//runtime
-//	stateMachine.Register("ONT_Runtime_CheckWitness", this.runtimeCheckWitness)
-//	stateMachine.Register("ONT_Runtime_Notify", this.runtimeNotify)
-//	stateMachine.Register("ONT_Runtime_CheckSig", this.runtimeCheckSig)
-//	stateMachine.Register("ONT_Runtime_GetTime", this.runtimeGetTime)
-//	stateMachine.Register("ONT_Runtime_Log", this.runtimeLog)
-//	//attribute
-//	stateMachine.Register("ONT_Attribute_GetUsage", this.attributeGetUsage)
-//	stateMachine.Register("ONT_Attribute_GetData", this.attributeGetData)
-//	//block
-//	stateMachine.Register("ONT_Block_GetCurrentHeaderHash", this.blockGetCurrentHeaderHash)
-//	stateMachine.Register("ONT_Block_GetCurrentHeaderHeight", this.blockGetCurrentHeaderHeight)
-//	stateMachine.Register("ONT_Block_GetCurrentBlockHash", this.blockGetCurrentBlockHash)
-//	stateMachine.Register("ONT_Block_GetCurrentBlockHeight", this.blockGetCurrentBlockHeight)
-//	stateMachine.Register("ONT_Block_GetTransactionByHash", this.blockGetTransactionByHash)
-//	stateMachine.Register("ONT_Block_GetTransactionCount", this.blockGetTransactionCount)
-//	stateMachine.Register("ONT_Block_GetTransactions", this.blockGetTransactions)
-//
-//	//blockchain
-//	stateMachine.Register("ONT_BlockChain_GetHeight", this.blockChainGetHeight)
-//	stateMachine.Register("ONT_BlockChain_GetHeaderByHeight", this.blockChainGetHeaderByHeight)
-//	stateMachine.Register("ONT_BlockChain_GetHeaderByHash", this.blockChainGetHeaderByHash)
-//	stateMachine.Register("ONT_BlockChain_GetBlockByHeight", this.blockChainGetBlockByHeight)
-//	stateMachine.Register("ONT_BlockChain_GetBlockByHash", this.blockChainGetBlockByHash)
-//	stateMachine.Register("ONT_BlockChain_GetContract", this.blockChainGetContract)
-//
-//	//header
-//	stateMachine.Register("ONT_Header_GetHash", this.headerGetHash)
-//	stateMachine.Register("ONT_Header_GetVersion", this.headerGetVersion)
-//	stateMachine.Register("ONT_Header_GetPrevHash", this.headerGetPrevHash)
-//	stateMachine.Register("ONT_Header_GetMerkleRoot", this.headerGetMerkleRoot)
-//	stateMachine.Register("ONT_Header_GetIndex", this.headerGetIndex)
-//	stateMachine.Register("ONT_Header_GetTimestamp", this.headerGetTimestamp)
-//	stateMachine.Register("ONT_Header_GetConsensusData", this.headerGetConsensusData)
-//	stateMachine.Register("ONT_Header_GetNextConsensus", this.headerGetNextConsensus)
-//
-//	//storage
-//	stateMachine.Register("ONT_Storage_Put", this.putstore)
-//	stateMachine.Register("ONT_Storage_Get", this.getstore)
-//	stateMachine.Register("ONT_Storage_Delete", this.deletestore)
-//
-//	//transaction
-//	stateMachine.Register("ONT_Transaction_GetHash", this.transactionGetHash)
-//	stateMachine.Register("ONT_Transaction_GetType", this.transactionGetType)
-//	stateMachine.Register("ONT_Transaction_GetAttributes", this.transactionGetAttributes)
-//
-//	engine := exec.NewExecutionEngine(
-//		this.Tx,
-//		new(util.ECDsaCrypto),
-//		stateMachine,
-//	)
-//
-//	contract := &states.Contract{}
-//	contract.Deserialize(bytes.NewBuffer(this.Code))
-//	addr := contract.Address
-//	if contract.Code == nil {
-//		dpcode, err := this.GetContractCodeFromAddress(addr)
-//		if err != nil {
-//			return nil, errors.NewErr("get contract  error")
-//		}
-//		contract.Code = dpcode
-//	}
-//
-//	var caller common.Address
-//	if this.ContextRef.CallingContext() == nil {
-//		caller = common.Address{}
-//	} else {
-//		caller = this.ContextRef.CallingContext().ContractAddress
-//	}
-//	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address})
-//	res, err := engine.Call(caller, contract.Code, contract.Method, contract.Args, contract.Version)
-//
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	//get the return message
-//	result, err := engine.GetVM().GetPointerMemory(uint64(binary.LittleEndian.Uint32(res)))
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	this.ContextRef.PopContext()
-//	this.ContextRef.PushNotifications(this.Notifications)
-//	return result, nil
-//}
-//
-//func (this *WasmVmService) marshalNeoParams(engine *exec.ExecutionEngine) (bool, error) {
-//	vm := engine.GetVM()
-//	envCall := vm.GetEnvCall()
-//	params := envCall.GetParams()
-//	if len(params) != 1 {
-//		return false, errors.NewErr("[marshalNeoParams]parameter count error while call marshalNativeParams")
-//	}
-//	argbytes, err := vm.GetPointerMemory(params[0])
-//	if err != nil {
-//		return false, err
-//	}
-//	bytesLen := len(argbytes)
-//	args := make([]interface{}, bytesLen/8)
-//	icount := 0
-//	for i := 0; i < bytesLen; i += 8 {
-//		tmpBytes := argbytes[i : i+8]
-//		ptype, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpBytes[:4])))
-//		if err != nil {
-//			return false, err
-//		}
-//		pvalue, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpBytes[4:8])))
-//		if err != nil {
-//			return false, err
-//		}
-//		switch strings.ToLower(util.TrimBuffToString(ptype)) {
-//		case "string":
-//			args[icount] = util.TrimBuffToString(pvalue)
-//		case "int":
-//			args[icount], err = strconv.Atoi(util.TrimBuffToString(pvalue))
-//			if err != nil {
-//				return false, err
-//			}
-//		case "int64":
-//			args[icount], err = strconv.ParseInt(util.TrimBuffToString(pvalue), 10, 64)
-//			if err != nil {
-//				return false, err
-//			}
-//		default:
-//			args[icount] = util.TrimBuffToString(pvalue)
-//		}
-//		icount++
-//	}
-//	builder := neovm.NewParamsBuilder(bytes.NewBuffer(nil))
-//	err = buildNeoVMParamInter(builder, []interface{}{args})
-//	if err != nil {
-//		return false, err
-//	}
-//	neoargs := builder.ToArray()
-//	idx, err := vm.SetPointerMemory(neoargs)
-//	if err != nil {
-//		return false, err
-//	}
-//	vm.RestoreCtx()
-//	vm.PushResult(uint64(idx))
-//	return true, nil
-//
-//}
-//
-//// marshalNativeParams
-//// make parameter bytes for call native contract
-//func (this *WasmVmService) marshalNativeParams(engine *exec.ExecutionEngine) (bool, error) {
-//	vm := engine.GetVM()
-//	envCall := vm.GetEnvCall()
-//	params := envCall.GetParams()
-//	if len(params) != 1 {
-//		return false, errors.NewErr("[callContract]parameter count error while call marshalNativeParams")
-//	}
-//
-//	transferbytes, err := vm.GetPointerMemory(params[0])
-//	if err != nil {
-//		return false, err
-//	}
-//	//transferbytes is a nested struct with states.Transfer
-//	//type Transfers struct {
-//	//	States  []*State		   -------->i32 pointer 4 bytes
-//	//}
-//	if len(transferbytes) != 4 {
-//		return false, errors.NewErr("[callContract]parameter format error while call marshalNativeParams")
-//	}
-//	transfer := &nstates.Transfers{}
-//
-//	statesAddr := binary.LittleEndian.Uint32(transferbytes[:4])
-//	statesbytes, err := vm.GetPointerMemory(uint64(statesAddr))
-//	if err != nil {
-//		return false, err
-//	}
-//
-//	//statesbytes is slice of struct with states.
-//	//type State struct {
-//	//	From    common.Address  -------->i32 pointer 4 bytes
-//	//	To      common.Address  -------->i32 pointer 4 bytes
-//	//	Value   *big.Int        -------->i64 8 bytes
-//	//}
-//	//total is 4 + 4 + 8 = 24 bytes
-//	statecnt := len(statesbytes) / 16
-//	states := make([]*nstates.State, statecnt)
-//
-//	for i := 0; i < statecnt; i++ {
-//		tmpbytes := statesbytes[i*16 : (i+1)*16]
-//		state := &nstates.State{}
-//		fromAddessBytes, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpbytes[:4])))
-//		if err != nil {
-//			return false, err
-//		}
-//		fromAddress, err := common.AddressFromBase58(util.TrimBuffToString(fromAddessBytes))
-//		if err != nil {
-//			return false, err
-//		}
-//		state.From = fromAddress
-//
-//		toAddressBytes, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpbytes[4:8])))
-//		if err != nil {
-//			return false, err
-//		}
-//		toAddress, err := common.AddressFromBase58(util.TrimBuffToString(toAddressBytes))
-//		state.To = toAddress
-//		//tmpbytes[12:16] is padding
-//		amount := binary.LittleEndian.Uint64(tmpbytes[8:])
-//		state.Value = amount
-//		states[i] = state
-//
-//	}
-//
-//	transfer.States = states
-//	tbytes := new(bytes.Buffer)
-//	transfer.Serialize(tbytes)
-//
-//	result, err := vm.SetPointerMemory(tbytes.Bytes())
-//	if err != nil {
-//		return false, err
-//	}
-//	vm.RestoreCtx()
-//	vm.PushResult(uint64(result))
-//	return true, nil
-//}
-//
-//// callContract
-//// need 4 parameters
-////0: contract address
-////1: contract code
-////2: method name
-////3: args
-//func (this *WasmVmService) callContract(engine *exec.ExecutionEngine) (bool, error) {
-//	vm := engine.GetVM()
-//	envCall := vm.GetEnvCall()
-//	params := envCall.GetParams()
-//	if len(params) != 4 {
-//		return false, errors.NewErr("[callContract]parameter count error while call readMessage")
-//	}
-//	var contractAddress common.Address
-//	var contractBytes []byte
-//	//get contract address
-//	contractAddressIdx := params[0]
-//	addr, err := vm.GetPointerMemory(contractAddressIdx)
-//	if err != nil {
-//		return false, errors.NewErr("[callContract]get Contract address failed:" + err.Error())
-//	}
-//
-//	if addr != nil {
-//		addrbytes, err := common.HexToBytes(util.TrimBuffToString(addr))
-//		if err != nil {
-//			return false, errors.NewErr("[callContract]get contract address error:" + err.Error())
-//		}
-//		contractAddress, err = common.AddressParseFromBytes(addrbytes)
-//		if err != nil {
-//			return false, errors.NewErr("[callContract]get contract address error:" + err.Error())
-//		}
-//
-//	}
-//
-//	//get contract code
-//	codeIdx := params[1]
-//
-//	offchainContractCode, err := vm.GetPointerMemory(codeIdx)
-//	if err != nil {
-//		return false, errors.NewErr("[callContract]get Contract address failed:" + err.Error())
-//	}
-//	if offchainContractCode != nil {
-//		contractBytes, err = common.HexToBytes(util.TrimBuffToString(offchainContractCode))
-//		if err != nil {
-//			return false, err
-//
-//		}
-//		//compute the offchain code address
-//		codestring := util.TrimBuffToString(offchainContractCode)
-//		contractAddress = GetContractAddress(codestring, vmtypes.WASMVM)
-//	}
-//	//get method
-//	methodName, err := vm.GetPointerMemory(params[2])
-//	if err != nil {
-//		return false, errors.NewErr("[callContract]get Contract methodName failed:" + err.Error())
-//	}
-//	//get args
-//	arg, err := vm.GetPointerMemory(params[3])
-//
-//	if err != nil {
-//		return false, errors.NewErr("[callContract]get Contract arg failed:" + err.Error())
-//	}
-//	this.ContextRef.PushContext(&context.Context{
-//		Code:            vm.VMCode,
-//		ContractAddress: vm.ContractAddress})
-//	result, err := this.ContextRef.AppCall(contractAddress, util.TrimBuffToString(methodName), contractBytes, arg)
-//
-//	this.ContextRef.PopContext()
-//	if err != nil {
-//		return false, errors.NewErr("[callContract]AppCall failed:" + err.Error())
-//	}
-//	vm.RestoreCtx()
-//	var res string
-//	if envCall.GetReturns() {
-//		if contractAddress[0] == byte(vmtypes.NEOVM) {
-//			result = sccommon.ConvertNeoVmReturnTypes(result)
-//			switch result.(type) {
-//			case int:
-//				res = strconv.Itoa(result.(int))
-//			case int64:
-//				res = strconv.FormatInt(result.(int64), 10)
-//			case string:
-//				res = result.(string)
-//			case []byte:
-//				tmp := result.([]byte)
-//				if len(tmp) == 1 {
-//					if tmp[0] == byte(1) {
-//						res = "true"
-//					}
-//					if tmp[0] == byte(0) {
-//						res = "false"
-//					}
-//				} else {
-//					res = string(result.([]byte))
-//				}
-//			default:
-//				res = fmt.Sprintf("%s", result)
-//			}
-//
-//		}
-//		if contractAddress[0] == byte(vmtypes.Native) {
-//			bresult := result.(bool)
-//			if bresult == true {
-//				res = "true"
-//			} else {
-//				res = "false"
-//			}
-//
-//		}
-//		if contractAddress[0] == byte(vmtypes.WASMVM) {
-//			res = fmt.Sprintf("%s", result)
-//		}
-//
-//		idx, err := vm.SetPointerMemory(res)
-//		if err != nil {
-//			return false, errors.NewErr("[callContract]SetPointerMemory failed:" + err.Error())
-//		}
-//		vm.PushResult(uint64(idx))
-//	}
-//
-//	return true, nil
-//}
-//
-//func (this *WasmVmService) GetContractCodeFromAddress(address common.Address) ([]byte, error) {
-//
-//	dcode, err := this.Store.GetContractState(address)
-//	if err != nil {
-//		return nil, err
-//	}
-//
-//	if dcode == nil {
-//		return nil, errors.NewErr("[GetContractCodeFromAddress] deployed code is nil")
-//	}
-//
-//	return dcode.Code.Code, nil
-//
-//}
-//
-//func (this *WasmVmService) getContractFromAddr(addr []byte) ([]byte, error) {
-//	addrbytes, err := common.HexToBytes(util.TrimBuffToString(addr))
-//	if err != nil {
-//		return nil, errors.NewErr("get contract address error")
-//	}
-//	contactaddress, err := common.AddressParseFromBytes(addrbytes)
-//	if err != nil {
-//		return nil, errors.NewErr("get contract address error")
-//	}
-//	dpcode, err := this.GetContractCodeFromAddress(contactaddress)
-//	if err != nil {
-//		return nil, errors.NewErr("get contract  error")
-//	}
-//	return dpcode, nil
-//}
-//
-////GetContractAddress return contract address
-//func GetContractAddress(code string, vmType vmtypes.VmType) common.Address {
-//	data, _ := hex.DecodeString(code)
-//	vmCode := &vmtypes.VmCode{
-//		VmType: vmType,
-//		Code:   data,
-//	}
-//	return vmCode.AddressFromVmCode()
-//}
-//
-////buildNeoVMParamInter build neovm invoke param code
-//func buildNeoVMParamInter(builder *neovm.ParamsBuilder, smartContractParams []interface{}) error {
-//	//VM load params in reverse order
-//	for i := len(smartContractParams) - 1; i >= 0; i-- {
-//		switch v := smartContractParams[i].(type) {
-//		case bool:
-//			builder.EmitPushBool(v)
-//		case int:
-//			builder.EmitPushInteger(big.NewInt(int64(v)))
-//		case uint:
-//			builder.EmitPushInteger(big.NewInt(int64(v)))
-//		case int32:
-//			builder.EmitPushInteger(big.NewInt(int64(v)))
-//		case uint32:
-//			builder.EmitPushInteger(big.NewInt(int64(v)))
-//		case int64:
-//			builder.EmitPushInteger(big.NewInt(int64(v)))
-//		case common.Fixed64:
-//			builder.EmitPushInteger(big.NewInt(int64(v.GetData())))
-//		case uint64:
-//			val := big.NewInt(0)
-//			builder.EmitPushInteger(val.SetUint64(uint64(v)))
-//		case string:
-//			builder.EmitPushByteArray([]byte(v))
-//		case *big.Int:
-//			builder.EmitPushInteger(v)
-//		case []byte:
-//			builder.EmitPushByteArray(v)
-//		case []interface{}:
-//			err := buildNeoVMParamInter(builder, v)
-//			if err != nil {
-//				return err
-//			}
-//			builder.EmitPushInteger(big.NewInt(int64(len(v))))
-//			builder.Emit(neovm.PACK)
-//		default:
-//			return fmt.Errorf("unsupported param:%s", v)
-//		}
-//	}
-//	return nil
-//}
+// invokeContract dispatch the call to native, wasm or neovm contract according to the callee
+func (this *WasmVmService) invokeContract(address common.Address, method string, args []byte) ([]byte, error) {
+	if _, ok := native.GetContract(this.CacheDB, address); ok {
+		ref, ok := this.ContextRef.(nativeServiceRef)
+		if !ok {
+			return nil, errors.NewErr("[invokeContract] native contract call is not supported!")
+		}
+		service, err := ref.NewNativeService()
+		if err != nil {
+			return nil, err
+		}
+		service.InvokeParam = states.ContractInvokeParam{Address: address, Method: method, Args: args}
+		result, err := service.Invoke()
+		if err != nil {
+			return nil, err
+		}
+		switch v := result.(type) {
+		case []byte:
+			return v, nil
+		case bool:
+			return []byte(strconv.FormatBool(v)), nil
+		default:
+			return nil, fmt.Errorf("unsupported native result type %T", result)
+		}
+	}
+
+	dep, err := this.CacheDB.GetContract(address)
+	if err != nil {
+		return nil, errors.NewErr("[invokeContract] get contract context error!")
+	}
+	if dep == nil {
+		return nil, CONTRACT_NOT_EXIST
+	}
+	if dep.IsWasm() {
+		bf := new(bytes.Buffer)
+		param := &states.ContractInvokeParam{Version: neovm.WASM_CONTRACT_VERSION, Address: address, Method: method, Args: args}
+		if err := param.Serialize(bf); err != nil {
+			return nil, err
+		}
+		service, err := this.ContextRef.NewWasmExecuteEngine(bf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		result, err := service.Invoke()
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, nil
+		}
+		return result.([]byte), nil
+	}
+
+	// args of neovm contract is the param code built by ONT_MarshalNeoParams
+	builder := vm.NewParamsBuilder(bytes.NewBuffer(nil))
+	builder.EmitPushByteArray([]byte(method))
+	builder.EmitPushCall(address[:])
+	code := append(append([]byte{}, args...), builder.ToArray()...)
+	service, err := this.ContextRef.NewExecuteEngine(code)
+	if err != nil {
+		return nil, err
+	}
+	result, err := service.Invoke()
+	if err != nil {
+		return nil, err
+	}
+	return convertNeoVmResult(result)
+}
+
+// convertNeoVmResult convert neovm return stack item to the string format of wasm contract
+func convertNeoVmResult(result interface{}) ([]byte, error) {
+	switch v := result.(type) {
+	case nil:
+		return nil, nil
+	case *ntypes.Boolean:
+		b, _ := v.GetBoolean()
+		return []byte(strconv.FormatBool(b)), nil
+	case *ntypes.Integer:
+		i, _ := v.GetBigInteger()
+		return []byte(i.String()), nil
+	case ntypes.StackItems:
+		return v.GetByteArray()
+	default:
+		return nil, fmt.Errorf("unsupported neovm result type %T", result)
+	}
+}
+
+func (this *WasmVmService) marshalNeoParams(engine *exec.ExecutionEngine) (bool, error) {
+	vm := engine.GetVM()
+	envCall := vm.GetEnvCall()
+	params := envCall.GetParams()
+	if len(params) != 1 {
+		return false, errors.NewErr("[marshalNeoParams]parameter count error while call marshalNeoParams")
+	}
+	argbytes, err := vm.GetPointerMemory(params[0])
+	if err != nil {
+		return false, err
+	}
+	bytesLen := len(argbytes)
+	args := make([]interface{}, bytesLen/8)
+	icount := 0
+	for i := 0; i+8 <= bytesLen; i += 8 {
+		tmpBytes := argbytes[i : i+8]
+		ptype, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpBytes[:4])))
+		if err != nil {
+			return false, err
+		}
+		pvalue, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpBytes[4:8])))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(util.TrimBuffToString(ptype)) {
+		case "int", "int64":
+			val, err := strconv.ParseInt(util.TrimBuffToString(pvalue), 10, 64)
+			if err != nil {
+				return false, err
+			}
+			args[icount] = val
+		default:
+			args[icount] = util.TrimBuffToString(pvalue)
+		}
+		icount++
+	}
+	builder := neovmParamsBuilder()
+	if err := buildNeoVMParamInter(builder, []interface{}{args}); err != nil {
+		return false, err
+	}
+	idx, err := vm.SetPointerMemory(builder.ToArray())
+	if err != nil {
+		return false, err
+	}
+	vm.RestoreCtx()
+	vm.PushResult(uint64(idx))
+	return true, nil
+}
+
+// marshalNativeParams
+// make parameter bytes for call native transfer
+func (this *WasmVmService) marshalNativeParams(engine *exec.ExecutionEngine) (bool, error) {
+	vm := engine.GetVM()
+	envCall := vm.GetEnvCall()
+	params := envCall.GetParams()
+	if len(params) != 1 {
+		return false, errors.NewErr("[marshalNativeParams]parameter count error while call marshalNativeParams")
+	}
+	transferbytes, err := vm.GetPointerMemory(params[0])
+	if err != nil {
+		return false, err
+	}
+	//transferbytes is a nested struct with ont.Transfers
+	//type Transfers struct {
+	//	States  []State		   -------->i32 pointer 4 bytes
+	//}
+	if len(transferbytes) != 4 {
+		return false, errors.NewErr("[marshalNativeParams]parameter format error while call marshalNativeParams")
+	}
+	statesbytes, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(transferbytes[:4])))
+	if err != nil {
+		return false, err
+	}
+	//statesbytes is slice of struct with states.
+	//type State struct {
+	//	From    common.Address  -------->i32 pointer 4 bytes
+	//	To      common.Address  -------->i32 pointer 4 bytes
+	//	Value   uint64          -------->i64 8 bytes
+	//}
+	//total is 4 + 4 + 8 = 16 bytes
+	statecnt := len(statesbytes) / 16
+	transfer := &ont.Transfers{States: make([]ont.State, statecnt)}
+	for i := 0; i < statecnt; i++ {
+		tmpbytes := statesbytes[i*16 : (i+1)*16]
+		fromAddressBytes, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpbytes[:4])))
+		if err != nil {
+			return false, err
+		}
+		from, err := common.AddressFromBase58(util.TrimBuffToString(fromAddressBytes))
+		if err != nil {
+			return false, err
+		}
+		toAddressBytes, err := vm.GetPointerMemory(uint64(binary.LittleEndian.Uint32(tmpbytes[4:8])))
+		if err != nil {
+			return false, err
+		}
+		to, err := common.AddressFromBase58(util.TrimBuffToString(toAddressBytes))
+		if err != nil {
+			return false, err
+		}
+		transfer.States[i] = ont.State{From: from, To: to, Value: binary.LittleEndian.Uint64(tmpbytes[8:])}
+	}
+	tbytes := new(bytes.Buffer)
+	if err := transfer.Serialize(tbytes); err != nil {
+		return false, err
+	}
+	result, err := vm.SetPointerMemory(tbytes.Bytes())
+	if err != nil {
+		return false, err
+	}
+	vm.RestoreCtx()
+	vm.PushResult(uint64(result))
+	return true, nil
+}
+
+func (this *WasmVmService) getContract(address common.Address) ([]byte, error) {
+	dep, err := this.CacheDB.GetContract(address)
+	if err != nil {
+		return nil, errors.NewErr("[getContract] get contract context error!")
+	}
+	if dep == nil {
+		return nil, CONTRACT_NOT_EXIST
+	}
+	if !dep.IsWasm() {
+		return nil, DEPLOYCODE_TYPE_ERROR
+	}
+	return dep.Code, nil
+}
+
+// ReadWasmModule parse and verify the wasm code of a deploy transaction
+// only "env" imports are allowed and the contract must export the invoke method
+func ReadWasmModule(code []byte) (m *wasm.Module, err error) {
+	//catch the panic of malformed code
+	defer func() {
+		if r := recover(); r != nil {
+			m = nil
+			err = fmt.Errorf("[ReadWasmModule] read wasm module error: %v", r)
+		}
+	}()
+	m, err = wasm.ReadModule(bytes.NewReader(code), func(name string) (*wasm.Module, error) {
+		return nil, fmt.Errorf("import [%s] is not supported", name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadWasmModule] read wasm module error: %s", err)
+	}
+	if m.Export == nil {
+		return nil, errors.NewErr("[ReadWasmModule] no export in wasm!")
+	}
+	if _, ok := m.Export.Entries[exec.CONTRACT_METHOD_NAME]; !ok {
+		return nil, errors.NewErr("[ReadWasmModule] method " + exec.CONTRACT_METHOD_NAME + " does not exist!")
+	}
+	if err := validate.VerifyModule(m); err != nil {
+		return nil, fmt.Errorf("[ReadWasmModule] verify wasm module error: %s", err)
+	}
+	return m, nil
+}
+
+// VerifyDeployCode check the code of wasm deploy payload
+func VerifyDeployCode(deploy *payload.DeployCode) error {
+	if !deploy.IsWasm() {
+		return nil
+	}
+	_, err := ReadWasmModule(deploy.Code)
+	return err
+}
+
+func neovmParamsBuilder() *vm.ParamsBuilder {
+	return vm.NewParamsBuilder(bytes.NewBuffer(nil))
+}
+
+//buildNeoVMParamInter build neovm invoke param code
+func buildNeoVMParamInter(builder *vm.ParamsBuilder, smartContractParams []interface{}) error {
+	//VM load params in reverse order
+	for i := len(smartContractParams) - 1; i >= 0; i-- {
+		switch v := smartContractParams[i].(type) {
+		case bool:
+			builder.EmitPushBool(v)
+		case int64:
+			builder.EmitPushInteger(big.NewInt(v))
+		case string:
+			builder.EmitPushByteArray([]byte(v))
+		case []byte:
+			builder.EmitPushByteArray(v)
+		case []interface{}:
+			if err := buildNeoVMParamInter(builder, v); err != nil {
+				return err
+			}
+			builder.EmitPushInteger(big.NewInt(int64(len(v))))
+			builder.Emit(vm.PACK)
+		default:
+			return fmt.Errorf("unsupported param:%v", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package wasmvm
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWasmModule(t *testing.T) {
+	code, err := ioutil.ReadFile("../../../vm/wasmvm/exec/test_data2/contract.wasm")
+	assert.Nil(t, err)
+	_, err = ReadWasmModule(code)
+	assert.Nil(t, err)
+
+	// module without the invoke export
+	code, err = ioutil.ReadFile("../../../vm/wasmvm/exec/test_data2/add.wasm")
+	assert.Nil(t, err)
+	_, err = ReadWasmModule(code)
+	assert.NotNil(t, err)
+
+	_, err = ReadWasmModule([]byte{0x00, 0x61, 0x73})
+	assert.NotNil(t, err)
+}
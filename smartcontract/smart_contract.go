@@ -28,6 +28,7 @@ import (
 	"github.com/dnaproject2/DNA/smartcontract/event"
 	"github.com/dnaproject2/DNA/smartcontract/service/native"
 	"github.com/dnaproject2/DNA/smartcontract/service/neovm"
+	"github.com/dnaproject2/DNA/smartcontract/service/wasmvm"
 	"github.com/dnaproject2/DNA/smartcontract/storage"
 	vm "github.com/dnaproject2/DNA/vm/neovm"
 )
@@ -46,6 +47,7 @@ type SmartContract struct {
 	Gas           uint64
 	ExecStep      int
 	PreExec       bool
+	Tracer        CallTracer // optional observer of the contract calls, also observes the NeoVM opcodes if it is a vm.Tracer
 }
 
 // Config describe smart contract need parameters configuration
@@ -58,6 +60,9 @@ type Config struct {
 
 // PushContext push current context to smart contract
 func (this *SmartContract) PushContext(context *context.Context) {
+	if this.Tracer != nil {
+		this.Tracer.CaptureEnter(context, this.Gas)
+	}
 	this.Contexts = append(this.Contexts, context)
 }
 
@@ -87,6 +92,9 @@ func (this *SmartContract) EntryContext() *context.Context {
 
 // PopContext pop smart contract current context
 func (this *SmartContract) PopContext() {
+	if this.Tracer != nil {
+		this.Tracer.CaptureExit(this.Gas)
+	}
 	if len(this.Contexts) > 1 {
 		this.Contexts = this.Contexts[:len(this.Contexts)-1]
 	}
@@ -126,6 +134,10 @@ func (this *SmartContract) NewExecuteEngine(code []byte) (context.Engine, error)
 	if !this.checkContexts() {
 		return nil, fmt.Errorf("%s", "engine over max limit!")
 	}
+	engine := vm.NewExecutionEngine()
+	if tracer, ok := this.Tracer.(vm.Tracer); ok {
+		engine.Tracer = tracer
+	}
 	service := &neovm.NeoVmService{
 		Store:      this.Store,
 		CacheDB:    this.CacheDB,
@@ -135,12 +147,40 @@ func (this *SmartContract) NewExecuteEngine(code []byte) (context.Engine, error)
 		Time:       this.Config.Time,
 		Height:     this.Config.Height,
 		BlockHash:  this.Config.BlockHash,
-		Engine:     vm.NewExecutionEngine(),
+		Engine:     engine,
+		PreExec:    this.PreExec,
+	}
+	return service, nil
+}
+
+// NewWasmExecuteEngine launch a wasm vm service
+// Param code: serialized ContractInvokeParam of the wasm contract to invoke
+func (this *SmartContract) NewWasmExecuteEngine(code []byte) (context.Engine, error) {
+	if !this.checkContexts() {
+		return nil, fmt.Errorf("%s", "engine over max limit!")
+	}
+	service := &wasmvm.WasmVmService{
+		Store:      this.Store,
+		CacheDB:    this.CacheDB,
+		ContextRef: this,
+		Code:       code,
+		Tx:         this.Config.Tx,
+		Time:       this.Config.Time,
+		Height:     this.Config.Height,
+		BlockHash:  this.Config.BlockHash,
 		PreExec:    this.PreExec,
 	}
 	return service, nil
 }
 
+// NewTxExecuteEngine launch the execute engine matching the vm type of invoke transaction
+func (this *SmartContract) NewTxExecuteEngine(txType ctypes.TransactionType, code []byte) (context.Engine, error) {
+	if txType == ctypes.InvokeWasm {
+		return this.NewWasmExecuteEngine(code)
+	}
+	return this.NewExecuteEngine(code)
+}
+
 func (this *SmartContract) NewNativeService() (*native.NativeService, error) {
 	if !this.checkContexts() {
 		return nil, fmt.Errorf("%s", "engine over max limit!")
@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package states
+
+import (
+	"github.com/dnaproject2/DNA/common"
+)
+
+const (
+	VM_TYPE_NATIVE = "native"
+	VM_TYPE_NEOVM  = "neovm"
+	VM_TYPE_WASM   = "wasm"
+)
+
+// CallFrame is a contract invocation made while executing a transaction,
+// GasUsed includes the gas used by its nested calls
+type CallFrame struct {
+	Contract common.Address
+	VmType   string
+	Method   string
+	GasUsed  uint64
+	Failed   bool
+	Calls    []*CallFrame
+}
+
+// StateChange is a state key written by a transaction, value is empty if the key is deleted
+type StateChange struct {
+	Key      []byte
+	OldValue []byte
+	NewValue []byte
+}
+
+// SimulateResult is the result of simulating a transaction on the state at Height.
+// Gas is split to the gas charged for the code length and the gas used by the execution,
+// Steps records the gas of every NeoVM opcode executed. WriteSet includes the fee charged
+// to the payer, and is empty if the execution failed
+type SimulateResult struct {
+	PreExecResult
+	Height    uint32
+	CodeGas   uint64
+	ExecGas   uint64
+	Error     string
+	CallTree  *CallFrame
+	Steps     []*ExecStep
+	Truncated bool
+	WriteSet  []*StateChange
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package states
+
+import (
+	"github.com/dnaproject2/DNA/common"
+)
+
+// ExecStep is a NeoVM opcode executed by a transaction. Gas is the gas charged for the opcode, including
+// the service called by SYSCALL, and GasUsed is the gas charged for all the traced opcodes till this one.
+// Stack is the evaluation stack before the opcode is executed, from the top, only recorded on request
+type ExecStep struct {
+	Contract common.Address
+	Depth    int
+	Pc       int
+	Op       string
+	SysCall  string
+	Gas      uint64
+	GasUsed  uint64
+	Stack    []interface{}
+	Error    string
+}
+
+// TraceResult is the trace of re-executing a committed transaction on the state of its parent block.
+// Steps are truncated if there are too many of them
+type TraceResult struct {
+	TxHash      common.Uint256
+	Height      uint32
+	State       byte
+	GasConsumed uint64
+	Error       string
+	CallTree    *CallFrame
+	Steps       []*ExecStep
+	Truncated   bool
+}
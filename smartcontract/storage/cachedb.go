@@ -19,8 +19,11 @@
 package storage
 
 import (
+	"bytes"
+
 	comm "github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/states"
 	"github.com/dnaproject2/DNA/core/store/common"
 	"github.com/dnaproject2/DNA/core/store/overlaydb"
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -30,10 +33,19 @@ import (
 // When smart contract execute finish, need to commit transaction cache to block cache
 type CacheDB struct {
 	memdb      *overlaydb.MemDB
-	backend    *overlaydb.OverlayDB
+	backend    cacheBackend
 	keyScratch []byte
 }
 
+// cacheBackend is where a cache is committed to, the block overlay or the parent of a child cache
+type cacheBackend interface {
+	Get(key []byte) ([]byte, error)
+	Put(key []byte, value []byte)
+	Delete(key []byte)
+	NewIterator(key []byte) common.StoreIterator
+	NewRangeIterator(start, limit []byte) common.StoreIterator
+}
+
 const initCap = 1024
 const initKvNum = 16
 
@@ -45,6 +57,14 @@ func NewCacheDB(store *overlaydb.OverlayDB) *CacheDB {
 	}
 }
 
+// NewChild return a cache reading through this one, its changes are written to this cache only when it is committed
+func (self *CacheDB) NewChild() *CacheDB {
+	return &CacheDB{
+		backend: &parentCache{self},
+		memdb:   overlaydb.NewMemDB(initCap, initKvNum),
+	}
+}
+
 func (self *CacheDB) Reset() {
 	self.memdb.Reset()
 }
@@ -118,6 +138,38 @@ func (self *CacheDB) DeleteContract(address comm.Address) {
 	self.delete(common.ST_CONTRACT, address[:])
 }
 
+// GetContractUpgrade return the upgrade history of contract address, nil if the contract is never upgraded
+func (self *CacheDB) GetContractUpgrade(addr comm.Address) (*states.ContractUpgradeInfo, error) {
+	value, err := self.get(common.ST_UPGRADE, addr[:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	info := new(states.ContractUpgradeInfo)
+	if err := info.Deserialize(bytes.NewReader(value)); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// IsContractUpgraded return whether the contract address has been upgraded to a successor. Such address can not
+// hold a contract again, since the successor still reads the storage not migrated yet
+func (self *CacheDB) IsContractUpgraded(addr comm.Address) (bool, error) {
+	info, err := self.GetContractUpgrade(addr)
+	if err != nil {
+		return false, err
+	}
+	return info != nil && info.Successor != comm.ADDRESS_EMPTY, nil
+}
+
+func (self *CacheDB) PutContractUpgrade(addr comm.Address, info *states.ContractUpgradeInfo) {
+	self.put(common.ST_UPGRADE, addr[:], info.ToArray())
+}
+
 func (self *CacheDB) Get(key []byte) ([]byte, error) {
 	return self.get(common.ST_STORAGE, key)
 }
@@ -157,6 +209,22 @@ func (self *CacheDB) NewIterator(key []byte) common.StoreIterator {
 	return &Iter{overlaydb.NewJoinIter(memIter, backIter)}
 }
 
+// NewRangeIterator return the iterator of storage keys in [start, limit), nil limit means no upper bound
+func (self *CacheDB) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	pstart := makePrefixedKey(nil, byte(common.ST_STORAGE), start)
+	var plimit []byte
+	if limit == nil {
+		plimit = []byte{byte(common.ST_STORAGE) + 1}
+	} else {
+		plimit = makePrefixedKey(nil, byte(common.ST_STORAGE), limit)
+	}
+	r := &util.Range{Start: pstart, Limit: plimit}
+	backIter := self.backend.NewRangeIterator(r.Start, r.Limit)
+	memIter := self.memdb.NewIterator(r)
+
+	return &Iter{overlaydb.NewJoinIter(memIter, backIter)}
+}
+
 type Iter struct {
 	*overlaydb.JoinIter
 }
@@ -168,3 +236,39 @@ func (self *Iter) Key() []byte {
 	}
 	return key
 }
+
+func (self *Iter) Seek(key []byte) bool {
+	return self.JoinIter.Seek(makePrefixedKey(nil, byte(common.ST_STORAGE), key))
+}
+
+// parentCache expose the raw keys of a cache to its children
+type parentCache struct {
+	cache *CacheDB
+}
+
+// if key is deleted, value == nil
+func (self *parentCache) Get(key []byte) ([]byte, error) {
+	value, unknown := self.cache.memdb.Get(key)
+	if unknown {
+		return self.cache.backend.Get(key)
+	}
+	return value, nil
+}
+
+func (self *parentCache) Put(key []byte, value []byte) {
+	self.cache.memdb.Put(key, value)
+}
+
+func (self *parentCache) Delete(key []byte) {
+	self.cache.memdb.Delete(key)
+}
+
+func (self *parentCache) NewIterator(key []byte) common.StoreIterator {
+	memIter := self.cache.memdb.NewIterator(util.BytesPrefix(key))
+	return overlaydb.NewJoinIter(memIter, self.cache.backend.NewIterator(key))
+}
+
+func (self *parentCache) NewRangeIterator(start, limit []byte) common.StoreIterator {
+	memIter := self.cache.memdb.NewIterator(&util.Range{Start: start, Limit: limit})
+	return overlaydb.NewJoinIter(memIter, self.cache.backend.NewRangeIterator(start, limit))
+}
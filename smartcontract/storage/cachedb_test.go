@@ -23,6 +23,7 @@ import (
 	"github.com/dnaproject2/DNA/core/store/overlaydb"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
+	"sort"
 	"testing"
 )
 
@@ -82,3 +83,111 @@ func TestCacheDB(t *testing.T) {
 	}
 
 }
+
+func newTestCacheDB(t *testing.T) (*CacheDB, *overlaydb.OverlayDB, common.PersistStore) {
+	memback, err := leveldbstore.NewMemLevelDBStore()
+	assert.Nil(t, err)
+	overlay := overlaydb.NewOverlayDB(memback)
+	return NewCacheDB(overlay), overlay, memback
+}
+
+func iterKeys(iter common.StoreIterator, reverse bool) []string {
+	var keys []string
+	var ok bool
+	if reverse {
+		ok = iter.Last()
+	} else {
+		ok = iter.First()
+	}
+	for ok {
+		keys = append(keys, string(iter.Key())+"="+string(iter.Value()))
+		if reverse {
+			ok = iter.Prev()
+		} else {
+			ok = iter.Next()
+		}
+	}
+	return keys
+}
+
+func TestCacheDBIterator(t *testing.T) {
+	cache, overlay, backend := newTestCacheDB(t)
+	// committed layer: a1 a2 a3 a5, overlay layer adds a0, cache layer overrides a2, deletes a3 and adds a4 a6
+	for _, key := range []string{"a1", "a2", "a3", "a5", "b1"} {
+		cache.Put([]byte(key), []byte("old"))
+	}
+	cache.Commit()
+	backend.NewBatch()
+	overlay.CommitTo()
+	assert.Nil(t, backend.BatchCommit())
+	overlay.Reset()
+	overlay.Put([]byte{byte(common.ST_STORAGE), 'a', '0'}, []byte("mid"))
+	cache.Put([]byte("a2"), []byte("new"))
+	cache.Delete([]byte("a3"))
+	cache.Put([]byte("a4"), []byte("new"))
+	cache.Put([]byte("a6"), []byte("new"))
+
+	forward := []string{"a0=mid", "a1=old", "a2=new", "a4=new", "a5=old", "a6=new"}
+	iter := cache.NewIterator([]byte("a"))
+	assert.Equal(t, forward, iterKeys(iter, false))
+	reverse := iterKeys(iter, true)
+	sort.Sort(sort.Reverse(sort.StringSlice(forward)))
+	assert.Equal(t, forward, reverse)
+
+	assert.True(t, iter.Seek([]byte("a3")))
+	assert.Equal(t, []byte("a4"), iter.Key())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte("a2"), iter.Key())
+	assert.Equal(t, []byte("new"), iter.Value())
+	assert.True(t, iter.Next())
+	assert.Equal(t, []byte("a4"), iter.Key())
+	assert.True(t, iter.Next())
+	assert.True(t, iter.Prev())
+	assert.Equal(t, []byte("a4"), iter.Key())
+	assert.False(t, iter.Seek([]byte("a7")))
+	iter.Release()
+	assert.Nil(t, iter.Error())
+
+	iter = cache.NewRangeIterator([]byte("a2"), []byte("a6"))
+	assert.Equal(t, []string{"a2=new", "a4=new", "a5=old"}, iterKeys(iter, false))
+	assert.Equal(t, []string{"a5=old", "a4=new", "a2=new"}, iterKeys(iter, true))
+	iter.Release()
+
+	iter = cache.NewRangeIterator([]byte("a5"), nil)
+	assert.Equal(t, []string{"a5=old", "a6=new", "b1=old"}, iterKeys(iter, false))
+	iter.Release()
+}
+
+func TestCacheDBChild(t *testing.T) {
+	cache, overlay, _ := newTestCacheDB(t)
+	for _, key := range []string{"a1", "a2", "a3"} {
+		cache.Put([]byte(key), []byte("old"))
+	}
+	cache.Commit()
+	cache.Put([]byte("a2"), []byte("mid"))
+	cache.Delete([]byte("a3"))
+
+	child := cache.NewChild()
+	child.Put([]byte("a1"), []byte("new"))
+	child.Put([]byte("a4"), []byte("new"))
+	child.Delete([]byte("a2"))
+	value, err := child.Get([]byte("a3"))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	assert.Equal(t, []string{"a1=new", "a4=new"}, iterKeys(child.NewIterator([]byte("a")), false))
+	assert.Equal(t, []string{"a4=new", "a1=new"}, iterKeys(child.NewRangeIterator([]byte("a"), nil), true))
+
+	//changes of the child are not seen before commit
+	assert.Equal(t, []string{"a1=old", "a2=mid"}, iterKeys(cache.NewIterator([]byte("a")), false))
+	child.Commit()
+	assert.Equal(t, []string{"a1=new", "a4=new"}, iterKeys(cache.NewIterator([]byte("a")), false))
+
+	//the overlay is changed only when the parent is committed
+	raw, err := overlay.Get([]byte{byte(common.ST_STORAGE), 'a', '2'})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("old"), raw)
+	cache.Commit()
+	raw, err = overlay.Get([]byte{byte(common.ST_STORAGE), 'a', '2'})
+	assert.Nil(t, err)
+	assert.Nil(t, raw)
+}
@@ -20,6 +20,7 @@
 package common
 
 import (
+	"container/heap"
 	"sort"
 	"sync"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/events/message"
 	vt "github.com/dnaproject2/DNA/validator/types"
 )
 
@@ -42,13 +44,38 @@ type TXEntry struct {
 	Attrs []*TXAttr          // the result from each validator
 }
 
+// MAX_DROPPED_RECORDS is the max number of replaced or evicted transactions remembered by the pool
+const MAX_DROPPED_RECORDS = 10000
+
 // TXPool contains all currently valid transactions. Transactions
 // enter the pool when they are valid from the network,
 // consensus or submitted. They exit the pool when they are included
-// in the ledger.
+// in the ledger. Unless replace-by-nonce is disabled, a transaction can be
+// replaced by another one of the same payer and nonce with a higher gas
+// price. The lowest gas price transactions are evicted when the pool is full.
 type TXPool struct {
 	sync.RWMutex
-	txList map[common.Uint256]*TXEntry // Transactions which have been verified
+	txList      map[common.Uint256]*TXEntry                // Transactions which have been verified
+	payers      map[common.Address]map[common.Uint256]bool // Transactions indexed by payer
+	nonces      map[payerNonce]common.Uint256              // The latest transaction of a payer and nonce
+	byPrice     priceHeap                                  // Transactions ordered by eviction priority
+	priced      map[common.Uint256]*pricedTx               // The heap items of the transactions
+	dropped     map[common.Uint256]*DroppedTx              // Transactions replaced or evicted recently
+	droppedList []common.Uint256                           // The dropped transactions, the oldest first
+}
+
+// payerNonce is the key of transactions which replace each other
+type payerNonce struct {
+	payer common.Address
+	nonce uint32
+}
+
+// DroppedTx records a transaction which is replaced or evicted before
+// it is committed
+type DroppedTx struct {
+	Tx         *types.Transaction
+	Reason     string         // One of the message.TXPOOL_REASON_* values
+	ReplacedBy common.Uint256 // The transaction replacing it
 }
 
 // Init creates a new transaction pool to gather.
@@ -56,24 +83,140 @@ func (tp *TXPool) Init() {
 	tp.Lock()
 	defer tp.Unlock()
 	tp.txList = make(map[common.Uint256]*TXEntry)
+	tp.payers = make(map[common.Address]map[common.Uint256]bool)
+	tp.nonces = make(map[payerNonce]common.Uint256)
+	tp.byPrice = make(priceHeap, 0)
+	tp.priced = make(map[common.Uint256]*pricedTx)
+	tp.dropped = make(map[common.Uint256]*DroppedTx)
+	tp.droppedList = make([]common.Uint256, 0)
 }
 
 // AddTxList adds a valid transaction to the transaction pool. If the
-// transaction is already in the pool, just return false. Parameter
-// txEntry includes transaction, fee, and verified information(height,
-// validator, error code).
+// transaction is already in the pool or is rejected by the pool, just
+// return false. Parameter txEntry includes transaction, fee, and verified
+// information(height, validator, error code).
 func (tp *TXPool) AddTxList(txEntry *TXEntry) bool {
+	_, _, errCode := tp.AddTx(txEntry)
+	return errCode == errors.ErrNoError
+}
+
+// AddTx adds a valid transaction to the transaction pool, and returns
+// the transaction of the same payer and nonce replaced by it unless
+// replace-by-nonce is disabled, and the transaction evicted to make room
+// for it if any.
+func (tp *TXPool) AddTx(txEntry *TXEntry) (replaced, evicted *types.Transaction, errCode errors.ErrCode) {
 	tp.Lock()
 	defer tp.Unlock()
-	txHash := txEntry.Tx.Hash()
-	if _, ok := tp.txList[txHash]; ok {
-		log.Infof("AddTxList: transaction %x is already in the pool",
-			txHash)
-		return false
+	tx := txEntry.Tx
+	txHash := tx.Hash()
+	if errCode = tp.checkTx(tx); errCode != errors.ErrNoError {
+		log.Infof("AddTxList: transaction %x is rejected: %s", txHash, errCode.Error())
+		return nil, nil, errCode
+	}
+
+	if same := tp.sameNonceTx(tx); same != nil {
+		replaced = same.Tx
+		tp.removeTx(replaced.Hash())
+		tp.addDropped(&DroppedTx{Tx: replaced, Reason: message.TXPOOL_REASON_REPLACED, ReplacedBy: txHash})
+	} else if tp.isFull() {
+		evicted = tp.byPrice[0].tx
+		tp.removeTx(evicted.Hash())
+		tp.addDropped(&DroppedTx{Tx: evicted, Reason: message.TXPOOL_REASON_EVICTED})
 	}
 
 	tp.txList[txHash] = txEntry
-	return true
+	if tp.payers[tx.Payer] == nil {
+		tp.payers[tx.Payer] = make(map[common.Uint256]bool)
+	}
+	tp.payers[tx.Payer][txHash] = true
+	tp.nonces[payerNonce{payer: tx.Payer, nonce: tx.Nonce}] = txHash
+	item := &pricedTx{tx: tx}
+	heap.Push(&tp.byPrice, item)
+	tp.priced[txHash] = item
+	return replaced, evicted, errors.ErrNoError
+}
+
+// CheckTx checks whether a transaction can be added to the pool, without
+// adding it.
+func (tp *TXPool) CheckTx(tx *types.Transaction) errors.ErrCode {
+	tp.RLock()
+	defer tp.RUnlock()
+	return tp.checkTx(tx)
+}
+
+func (tp *TXPool) checkTx(tx *types.Transaction) errors.ErrCode {
+	if _, ok := tp.txList[tx.Hash()]; ok {
+		return errors.ErrDuplicateInput
+	}
+	if same := tp.sameNonceTx(tx); same != nil {
+		if tx.GasPrice <= same.Tx.GasPrice {
+			return errors.ErrReplaceUnderpriced
+		}
+		return errors.ErrNoError
+	}
+	maxPerPayer := int(config.DefConfig.TxPool.MaxTxPerPayer)
+	if maxPerPayer > 0 && len(tp.payers[tx.Payer]) >= maxPerPayer {
+		return errors.ErrPayerTxLimit
+	}
+	if tp.isFull() && tx.GasPrice <= tp.byPrice[0].tx.GasPrice {
+		return errors.ErrTxPoolFull
+	}
+	return errors.ErrNoError
+}
+
+// isFull returns whether the pool reaches the configured size
+func (tp *TXPool) isFull() bool {
+	max := int(config.DefConfig.TxPool.MaxTxInPool)
+	return max > 0 && len(tp.txList) >= max
+}
+
+// sameNonceTx returns the transaction in the pool which tx replaces, that
+// is of the same payer and nonce, unless replace-by-nonce is disabled for
+// the clients using timestamps as nonces, whose transactions of the same
+// nonce are unrelated.
+func (tp *TXPool) sameNonceTx(tx *types.Transaction) *TXEntry {
+	if config.DefConfig.TxPool.DisableReplaceByNonce {
+		return nil
+	}
+	hash, ok := tp.nonces[payerNonce{payer: tx.Payer, nonce: tx.Nonce}]
+	if !ok {
+		return nil
+	}
+	return tp.txList[hash]
+}
+
+// removeTx removes a transaction from the list and the indexes
+func (tp *TXPool) removeTx(hash common.Uint256) {
+	txEntry, ok := tp.txList[hash]
+	if !ok {
+		return
+	}
+	delete(tp.txList, hash)
+	payer := txEntry.Tx.Payer
+	delete(tp.payers[payer], hash)
+	if len(tp.payers[payer]) == 0 {
+		delete(tp.payers, payer)
+	}
+	key := payerNonce{payer: payer, nonce: txEntry.Tx.Nonce}
+	if tp.nonces[key] == hash {
+		delete(tp.nonces, key)
+	}
+	heap.Remove(&tp.byPrice, tp.priced[hash].index)
+	delete(tp.priced, hash)
+}
+
+// addDropped records a dropped transaction, the oldest records are
+// forgotten when there are too many
+func (tp *TXPool) addDropped(dropped *DroppedTx) {
+	hash := dropped.Tx.Hash()
+	if _, ok := tp.dropped[hash]; !ok {
+		tp.droppedList = append(tp.droppedList, hash)
+	}
+	tp.dropped[hash] = dropped
+	if len(tp.droppedList) > MAX_DROPPED_RECORDS {
+		delete(tp.dropped, tp.droppedList[0])
+		tp.droppedList = tp.droppedList[1:]
+	}
 }
 
 // CleanTransactionList cleans the transaction list included in the ledger.
@@ -84,7 +227,7 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	defer tp.Unlock()
 	for _, tx := range txs {
 		if _, ok := tp.txList[tx.Hash()]; ok {
-			delete(tp.txList, tx.Hash())
+			tp.removeTx(tx.Hash())
 			cleaned++
 		}
 	}
@@ -94,6 +237,61 @@ func (tp *TXPool) CleanTransactionList(txs []*types.Transaction) error {
 	return nil
 }
 
+// RemoveTxsReplacedBy removes the transactions with the same payer and
+// nonce as the given ones, which are committed to the ledger, and returns
+// the removed transactions. Nothing is removed if replace-by-nonce is
+// disabled.
+func (tp *TXPool) RemoveTxsReplacedBy(txs []*types.Transaction) []*types.Transaction {
+	tp.Lock()
+	defer tp.Unlock()
+	removed := make([]*types.Transaction, 0)
+	for _, tx := range txs {
+		same := tp.sameNonceTx(tx)
+		if same == nil || same.Tx.Hash() == tx.Hash() {
+			continue
+		}
+		replaced := same.Tx
+		tp.removeTx(replaced.Hash())
+		tp.addDropped(&DroppedTx{Tx: replaced, Reason: message.TXPOOL_REASON_REPLACED, ReplacedBy: tx.Hash()})
+		removed = append(removed, replaced)
+	}
+	return removed
+}
+
+// RemoveExpiredTxs removes the transactions which can not be included in
+// the block of height any more, and returns the removed transactions.
+func (tp *TXPool) RemoveExpiredTxs(height uint32) []*types.Transaction {
+	tp.Lock()
+	defer tp.Unlock()
+	removed := make([]*types.Transaction, 0)
+	for hash, txEntry := range tp.txList {
+		if txEntry.Tx.IsExpired(height) {
+			tp.removeTx(hash)
+			tp.addDropped(&DroppedTx{Tx: txEntry.Tx, Reason: message.TXPOOL_REASON_EXPIRED})
+			removed = append(removed, txEntry.Tx)
+		}
+	}
+	return removed
+}
+
+// DropTxs removes the transactions in the pool with the reason recorded,
+// and returns the removed transactions.
+func (tp *TXPool) DropTxs(hashes []common.Uint256, reason string) []*types.Transaction {
+	tp.Lock()
+	defer tp.Unlock()
+	removed := make([]*types.Transaction, 0, len(hashes))
+	for _, hash := range hashes {
+		txEntry, ok := tp.txList[hash]
+		if !ok {
+			continue
+		}
+		tp.removeTx(hash)
+		tp.addDropped(&DroppedTx{Tx: txEntry.Tx, Reason: reason})
+		removed = append(removed, txEntry.Tx)
+	}
+	return removed
+}
+
 // DelTxList removes a single transaction from the pool.
 func (tp *TXPool) DelTxList(tx *types.Transaction) bool {
 	tp.Lock()
@@ -102,7 +300,7 @@ func (tp *TXPool) DelTxList(tx *types.Transaction) bool {
 	if _, ok := tp.txList[txHash]; !ok {
 		return false
 	}
-	delete(tp.txList, txHash)
+	tp.removeTx(txHash)
 	return true
 }
 
@@ -122,16 +320,14 @@ func (tp *TXPool) compareTxHeight(txEntry *TXEntry, height uint32) bool {
 // GetTxPool gets the transaction lists from the pool for the consensus,
 // if the byCount is marked, return the configured number at most; if the
 // the byCount is not marked, return all of the current transaction pool.
+// Transactions are ordered by gas price, while the transactions of a payer
+// are kept in the order of nonce.
 func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 	[]*types.Transaction) {
 	tp.RLock()
 	defer tp.RUnlock()
 
-	orderByFee := make([]*TXEntry, 0, len(tp.txList))
-	for _, txEntry := range tp.txList {
-		orderByFee = append(orderByFee, txEntry)
-	}
-	sort.Sort(OrderByNetWorkFee(orderByFee))
+	orderByFee := tp.orderByFeeAndNonce()
 
 	count := int(config.DefConfig.Consensus.MaxTxInBlock)
 	if count <= 0 {
@@ -159,6 +355,92 @@ func (tp *TXPool) GetTxPool(byCount bool, height uint32) ([]*TXEntry,
 	return txList, oldTxList
 }
 
+// orderByFeeAndNonce sorts the transactions by gas price, but a transaction
+// is never placed before the ones of the same payer with lower nonces
+func (tp *TXPool) orderByFeeAndNonce() []*TXEntry {
+	byPayer := make(map[common.Address][]*TXEntry, len(tp.payers))
+	for _, txEntry := range tp.txList {
+		payer := txEntry.Tx.Payer
+		byPayer[payer] = append(byPayer[payer], txEntry)
+	}
+	heads := &payerHeads{OrderByNetWorkFee: make(OrderByNetWorkFee, 0, len(byPayer))}
+	for payer, list := range byPayer {
+		sort.Sort(OrderByNonce(list))
+		heads.OrderByNetWorkFee = append(heads.OrderByNetWorkFee, list[0])
+		byPayer[payer] = list[1:]
+	}
+	heap.Init(heads)
+
+	ordered := make([]*TXEntry, 0, len(tp.txList))
+	for heads.Len() > 0 {
+		txEntry := heap.Pop(heads).(*TXEntry)
+		ordered = append(ordered, txEntry)
+		payer := txEntry.Tx.Payer
+		if list := byPayer[payer]; len(list) > 0 {
+			heap.Push(heads, list[0])
+			byPayer[payer] = list[1:]
+		}
+	}
+	return ordered
+}
+
+// payerHeads is a heap of the lowest nonce transaction of each payer,
+// the highest gas price first
+type payerHeads struct {
+	OrderByNetWorkFee
+}
+
+func (h *payerHeads) Push(x interface{}) {
+	h.OrderByNetWorkFee = append(h.OrderByNetWorkFee, x.(*TXEntry))
+}
+
+func (h *payerHeads) Pop() interface{} {
+	old := h.OrderByNetWorkFee
+	n := len(old)
+	x := old[n-1]
+	h.OrderByNetWorkFee = old[:n-1]
+	return x
+}
+
+// pricedTx is an item of priceHeap
+type pricedTx struct {
+	tx    *types.Transaction
+	index int
+}
+
+// priceHeap is a heap of the pool transactions, the one to be evicted
+// first, which has the lowest gas price and the highest nonce, on top
+type priceHeap []*pricedTx
+
+func (h priceHeap) Len() int { return len(h) }
+
+func (h priceHeap) Less(i, j int) bool {
+	if h[i].tx.GasPrice != h[j].tx.GasPrice {
+		return h[i].tx.GasPrice < h[j].tx.GasPrice
+	}
+	return h[i].tx.Nonce > h[j].tx.Nonce
+}
+
+func (h priceHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *priceHeap) Push(x interface{}) {
+	item := x.(*pricedTx)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *priceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
 // GetTransaction returns a transaction if it is contained in the pool
 // and nil otherwise.
 func (tp *TXPool) GetTransaction(hash common.Uint256) *types.Transaction {
@@ -171,21 +453,38 @@ func (tp *TXPool) GetTransaction(hash common.Uint256) *types.Transaction {
 }
 
 // GetTxStatus returns a transaction status if it is contained in the pool
-// and nil otherwise.
+// or dropped from the pool recently, and nil otherwise.
 func (tp *TXPool) GetTxStatus(hash common.Uint256) *TxStatus {
 	tp.RLock()
 	defer tp.RUnlock()
 	txEntry, ok := tp.txList[hash]
 	if !ok {
-		return nil
+		dropped, ok := tp.dropped[hash]
+		if !ok {
+			return nil
+		}
+		ret := &TxStatus{Hash: hash, Pool: tp.txPoolState(dropped.Tx)}
+		ret.Pool.Dropped = dropped.Reason
+		ret.Pool.ReplacedBy = dropped.ReplacedBy
+		return ret
 	}
 	ret := &TxStatus{
 		Hash:  hash,
 		Attrs: txEntry.Attrs,
+		Pool:  tp.txPoolState(txEntry.Tx),
 	}
 	return ret
 }
 
+func (tp *TXPool) txPoolState(tx *types.Transaction) *TxPoolState {
+	return &TxPoolState{
+		Payer:        tx.Payer,
+		Nonce:        tx.Nonce,
+		GasPrice:     tx.GasPrice,
+		PayerTxCount: len(tp.payers[tx.Payer]),
+	}
+}
+
 // GetTransactionCount returns the tx number of the pool.
 func (tp *TXPool) GetTransactionCount() int {
 	tp.RLock()
@@ -214,7 +513,7 @@ func (tp *TXPool) GetUnverifiedTxs(txs []*types.Transaction,
 		}
 
 		if !tp.compareTxHeight(txEntry, height) {
-			delete(tp.txList, tx.Hash())
+			tp.removeTx(tx.Hash())
 			res.OldTxs = append(res.OldTxs, txEntry.Tx)
 			continue
 		}
@@ -237,14 +536,18 @@ func (tp *TXPool) GetUnverifiedTxs(txs []*types.Transaction,
 }
 
 // RemoveTxsBelowGasPrice drops all transactions below the gas price
-func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) {
+// and returns the dropped ones
+func (tp *TXPool) RemoveTxsBelowGasPrice(gasPrice uint64) []*types.Transaction {
 	tp.Lock()
 	defer tp.Unlock()
+	removed := make([]*types.Transaction, 0)
 	for _, txEntry := range tp.txList {
 		if txEntry.Tx.GasPrice < gasPrice {
-			delete(tp.txList, txEntry.Tx.Hash())
+			tp.removeTx(txEntry.Tx.Hash())
+			removed = append(removed, txEntry.Tx)
 		}
 	}
+	return removed
 }
 
 // Remain returns the remaining tx list to cleanup
@@ -255,7 +558,7 @@ func (tp *TXPool) Remain() []*types.Transaction {
 	txList := make([]*types.Transaction, 0, len(tp.txList))
 	for _, txEntry := range tp.txList {
 		txList = append(txList, txEntry.Tx)
-		delete(tp.txList, txEntry.Tx.Hash())
+		tp.removeTx(txEntry.Tx.Hash())
 	}
 
 	return txList
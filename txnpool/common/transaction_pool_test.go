@@ -22,9 +22,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/log"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/types"
+	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/events/message"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,3 +103,166 @@ func TestTxPool(t *testing.T) {
 		return
 	}
 }
+
+func newTestTx(payer byte, nonce uint32, gasPrice uint64) *types.Transaction {
+	mutable := &types.MutableTransaction{
+		TxType:   types.Invoke,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Payer:    common.Address{payer},
+		Payload:  &payload.InvokeCode{Code: []byte{}},
+	}
+	tx, _ := mutable.IntoImmutable()
+	return tx
+}
+
+func TestTxPoolSameNonce(t *testing.T) {
+	config.DefConfig.TxPool.DisableReplaceByNonce = true
+	defer func() {
+		config.DefConfig.TxPool.DisableReplaceByNonce = false
+	}()
+	txPool := &TXPool{}
+	txPool.Init()
+
+	// replace-by-nonce is disabled, transactions of the same nonce are unrelated
+	tx1 := newTestTx(1, 1, 500)
+	tx2 := newTestTx(1, 1, 400)
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: tx1}))
+	replaced, evicted, errCode := txPool.AddTx(&TXEntry{Tx: tx2})
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Nil(t, replaced)
+	assert.Nil(t, evicted)
+	assert.Equal(t, 2, txPool.GetTransactionCount())
+
+	committed := newTestTx(1, 1, 600)
+	assert.Empty(t, txPool.RemoveTxsReplacedBy([]*types.Transaction{committed}))
+	assert.Equal(t, 2, txPool.GetTransactionCount())
+}
+
+func TestTxPoolReplace(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	tx1 := newTestTx(1, 1, 500)
+	_, _, errCode := txPool.AddTx(&TXEntry{Tx: tx1})
+	assert.Equal(t, errors.ErrNoError, errCode)
+
+	// same payer and nonce without a higher gas price
+	tx2 := newTestTx(1, 1, 499)
+	assert.Equal(t, errors.ErrReplaceUnderpriced, txPool.CheckTx(tx2))
+	_, _, errCode = txPool.AddTx(&TXEntry{Tx: tx2})
+	assert.Equal(t, errors.ErrReplaceUnderpriced, errCode)
+
+	tx3 := newTestTx(1, 1, 501)
+	replaced, evicted, errCode := txPool.AddTx(&TXEntry{Tx: tx3})
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Equal(t, tx1, replaced)
+	assert.Nil(t, evicted)
+	assert.Nil(t, txPool.GetTransaction(tx1.Hash()))
+	assert.Equal(t, 1, txPool.GetTransactionCount())
+
+	status := txPool.GetTxStatus(tx1.Hash())
+	assert.Equal(t, message.TXPOOL_REASON_REPLACED, status.Pool.Dropped)
+	assert.Equal(t, tx3.Hash(), status.Pool.ReplacedBy)
+	status = txPool.GetTxStatus(tx3.Hash())
+	assert.Equal(t, "", status.Pool.Dropped)
+	assert.Equal(t, 1, status.Pool.PayerTxCount)
+
+	// a committed transaction replaces the pooled one of the same payer and nonce
+	removed := txPool.RemoveTxsReplacedBy([]*types.Transaction{tx1})
+	assert.Equal(t, []*types.Transaction{tx3}, removed)
+	assert.Equal(t, 0, txPool.GetTransactionCount())
+	assert.Equal(t, tx1.Hash(), txPool.GetTxStatus(tx3.Hash()).Pool.ReplacedBy)
+	assert.Empty(t, txPool.nonces)
+}
+
+func TestTxPoolLimit(t *testing.T) {
+	maxInPool, maxPerPayer := config.DefConfig.TxPool.MaxTxInPool, config.DefConfig.TxPool.MaxTxPerPayer
+	defer func() {
+		config.DefConfig.TxPool.MaxTxInPool, config.DefConfig.TxPool.MaxTxPerPayer = maxInPool, maxPerPayer
+	}()
+	config.DefConfig.TxPool.MaxTxInPool = 3
+	config.DefConfig.TxPool.MaxTxPerPayer = 2
+
+	txPool := &TXPool{}
+	txPool.Init()
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: newTestTx(1, 1, 600)}))
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: newTestTx(1, 2, 500)}))
+	_, _, errCode := txPool.AddTx(&TXEntry{Tx: newTestTx(1, 3, 700)})
+	assert.Equal(t, errors.ErrPayerTxLimit, errCode)
+
+	low := newTestTx(2, 1, 400)
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: low}))
+	assert.Equal(t, errors.ErrTxPoolFull, txPool.CheckTx(newTestTx(3, 1, 400)))
+
+	// the pool is full, the lowest gas price one is evicted
+	high := newTestTx(3, 1, 800)
+	replaced, evicted, errCode := txPool.AddTx(&TXEntry{Tx: high})
+	assert.Equal(t, errors.ErrNoError, errCode)
+	assert.Nil(t, replaced)
+	assert.Equal(t, low, evicted)
+	assert.Equal(t, 3, txPool.GetTransactionCount())
+	assert.Equal(t, message.TXPOOL_REASON_EVICTED, txPool.GetTxStatus(low.Hash()).Pool.Dropped)
+}
+
+func TestTxPoolOrder(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+	txs := []*types.Transaction{
+		newTestTx(1, 2, 900),
+		newTestTx(1, 1, 100),
+		newTestTx(2, 5, 500),
+		newTestTx(2, 6, 300),
+		newTestTx(3, 1, 200),
+	}
+	for _, tx := range txs {
+		assert.True(t, txPool.AddTxList(&TXEntry{Tx: tx}))
+	}
+	txList, _ := txPool.GetTxPool(false, 0)
+	ordered := make([]*types.Transaction, 0, len(txList))
+	for _, txEntry := range txList {
+		ordered = append(ordered, txEntry.Tx)
+	}
+	assert.Equal(t, []*types.Transaction{txs[2], txs[3], txs[4], txs[1], txs[0]}, ordered)
+}
+
+func TestTxPoolExpire(t *testing.T) {
+	txPool := &TXPool{}
+	txPool.Init()
+
+	mutable := &types.MutableTransaction{
+		Version:  types.TX_VERSION_VALID_UNTIL,
+		TxType:   types.Invoke,
+		Nonce:    1,
+		GasPrice: 500,
+		Payer:    common.Address{1},
+		Payload:  &payload.InvokeCode{Code: []byte{}},
+
+		ValidUntilHeight: 10,
+	}
+	tx1, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	tx, err := types.TransactionFromRawBytes(tx1.Raw)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), tx.ValidUntilHeight)
+	assert.False(t, tx.IsExpired(10))
+	assert.True(t, tx.IsExpired(11))
+
+	// ValidUntilHeight is not serialized in the base version
+	mutable.Version = types.TX_VERSION_BASE
+	_, err = mutable.IntoImmutable()
+	assert.NotNil(t, err)
+
+	tx2 := newTestTx(1, 2, 500)
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: tx1}))
+	assert.True(t, txPool.AddTxList(&TXEntry{Tx: tx2}))
+
+	assert.Equal(t, 0, len(txPool.RemoveExpiredTxs(10)))
+	assert.Equal(t, []*types.Transaction{tx1}, txPool.RemoveExpiredTxs(11))
+	assert.Equal(t, message.TXPOOL_REASON_EXPIRED, txPool.GetTxStatus(tx1.Hash()).Pool.Dropped)
+
+	removed := txPool.DropTxs([]common.Uint256{tx1.Hash(), tx2.Hash()}, message.TXPOOL_REASON_TIMEOUT)
+	assert.Equal(t, []*types.Transaction{tx2}, removed)
+	assert.Equal(t, 0, txPool.GetTransactionCount())
+	assert.Equal(t, message.TXPOOL_REASON_TIMEOUT, txPool.GetTxStatus(tx2.Hash()).Pool.Dropped)
+}
@@ -19,13 +19,14 @@
 package common
 
 import (
+	"time"
+
 	"github.com/dnaproject2/DNA/common"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
 )
 
 const (
-	MAX_CAPACITY     = 100140                           // The tx pool's capacity that holds the verified txs
 	MAX_PENDING_TXN  = 4096 * 10                        // The max length of pending txs
 	MAX_WORKER_NUM   = 2                                // The max concurrent workers
 	MAX_RCV_TXN_LEN  = MAX_WORKER_NUM * MAX_PENDING_TXN // The max length of the queue that server can hold
@@ -36,6 +37,8 @@ const (
 	VERIFY_MASK      = STATELESS_MASK | STATEFUL_MASK   // The mask that indicates tx valid
 	MAX_LIMITATION   = 10000                            // The length of pending tx from net and http
 	UPDATE_FREQUENCY = 100                              // The frequency to update gas price from global params
+	GC_INTERVAL      = 60                               // The interval in seconds to evict the txs staying too long
+	JOURNAL_FILE     = "txpool.journal"                 // The file name of the tx pool journal under the store dir
 	MAX_TX_SIZE      = 1024 * 1024                      // The max size of a transaction to prevent DOS attacks
 )
 
@@ -55,9 +58,10 @@ const (
 type SenderType uint8
 
 const (
-	NilSender  SenderType = iota
-	NetSender             // Net sends tx req
-	HttpSender            // Http sends tx req
+	NilSender     SenderType = iota
+	NetSender                // Net sends tx req
+	HttpSender               // Http sends tx req
+	JournalSender            // Tx reloaded from the journal
 )
 
 func (sender SenderType) Sender() string {
@@ -68,6 +72,8 @@ func (sender SenderType) Sender() string {
 		return "net sender"
 	case HttpSender:
 		return "http sender"
+	case JournalSender:
+		return "journal sender"
 	default:
 		return "unknown sender"
 	}
@@ -101,6 +107,17 @@ type CheckBlkResult struct {
 type TxStatus struct {
 	Hash  common.Uint256 // transaction hash
 	Attrs []*TXAttr      // transaction's status
+	Pool  *TxPoolState   // transaction's state in the pool, nil if it is not in the pool
+}
+
+// TxPoolState shows a transaction in the pool or dropped from it
+type TxPoolState struct {
+	Payer        common.Address
+	Nonce        uint32
+	GasPrice     uint64
+	PayerTxCount int            // The number of transactions of the payer in the pool
+	Dropped      string         // The reason why the transaction is dropped, empty if it is in the pool
+	ReplacedBy   common.Uint256 // The transaction replacing it if it is replaced
 }
 type TxResult struct {
 	Err  errors.ErrCode
@@ -157,10 +174,12 @@ type GetTxnStatusReq struct {
 }
 
 // GetTxnStatusRsp returns a transaction status for GetTxnStatusReq.
-// Output: a transaction hash and it's verified result.
+// Output: a transaction hash, it's verified result and it's state in
+// the pool.
 type GetTxnStatusRsp struct {
 	Hash     common.Uint256
 	TxStatus []*TXAttr
+	Pool     *TxPoolState
 }
 
 // GetTxnStats specifies the api that how to get the tx statistics.
@@ -181,6 +200,37 @@ type GetTxnCountRsp struct {
 	Count []uint32
 }
 
+// GetTxnListReq specifies the api that how to list the transactions in the
+// pool, including the ones being re-verified
+type GetTxnListReq struct {
+}
+
+// GetTxnListRsp returns the transactions in the pool ordered by gas price
+// and nonce, followed by the ones being re-verified
+type GetTxnListRsp struct {
+	Txs []*PoolTxEntry
+}
+
+// PoolTxEntry is a transaction listed in the pool
+type PoolTxEntry struct {
+	Tx        *types.Transaction
+	Attrs     []*TXAttr // The verified result, nil if it is being re-verified
+	Time      time.Time // The time the transaction entered the pool
+	Verifying bool      // Whether the transaction is being re-verified
+}
+
+// EvictTxnReq specifies the api that how to remove a transaction from the
+// pool by the node administrator.
+// Input: a transaction hash
+type EvictTxnReq struct {
+	Hash common.Uint256
+}
+
+// EvictTxnRsp returns the transaction removed, nil if it is not in the pool
+type EvictTxnRsp struct {
+	Tx *types.Transaction
+}
+
 // GetPendingTxnReq specifies the api that how to get a pending tx list
 // in the pool.
 type GetPendingTxnReq struct {
@@ -252,3 +302,11 @@ func (n OrderByNetWorkFee) Len() int { return len(n) }
 func (n OrderByNetWorkFee) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
 
 func (n OrderByNetWorkFee) Less(i, j int) bool { return n[j].Tx.GasPrice < n[i].Tx.GasPrice }
+
+type OrderByNonce []*TXEntry
+
+func (n OrderByNonce) Len() int { return len(n) }
+
+func (n OrderByNonce) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+
+func (n OrderByNonce) Less(i, j int) bool { return n[i].Tx.Nonce < n[j].Tx.Nonce }
@@ -140,14 +140,13 @@ func (ta *TxActor) handleTransaction(sender tc.SenderType, self *actor.PID,
 			replyTxResult(txResultCh, txn.Hash(), errors.ErrDuplicateInput,
 				fmt.Sprintf("transaction %x is already in the tx pool", txn.Hash()))
 		}
-	} else if ta.server.getTransactionCount() >= tc.MAX_CAPACITY {
-		log.Debugf("handleTransaction: transaction pool is full for tx %x",
-			txn.Hash())
+	} else if errCode := ta.server.txPool.CheckTx(txn); errCode != errors.ErrNoError {
+		log.Debugf("handleTransaction: transaction %x rejected by the txn pool: %s",
+			txn.Hash(), errCode.Error())
 
 		ta.server.increaseStats(tc.FailureStats)
 		if sender == tc.HttpSender && txResultCh != nil {
-			replyTxResult(txResultCh, txn.Hash(), errors.ErrTxPoolFull,
-				"transaction pool is full")
+			replyTxResult(txResultCh, txn.Hash(), errCode, errCode.Error())
 		}
 	} else {
 		if _, overflow := common.SafeMul(txn.GasLimit, txn.GasPrice); overflow {
@@ -264,7 +263,7 @@ func (ta *TxActor) Receive(context actor.Context) {
 					TxStatus: nil}, context.Self())
 			} else {
 				sender.Request(&tc.GetTxnStatusRsp{Hash: res.Hash,
-					TxStatus: res.Attrs}, context.Self())
+					TxStatus: res.Attrs, Pool: res.Pool}, context.Self())
 			}
 		}
 
@@ -279,6 +278,26 @@ func (ta *TxActor) Receive(context actor.Context) {
 				context.Self())
 		}
 
+	case *tc.GetTxnListReq:
+		sender := context.Sender()
+
+		log.Debugf("txpool-tx actor receives listing tx req from %v", sender)
+
+		res := ta.server.getTxList()
+		if sender != nil {
+			sender.Request(&tc.GetTxnListRsp{Txs: res}, context.Self())
+		}
+
+	case *tc.EvictTxnReq:
+		sender := context.Sender()
+
+		log.Debugf("txpool-tx actor receives evicting tx req from %v", sender)
+
+		res := ta.server.evictTx(msg.Hash)
+		if sender != nil {
+			sender.Request(&tc.EvictTxnRsp{Tx: res}, context.Self())
+		}
+
 	default:
 		log.Debugf("txpool-tx actor: unknown msg %v type %v", msg, reflect.TypeOf(msg))
 	}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proc
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dnaproject2/DNA/common/log"
+	"github.com/dnaproject2/DNA/common/serialization"
+	tx "github.com/dnaproject2/DNA/core/types"
+)
+
+// txJournal is a log of the transactions accepted by the pool, so that
+// they can be reloaded after the node restarts. Each record is the raw
+// transaction prefixed with its length.
+type txJournal struct {
+	mu     sync.Mutex
+	path   string   // The file the journal is stored in
+	writer *os.File // The file opened to append new records
+}
+
+func newTxJournal(path string) *txJournal {
+	return &txJournal{path: path}
+}
+
+// load reads the transactions in the journal, the records after a broken
+// one are discarded
+func (j *txJournal) load() ([]*tx.Transaction, error) {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	txs := make([]*tx.Transaction, 0)
+	for {
+		size, err := serialization.ReadVarUint(reader, tx.MAX_TX_SIZE)
+		if err == io.EOF {
+			break
+		}
+		var raw []byte
+		if err == nil {
+			raw, err = serialization.ReadBytes(reader, size)
+		}
+		var t *tx.Transaction
+		if err == nil {
+			t, err = tx.TransactionFromRawBytes(raw)
+		}
+		if err != nil {
+			log.Warnf("txJournal: discard broken records after %d transactions in %s: %s",
+				len(txs), j.path, err)
+			break
+		}
+		txs = append(txs, t)
+	}
+	return txs, nil
+}
+
+// insert appends a transaction to the journal
+func (j *txJournal) insert(t *tx.Transaction) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.writer == nil {
+		return nil
+	}
+	return serialization.WriteVarBytes(j.writer, t.Raw)
+}
+
+// rotate rewrites the journal with the transactions returned by snapshot,
+// which is called with the journal locked so no insertion is lost
+func (j *txJournal) rotate(snapshot func() []*tx.Transaction) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	tmp := j.path + ".new"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	for _, t := range snapshot() {
+		if err = serialization.WriteVarBytes(writer, t.Raw); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if j.writer != nil {
+		j.writer.Close()
+		j.writer = nil
+	}
+	if err = os.Rename(tmp, j.path); err != nil {
+		return err
+	}
+	j.writer, err = os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	return err
+}
+
+// close stops appending to the journal
+func (j *txJournal) close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.writer == nil {
+		return nil
+	}
+	err := j.writer.Close()
+	j.writer = nil
+	return err
+}
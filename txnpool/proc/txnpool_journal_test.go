@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2018 The DNA Authors
+ * This file is part of The DNA library.
+ *
+ * The DNA is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The DNA is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The DNA.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/core/payload"
+	"github.com/dnaproject2/DNA/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newJournalTestTx(nonce uint32) *types.Transaction {
+	mutable := &types.MutableTransaction{
+		TxType:  types.Invoke,
+		Nonce:   nonce,
+		Payload: &payload.InvokeCode{Code: []byte("ont")},
+	}
+	t, _ := mutable.IntoImmutable()
+	return t
+}
+
+func TestTxJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpool")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "txpool.journal")
+
+	journal := newTxJournal(path)
+	txs, err := journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(txs))
+
+	tx1, tx2, tx3 := newJournalTestTx(1), newJournalTestTx(2), newJournalTestTx(3)
+	assert.Nil(t, journal.rotate(func() []*types.Transaction { return []*types.Transaction{tx1} }))
+	assert.Nil(t, journal.insert(tx2))
+	assert.Nil(t, journal.insert(tx3))
+	assert.Nil(t, journal.close())
+
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Uint256{tx1.Hash(), tx2.Hash(), tx3.Hash()}, journalHashes(txs))
+
+	// a partially written record is discarded
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Truncate(path, info.Size()-1))
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Uint256{tx1.Hash(), tx2.Hash()}, journalHashes(txs))
+
+	assert.Nil(t, journal.rotate(func() []*types.Transaction { return []*types.Transaction{tx3} }))
+	assert.Nil(t, journal.close())
+	txs, err = journal.load()
+	assert.Nil(t, err)
+	assert.Equal(t, []common.Uint256{tx3.Hash()}, journalHashes(txs))
+}
+
+func journalHashes(txs []*types.Transaction) []common.Uint256 {
+	hashes := make([]common.Uint256, 0, len(txs))
+	for _, t := range txs {
+		hashes = append(hashes, t.Hash())
+	}
+	return hashes
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/dnaproject2/DNA/core/ledger"
 	tx "github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/events"
+	"github.com/dnaproject2/DNA/events/message"
 	httpcom "github.com/dnaproject2/DNA/http/base/common"
 	params "github.com/dnaproject2/DNA/smartcontract/service/native/global_params"
 	nutils "github.com/dnaproject2/DNA/smartcontract/service/native/utils"
@@ -39,6 +41,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type txStats struct {
@@ -86,6 +89,9 @@ type TXPoolServer struct {
 	gasPrice              uint64                              // Gas price to enforce for acceptance into the pool
 	disablePreExec        bool                                // Disbale PreExecute a transaction
 	disableBroadcastNetTx bool                                // Disable broadcast tx from network
+	txTimes               map[common.Uint256]time.Time        // The time txs entered the pool, kept while re-verifying
+	journal               *txJournal                          // The journal of the txs in the pool, nil if disabled
+	quit                  chan struct{}                       // Stop the garbage collection of the pool
 }
 
 // NewTxPoolServer creates a new tx pool server to schedule workers to
@@ -154,6 +160,8 @@ func (s *TXPoolServer) init(num uint8, disablePreExec, disableBroadcastNetTx boo
 	s.txPool = &tc.TXPool{}
 	s.txPool.Init()
 	s.allPendingTxs = make(map[common.Uint256]*serverPendingTx)
+	s.txTimes = make(map[common.Uint256]time.Time)
+	s.quit = make(chan struct{})
 	s.actors = make(map[tc.ActorType]*actor.PID)
 
 	s.validators = &registerValidators{
@@ -189,6 +197,7 @@ func (s *TXPoolServer) init(num uint8, disablePreExec, disableBroadcastNetTx boo
 		s.workers[i].init(i, s)
 		go s.workers[i].start()
 	}
+	go s.gcLoop()
 }
 
 // checkPendingBlockOk checks whether a block from consensus is verified.
@@ -266,7 +275,7 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 		return
 	}
 
-	if err == errors.ErrNoError && ((pt.sender == tc.HttpSender) ||
+	if err == errors.ErrNoError && ((pt.sender == tc.HttpSender || pt.sender == tc.JournalSender) ||
 		(pt.sender == tc.NetSender && !s.disableBroadcastNetTx)) {
 		pid := s.GetPID(tc.NetActor)
 		if pid != nil {
@@ -278,6 +287,12 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 		replyTxResult(pt.ch, hash, err, err.Error())
 	}
 
+	// A pooled tx failing its re-verification is dropped from the pool
+	if pt.sender == tc.NilSender && err != errors.ErrNoError {
+		delete(s.txTimes, hash)
+		publishTxPoolChange(pt.tx, message.TXPOOL_TX_LEAVE, err.Error())
+	}
+
 	delete(s.allPendingTxs, hash)
 
 	if len(s.allPendingTxs) < tc.MAX_LIMITATION {
@@ -291,10 +306,21 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 	s.mu.Unlock()
 
 	// Check if the tx is in the pending block and
-	// the pending block is verified
+	// the pending block is verified, a valid tx rejected by
+	// the pool is still valid in the block
+	if isTxPoolRejection(err) {
+		err = errors.ErrNoError
+	}
 	s.checkPendingBlockOk(hash, err)
 }
 
+// isTxPoolRejection returns whether the error is returned by the tx
+// pool for a valid transaction which is not accepted by the pool
+func isTxPoolRejection(err errors.ErrCode) bool {
+	return err == errors.ErrTxPoolFull || err == errors.ErrReplaceUnderpriced ||
+		err == errors.ErrPayerTxLimit
+}
+
 // setPendingTx adds a transaction to the pending list, if the
 // transaction is already in the pending list, just return false.
 func (s *TXPoolServer) setPendingTx(tx *tx.Transaction,
@@ -481,6 +507,10 @@ func (s *TXPoolServer) Stop() {
 		s.workers[i].stop()
 	}
 	s.wg.Wait()
+	close(s.quit)
+	if journal := s.getJournal(); journal != nil {
+		journal.close()
+	}
 
 	if s.slots != nil {
 		close(s.slots)
@@ -527,7 +557,25 @@ func (s *TXPoolServer) getPendingTxs(byCount bool) []*tx.Transaction {
 
 // cleanTransactionList cleans the txs in the block from the ledger
 func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32) {
+	committed := make([]*tx.Transaction, 0, len(txs))
+	for _, t := range txs {
+		if s.txPool.GetTransaction(t.Hash()) != nil {
+			committed = append(committed, t)
+		}
+	}
 	s.txPool.CleanTransactionList(txs)
+	for _, t := range committed {
+		s.txLeft(t, message.TXPOOL_REASON_COMMITTED)
+	}
+	// The txs with the same payer and nonce as the committed ones are
+	// taken as replaced
+	for _, t := range s.txPool.RemoveTxsReplacedBy(txs) {
+		s.txLeft(t, message.TXPOOL_REASON_REPLACED)
+	}
+	// The txs can not be included in the next block any more
+	for _, t := range s.txPool.RemoveExpiredTxs(height + 1) {
+		s.txLeft(t, message.TXPOOL_REASON_EXPIRED)
+	}
 
 	// Check whether to update the gas price and remove txs below the
 	// threshold
@@ -543,15 +591,19 @@ func (s *TXPoolServer) cleanTransactionList(txs []*tx.Transaction, height uint32
 		}
 
 		if oldGasPrice < gasPrice {
-			s.txPool.RemoveTxsBelowGasPrice(gasPrice)
+			removed := s.txPool.RemoveTxsBelowGasPrice(gasPrice)
+			for _, t := range removed {
+				s.txLeft(t, message.TXPOOL_REASON_UNDERPRICED)
+			}
 		}
 	}
 	// Cleanup tx pool
 	if !s.disablePreExec {
 		remain := s.txPool.Remain()
 		for _, t := range remain {
-			if ok, _ := preExecCheck(t); !ok {
+			if ok, desc := preExecCheck(t); !ok {
 				log.Debugf("cleanTransactionList: preExecCheck tx %x failed", t.Hash())
+				s.txLeft(t, desc)
 				continue
 			}
 			s.reVerifyStateful(t, tc.NilSender)
@@ -564,13 +616,198 @@ func (s *TXPoolServer) delTransaction(t *tx.Transaction) {
 	s.txPool.DelTxList(t)
 }
 
-// addTxList adds a valid transaction to the tx pool.
-func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) bool {
-	ret := s.txPool.AddTxList(txEntry)
-	if !ret {
-		s.increaseStats(tc.DuplicateStats)
+// addTxList adds a valid transaction to the tx pool, and returns the
+// error code if the pool rejects it.
+func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) errors.ErrCode {
+	replaced, evicted, errCode := s.txPool.AddTx(txEntry)
+	if errCode != errors.ErrNoError {
+		if errCode == errors.ErrDuplicateInput {
+			s.increaseStats(tc.DuplicateStats)
+		} else {
+			s.increaseStats(tc.FailureStats)
+		}
+		return errCode
 	}
-	return ret
+	if replaced != nil {
+		s.txLeft(replaced, message.TXPOOL_REASON_REPLACED)
+	}
+	if evicted != nil {
+		s.txLeft(evicted, message.TXPOOL_REASON_EVICTED)
+	}
+
+	// Txs re-verified by the pool itself never left it from the
+	// subscribers' point of view
+	hash := txEntry.Tx.Hash()
+	s.mu.Lock()
+	pt, ok := s.allPendingTxs[hash]
+	_, seen := s.txTimes[hash]
+	if !seen {
+		s.txTimes[hash] = time.Now()
+	}
+	journal := s.journal
+	s.mu.Unlock()
+	if !ok || pt.sender != tc.NilSender {
+		publishTxPoolChange(txEntry.Tx, message.TXPOOL_TX_ENTER, "")
+	}
+	// Txs reloaded from the journal are already in it
+	if !seen && journal != nil && (!ok || pt.sender != tc.JournalSender) {
+		if err := journal.insert(txEntry.Tx); err != nil {
+			log.Warnf("addTxList: failed to journal transaction %x: %s", hash, err)
+		}
+	}
+	return errors.ErrNoError
+}
+
+// txLeft forgets a transaction which left the tx pool and notifies the
+// subscribers
+func (s *TXPoolServer) txLeft(t *tx.Transaction, reason string) {
+	s.mu.Lock()
+	delete(s.txTimes, t.Hash())
+	s.mu.Unlock()
+	publishTxPoolChange(t, message.TXPOOL_TX_LEAVE, reason)
+}
+
+// gcLoop evicts the txs staying in the pool too long periodically
+func (s *TXPoolServer) gcLoop() {
+	ticker := time.NewTicker(time.Second * tc.GC_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.removeTimeoutTxs(time.Now())
+			s.rotateJournal()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// removeTimeoutTxs removes the txs entered the pool longer than the
+// configured lifetime before now
+func (s *TXPoolServer) removeTimeoutTxs(now time.Time) {
+	lifetime := time.Duration(config.DefConfig.TxPool.MaxTxLifetime) * time.Second
+	if lifetime == 0 {
+		return
+	}
+	timeout := make([]common.Uint256, 0)
+	s.mu.RLock()
+	for hash, since := range s.txTimes {
+		if now.Sub(since) >= lifetime {
+			timeout = append(timeout, hash)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, t := range s.txPool.DropTxs(timeout, message.TXPOOL_REASON_TIMEOUT) {
+		log.Debugf("removeTimeoutTxs: transaction %x stays in the pool too long", t.Hash())
+		s.txLeft(t, message.TXPOOL_REASON_TIMEOUT)
+	}
+}
+
+// StartJournal reloads the txs journaled at path into the pool, and then
+// journals the txs accepted by the pool. The txs already in the ledger
+// are dropped, and the others are verified again by the validators.
+func (s *TXPoolServer) StartJournal(path string) error {
+	journal := newTxJournal(path)
+	txs, err := journal.load()
+	if err != nil {
+		return err
+	}
+
+	reload := make([]*tx.Transaction, 0, len(txs))
+	seen := make(map[common.Uint256]bool, len(txs))
+	for _, t := range txs {
+		hash := t.Hash()
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		exist, err := ledger.DefLedger.IsContainTransaction(hash)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			reload = append(reload, t)
+		}
+	}
+	if err := journal.rotate(func() []*tx.Transaction { return reload }); err != nil {
+		return err
+	}
+	log.Infof("tx pool: reload %d transactions from journal %s, %d dropped",
+		len(reload), path, len(txs)-len(reload))
+
+	s.mu.Lock()
+	s.journal = journal
+	s.mu.Unlock()
+
+	go func() {
+		if !s.waitValidators(time.Second * tc.EXPIRE_INTERVAL) {
+			log.Warn("tx pool: no validators registered to verify journaled transactions")
+		}
+		for _, t := range reload {
+			s.assignTxToWorker(t, tc.JournalSender, nil)
+		}
+	}()
+	return nil
+}
+
+// waitValidators waits until both the stateless and stateful validators
+// are registered, and returns false if timeout
+func (s *TXPoolServer) waitValidators(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.validators.RLock()
+		ready := len(s.validators.entries[types.Stateless]) > 0 &&
+			len(s.validators.entries[types.Stateful]) > 0
+		s.validators.RUnlock()
+		if ready || time.Now().After(deadline) {
+			return ready
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *TXPoolServer) getJournal() *txJournal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.journal
+}
+
+// rotateJournal rewrites the journal with the txs in the pool, including
+// the ones being re-verified or reloaded
+func (s *TXPoolServer) rotateJournal() {
+	journal := s.getJournal()
+	if journal == nil {
+		return
+	}
+	err := journal.rotate(func() []*tx.Transaction {
+		txList, _ := s.txPool.GetTxPool(false, 0)
+		txs := make([]*tx.Transaction, 0, len(txList))
+		for _, txEntry := range txList {
+			txs = append(txs, txEntry.Tx)
+		}
+		s.mu.RLock()
+		for _, pt := range s.allPendingTxs {
+			if pt.sender == tc.NilSender || pt.sender == tc.JournalSender {
+				txs = append(txs, pt.tx)
+			}
+		}
+		s.mu.RUnlock()
+		return txs
+	})
+	if err != nil {
+		log.Warnf("rotateJournal: %s", err)
+	}
+}
+
+// publishTxPoolChange notifies the subscribers that a transaction
+// entered or left the tx pool
+func publishTxPoolChange(t *tx.Transaction, change, reason string) {
+	if events.DefActorPublisher == nil {
+		return
+	}
+	events.DefActorPublisher.Publish(message.TOPIC_TXPOOL_CHANGE,
+		&message.TxPoolChangeMsg{Tx: t, Change: change, Reason: reason})
 }
 
 // increaseStats increases the count with the stats type
@@ -622,15 +859,65 @@ func (s *TXPoolServer) getTxStatusReq(hash common.Uint256) *tc.TxStatus {
 	return s.txPool.GetTxStatus(hash)
 }
 
+// getTxList returns the txs in the pool and the ones being re-verified
+func (s *TXPoolServer) getTxList() []*tc.PoolTxEntry {
+	txList, _ := s.txPool.GetTxPool(false, 0)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ret := make([]*tc.PoolTxEntry, 0, len(txList))
+	listed := make(map[common.Uint256]bool, len(txList))
+	for _, txEntry := range txList {
+		hash := txEntry.Tx.Hash()
+		listed[hash] = true
+		ret = append(ret, &tc.PoolTxEntry{
+			Tx:    txEntry.Tx,
+			Attrs: txEntry.Attrs,
+			Time:  s.txTimes[hash],
+		})
+	}
+	for hash, pt := range s.allPendingTxs {
+		since, ok := s.txTimes[hash]
+		if !ok || listed[hash] {
+			continue
+		}
+		ret = append(ret, &tc.PoolTxEntry{
+			Tx:        pt.tx,
+			Time:      since,
+			Verifying: true,
+		})
+	}
+	return ret
+}
+
+// evictTx removes a tx from the pool on request of the node administrator,
+// and returns the tx removed
+func (s *TXPoolServer) evictTx(hash common.Uint256) *tx.Transaction {
+	removed := s.txPool.DropTxs([]common.Uint256{hash}, message.TXPOOL_REASON_REMOVED)
+	if len(removed) == 0 {
+		return nil
+	}
+	log.Infof("evictTx: transaction %x is removed from the pool", hash)
+	s.txLeft(removed[0], message.TXPOOL_REASON_REMOVED)
+	return removed[0]
+}
+
 // getTransactionCount returns the tx size of the transaction pool.
 func (s *TXPoolServer) getTransactionCount() int {
 	return s.txPool.GetTransactionCount()
 }
 
-// reVerifyStateful re-verify a transaction's stateful data.
+// reVerifyStateful re-verify a transaction's stateful data. The pool takes
+// a tx out before re-verifying it with NilSender, so its entering time is
+// dropped if the tx is already being verified.
 func (s *TXPoolServer) reVerifyStateful(tx *tx.Transaction, sender tc.SenderType) {
 	if ok := s.setPendingTx(tx, sender, nil); !ok {
 		s.increaseStats(tc.DuplicateStats)
+		if sender == tc.NilSender {
+			s.mu.Lock()
+			delete(s.txTimes, tx.Hash())
+			s.mu.Unlock()
+		}
 		return
 	}
 
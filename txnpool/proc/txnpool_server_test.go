@@ -22,9 +22,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/core/payload"
 	"github.com/dnaproject2/DNA/core/types"
 	"github.com/dnaproject2/DNA/errors"
+	"github.com/dnaproject2/DNA/events/message"
 	tc "github.com/dnaproject2/DNA/txnpool/common"
 	"github.com/dnaproject2/DNA/validator/stateless"
 	vt "github.com/dnaproject2/DNA/validator/types"
@@ -285,3 +287,48 @@ func TestValidator(t *testing.T) {
 
 	t.Log("Ending validator testing")
 }
+
+func TestRemoveTimeoutTxs(t *testing.T) {
+	s := NewTxPoolServer(tc.MAX_WORKER_NUM, true, false)
+	defer s.Stop()
+
+	assert.Equal(t, errors.ErrNoError, s.addTxList(&tc.TXEntry{Tx: txn}))
+	lifetime := time.Duration(config.DefConfig.TxPool.MaxTxLifetime) * time.Second
+
+	s.removeTimeoutTxs(time.Now().Add(lifetime - time.Minute))
+	assert.NotNil(t, s.getTransaction(txn.Hash()))
+
+	s.removeTimeoutTxs(time.Now().Add(lifetime))
+	assert.Nil(t, s.getTransaction(txn.Hash()))
+	assert.Equal(t, 0, len(s.txTimes))
+}
+
+func TestReVerifyVerifyingTx(t *testing.T) {
+	s := NewTxPoolServer(tc.MAX_WORKER_NUM, true, false)
+	defer s.Stop()
+
+	assert.Equal(t, errors.ErrNoError, s.addTxList(&tc.TXEntry{Tx: txn}))
+	assert.True(t, s.setPendingTx(txn, tc.HttpSender, nil))
+	// the tx taken out for re-verification is already being verified
+	s.delTransaction(txn)
+	s.reVerifyStateful(txn, tc.NilSender)
+	assert.Equal(t, 0, len(s.txTimes))
+}
+
+func TestGetTxListAndEvict(t *testing.T) {
+	s := NewTxPoolServer(tc.MAX_WORKER_NUM, true, false)
+	defer s.Stop()
+
+	assert.Equal(t, errors.ErrNoError, s.addTxList(&tc.TXEntry{Tx: txn}))
+	list := s.getTxList()
+	if assert.Equal(t, 1, len(list)) {
+		assert.Equal(t, txn, list[0].Tx)
+		assert.False(t, list[0].Time.IsZero())
+		assert.False(t, list[0].Verifying)
+	}
+
+	assert.Equal(t, txn, s.evictTx(txn.Hash()))
+	assert.Nil(t, s.evictTx(txn.Hash()))
+	assert.Equal(t, 0, len(s.getTxList()))
+	assert.Equal(t, message.TXPOOL_REASON_REMOVED, s.getTxStatusReq(txn.Hash()).Pool.Dropped)
+}
@@ -167,9 +167,9 @@ func (worker *txPoolWorker) putTxPool(pt *pendingTx) bool {
 		Tx:    pt.tx,
 		Attrs: pt.ret,
 	}
-	worker.server.addTxList(txEntry)
-	worker.server.removePendingTx(pt.tx.Hash(), errors.ErrNoError)
-	return true
+	errCode := worker.server.addTxList(txEntry)
+	worker.server.removePendingTx(pt.tx.Hash(), errCode)
+	return errCode == errors.ErrNoError
 }
 
 // verifyTx prepares a check request and sends it to the validators.
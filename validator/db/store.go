@@ -21,12 +21,15 @@ package db
 import (
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 
 	"github.com/dnaproject2/DNA/common"
+	"github.com/dnaproject2/DNA/common/config"
 	"github.com/dnaproject2/DNA/common/serialization"
 	storcomm "github.com/dnaproject2/DNA/core/store/common"
-	leveldb "github.com/dnaproject2/DNA/core/store/leveldbstore"
+	_ "github.com/dnaproject2/DNA/core/store/leveldbstore"
+	_ "github.com/dnaproject2/DNA/core/store/memstore"
 	"github.com/dnaproject2/DNA/core/types"
 	pool "github.com/valyala/bytebufferpool"
 )
@@ -43,7 +46,13 @@ type Store struct {
 }
 
 func NewStore(path string) (*Store, error) {
-	ldb, err := leveldb.NewLevelDBStore(path)
+	// stores created before the backend record are leveldb
+	_, statErr := os.Stat(path)
+	backend, err := storcomm.SelectBackend(path, config.DefConfig.Common.StoreBackend, statErr == nil)
+	if err != nil {
+		return nil, err
+	}
+	ldb, err := backend.Open(path)
 	if err != nil {
 		return nil, err
 	}
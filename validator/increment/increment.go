@@ -88,7 +88,8 @@ func (self *IncrementValidator) AddBlock(block *types.Block) {
 	self.blocks = append(self.blocks, txHashes)
 }
 
-// Verfiy does increment check start at startHeight
+// Verfiy does increment check start at startHeight, and checks the tx is not
+// expired in the block following the blocks of this validator
 func (self *IncrementValidator) Verify(tx *types.Transaction, startHeight uint32) error {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
@@ -96,6 +97,10 @@ func (self *IncrementValidator) Verify(tx *types.Transaction, startHeight uint32
 		return fmt.Errorf("can not do increment validation: startHeight %v < self.baseHeight %v", startHeight, self.baseHeight)
 	}
 
+	if _, end := self.blockRange(); len(self.blocks) != 0 && tx.IsExpired(end) {
+		return fmt.Errorf("tx expired at height %d", tx.ValidUntilHeight)
+	}
+
 	for i := int(startHeight - self.baseHeight); i < len(self.blocks); i++ {
 		if _, ok := self.blocks[i][tx.Hash()]; ok {
 			return fmt.Errorf("tx duplicated")
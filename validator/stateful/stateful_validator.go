@@ -77,6 +77,10 @@ func (self *validator) Receive(context actor.Context) {
 			errCode = errors.ErrUnknown
 		} else if exist {
 			errCode = errors.ErrDuplicatedTx
+		} else if msg.Tx.CheckVersion(height+1) != nil {
+			errCode = errors.ErrTxVersion
+		} else if msg.Tx.IsExpired(height + 1) {
+			errCode = errors.ErrTxExpired
 		}
 
 		response := &vatypes.CheckResponse{
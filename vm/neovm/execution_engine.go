@@ -39,6 +39,16 @@ type ExecutionEngine struct {
 	Context         *ExecutionContext
 	OpCode          OpCode
 	OpExec          OpExec
+	Tracer          Tracer
+}
+
+// Tracer observes the opcodes executed by the engine
+type Tracer interface {
+	// CaptureStep is called before an opcode is executed, ip is the offset of the opcode in the code
+	// of the current context and gas is the gas charged for it
+	CaptureStep(engine *ExecutionEngine, ip int, op OpCode, gas uint64)
+	// CaptureSysCall is called when the service called by the SYSCALL opcode is charged
+	CaptureSysCall(name string, gas uint64)
 }
 
 func (this *ExecutionEngine) CurrentContext() *ExecutionContext {
@@ -24,6 +24,8 @@ package exec
 
 import (
 	"errors"
+	"fmt"
+
 	"github.com/dnaproject2/DNA/common/log"
 )
 
@@ -65,6 +67,12 @@ func (vm *VM) doCall(compiled compiledFunction, index int64) {
 			rtn, err := v(vm.Engine)
 			if err != nil || !rtn {
 				log.Errorf("call method :%s failed\n", compiled.name)
+				//abort the execution, the error is returned by ExecCode
+				if err == nil {
+					err = fmt.Errorf("call method :%s failed", compiled.name)
+				}
+				vm.abortErr = err
+				return
 			}
 		} else {
 			vm.ctx = prevCtxt
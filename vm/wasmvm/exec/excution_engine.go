@@ -41,6 +41,10 @@ const (
 	VM_STACK_DEPTH       = 10
 )
 
+var (
+	ERR_GAS_INSUFFICIENT = errors.NewErr("[ExecutionEngine] insufficient gas for wasm execution!")
+)
+
 // backup vm while call other contracts
 type vmstack struct {
 	top   int
@@ -92,6 +96,19 @@ type ExecutionEngine struct {
 	CodeContainer interfaces.CodeContainer
 	vm            *VM
 	backupVM      *vmstack
+	gasChecker    func() bool
+}
+
+//SetGasChecker register the gas meter called before every executed instruction
+//the execution is aborted once the checker return false
+func (e *ExecutionEngine) SetGasChecker(checker func() bool) {
+	e.gasChecker = checker
+}
+
+func (e *ExecutionEngine) checkGas() {
+	if e.gasChecker != nil && !e.gasChecker() {
+		panic(ERR_GAS_INSUFFICIENT)
+	}
 }
 
 //GetVM return vm pointer
@@ -320,7 +337,7 @@ func (e *ExecutionEngine) Call(caller common.Address,
 	defer func() {
 		if err := recover(); err != nil {
 			returnbytes = nil
-			er = errors.NewErr("[Call] error happened while call wasmvm")
+			er = errors.NewErr(fmt.Sprintf("[Call] error happened while call wasmvm: %v", err))
 		}
 	}()
 
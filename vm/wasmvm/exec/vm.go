@@ -103,6 +103,8 @@ type VM struct {
 	Caller          common.Address
 	Engine          *ExecutionEngine
 	VMCode          []byte
+	//the error of a failed env call, which aborts the execution
+	abortErr error
 }
 
 // As per the WebAssembly spec: https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/Semantics.md#linear-memory
@@ -358,6 +360,9 @@ func (vm *VM) ExecCode(insideCall bool, fnIndex int64, args ...uint64) (interfac
 	}
 	var rtrn interface{}
 	res := vm.execCode(insideCall, compiled)
+	if vm.abortErr != nil {
+		return nil, vm.abortErr
+	}
 	// for the call contract case
 	if insideCall {
 		return res, nil
@@ -385,6 +390,12 @@ func (vm *VM) ExecCode(insideCall bool, fnIndex int64, args ...uint64) (interfac
 func (vm *VM) execCode(isinside bool, compiled compiledFunction) uint64 {
 outer:
 	for int(vm.ctx.pc) < len(vm.ctx.code) {
+		if vm.abortErr != nil {
+			return 0
+		}
+		if vm.Engine != nil {
+			vm.Engine.checkGas()
+		}
 		op := vm.ctx.code[vm.ctx.pc]
 		vm.ctx.pc++
 
@@ -458,6 +469,9 @@ outer:
 		}
 	}
 
+	if vm.abortErr != nil {
+		return 0
+	}
 	if compiled.returns {
 		return vm.ctx.stack[len(vm.ctx.stack)-1]
 	}